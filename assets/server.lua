@@ -55,6 +55,23 @@ end
 
 local available_hooks = {"on_init", "on_frame", "on_settings_changed"}
 
+-- Last load/init error per plugin; replayed to the controller on connect so
+-- the admin UI can show why a plugin failed.
+local plugin_errors = {}
+
+local function report_plugin_error(plugin_name, err)
+    console.log("Plugin " .. plugin_name .. " error: " .. tostring(err))
+    plugin_errors[plugin_name] = tostring(err)
+    SendCommand("plugin_error", {plugin = plugin_name, error = tostring(err)})
+end
+
+local function clear_plugin_error(plugin_name)
+    if plugin_errors[plugin_name] then
+        plugin_errors[plugin_name] = nil
+        SendCommand("plugin_error", {plugin = plugin_name, error = ""})
+    end
+end
+
 -- Plugin hook functions
 local function call_plugin_hook(hook_name, ...)
     for plugin_name, plugin_data in pairs(loaded_plugins) do
@@ -74,7 +91,7 @@ local function load_single_plugin(plugin_name, settings)
     local plugin_lua_path = plugin_path .. "/plugin.lua"
 
     if not file_exists(plugin_lua_path) then
-        console.log("Plugin " .. plugin_name .. " missing required files")
+        report_plugin_error(plugin_name, "missing required file plugin.lua")
         return false
     end
 
@@ -107,7 +124,7 @@ local function load_single_plugin(plugin_name, settings)
         local is_valid = true
         for _, hook in ipairs(available_hooks) do
             if plugin_module[hook] and type(plugin_module[hook]) ~= "function" then
-                console.log("Plugin " .. plugin_name .. " has invalid hook '" .. hook .. "'; must be a function")
+                report_plugin_error(plugin_name, "invalid hook '" .. hook .. "'; must be a function")
                 is_valid = false
             end
         end
@@ -150,14 +167,16 @@ local function load_single_plugin(plugin_name, settings)
                     plugin_module._initialized = true
                     console.log("Plugin " .. plugin_name .. " initialized successfully")
                 else
-                    console.log("Plugin " .. plugin_name .. " init error: " .. tostring(init_err))
+                    report_plugin_error(plugin_name, "init error: " .. tostring(init_err))
+                    return true
                 end
             end
+            clear_plugin_error(plugin_name)
             console.log("Plugin " .. plugin_name .. " loaded successfully")
             return true
         end
     else
-        console.log("Failed to load plugin " .. plugin_name .. ": " .. tostring(plugin_module))
+        report_plugin_error(plugin_name, "load error: " .. tostring(plugin_module))
         return false
     end
 end
@@ -218,7 +237,7 @@ local function reload_plugin_file(plugin_name)
                     plugin_data._initialized = true
                     console.log("Plugin " .. plugin_name .. " re-initialized successfully")
                 else
-                    console.log("Plugin " .. plugin_name .. " init error: " .. tostring(init_err))
+                    report_plugin_error(plugin_name, "init error: " .. tostring(init_err))
                 end
             end
 
@@ -744,6 +763,14 @@ local function handle_line(line)
                     error("AUTOSAVE command requires 'true' or 'false' argument")
                 end
             end)
+        elseif cmd == "SCREENSHOT" then
+            safe_exec_and_ack(id, function()
+                local path = parts[4]
+                if not path or path == "" then
+                    error("SCREENSHOT command requires a path")
+                end
+                client.screenshot(path)
+            end)
         else
             send_line("NACK|" .. id .. "|Unknown command: " .. tostring(cmd))
         end
@@ -834,6 +861,9 @@ while true do
             client_socket = c
             client_socket:settimeout(0)
             send_hello()
+            for plugin_name, err in pairs(plugin_errors) do
+                SendCommand("plugin_error", {plugin = plugin_name, error = err})
+            end
         end
     else
         -- read lines
@@ -2,7 +2,10 @@ package clienthost
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,7 +17,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/michael4d45/bizshuffle/clienthost/installer"
+	"github.com/michael4d45/bizshuffle/protocol"
 )
 
 // ErrNotFound is returned when the server responds with HTTP 404.
@@ -23,12 +30,19 @@ var ErrNotFound = errors.New("not found")
 // ErrFileLocked is returned when a save file is in use by another process.
 var ErrFileLocked = errors.New("file locked")
 
+// errUploadTransient marks save upload failures worth retrying: the request
+// could not complete or the server answered with a 5xx.
+var errUploadTransient = errors.New("transient upload failure")
+
 // API centralises HTTP interactions with the server for the client.
 type API struct {
 	BaseURL    string
 	HTTPClient *http.Client
 	cfg        Config
 	Ctx        context.Context
+	// serverGzip is set once a save response advertises "Accept-Encoding: gzip",
+	// after which save uploads are sent gzip-compressed.
+	serverGzip atomic.Bool
 }
 
 // NewAPI constructs an API instance. base may be empty.
@@ -69,6 +83,34 @@ func (a *API) GetState(dest any) error {
 	return json.Unmarshal(data, dest)
 }
 
+// FileTree lists every file under the server's roms/{dir}, recursively, via
+// /api/files/tree. Names are relative to roms/ like other /files/ paths.
+func (a *API) FileTree(ctx context.Context, dir string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", a.BaseURL+"/api/files/tree?dir="+url.QueryEscape(dir), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", dir, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("bad status %s: %s", resp.Status, string(b))
+	}
+	var body struct {
+		Files []string `json:"files"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Files, nil
+}
+
 // FetchServerState fetches the server state and extracts whether the server
 // is running and the current game for the given player name (if any).
 // It returns (running, playerGame, error).
@@ -158,9 +200,42 @@ func waitForFileStable(filePath string, timeout time.Duration) error {
 	return nil
 }
 
-// UploadSave uploads a local save file to the server.
-func (a *API) UploadSaveState(instanceID string) error {
+// noteServerEncodings records whether the server accepts gzip request bodies.
+func (a *API) noteServerEncodings(resp *http.Response) {
+	for _, part := range strings.Split(resp.Header.Get("Accept-Encoding"), ",") {
+		if strings.EqualFold(strings.TrimSpace(part), "gzip") {
+			a.serverGzip.Store(true)
+			return
+		}
+	}
+}
+
+// UploadSaveState uploads a local save file to the server, retrying transient
+// failures with exponential backoff per Config.saveUploadPolicy. A non-empty
+// owner stores it on the server as that player's own save of the instance
+// (<instance>__<owner>.state); the local file is always ./saves/<instance>.state.
+func (a *API) UploadSaveState(instanceID, owner string) error {
+	attempts, backoff, timeout := a.cfg.saveUploadPolicy()
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(a.Ctx, timeout)
+		err := a.uploadSaveStateOnce(ctx, instanceID, owner)
+		cancel()
+		if err == nil || !errors.Is(err, errUploadTransient) || attempt >= attempts {
+			return err
+		}
+		log.Printf("upload save %s: attempt %d/%d failed: %v; retrying in %s", instanceID, attempt, attempts, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-a.Ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func (a *API) uploadSaveStateOnce(ctx context.Context, instanceID, owner string) error {
 	localPath := "./saves/" + instanceID + ".state"
+	remoteName := protocol.SaveKey(instanceID, owner) + ".state"
 
 	log.Println("Waiting for file to be stable before uploading")
 	if err := waitForFileStable(localPath, 2*time.Second); err != nil {
@@ -189,35 +264,57 @@ func (a *API) UploadSaveState(instanceID string) error {
 	if len(data) > clientSaveMaxBytes {
 		return fmt.Errorf("save file too large")
 	}
-	if err := verifySaveFileBytes(data); err != nil {
+	if err := verifySaveFileBytes(data, a.cfg.requireZipSaves()); err != nil {
 		return err
 	}
 	var buf bytes.Buffer
 	w := multipart.NewWriter(&buf)
-	fw, err := w.CreateFormFile("save", filepath.Base(localPath))
+	fw, err := w.CreateFormFile("save", remoteName)
 	if err != nil {
 		return err
 	}
 	if _, err := fw.Write(data); err != nil {
 		return err
 	}
-	_ = w.WriteField("filename", filepath.Base(localPath))
+	_ = w.WriteField("filename", remoteName)
 	if err := w.Close(); err != nil {
 		return err
 	}
-	req, err := http.NewRequestWithContext(a.Ctx, "POST", a.BaseURL+"/save/upload", &buf)
+	body := &buf
+	compressed := a.serverGzip.Load()
+	if compressed {
+		var gzBuf bytes.Buffer
+		gz := gzip.NewWriter(&gzBuf)
+		if _, err := gz.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		log.Printf("upload save %s: %s raw, %s gzip", instanceID, formatBytes(int64(buf.Len())), formatBytes(int64(gzBuf.Len())))
+		body = &gzBuf
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.BaseURL+"/save/upload", body)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", w.FormDataContentType())
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
 	resp, err := a.HTTPClient.Do(req)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", errUploadTransient, err)
 	}
 	defer func() { _ = resp.Body.Close() }()
+	a.noteServerEncodings(resp)
 	if resp.StatusCode != http.StatusOK {
 		data, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload failed: %s %s", resp.Status, string(data))
+		err := fmt.Errorf("upload failed: %s %s", resp.Status, string(data))
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("%w: %w", errUploadTransient, err)
+		}
+		return err
 	}
 	return nil
 }
@@ -249,22 +346,50 @@ func (a *API) UploadNoSaveState(instanceID string) error {
 	return nil
 }
 
+// UploadScreenshot posts the PNG at path to /api/screenshots/{player}.
+func (a *API) UploadScreenshot(player, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(a.Ctx, "POST", a.BaseURL+"/api/screenshots/"+url.PathEscape(player), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "image/png")
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("screenshot upload failed: %s %s", resp.Status, string(body))
+	}
+	return nil
+}
+
 // DownloadSave downloads a save file for player/filename into ./saves/player.
 // Returns ErrNotFound when the server responds 404.
 // Returns ErrFileLocked when the save file is in use by another process.
-func (a *API) EnsureSaveState(instanceID string) error {
+// A non-empty owner fetches that player's own save of the instance instead.
+func (a *API) EnsureSaveState(instanceID, owner string) error {
 	if instanceID == "" {
 		return nil
 	}
 
-	p := "/save/" + url.PathEscape(instanceID+".state")
+	p := "/save/" + url.PathEscape(protocol.SaveKey(instanceID, owner)+".state")
 	fetch := a.BaseURL + p
 	req, _ := http.NewRequestWithContext(a.Ctx, "GET", fetch, nil)
+	// Setting Accept-Encoding ourselves disables the transport's transparent
+	// decompression, so the gzip body is decoded below.
+	req.Header.Set("Accept-Encoding", "gzip")
 	resp, err := a.HTTPClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
+	a.noteServerEncodings(resp)
 	if resp.StatusCode != 200 {
 		if resp.StatusCode == http.StatusNotFound {
 			return ErrNotFound
@@ -298,20 +423,43 @@ func (a *API) EnsureSaveState(instanceID string) error {
 	}
 
 	defer func() { _ = out.Close() }()
-	data, err := io.ReadAll(io.LimitReader(resp.Body, clientSaveMaxBytes+1))
+	wire := &countingReader{r: resp.Body}
+	var body io.Reader = wire
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(wire)
+		if err != nil {
+			return fmt.Errorf("downloaded save gzip: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		body = gz
+	}
+	data, err := io.ReadAll(io.LimitReader(body, clientSaveMaxBytes+1))
 	if err != nil {
 		return err
 	}
 	if len(data) > clientSaveMaxBytes {
 		return fmt.Errorf("downloaded save too large")
 	}
-	if err := verifySaveFileBytes(data); err != nil {
+	log.Printf("download save %s: %s on the wire, %s decoded", instanceID, formatBytes(wire.n), formatBytes(int64(len(data))))
+	if err := verifySaveFileBytes(data, a.cfg.requireZipSaves()); err != nil {
 		return fmt.Errorf("downloaded save invalid: %w", err)
 	}
 	_, err = out.Write(data)
 	return err
 }
 
+// countingReader counts bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
 // FileInfo mirrors the server file list entry.
 type FileInfo struct {
 	Name string `json:"name"`
@@ -377,33 +525,10 @@ func (a *API) EnsureFile(ctx context.Context, name string) error {
 	}
 	fetch += "/files/" + name
 
-	// try up to 3 times
+	// try up to 3 times; each attempt resumes from the partial file left by the last
 	var lastErr error
 	for i := 0; i < 3; i++ {
-		req, _ := http.NewRequestWithContext(ctx, "GET", fetch, nil)
-		resp, err := a.HTTPClient.Do(req)
-		if err != nil {
-			lastErr = err
-			time.Sleep(500 * time.Millisecond)
-			continue
-		}
-		if resp.StatusCode != 200 {
-			lastErr = fmt.Errorf("bad status: %s", resp.Status)
-			_ = resp.Body.Close()
-			time.Sleep(500 * time.Millisecond)
-			continue
-		}
-		out, err := os.Create(dest)
-		if err != nil {
-			_ = resp.Body.Close()
-			return err
-		}
-		_, err = io.Copy(out, resp.Body)
-		_ = resp.Body.Close()
-		if err := out.Close(); err != nil {
-			_ = err
-		}
-		if err != nil {
+		if err := installer.ResumeDownload(ctx, a.HTTPClient, fetch, dest, nil); err != nil {
 			lastErr = err
 			time.Sleep(500 * time.Millisecond)
 			continue
@@ -412,3 +537,17 @@ func (a *API) EnsureFile(ctx context.Context, name string) error {
 	}
 	return lastErr
 }
+
+// romFileSHA256 returns the sha256 hex digest of ./roms/{name} so the server can detect mismatched ROMs.
+func romFileSHA256(name string) (string, error) {
+	f, err := os.Open(filepath.Join("./roms", filepath.FromSlash(name)))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
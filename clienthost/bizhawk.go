@@ -69,6 +69,31 @@ func (c *BizHawkController) VerifyBizHawkPath() error {
 	return nil
 }
 
+// bizhawkLaunchArgs builds the EmuHawk command line: --lua=<script> first,
+// then "bizhawk_args" in order. The script is "lua_script" from config when
+// set, else luaPath, else server.lua under dataDir; relative scripts resolve
+// against dataDir and are passed absolute because the Linux launcher script
+// changes directory before starting EmuHawk.
+func bizhawkLaunchArgs(cfg Config, dataDir, luaPath string) ([]string, error) {
+	if s := strings.TrimSpace(cfg["lua_script"]); s != "" {
+		luaPath = s
+	}
+	if luaPath == "" {
+		luaPath = filepath.Join(dataDir, "server.lua")
+	}
+	if !filepath.IsAbs(luaPath) {
+		luaPath = filepath.Join(dataDir, luaPath)
+	}
+	if abs, err := filepath.Abs(luaPath); err == nil {
+		luaPath = abs
+	}
+	extra, err := cfg.BizhawkArgs()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{"--lua=" + luaPath}, extra...), nil
+}
+
 // LaunchBizHawk starts EmuHawk with server.lua under dataDir and cwd set to dataDir.
 func (c *BizHawkController) LaunchBizHawk(ctx context.Context, dataDir, luaPath string) (*exec.Cmd, error) {
 	bp := c.cfg["bizhawk_path"]
@@ -168,10 +193,18 @@ func (c *BizHawkController) LaunchBizHawk(ctx context.Context, dataDir, luaPath
 	// On Linux the launcher script expects args relative to the install dir,
 	// and it changes working dir to the install dir. Emulate that by setting
 	// Cmd.Dir to the install dir so relative paths work.
-	if luaPath == "" {
-		luaPath = filepath.Join(dataDir, "server.lua")
+	args, err := bizhawkLaunchArgs(c.cfg, dataDir, luaPath)
+	if err != nil {
+		return nil, err
+	}
+	// Reapply the configured window geometry, which EmuHawk overwrites with its
+	// last position on exit. Bad values are logged rather than blocking launch.
+	if geom, err := c.cfg.WindowGeometry(); err != nil {
+		log.Printf("LaunchBizHawk: window geometry not applied: %v", err)
+	} else if err := applyWindowGeometry(filepath.Join(filepath.Dir(bp), "config.ini"), geom); err != nil {
+		log.Printf("LaunchBizHawk: window geometry not applied: %v", err)
 	}
-	args := []string{"--lua=" + luaPath}
+	log.Printf("LaunchBizHawk: args=%q", args)
 	cmd := exec.CommandContext(ctx, bp, args...)
 	cmd.Dir = dataDir
 	// ensure executable bit on non-windows
@@ -240,9 +273,11 @@ func (c *BizHawkController) LaunchAndManage(ctx context.Context, origCancel func
 			log.Printf("MonitorProcess: BizHawk pid=%d exited with err=%v", bhCmd.Process.Pid, err)
 			// Notify IPC that BizHawk has closed
 			c.bipc.SetBizhawkLaunched(false)
-			// Clear current process
+			// Clear current process unless a config relaunch already replaced it
 			c.processMutex.Lock()
-			c.currentProcess = nil
+			if c.currentProcess == cmd {
+				c.currentProcess = nil
+			}
 			c.processMutex.Unlock()
 			if !c.restartMode && c.onBizhawkLost != nil {
 				c.onBizhawkLost()
@@ -265,7 +300,7 @@ func (c *BizHawkController) LaunchAndManage(ctx context.Context, origCancel func
 		case s := <-sigs:
 			log.Printf("signal: %v", s)
 			log.Printf("terminating BizHawk due to signal: %v", s)
-			TerminateProcess(&bhCmd, &bhMu, 3*time.Second)
+			TerminateProcess(&bhCmd, &bhMu, c.cfg.terminateGrace())
 			// Notify IPC that BizHawk has closed
 			c.bipc.SetBizhawkLaunched(false)
 			log.Printf("signal handler: calling origCancel() after TerminateProcess")
@@ -309,33 +344,32 @@ func (c *BizHawkController) Terminate() {
 		log.Printf("terminating BizHawk pid=%d for config update", c.currentProcess.Process.Pid)
 		pid := c.currentProcess.Process.Pid
 
-		// Kill the process
-		if runtime.GOOS == "windows" {
-			log.Printf("killing BizHawk pid=%d (windows)", pid)
-			_ = c.currentProcess.Process.Kill()
+		// Ask BizHawk to close, then force kill after the grace period
+		grace := c.cfg.terminateGrace()
+		if err := requestClose(c.currentProcess.Process); err != nil {
+			log.Printf("graceful close of BizHawk pid=%d failed: %v", pid, err)
+			grace = 0
 		} else {
-			log.Printf("sending SIGTERM to BizHawk pid=%d", pid)
-			_ = c.currentProcess.Process.Signal(syscall.SIGTERM)
+			log.Printf("asked BizHawk pid=%d to close (grace %s)", pid, grace)
+		}
 
-			// Wait up to 5 seconds for graceful exit, then force kill
-			done := make(chan error, 1)
-			go func() {
-				done <- c.currentProcess.Wait()
-			}()
+		done := make(chan error, 1)
+		go func() {
+			done <- c.currentProcess.Wait()
+		}()
 
-			select {
-			case err := <-done:
-				if err != nil {
-					log.Printf("BizHawk pid=%d exited with error: %v", pid, err)
-				} else {
-					log.Printf("BizHawk pid=%d exited cleanly", pid)
-				}
-			case <-time.After(5 * time.Second):
-				log.Printf("BizHawk pid=%d didn't exit gracefully, force killing", pid)
-				_ = c.currentProcess.Process.Kill()
-				<-done // Wait for the kill to complete
-				log.Printf("BizHawk pid=%d force killed", pid)
+		select {
+		case err := <-done:
+			if err != nil {
+				log.Printf("BizHawk pid=%d exited with error: %v", pid, err)
+			} else {
+				log.Printf("BizHawk pid=%d exited cleanly", pid)
 			}
+		case <-time.After(grace):
+			log.Printf("BizHawk pid=%d didn't exit gracefully, force killing", pid)
+			_ = c.currentProcess.Process.Kill()
+			<-done // Wait for the kill to complete
+			log.Printf("BizHawk pid=%d force killed", pid)
 		}
 
 		// Clear the process reference
@@ -346,6 +380,58 @@ func (c *BizHawkController) Terminate() {
 	}
 }
 
+// configRestartExitWait bounds how long TerminateForConfig waits for the
+// process monitor to see BizHawk exit.
+const configRestartExitWait = 5 * time.Second
+
+// TerminateForConfig stops BizHawk for a config change without shutting the
+// client down: restart mode stays on until Lua sends HELLO again. It returns
+// once the exit has been observed, so config.ini is no longer in use, or an
+// error when BizHawk still looks alive after configRestartExitWait.
+func (c *BizHawkController) TerminateForConfig() error {
+	c.SetRestartMode(true)
+	c.Terminate()
+	for deadline := time.Now().Add(configRestartExitWait); c.bipc.IsBizhawkLaunched(); {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("BizHawk did not exit within %s", configRestartExitWait)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
+
+// RelaunchForConfig starts BizHawk again after TerminateForConfig. The new
+// process is monitored like the first launch, but its exit never cancels ctx;
+// onBizhawkLost still reports it outside restart mode.
+func (c *BizHawkController) RelaunchForConfig(ctx context.Context) {
+	dataDir := c.cfg["data_dir"]
+	cmd, err := c.LaunchBizHawk(ctx, dataDir, filepath.Join(dataDir, "server.lua"))
+	if err != nil {
+		log.Printf("relaunch BizHawk after config change: %v", err)
+		c.SetRestartMode(false)
+		if c.onBizhawkLost != nil {
+			c.onBizhawkLost()
+		}
+		return
+	}
+	c.bipc.SetBizhawkLaunched(true)
+	c.processMutex.Lock()
+	c.currentProcess = cmd
+	c.processMutex.Unlock()
+	MonitorProcess(cmd, func(err error) {
+		log.Printf("MonitorProcess: relaunched BizHawk pid=%d exited with err=%v", cmd.Process.Pid, err)
+		c.bipc.SetBizhawkLaunched(false)
+		c.processMutex.Lock()
+		if c.currentProcess == cmd {
+			c.currentProcess = nil
+		}
+		c.processMutex.Unlock()
+		if !c.restartMode && c.onBizhawkLost != nil {
+			c.onBizhawkLost()
+		}
+	})
+}
+
 // MonitorProcess waits for the process to exit and calls onExit.
 func MonitorProcess(cmd *exec.Cmd, onExit func(error)) {
 	if cmd == nil {
@@ -364,10 +450,10 @@ func MonitorProcess(cmd *exec.Cmd, onExit func(error)) {
 // the same mutex (the pattern used in run.go). This preserves the original
 // locking behavior while centralizing platform differences.
 //
-// On Windows the function will call Process.Kill() immediately because
-// POSIX signals are not supported there in the same way. On other OSes it
-// sends SIGTERM and schedules a forced kill after the grace period if the
-// process hasn't exited.
+// The close request is WM_CLOSE to BizHawk's windows on Windows and SIGTERM
+// elsewhere (see requestClose). A forced kill is scheduled after the grace
+// period if the process hasn't exited, and happens at once if the request
+// could not be delivered.
 func TerminateProcess(cmdPtr **exec.Cmd, mu *sync.Mutex, grace time.Duration) {
 	if cmdPtr == nil || mu == nil {
 		return
@@ -380,17 +466,14 @@ func TerminateProcess(cmdPtr **exec.Cmd, mu *sync.Mutex, grace time.Duration) {
 	}
 	pid := cmd.Process.Pid
 
-	// Windows: kill immediately
-	if runtime.GOOS == "windows" {
-		log.Printf("killing BizHawk pid=%d (windows)", pid)
+	// Ask the process to close and schedule a force kill after grace
+	log.Printf("asking BizHawk pid=%d to close", pid)
+	if err := requestClose(cmd.Process); err != nil {
+		log.Printf("graceful close of BizHawk pid=%d failed, killing: %v", pid, err)
 		_ = cmd.Process.Kill()
 		mu.Unlock()
 		return
 	}
-
-	// POSIX: send SIGTERM and schedule a force kill after grace
-	log.Printf("sending SIGTERM to BizHawk pid=%d", pid)
-	_ = cmd.Process.Signal(syscall.SIGTERM)
 	mu.Unlock()
 
 	if grace <= 0 {
@@ -448,27 +531,13 @@ func (c *BizHawkController) UpdateBizHawk(progress func(string)) error {
 	progress(fmt.Sprintf("Latest version is %s", tagName))
 
 	// Find the appropriate asset
-	platformSuffix := installer.GetBizHawkPlatformSuffix()
-	assetName := fmt.Sprintf("BizHawk-%s-%s.zip", tagName, platformSuffix)
-	asset := rel.FindAssetByName(assetName)
+	asset := installer.FindBizHawkAsset(rel)
 	if asset == nil {
-		// Try alternative naming pattern
-		assetName = fmt.Sprintf("BizHawk-%s-%s.zip", rel.TagName, platformSuffix)
-		asset = rel.FindAssetByName(assetName)
+		return fmt.Errorf("could not find BizHawk asset for platform %s", installer.GetBizHawkPlatformSuffix())
 	}
-
-	if asset == nil {
-		// Fallback: search for any zip with platform suffix
-		for _, a := range rel.Assets {
-			if strings.Contains(a.Name, platformSuffix) && strings.HasSuffix(a.Name, ".zip") {
-				asset = &a
-				break
-			}
-		}
-	}
-
-	if asset == nil {
-		return fmt.Errorf("could not find BizHawk asset for platform %s", platformSuffix)
+	sum, err := installer.FindAssetSHA256(c.httpClient, rel, asset)
+	if err != nil {
+		return fmt.Errorf("failed to get BizHawk checksum: %w", err)
 	}
 
 	bp := c.cfg["bizhawk_path"]
@@ -507,7 +576,7 @@ func (c *BizHawkController) UpdateBizHawk(progress func(string)) error {
 
 	progress(fmt.Sprintf("Downloading BizHawk %s...", tagName))
 	bhInstaller := installer.NewBizHawkInstaller()
-	if err := bhInstaller.InstallBizHawk(asset.DownloadURL, bizhawkDir, progress); err != nil {
+	if err := bhInstaller.InstallBizHawkVerified(asset.DownloadURL, sum, bizhawkDir, progress); err != nil {
 		return fmt.Errorf("failed to install BizHawk: %w", err)
 	}
 
@@ -602,10 +671,26 @@ func (c *BizHawkController) StartIPCGoroutine(ctx context.Context) {
 					// don't cancel the main context here; allow reconnect logic to run
 					continue
 				}
+				if line == MsgUnresponsive {
+					log.Printf("ipc handler: BizHawk hung (no PONG); notifying server")
+					obslog.Event(obslog.Lua, "unresponsive", nil)
+					if err := c.wsClient.SendBizhawkReadinessUpdate(false); err != nil {
+						log.Printf("ipc handler: failed to send BizHawk readiness update: %v", err)
+					}
+					continue
+				}
+				if line == MsgResponsive {
+					log.Printf("ipc handler: BizHawk responsive again; notifying server")
+					obslog.Event(obslog.Lua, "responsive", nil)
+					if err := c.wsClient.SendBizhawkReadinessUpdate(true); err != nil {
+						log.Printf("ipc handler: failed to send BizHawk readiness update: %v", err)
+					}
+					continue
+				}
 				log.Printf("lua incoming: %s", line)
 				if strings.HasPrefix(line, msgHELLO) {
 					log.Printf("ipc handler: received HELLO from lua")
-					wsConnected, _ := c.wsClient.GetConnectionStatus()
+					wsConnected, _, _ := c.wsClient.GetConnectionStatus()
 					obslog.Event(obslog.Lua, "hello", map[string]string{
 						"ws_connected": fmt.Sprintf("%v", wsConnected),
 					})
@@ -630,6 +715,22 @@ func (c *BizHawkController) StartIPCGoroutine(ctx context.Context) {
 					// Parse and log Lua command messages for now.
 					if cmd, err := protocol.ParseLuaCommand(line); err != nil {
 						log.Printf("ipc handler: failed to parse CMD line: %v", err)
+					} else if cmd.Kind == protocol.LuaCmdPluginError {
+						log.Printf("ipc handler: plugin %q error: %q", cmd.Fields["plugin"], cmd.Fields["error"])
+						if err := c.wsClient.Send(protocol.Command{
+							Cmd: protocol.CmdPluginError,
+							Payload: map[string]string{
+								"plugin": cmd.Fields["plugin"],
+								"error":  cmd.Fields["error"],
+							},
+						}); err != nil {
+							log.Printf("ipc handler: failed to send plugin error: %v", err)
+						}
+					} else if cmd.Kind == protocol.LuaCmdVoteSkip {
+						log.Printf("ipc handler: plugin voted to skip the current game")
+						if err := c.wsClient.SendVoteSkip(); err != nil {
+							log.Printf("ipc handler: failed to send vote skip: %v", err)
+						}
 					} else {
 						log.Printf("ipc handler: parsed CMD: kind=%q fields=%v", cmd.Kind, cmd.Fields)
 						if err := c.wsClient.Send(
@@ -646,9 +747,13 @@ func (c *BizHawkController) StartIPCGoroutine(ctx context.Context) {
 	}()
 }
 
+// bizhawkFilesTimeout bounds the whole BizhawkFiles.zip download so a stalled
+// server can't hang client startup.
+const bizhawkFilesTimeout = 10 * time.Minute
+
 // EnsureBizhawkFiles downloads and extracts BizhawkFiles.zip if config.ini doesn't exist
 // in the BizHawk directory. Returns nil if files already exist or were successfully downloaded.
-func (c *BizHawkController) EnsureBizhawkFiles() error {
+func (c *BizHawkController) EnsureBizhawkFiles(ctx context.Context) error {
 	if c.api == nil {
 		return fmt.Errorf("API not available, cannot download BizhawkFiles")
 	}
@@ -690,33 +795,15 @@ func (c *BizHawkController) EnsureBizhawkFiles() error {
 	tempZip := filepath.Join(os.TempDir(), "BizhawkFiles.zip")
 	defer func() { _ = os.Remove(tempZip) }()
 
-	// Download the file
+	// Download the file, giving up after bizhawkFilesTimeout or when ctx is cancelled.
+	// A failed attempt removes its partial file rather than resuming a stalled transfer.
 	log.Printf("Downloading BizhawkFiles.zip from %s...", bizFilesURL)
-	resp, err := c.httpClient.Get(bizFilesURL)
-	if err != nil {
+	dlCtx, cancel := context.WithTimeout(ctx, bizhawkFilesTimeout)
+	defer cancel()
+	if err := installer.ResumeDownload(dlCtx, c.httpClient, bizFilesURL, tempZip, logDownloadProgress("BizhawkFiles.zip")); err != nil {
+		_ = os.Remove(tempZip + ".part")
 		return fmt.Errorf("failed to download BizhawkFiles.zip: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed: status %s", resp.Status)
-	}
-
-	// Save to temporary file
-	out, err := os.Create(tempZip)
-	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
-	}
-
-	_, err = io.Copy(out, resp.Body)
-	if cerr := out.Close(); cerr != nil {
-		if err == nil {
-			err = cerr
-		}
-	}
-	if err != nil {
-		return fmt.Errorf("failed to save BizhawkFiles.zip: %w", err)
-	}
 
 	// Extract the zip file
 	log.Printf("Extracting BizhawkFiles.zip to %s...", bizhawkDir)
@@ -733,6 +820,24 @@ func (c *BizHawkController) EnsureBizhawkFiles() error {
 	return nil
 }
 
+// logDownloadProgress returns a ResumeDownload progress callback that logs every
+// 10% of a known size, or every 10 MiB when the server sends no length.
+func logDownloadProgress(name string) func(current, total int64) {
+	var next int64
+	return func(current, total int64) {
+		if current < next {
+			return
+		}
+		if total > 0 {
+			log.Printf("Downloading %s: %d%% (%d/%d bytes)", name, current*100/total, current, total)
+			next = current + max(total/10, 1)
+		} else {
+			log.Printf("Downloading %s: %d bytes", name, current)
+			next = current + 10<<20
+		}
+	}
+}
+
 // extractZip extracts a zip file to the destination directory
 func (c *BizHawkController) extractZip(zipPath, destDir string) error {
 	r, err := zip.OpenReader(zipPath)
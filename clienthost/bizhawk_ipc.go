@@ -6,9 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"net"
 	"os"
-	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -23,9 +23,15 @@ const (
 	msgHELLO = "HELLO"
 	msgCMD   = "CMD"
 	msgPING  = "PING"
+	msgPONG  = "PONG"
 	// sentinel used to notify consumers that the IPC connection was lost
 	// exported so callers can react when the Lua side disconnects.
 	MsgDisconnected = "__BIZHAWK_IPC_DISCONNECTED__"
+	// MsgUnresponsive is sent when Lua stops answering liveness PINGs while
+	// the connection stays open (BizHawk hung); MsgResponsive when it answers
+	// again.
+	MsgUnresponsive = "__BIZHAWK_IPC_UNRESPONSIVE__"
+	MsgResponsive   = "__BIZHAWK_IPC_RESPONSIVE__"
 )
 
 // Pending command waiting for ack
@@ -35,6 +41,7 @@ type pendingCmd struct {
 	sentAt   time.Time
 	attempts int
 	line     string
+	timeout  time.Duration // how long this command waits for its ACK
 }
 
 // Queued command to be sent sequentially
@@ -43,21 +50,65 @@ type queuedCmd struct {
 	ch    chan error
 }
 
+// ipcAckTimeout is how long a command waits for the Lua ACK or NACK unless
+// configured otherwise (Config.ipcCommandTimeouts).
+const ipcAckTimeout = 10 * time.Second
+
+// ipcMaxAckTimeout caps configured ACK timeouts so a swap's SAVE and LOAD
+// still answer inside the server's 20s swap wait.
+const ipcMaxAckTimeout = 18 * time.Second
+
+// ipcPingInterval is how often a ready IPC sends a liveness PING to Lua;
+// ipcPingTimeout is how long without a PONG before BizHawk counts as hung.
+const (
+	ipcPingInterval = 5 * time.Second
+	ipcPingTimeout  = 15 * time.Second
+)
+
+// ipcTransport opens the connection to the Lua side. The connection is used as
+// a line reader/writer; read deadlines make the read loop cancellable.
+type ipcTransport interface {
+	Dial(addr string) (net.Conn, error)
+}
+
+// tcpTransport dials server.lua on its localhost TCP port.
+type tcpTransport struct{}
+
+func (tcpTransport) Dial(addr string) (net.Conn, error) {
+	return net.DialTimeout("tcp", addr, 2*time.Second)
+}
+
 // BizhawkIPC provides a small bridge to the Lua script listening on a TCP port
 type BizhawkIPC struct {
-	dataDir  string
-	addr     string
-	mu       sync.Mutex
-	conn     net.Conn
-	reader   *bufio.Reader
-	pending  *pendingCmd
-	incoming chan string
-	closed   bool
+	dataDir   string
+	addr      string
+	transport ipcTransport
+	// ackTimeout overrides ipcAckTimeout when set; cmdTimeouts overrides it
+	// per command name (e.g. "SAVE"). Both guarded by mu.
+	ackTimeout  time.Duration
+	cmdTimeouts map[string]time.Duration
+	mu          sync.Mutex
+	conn        net.Conn
+	reader      *bufio.Reader
+	pending     *pendingCmd
+	incoming    chan string
+	closed      bool
 	// ready indicates whether the Lua side has completed its HELLO handshake
 	// and the IPC is considered ready to accept commands. Use the provided
 	// accessor methods to read/update this flag.
 	readyMu sync.Mutex
 	ready   bool
+	// connectFailures counts consecutive failed connect attempts in readLoop;
+	// guarded by readyMu and reset when the HELLO handshake completes.
+	connectFailures int
+	// lastPong is when Lua last answered a liveness PING; hung is set while
+	// it has not answered within the ping timeout. Both guarded by readyMu.
+	lastPong time.Time
+	hung     bool
+	// pingInterval and pingTimeout override ipcPingInterval and
+	// ipcPingTimeout when set (tests).
+	pingInterval time.Duration
+	pingTimeout  time.Duration
 
 	// bizhawkLaunched tracks whether BizHawk has been launched by the client
 	// This prevents IPC from attempting connections before BizHawk is available
@@ -73,16 +124,31 @@ type BizhawkIPC struct {
 
 // NewBizhawkIPC reserves a Lua listen port and writes lua_server_port.txt under dataDir.
 func NewBizhawkIPC(dataDir string) (*BizhawkIPC, error) {
-	port, err := ReserveLuaPort()
-	if err != nil {
-		return nil, err
+	return NewBizhawkIPCOnPort(dataDir, 0)
+}
+
+// NewBizhawkIPCOnPort is NewBizhawkIPC with a pinned port (config "ipc_port").
+// A pinned port that is already taken is an error; port 0 scans from 55355.
+func NewBizhawkIPCOnPort(dataDir string, port int) (*BizhawkIPC, error) {
+	source := "pinned"
+	if port == 0 {
+		source = "scanned"
+		p, err := ReserveLuaPort()
+		if err != nil {
+			return nil, err
+		}
+		port = p
+	} else if err := checkLuaPortFree(port); err != nil {
+		return nil, fmt.Errorf("lua ipc port %d (ipc_port) unavailable: %w", port, err)
 	}
+	log.Printf("bizhawk ipc: using lua port %d (%s)", port, source)
 	if err := WriteLuaPortFile(dataDir, port); err != nil {
 		return nil, err
 	}
 	return &BizhawkIPC{
 		dataDir:      dataDir,
 		addr:         fmt.Sprintf("127.0.0.1:%d", port),
+		transport:    tcpTransport{},
 		pending:      nil,
 		incoming:     make(chan string, 16),
 		commandQueue: make(chan *queuedCmd, 16),
@@ -106,13 +172,72 @@ func ReserveLuaPort() (int, error) {
 	}
 }
 
+// checkLuaPortFree reports an error if port cannot be bound on localhost.
+func checkLuaPortFree(port int) error {
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return err
+	}
+	return ln.Close()
+}
+
 // WriteLuaPortFile writes the port for server.lua to read at BizHawk launch.
+// The file is replaced atomically so a launching BizHawk never reads it half-written.
 func WriteLuaPortFile(dataDir string, port int) error {
 	if err := os.MkdirAll(dataDir, 0o755); err != nil {
 		return err
 	}
-	path := filepath.Join(dataDir, "lua_server_port.txt")
-	return os.WriteFile(path, fmt.Appendf(nil, "%d\n", port), 0o644)
+	path := PortFilePath(dataDir)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, fmt.Appendf(nil, "%d\n", port), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// readLuaPortFile returns the port recorded in dataDir, or 0 if none.
+func readLuaPortFile(dataDir string) int {
+	data, err := os.ReadFile(PortFilePath(dataDir))
+	if err != nil {
+		return 0
+	}
+	port, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return port
+}
+
+// ensurePortFile rewrites the port file if it has gone missing while the IPC
+// is open, so BizHawk relaunched mid-session (e.g. for a config update) can
+// always find the port. The file lives as long as the IPC, not a connection.
+func (b *BizhawkIPC) ensurePortFile() {
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed || b.dataDir == "" {
+		return
+	}
+	if _, err := os.Stat(PortFilePath(b.dataDir)); !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err := WriteLuaPortFile(b.dataDir, b.Port()); err != nil {
+		log.Printf("bizhawk ipc: restore lua port file: %v", err)
+		return
+	}
+	log.Printf("bizhawk ipc: restored missing lua port file (port %d)", b.Port())
+}
+
+// removePortFile deletes the port file on Close unless a newer IPC has
+// already written its own port there.
+func (b *BizhawkIPC) removePortFile() {
+	if b.dataDir == "" || readLuaPortFile(b.dataDir) != b.Port() {
+		return
+	}
+	if err := os.Remove(PortFilePath(b.dataDir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("bizhawk ipc: remove lua port file: %v", err)
+	}
 }
 
 // Start connects and starts background readers and resender
@@ -134,6 +259,7 @@ func (b *BizhawkIPC) Start(ctx context.Context) error {
 		b.commandProcessor(ctx)
 		log.Printf("bizhawk ipc: commandProcessor goroutine exited")
 	}()
+	go b.livenessLoop(ctx)
 	return nil
 }
 
@@ -150,7 +276,11 @@ func (b *BizhawkIPC) connect() error {
 			return 0
 		}
 	}())
-	c, err := net.DialTimeout("tcp", b.addr, 2*time.Second)
+	transport := b.transport
+	if transport == nil {
+		transport = tcpTransport{}
+	}
+	c, err := transport.Dial(b.addr)
 	if err != nil {
 		log.Printf("bizhawk ipc: connect error: %v", err)
 		return err
@@ -163,7 +293,6 @@ func (b *BizhawkIPC) connect() error {
 // Reset clears connection state for restart without fully closing the IPC
 func (b *BizhawkIPC) Reset() {
 	b.mu.Lock()
-	defer b.mu.Unlock()
 
 	// Close existing connection if any
 	if b.conn != nil {
@@ -181,7 +310,10 @@ func (b *BizhawkIPC) Reset() {
 		b.pending = nil
 	}
 
+	b.mu.Unlock()
+
 	log.Printf("bizhawk ipc: reset connection state for restart")
+	b.ensurePortFile()
 }
 
 func (b *BizhawkIPC) Close() error {
@@ -205,6 +337,7 @@ func (b *BizhawkIPC) Close() error {
 
 	// closing incoming so consumers will see range() end
 	close(b.incoming)
+	b.removePortFile()
 	log.Printf("bizhawk ipc: closed and incoming channel closed")
 	return nil
 }
@@ -269,13 +402,16 @@ func (b *BizhawkIPC) readLoop(ctx context.Context) {
 			}
 
 			// try reconnect
+			b.ensurePortFile()
 			if err := b.connect(); err != nil {
-				log.Printf("bizhawk ipc: connect failed in readLoop: %v", err)
+				attempt := b.noteConnectFailure()
+				delay := ipcReconnectDelay(attempt)
+				log.Printf("bizhawk ipc: connect failed in readLoop (attempt %d, retrying in %s): %v", attempt, delay.Round(time.Millisecond), err)
 				select {
 				case <-ctx.Done():
 					log.Printf("bizhawk ipc: readLoop context done while reconnecting")
 					return
-				case <-time.After(1 * time.Second):
+				case <-time.After(delay):
 					continue
 				}
 			}
@@ -401,6 +537,19 @@ func (b *BizhawkIPC) handleLine(line string) {
 				log.Printf("bizhawk ipc: replied with PONG %s", ts)
 			}
 		}
+	case msgPONG:
+		b.readyMu.Lock()
+		b.lastPong = time.Now()
+		recovered := b.hung
+		if recovered {
+			b.hung = false
+			b.ready = true
+		}
+		b.readyMu.Unlock()
+		if recovered {
+			log.Printf("bizhawk ipc: PONG received; BizHawk responsive again")
+			b.safeSend(MsgResponsive)
+		}
 	default:
 		// forward other messages to incoming channel
 		if b.safeSend(line) {
@@ -441,12 +590,39 @@ func (b *BizhawkIPC) commandProcessor(ctx context.Context) {
 	}
 }
 
+// SetCommandTimeouts sets how long commands wait for the Lua ACK: def for
+// every command (0 keeps ipcAckTimeout) and perCmd by command name.
+func (b *BizhawkIPC) SetCommandTimeouts(def time.Duration, perCmd map[string]time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ackTimeout = def
+	b.cmdTimeouts = perCmd
+}
+
+// commandTimeout returns the ACK timeout for the command named name.
+func (b *BizhawkIPC) commandTimeout(name string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if d := b.cmdTimeouts[name]; d > 0 {
+		return d
+	}
+	if b.ackTimeout > 0 {
+		return b.ackTimeout
+	}
+	return ipcAckTimeout
+}
+
 // processCommand sends a command and waits for response
 func (b *BizhawkIPC) processCommand(ctx context.Context, qc *queuedCmd) {
 	id := strconv.FormatInt(time.Now().UnixNano(), 10)
 	line := "CMD|" + id + "|" + strings.Join(qc.parts, "|")
+	name := ""
+	if len(qc.parts) > 0 {
+		name = qc.parts[0]
+	}
+	timeout := b.commandTimeout(name)
 
-	pc := &pendingCmd{id: id, ch: make(chan error, 1), sentAt: time.Now(), attempts: 1, line: line}
+	pc := &pendingCmd{id: id, ch: make(chan error, 1), sentAt: time.Now(), attempts: 1, line: line, timeout: timeout}
 	b.mu.Lock()
 	b.pending = pc
 	b.mu.Unlock()
@@ -459,18 +635,23 @@ func (b *BizhawkIPC) processCommand(ctx context.Context, qc *queuedCmd) {
 		return
 	}
 
-	select {
-	case <-ctx.Done():
-		qc.ch <- ctx.Err()
-	case err := <-pc.ch:
-		qc.ch <- err
-	case <-time.After(10 * time.Second):
+	// Drop the pending entry so a late ACK for it is ignored.
+	clearPending := func() {
 		b.mu.Lock()
 		if b.pending == pc {
 			b.pending = nil
 		}
 		b.mu.Unlock()
-		qc.ch <- fmt.Errorf("timeout waiting for ACK: %s", line)
+	}
+	select {
+	case <-ctx.Done():
+		clearPending()
+		qc.ch <- ctx.Err()
+	case err := <-pc.ch:
+		qc.ch <- err
+	case <-time.After(timeout):
+		clearPending()
+		qc.ch <- fmt.Errorf("timeout waiting for ACK after %s: %s", timeout, line)
 	}
 }
 
@@ -489,6 +670,13 @@ func (b *BizhawkIPC) SendSwap(ctx context.Context, game string, instanceID strin
 	return b.SendCommand(ctx, "SWAP", game, instanceID)
 }
 
+// SendLoad opens game and loads instanceID's save without saving the running game first.
+func (b *BizhawkIPC) SendLoad(ctx context.Context, game string, instanceID string) error {
+	b.instanceID = instanceID
+	b.game = game
+	return b.SendCommand(ctx, "LOAD", game, instanceID)
+}
+
 func (b *BizhawkIPC) SendPause(ctx context.Context) error {
 	b.running = false
 	return b.SendCommand(ctx, "PAUSE")
@@ -519,6 +707,11 @@ func (b *BizhawkIPC) SendPluginReload(ctx context.Context, pluginName string) er
 	return b.SendCommand(ctx, "PLUGIN_RELOAD", pluginName)
 }
 
+// SendScreenshot asks BizHawk to write a PNG of the current frame to path.
+func (b *BizhawkIPC) SendScreenshot(ctx context.Context, path string) error {
+	return b.SendCommand(ctx, "SCREENSHOT", path)
+}
+
 func (b *BizhawkIPC) SendAutoSaveEnable(ctx context.Context) error {
 	return b.SendCommand(ctx, "AUTOSAVE", "true")
 }
@@ -533,7 +726,91 @@ func (b *BizhawkIPC) SendAutoSaveDisable(ctx context.Context) error {
 func (b *BizhawkIPC) SetReady(v bool) {
 	b.readyMu.Lock()
 	b.ready = v
+	b.hung = false
+	if v {
+		b.connectFailures = 0
+		b.lastPong = time.Now()
+	}
+	b.readyMu.Unlock()
+}
+
+// livenessLoop PINGs Lua while the IPC is ready (or hung) so a BizHawk that
+// stops responding with the socket still open is noticed.
+func (b *BizhawkIPC) livenessLoop(ctx context.Context) {
+	interval := b.pingInterval
+	if interval <= 0 {
+		interval = ipcPingInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			b.probeLiveness(now)
+		}
+	}
+}
+
+// probeLiveness marks the IPC hung and unready once no PONG has arrived for
+// the ping timeout, then sends the next PING. Lua can't answer PINGs while it
+// runs a command, so with one in flight the threshold is stretched to that
+// command's ACK timeout; a slow SAVE times out as a command, not as a hang.
+func (b *BizhawkIPC) probeLiveness(now time.Time) {
+	timeout := b.pingTimeout
+	if timeout <= 0 {
+		timeout = ipcPingTimeout
+	}
+	b.mu.Lock()
+	if b.pending != nil {
+		timeout = max(timeout, b.pending.timeout)
+	}
+	b.mu.Unlock()
+	b.readyMu.Lock()
+	if !b.ready && !b.hung {
+		b.readyMu.Unlock()
+		return
+	}
+	silent := now.Sub(b.lastPong)
+	becameHung := !b.hung && silent > timeout
+	if becameHung {
+		b.hung = true
+		b.ready = false
+	}
 	b.readyMu.Unlock()
+	if becameHung {
+		log.Printf("bizhawk ipc: no PONG for %s; BizHawk appears hung, marking ipcReady=false", silent.Round(time.Millisecond))
+		b.safeSend(MsgUnresponsive)
+	}
+	if err := b.sendLine(msgPING + "|" + strconv.FormatInt(now.UnixNano(), 10)); err != nil {
+		log.Printf("bizhawk ipc: failed to send liveness PING: %v", err)
+	}
+}
+
+// noteConnectFailure records a failed connect attempt and returns the number
+// of consecutive failures since the last HELLO.
+func (b *BizhawkIPC) noteConnectFailure() int {
+	b.readyMu.Lock()
+	defer b.readyMu.Unlock()
+	b.connectFailures++
+	return b.connectFailures
+}
+
+const (
+	ipcReconnectBase = 1 * time.Second
+	ipcReconnectMax  = 10 * time.Second
+)
+
+// ipcReconnectDelay returns the wait before reconnect attempt n+1: exponential
+// from ipcReconnectBase, capped at ipcReconnectMax, plus up to 20% jitter.
+func ipcReconnectDelay(attempt int) time.Duration {
+	d := ipcReconnectBase
+	for i := 1; i < attempt && d < ipcReconnectMax; i++ {
+		d *= 2
+	}
+	d = min(d, ipcReconnectMax)
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
 }
 
 // IsReady returns the current ready flag.
@@ -544,6 +821,14 @@ func (b *BizhawkIPC) IsReady() bool {
 	return v
 }
 
+// IsHung reports whether Lua has stopped answering liveness PINGs while the
+// connection is still open.
+func (b *BizhawkIPC) IsHung() bool {
+	b.readyMu.Lock()
+	defer b.readyMu.Unlock()
+	return b.hung
+}
+
 // SetBizhawkLaunched sets whether BizHawk has been launched by the client.
 func (b *BizhawkIPC) SetBizhawkLaunched(launched bool) {
 	b.bizhawkLaunchedMu.Lock()
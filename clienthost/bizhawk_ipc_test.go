@@ -0,0 +1,361 @@
+package clienthost
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIPCReconnectDelayBacksOffAndCaps(t *testing.T) {
+	for attempt, base := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 50: 10 * time.Second} {
+		d := ipcReconnectDelay(attempt)
+		if d < base || d > base+base/5 {
+			t.Fatalf("attempt %d: delay %s outside [%s, %s]", attempt, d, base, base+base/5)
+		}
+	}
+}
+
+func TestIPCReconnectBackoffResetsOnReady(t *testing.T) {
+	b := &BizhawkIPC{}
+	b.noteConnectFailure()
+	b.noteConnectFailure()
+	if n := b.noteConnectFailure(); n != 3 {
+		t.Fatalf("failures %d", n)
+	}
+	b.SetReady(true)
+	if n := b.noteConnectFailure(); n != 1 {
+		t.Fatalf("expected backoff reset after HELLO, got attempt %d", n)
+	}
+}
+
+func TestLuaPortFileLivesWithIPCAcrossReconnect(t *testing.T) {
+	dir := t.TempDir()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	bipc, err := NewBizhawkIPCOnPort(dir, port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stand-in for server.lua listening on the reserved port.
+	lua, err := net.Listen("tcp", bipc.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lua.Close() })
+
+	assertPortFile := func(when string) {
+		t.Helper()
+		if got := readLuaPortFile(dir); got != port {
+			t.Fatalf("%s: port file = %d, want %d", when, got, port)
+		}
+	}
+	accept := func() net.Conn {
+		t.Helper()
+		_ = lua.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+		c, err := lua.Accept()
+		if err != nil {
+			t.Fatalf("accept: %v", err)
+		}
+		return c
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	bipc.SetBizhawkLaunched(true)
+	if err := bipc.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	conn := accept()
+	assertPortFile("connected")
+
+	// BizHawk exits and something removes the port file before it relaunches.
+	if err := os.Remove(PortFilePath(dir)); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	conn = accept()
+	defer func() { _ = conn.Close() }()
+	assertPortFile("reconnected")
+
+	bipc.Reset()
+	assertPortFile("after reset")
+
+	if err := bipc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(PortFilePath(dir)); !os.IsNotExist(err) {
+		t.Fatalf("port file should be removed on close, stat err = %v", err)
+	}
+}
+
+func TestIPCCloseKeepsNewerPortFile(t *testing.T) {
+	dir := t.TempDir()
+	bipc, err := NewBizhawkIPC(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteLuaPortFile(dir, bipc.Port()+1); err != nil {
+		t.Fatal(err)
+	}
+	if err := bipc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readLuaPortFile(dir); got != bipc.Port()+1 {
+		t.Fatalf("close removed another session's port file (got %d)", got)
+	}
+}
+
+// pipeTransport hands the IPC one end of an in-memory pipe per Dial and
+// delivers the other end, standing in for server.lua, on accepted.
+type pipeTransport struct {
+	accepted chan net.Conn
+}
+
+func (p *pipeTransport) Dial(string) (net.Conn, error) {
+	client, lua := net.Pipe()
+	p.accepted <- lua
+	return client, nil
+}
+
+// startPipeIPC starts an IPC over pipeTransport and returns the Lua end of
+// its connection as a line reader/writer.
+func startPipeIPC(t *testing.T, ackTimeout time.Duration) (*BizhawkIPC, *bufio.Reader, net.Conn) {
+	t.Helper()
+	return startPipeIPCWith(t, &BizhawkIPC{ackTimeout: ackTimeout})
+}
+
+// startPipeIPCWith is startPipeIPC for a caller-configured IPC.
+func startPipeIPCWith(t *testing.T, b *BizhawkIPC) (*BizhawkIPC, *bufio.Reader, net.Conn) {
+	t.Helper()
+	tr := &pipeTransport{accepted: make(chan net.Conn, 1)}
+	b.transport = tr
+	b.incoming = make(chan string, 16)
+	b.commandQueue = make(chan *queuedCmd, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	b.SetBizhawkLaunched(true)
+	if err := b.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var lua net.Conn
+	select {
+	case lua = <-tr.accepted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("IPC never dialed")
+	}
+	t.Cleanup(func() {
+		cancel()
+		_ = lua.Close()
+		_ = b.Close()
+	})
+	return b, bufio.NewReader(lua), lua
+}
+
+func TestIPCPipeHelloAndAck(t *testing.T) {
+	b, r, lua := startPipeIPC(t, 0)
+
+	if _, err := lua.Write([]byte("HELLO\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case line := <-b.Incoming():
+		if line != msgHELLO {
+			t.Fatalf("incoming %q, want HELLO", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("HELLO not forwarded")
+	}
+
+	if _, err := lua.Write([]byte("PING|42\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := r.ReadString('\n'); err != nil || line != "PONG|42\n" {
+		t.Fatalf("PING reply %q, %v", line, err)
+	}
+
+	for _, reply := range []string{msgACK, msgNACK} {
+		done := make(chan error, 1)
+		go func() { done <- b.SendCommand(context.Background(), "PAUSE") }()
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := strings.Split(strings.TrimSpace(line), "|")
+		if len(parts) != 3 || parts[0] != msgCMD || parts[2] != "PAUSE" {
+			t.Fatalf("command line %q", line)
+		}
+		if _, err := lua.Write([]byte(reply + "|" + parts[1] + "|busy\n")); err != nil {
+			t.Fatal(err)
+		}
+		err = <-done
+		if reply == msgACK && err != nil {
+			t.Fatalf("ACK: SendCommand error %v", err)
+		}
+		if reply == msgNACK && (err == nil || !strings.Contains(err.Error(), "nack: busy")) {
+			t.Fatalf("NACK: SendCommand error %v", err)
+		}
+	}
+}
+
+func TestIPCPipeCommandTimeout(t *testing.T) {
+	b, r, lua := startPipeIPC(t, 50*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- b.SendCommand(context.Background(), "RESUME") }()
+	first, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "timeout waiting for ACK") {
+			t.Fatalf("SendCommand error %v, want ACK timeout", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("SendCommand did not time out")
+	}
+
+	// A late ACK for the timed-out command must not satisfy the next one.
+	done2 := make(chan error, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	go func() { done2 <- b.SendCommand(ctx, "PAUSE") }()
+	if _, err := r.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	lateID := strings.Split(first, "|")[1]
+	if _, err := lua.Write([]byte(msgACK + "|" + lateID + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done2; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SendCommand error %v, want context deadline", err)
+	}
+}
+
+func TestIPCPipePerCommandTimeout(t *testing.T) {
+	b, r, lua := startPipeIPCWith(t, &BizhawkIPC{
+		ackTimeout:  50 * time.Millisecond,
+		cmdTimeouts: map[string]time.Duration{"SAVE": 2 * time.Second},
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- b.SendCommand(context.Background(), "SAVE") }()
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Past the default timeout, but within SAVE's.
+	time.Sleep(150 * time.Millisecond)
+	if _, err := lua.Write([]byte(msgACK + "|" + strings.Split(line, "|")[1] + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("SAVE: SendCommand error %v", err)
+	}
+
+	go func() { done <- b.SendCommand(context.Background(), "MSG", "hi") }()
+	if _, err := r.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "timeout waiting for ACK") {
+			t.Fatalf("MSG: SendCommand error %v, want ACK timeout", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("MSG did not use the default timeout")
+	}
+}
+
+func TestIPCLivenessProbeDetectsHang(t *testing.T) {
+	b, r, lua := startPipeIPCWith(t, &BizhawkIPC{pingInterval: 10 * time.Millisecond, pingTimeout: 80 * time.Millisecond})
+	pings := make(chan string, 64)
+	go func() {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			select {
+			case pings <- strings.TrimSpace(line):
+			default:
+			}
+		}
+	}()
+	next := func(want string) {
+		t.Helper()
+		for {
+			select {
+			case line := <-b.Incoming():
+				if line == want {
+					return
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("%s not delivered", want)
+			}
+		}
+	}
+
+	if _, err := lua.Write([]byte("HELLO\n")); err != nil {
+		t.Fatal(err)
+	}
+	next(msgHELLO)
+	b.SetReady(true)
+	select {
+	case line := <-pings:
+		if !strings.HasPrefix(line, msgPING+"|") {
+			t.Fatalf("probe sent %q, want PING", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no liveness PING sent")
+	}
+
+	// Lua never answers: the IPC goes unready and reports the hang.
+	next(MsgUnresponsive)
+	if b.IsReady() || !b.IsHung() {
+		t.Fatalf("after hang ready=%v hung=%v", b.IsReady(), b.IsHung())
+	}
+
+	// A late PONG brings it back.
+	if _, err := lua.Write([]byte("PONG|1\n")); err != nil {
+		t.Fatal(err)
+	}
+	next(MsgResponsive)
+	if !b.IsReady() {
+		t.Fatal("not ready after PONG")
+	}
+	// A disconnect is not a hang.
+	b.SetReady(false)
+	if b.IsHung() {
+		t.Fatal("SetReady(false) left the IPC hung")
+	}
+}
+
+func TestIPCLivenessProbeWaitsOutPendingCommand(t *testing.T) {
+	now := time.Now()
+	b := &BizhawkIPC{incoming: make(chan string, 4)}
+	b.ready = true
+	b.lastPong = now.Add(-(ipcPingTimeout + time.Second))
+	b.pending = &pendingCmd{id: "1", ch: make(chan error, 1), sentAt: now.Add(-ipcPingTimeout), timeout: ipcMaxAckTimeout}
+
+	// A long SAVE keeps Lua from answering PINGs; that is not a hang yet.
+	b.probeLiveness(now)
+	if b.IsHung() || !b.IsReady() {
+		t.Fatalf("hung during pending command: ready=%v hung=%v", b.IsReady(), b.IsHung())
+	}
+
+	b.pending = nil
+	b.probeLiveness(now)
+	if !b.IsHung() {
+		t.Fatal("no PONG past the ping timeout without a command should be a hang")
+	}
+}
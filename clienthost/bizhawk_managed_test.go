@@ -1,8 +1,13 @@
 package clienthost
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestCompareBizHawkVersions(t *testing.T) {
@@ -36,3 +41,34 @@ func TestGetDependenciesSnapshotMissingBizHawk(t *testing.T) {
 		t.Fatalf("unexpected items: %+v", snap.Items)
 	}
 }
+
+func TestEnsureBizhawkFilesCancelsStalledDownload(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1048576")
+		_, _ = w.Write(make([]byte, 1024))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // stall until the client gives up
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := Config{"bizhawk_path": filepath.Join(t.TempDir(), "EmuHawk.exe")}
+	c := NewBizHawkController(NewAPI(srv.URL, srv.Client(), cfg), srv.Client(), cfg, nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- c.EnsureBizhawkFiles(ctx) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected stalled download to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("EnsureBizhawkFiles did not return after its context expired")
+	}
+	part := filepath.Join(os.TempDir(), "BizhawkFiles.zip.part")
+	if _, err := os.Stat(part); !os.IsNotExist(err) {
+		t.Fatalf("partial download left behind: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package clienthost
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClearSavesSaveRAM(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	bizhawk := filepath.Join(dir, "BizHawk")
+	files := []string{
+		filepath.Join("saves", "i1.state"),
+		filepath.Join(bizhawk, "NES", "SaveRAM", "zelda.SaveRAM"),
+		filepath.Join(bizhawk, "Genesis", "saveram", "sonic.SaveRAM"),
+	}
+	write := func() {
+		for _, f := range files {
+			if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(f, []byte("x"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	exists := func(f string) bool {
+		_, err := os.Stat(f)
+		return err == nil
+	}
+	c := &Controller{cfg: Config{"bizhawk_path": filepath.Join(bizhawk, "EmuHawk.exe")}}
+
+	write()
+	c.ClearSaves(false)
+	if exists(files[0]) || !exists(files[1]) || !exists(files[2]) {
+		t.Fatal("keep SaveRAM: expected only ./saves cleared")
+	}
+
+	write()
+	c.ClearSaves(true)
+	for _, f := range files {
+		if exists(f) {
+			t.Fatalf("%s not cleared", f)
+		}
+	}
+}
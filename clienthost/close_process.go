@@ -0,0 +1,51 @@
+//go:build windows
+
+package clienthost
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+const wmClose = 0x0010
+
+var procPostMessageW = windows.NewLazySystemDLL("user32.dll").NewProc("PostMessageW")
+
+// closeTarget is the process one EnumWindows pass in requestClose looks for;
+// closeMu serializes passes.
+var (
+	closeMu     sync.Mutex
+	closeTarget struct {
+		pid    uint32
+		posted int
+	}
+)
+
+// postCloseCallback is created once: Windows callbacks are never freed.
+var postCloseCallback = windows.NewCallback(func(hwnd windows.HWND, _ uintptr) uintptr {
+	var owner uint32
+	if _, err := windows.GetWindowThreadProcessId(hwnd, &owner); err == nil && owner == closeTarget.pid {
+		if r, _, _ := procPostMessageW.Call(uintptr(hwnd), wmClose, 0, 0); r != 0 {
+			closeTarget.posted++
+		}
+	}
+	return 1
+})
+
+// requestClose asks p to exit on its own by posting WM_CLOSE to each of its
+// top-level windows, which EmuHawk handles like the user closing it.
+func requestClose(p *os.Process) error {
+	closeMu.Lock()
+	defer closeMu.Unlock()
+	closeTarget.pid, closeTarget.posted = uint32(p.Pid), 0
+	if err := windows.EnumWindows(postCloseCallback, nil); err != nil {
+		return fmt.Errorf("enum windows: %w", err)
+	}
+	if closeTarget.posted == 0 {
+		return fmt.Errorf("no windows found for pid %d", p.Pid)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package clienthost
+
+import (
+	"os"
+	"syscall"
+)
+
+// requestClose asks p to exit on its own with SIGTERM.
+func requestClose(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
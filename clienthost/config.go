@@ -1,15 +1,23 @@
 package clienthost
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config is a string map persisted as config.json in the client data directory.
 type Config map[string]string
 
-// LoadConfig loads config.json from dataDir.
+// LoadConfig loads config.json from dataDir. When it cannot be parsed, the
+// previous copy SaveConfig kept in config.json.bak is used instead.
 func LoadConfig(dataDir string) (Config, error) {
 	cfg := Config{}
 	path := configPath(dataDir)
@@ -21,13 +29,26 @@ func LoadConfig(dataDir string) (Config, error) {
 		return nil, err
 	}
 	if err := json.Unmarshal(b, &cfg); err != nil {
-		return nil, err
+		bak := path + ".bak"
+		log.Printf("config: %s is corrupt (%v); trying %s", path, err, bak)
+		cfg = Config{}
+		bb, bakErr := os.ReadFile(bak)
+		if bakErr == nil {
+			bakErr = json.Unmarshal(bb, &cfg)
+		}
+		if bakErr != nil {
+			return nil, fmt.Errorf("parse %s: %w (backup unusable: %v)", path, err, bakErr)
+		}
+		log.Printf("config: recovered settings from %s; changes since that save are lost", bak)
 	}
 	cfg.normalizeServer()
 	return cfg, nil
 }
 
-// SaveConfig writes config to dataDir/config.json.
+// SaveConfig writes config to dataDir/config.json atomically: the content is
+// written to config.json.tmp and renamed into place, so a crash mid-write
+// never leaves a truncated file. The previous config.json, if it parses, is
+// copied to config.json.bak first.
 func SaveConfig(dataDir string, c Config) error {
 	jb, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
@@ -36,7 +57,39 @@ func SaveConfig(dataDir string, c Config) error {
 	if err := os.MkdirAll(dataDir, 0o755); err != nil {
 		return err
 	}
-	return os.WriteFile(configPath(dataDir), jb, 0o644)
+	path := configPath(dataDir)
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, jb); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if prev, err := os.ReadFile(path); err == nil && json.Valid(prev) {
+		if err := os.WriteFile(path+".bak", prev, 0o644); err != nil {
+			log.Printf("config: failed to back up %s: %v", path, err)
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeFileSync writes data to name and flushes it to disk before closing.
+func writeFileSync(name string, data []byte) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 // Save writes the config using data_dir from the map, or the current directory.
@@ -73,9 +126,161 @@ func (c Config) EnsureDefaults() error {
 	if c["auto_open_bizhawk"] == "" {
 		c["auto_open_bizhawk"] = "true"
 	}
+	if c["max_concurrent_downloads"] == "" {
+		c["max_concurrent_downloads"] = strconv.Itoa(defaultMaxConcurrentDownloads)
+	}
 	return nil
 }
 
+// defaultMaxConcurrentDownloads caps parallel ROM downloads during games_update.
+const defaultMaxConcurrentDownloads = 4
+
+// GetInt returns the integer value of the given key, or def if missing or invalid.
+func (c Config) GetInt(key string, def int) int {
+	v, ok := c[key]
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// IPCPort returns the pinned Lua IPC port from "ipc_port", or 0 when unset
+// (meaning: scan for a free port). A malformed or out-of-range value is an
+// error rather than a silent fallback, since the user asked for that port.
+func (c Config) IPCPort() (int, error) {
+	v := strings.TrimSpace(c["ipc_port"])
+	if v == "" || v == "0" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > 65535 {
+		return 0, fmt.Errorf("invalid ipc_port %q: must be 1-65535", v)
+	}
+	return n, nil
+}
+
+// BizhawkArgs returns extra EmuHawk arguments from "bizhawk_args", given
+// either as a JSON array of strings or as whitespace-separated flags.
+func (c Config) BizhawkArgs() ([]string, error) {
+	v := strings.TrimSpace(c["bizhawk_args"])
+	if v == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(v, "[") {
+		var args []string
+		if err := json.Unmarshal([]byte(v), &args); err != nil {
+			return nil, fmt.Errorf("invalid bizhawk_args %q: %w", v, err)
+		}
+		return args, nil
+	}
+	return strings.Fields(v), nil
+}
+
+// Save upload retry defaults, overridable with "save_upload_attempts",
+// "save_upload_backoff_ms" and "save_upload_timeout_ms".
+const (
+	defaultSaveUploadAttempts  = 3
+	defaultSaveUploadBackoffMs = 500
+	defaultSaveUploadTimeoutMs = 30000
+)
+
+// saveUploadPolicy returns how many times a save upload is attempted, the
+// delay before the first retry (doubled after each failure) and the timeout
+// for a single attempt.
+func (c Config) saveUploadPolicy() (attempts int, backoff, timeout time.Duration) {
+	attempts = max(c.GetInt("save_upload_attempts", defaultSaveUploadAttempts), 1)
+	backoff = time.Duration(max(c.GetInt("save_upload_backoff_ms", defaultSaveUploadBackoffMs), 0)) * time.Millisecond
+	timeout = time.Duration(c.GetInt("save_upload_timeout_ms", defaultSaveUploadTimeoutMs)) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultSaveUploadTimeoutMs * time.Millisecond
+	}
+	return attempts, backoff, timeout
+}
+
+// Websocket reconnect defaults, overridable with "ws_reconnect_base_ms" and
+// "ws_reconnect_max_ms".
+const (
+	defaultWSReconnectBaseMs = 500
+	defaultWSReconnectMaxMs  = 30000
+)
+
+// wsReconnectPolicy returns the first reconnect delay and the cap it doubles up to.
+func (c Config) wsReconnectPolicy() (base, maxDelay time.Duration) {
+	base = time.Duration(c.GetInt("ws_reconnect_base_ms", defaultWSReconnectBaseMs)) * time.Millisecond
+	if base <= 0 {
+		base = defaultWSReconnectBaseMs * time.Millisecond
+	}
+	maxDelay = time.Duration(c.GetInt("ws_reconnect_max_ms", defaultWSReconnectMaxMs)) * time.Millisecond
+	if maxDelay < base {
+		maxDelay = base
+	}
+	return base, maxDelay
+}
+
+// defaultTerminateGraceMs is how long BizHawk gets to close on its own before
+// it is killed.
+const defaultTerminateGraceMs = 5000
+
+// terminateGrace returns "bizhawk_terminate_grace_ms": the wait between the
+// graceful close request and Kill. 0 kills right away.
+func (c Config) terminateGrace() time.Duration {
+	return time.Duration(max(c.GetInt("bizhawk_terminate_grace_ms", defaultTerminateGraceMs), 0)) * time.Millisecond
+}
+
+// ipcTimeoutKey is the config key for the Lua ACK timeout of every IPC
+// command; "ipc_timeout_ms_<command>" (e.g. ipc_timeout_ms_save) overrides
+// it for one command.
+const ipcTimeoutKey = "ipc_timeout_ms"
+
+// ipcCommandTimeouts returns the configured IPC ACK timeout (0 means the
+// default) and per-command overrides keyed by upper-case command name.
+// Missing, malformed and non-positive values are ignored, and values above
+// ipcMaxAckTimeout are clamped to it.
+func (c Config) ipcCommandTimeouts() (def time.Duration, perCmd map[string]time.Duration) {
+	def = min(time.Duration(max(c.GetInt(ipcTimeoutKey, 0), 0))*time.Millisecond, ipcMaxAckTimeout)
+	perCmd = map[string]time.Duration{}
+	for k := range c {
+		name, ok := strings.CutPrefix(k, ipcTimeoutKey+"_")
+		if !ok || name == "" {
+			continue
+		}
+		if ms := c.GetInt(k, 0); ms > 0 {
+			perCmd[strings.ToUpper(name)] = min(time.Duration(ms)*time.Millisecond, ipcMaxAckTimeout)
+		}
+	}
+	return def, perCmd
+}
+
+// tlsClientConfig returns the TLS settings for server connections.
+// "insecure_skip_verify" accepts any certificate, for LAN servers started with
+// -tls and a self-signed certificate; nil keeps normal verification.
+func (c Config) tlsClientConfig() *tls.Config {
+	if !c.GetBool("insecure_skip_verify") {
+		return nil
+	}
+	return &tls.Config{InsecureSkipVerify: true}
+}
+
+// serverHTTPClient returns an HTTP client for the server honoring tlsClientConfig.
+func (c Config) serverHTTPClient() *http.Client {
+	client := &http.Client{Timeout: 0}
+	if tc := c.tlsClientConfig(); tc != nil {
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.TLSClientConfig = tc
+		client.Transport = tr
+	}
+	return client
+}
+
+// requireZipSaves reports whether "verify_zip_saves" rejects raw (non-ZIP) savestates.
+func (c Config) requireZipSaves() bool {
+	return c.GetBool("verify_zip_saves")
+}
+
 // GetBool returns the boolean value of the given key. Defaults to false if not
 // found or invalid.
 func (c Config) GetBool(key string) bool {
@@ -0,0 +1,107 @@
+package clienthost
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// configProfilePath returns where profile's copy of config.ini is kept, next
+// to BizHawk's own config.ini.
+func configProfilePath(bizhawkDir, profile string) string {
+	return filepath.Join(bizhawkDir, "config.ini."+profile)
+}
+
+// activeConfigProfile returns the profile config.ini currently holds. It
+// waits for a switch in progress.
+func (c *Controller) activeConfigProfile() string {
+	c.profileMu.Lock()
+	defer c.profileMu.Unlock()
+	return c.configProfileLocked()
+}
+
+// configProfileLocked is activeConfigProfile for callers holding profileMu.
+func (c *Controller) configProfileLocked() string {
+	if c.configProfile != "" {
+		return c.configProfile
+	}
+	return protocol.DefaultConfigProfile
+}
+
+// switchConfigProfile stores BizHawk's live config.ini as profile from and
+// replaces it with profile to's copy. A profile used for the first time starts
+// from the live config.ini. BizHawk must not be running, as it rewrites
+// config.ini on exit.
+func switchConfigProfile(bizhawkDir, from, to string) error {
+	live := filepath.Join(bizhawkDir, "config.ini")
+	if err := copyFile(live, configProfilePath(bizhawkDir, from)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("save config profile %q: %w", from, err)
+	}
+	src := configProfilePath(bizhawkDir, to)
+	if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+		log.Printf("config profile %q is new; starting it from the current config.ini", to)
+		return nil
+	}
+	if err := copyFile(src, live); err != nil {
+		return fmt.Errorf("load config profile %q: %w", to, err)
+	}
+	return nil
+}
+
+// ApplyConfigProfile makes profile ("" = default) BizHawk's active config.ini.
+// When it differs from the active profile, BizHawk is terminated, the
+// config.ini files are swapped and BizHawk is launched again through the
+// config callbacks. It reports whether BizHawk was restarted.
+func (c *Controller) ApplyConfigProfile(profile string) (bool, error) {
+	if profile == "" {
+		profile = protocol.DefaultConfigProfile
+	}
+	if !protocol.ValidConfigProfile(profile) {
+		return false, fmt.Errorf("invalid config profile name %q", profile)
+	}
+	c.profileMu.Lock()
+	defer c.profileMu.Unlock()
+	from := c.configProfileLocked()
+	if from == profile {
+		return false, nil
+	}
+	bp := c.cfg["bizhawk_path"]
+	if bp == "" {
+		return false, fmt.Errorf("bizhawk_path not configured")
+	}
+
+	log.Printf("switching BizHawk config profile %q -> %q", from, profile)
+	if c.terminateBizhawkForConfig != nil {
+		if err := c.terminateBizhawkForConfig(); err != nil {
+			// BizHawk may still rewrite config.ini on exit, so leave the files
+			// alone and let the process monitor handle it as a normal exit.
+			if c.setRestartMode != nil {
+				c.setRestartMode(false)
+			}
+			return false, fmt.Errorf("switch config profile %q: %w", profile, err)
+		}
+	}
+	// Relaunch even when the switch failed so the player keeps a running BizHawk.
+	defer func() {
+		if c.launchBizhawkForConfig != nil {
+			c.launchBizhawkForConfig()
+		}
+	}()
+	if err := switchConfigProfile(filepath.Dir(bp), from, profile); err != nil {
+		return true, err
+	}
+	c.configProfile = profile
+	// cfg is read without a lock elsewhere, so persist the change from a copy.
+	saved := maps.Clone(c.cfg)
+	saved["config_profile"] = profile
+	if err := saved.Save(); err != nil {
+		log.Printf("failed to save config after switching config profile: %v", err)
+	}
+	return true, nil
+}
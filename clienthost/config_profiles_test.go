@@ -0,0 +1,72 @@
+package clienthost
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestApplyConfigProfileSwapsConfigIni(t *testing.T) {
+	dataDir := t.TempDir()
+	bizhawkDir := t.TempDir()
+	live := filepath.Join(bizhawkDir, "config.ini")
+	if err := os.WriteFile(live, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{"data_dir": dataDir, "bizhawk_path": filepath.Join(bizhawkDir, "EmuHawk.exe")}
+	c := NewController(cfg, nil, nil, func(protocol.Command) error { return nil })
+	var calls []string
+	c.SetBizhawkCallbacks(nil, func() error { calls = append(calls, "terminate"); return nil }, nil, func() { calls = append(calls, "launch") }, nil)
+
+	read := func(path string) string {
+		t.Helper()
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	// A new profile starts from the live config; the old one is stored.
+	if restarted, err := c.ApplyConfigProfile("snes"); err != nil || !restarted {
+		t.Fatalf("apply snes: restarted=%v err=%v", restarted, err)
+	}
+	if got := read(configProfilePath(bizhawkDir, "default")); got != "original" {
+		t.Fatalf("default profile = %q", got)
+	}
+	if got := c.activeConfigProfile(); got != "snes" {
+		t.Fatalf("active profile = %q", got)
+	}
+	if saved, err := LoadConfig(dataDir); err != nil || saved["config_profile"] != "snes" {
+		t.Fatalf("saved config_profile = %q (%v)", saved["config_profile"], err)
+	}
+
+	// Edits made while on a profile are kept when switching away.
+	if err := os.WriteFile(live, []byte("snes layout"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ApplyConfigProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(configProfilePath(bizhawkDir, "snes")); got != "snes layout" {
+		t.Fatalf("snes profile = %q", got)
+	}
+	if got := read(live); got != "original" {
+		t.Fatalf("config.ini after switching back = %q", got)
+	}
+	if want := []string{"terminate", "launch", "terminate", "launch"}; len(calls) != len(want) {
+		t.Fatalf("callbacks = %v, want %v", calls, want)
+	}
+
+	if restarted, err := c.ApplyConfigProfile(protocol.DefaultConfigProfile); err != nil || restarted {
+		t.Fatalf("reapply active profile: restarted=%v err=%v", restarted, err)
+	}
+	if _, err := c.ApplyConfigProfile("../escape"); err == nil {
+		t.Fatal("invalid profile name accepted")
+	}
+	if len(calls) != 4 {
+		t.Fatalf("no-op applies restarted BizHawk: %v", calls)
+	}
+}
@@ -1,6 +1,14 @@
 package clienthost
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestConfigNormalizeServer(t *testing.T) {
 	c := Config{"server": "ws://127.0.0.1:8080/ws"}
@@ -9,3 +17,154 @@ func TestConfigNormalizeServer(t *testing.T) {
 		t.Fatalf("got %q", c["server"])
 	}
 }
+
+func TestConfigGetInt(t *testing.T) {
+	c := Config{"max_concurrent_downloads": "8", "bad": "x"}
+	if got := c.GetInt("max_concurrent_downloads", 4); got != 8 {
+		t.Fatalf("got %d", got)
+	}
+	if got := c.GetInt("bad", 4); got != 4 {
+		t.Fatalf("invalid value should fall back, got %d", got)
+	}
+	if got := c.GetInt("missing", 4); got != 4 {
+		t.Fatalf("missing value should fall back, got %d", got)
+	}
+}
+
+func TestConfigIPCPort(t *testing.T) {
+	for raw, want := range map[string]int{"": 0, "0": 0, "55400": 55400, " 6000 ": 6000} {
+		got, err := Config{"ipc_port": raw}.IPCPort()
+		if err != nil || got != want {
+			t.Fatalf("ipc_port %q: got %d, %v; want %d", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"abc", "-1", "70000"} {
+		if _, err := (Config{"ipc_port": raw}).IPCPort(); err == nil {
+			t.Fatalf("ipc_port %q: expected error", raw)
+		}
+	}
+}
+
+func TestConfigIPCCommandTimeouts(t *testing.T) {
+	def, per := Config{}.ipcCommandTimeouts()
+	if def != 0 || len(per) != 0 {
+		t.Fatalf("empty config: %v %v", def, per)
+	}
+	def, per = Config{
+		"ipc_timeout_ms":      "12000",
+		"ipc_timeout_ms_save": "60000",
+		"ipc_timeout_ms_load": "nope",
+		"ipc_timeout_ms_msg":  "-1",
+	}.ipcCommandTimeouts()
+	if def != 12*time.Second {
+		t.Fatalf("default %v", def)
+	}
+	// Longer than the server's swap wait: clamped.
+	if len(per) != 1 || per["SAVE"] != ipcMaxAckTimeout {
+		t.Fatalf("per-command %v", per)
+	}
+}
+
+func TestConfigTerminateGrace(t *testing.T) {
+	if got := (Config{}).terminateGrace(); got != 5*time.Second {
+		t.Fatalf("default %v", got)
+	}
+	if got := (Config{"bizhawk_terminate_grace_ms": "1500"}).terminateGrace(); got != 1500*time.Millisecond {
+		t.Fatalf("configured %v", got)
+	}
+	if got := (Config{"bizhawk_terminate_grace_ms": "-1"}).terminateGrace(); got != 0 {
+		t.Fatalf("negative %v", got)
+	}
+}
+
+func TestConfigBizhawkArgs(t *testing.T) {
+	got, err := Config{"bizhawk_args": `["--lua=C:\\My Scripts\\hud.lua", "--fullscreen"]`}.BizhawkArgs()
+	if err != nil || len(got) != 2 || got[0] != `--lua=C:\My Scripts\hud.lua` || got[1] != "--fullscreen" {
+		t.Fatalf("json form: got %q, %v", got, err)
+	}
+	got, err = Config{"bizhawk_args": " --fullscreen  --config=x.ini "}.BizhawkArgs()
+	if err != nil || len(got) != 2 || got[1] != "--config=x.ini" {
+		t.Fatalf("plain form: got %q, %v", got, err)
+	}
+	if _, err := (Config{"bizhawk_args": "[--oops"}).BizhawkArgs(); err == nil {
+		t.Fatal("expected error for malformed JSON list")
+	}
+}
+
+func TestBizhawkLaunchArgsPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	args, err := bizhawkLaunchArgs(Config{}, dir, "")
+	if err != nil || len(args) != 1 || args[0] != "--lua="+filepath.Join(dir, "server.lua") {
+		t.Fatalf("default: got %q, %v", args, err)
+	}
+	cfg := Config{"lua_script": "custom.lua", "bizhawk_args": "--lua=extra.lua --fullscreen"}
+	args, err = bizhawkLaunchArgs(cfg, dir, filepath.Join(dir, "server.lua"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--lua=" + filepath.Join(dir, "custom.lua"), "--lua=extra.lua", "--fullscreen"}
+	if len(args) != len(want) {
+		t.Fatalf("got %q, want %q", args, want)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Fatalf("got %q, want %q", args, want)
+		}
+	}
+}
+
+func TestConfigInsecureSkipVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	if _, err := (Config{}).serverHTTPClient().Get(srv.URL); err == nil {
+		t.Fatal("self-signed certificate accepted without insecure_skip_verify")
+	}
+	resp, err := (Config{"insecure_skip_verify": "true"}).serverHTTPClient().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("insecure_skip_verify not honored: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestSaveConfigAtomicWithBackup(t *testing.T) {
+	dir := t.TempDir()
+	if err := SaveConfig(dir, Config{"name": "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveConfig(dir, Config{"name": "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(configPath(dir) + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("tmp file left behind: %v", err)
+	}
+	cfg, err := LoadConfig(dir)
+	if err != nil || cfg["name"] != "bob" {
+		t.Fatalf("load = %v, %v", cfg, err)
+	}
+
+	// A truncated config.json falls back to the previous save.
+	if err := os.WriteFile(configPath(dir), []byte(`{"name": "bo`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadConfig(dir)
+	if err != nil || cfg["name"] != "alice" {
+		t.Fatalf("recovered = %v, %v", cfg, err)
+	}
+	// Saving over the corrupt file must not replace the good backup.
+	if err := SaveConfig(dir, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(configPath(dir) + ".bak"); err != nil || !strings.Contains(string(b), "alice") {
+		t.Fatalf("backup = %s, %v", b, err)
+	}
+
+	// With no usable backup the parse error is reported.
+	other := t.TempDir()
+	if err := os.WriteFile(configPath(other), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(other); err == nil {
+		t.Fatal("expected error without a backup")
+	}
+}
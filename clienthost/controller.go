@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -26,7 +26,9 @@ type Controller struct {
 	writeJSON        func(protocol.Command) error
 	// mainGames caches the server's main games list for extra_files lookup
 	mainGames []protocol.GameEntry
-	mu        sync.RWMutex // protects mainGames and state fields
+	// instanceGames maps instance ID to game from the last games_update
+	instanceGames map[string]string
+	mu            sync.RWMutex // protects mainGames, instanceGames and state fields
 
 	// state fields
 	currentGame       string
@@ -36,24 +38,33 @@ type Controller struct {
 	// helloAck signals when hello has been acknowledged (first CmdGamesUpdate received)
 	helloAck chan struct{}
 
+	// lastGamesUpdate is the payload of the most recent games_update, replayed by Resync.
+	lastGamesUpdate any
+
 	// pendingSwap holds a swap command received before BizHawk IPC is ready.
 	pendingSwap protocol.Command
 
 	// ipcMu serializes BizHawk IPC (swap, request_save) — TS commandChain parity.
 	ipcMu sync.Mutex
+	// profileMu serializes config profile switches and guards configProfile.
+	profileMu sync.Mutex
+	// configProfile is the profile config.ini holds; "" means the default.
+	configProfile string
 
 	// restartBizhawk is called to restart BizHawk after config updates
 	restartBizhawk func()
 	// closeBizhawk is called to close BizHawk
 	closeBizhawk func()
 	// terminateBizhawkForConfig is called to terminate BizHawk for config updates (without cancelling client context)
-	terminateBizhawkForConfig func()
+	terminateBizhawkForConfig func() error
 	// launchBizhawk is called to launch BizHawk (normal launch, resets restart mode)
 	launchBizhawk func()
 	// launchBizhawkForConfig is called to launch BizHawk after config update (preserves restart mode)
 	launchBizhawkForConfig func()
 	// setRestartMode is called to set BizHawk restart mode
 	setRestartMode func(bool)
+	// onSwapResult is told the outcome of each swap acked or nacked to the server
+	onSwapResult func(game, instanceID string, err error)
 }
 
 func NewController(cfg Config, bipc *BizhawkIPC, api *API, writeJSON func(protocol.Command) error) *Controller {
@@ -68,7 +79,7 @@ func NewControllerWithHelloAckAndRestart(cfg Config, bipc *BizhawkIPC, api *API,
 	return NewControllerWithHelloAckAndCallbacks(cfg, bipc, api, writeJSON, helloAck, restartBizhawk, nil, nil, nil, nil, nil)
 }
 
-func NewControllerWithHelloAckAndCallbacks(cfg Config, bipc *BizhawkIPC, api *API, writeJSON func(protocol.Command) error, helloAck chan struct{}, restartBizhawk func(), closeBizhawk func(), terminateBizhawkForConfig func(), launchBizhawk func(), launchBizhawkForConfig func(), setRestartMode func(bool)) *Controller {
+func NewControllerWithHelloAckAndCallbacks(cfg Config, bipc *BizhawkIPC, api *API, writeJSON func(protocol.Command) error, helloAck chan struct{}, restartBizhawk func(), closeBizhawk func(), terminateBizhawkForConfig func() error, launchBizhawk func(), launchBizhawkForConfig func(), setRestartMode func(bool)) *Controller {
 	c := &Controller{
 		cfg:                       cfg,
 		bipc:                      bipc,
@@ -82,6 +93,7 @@ func NewControllerWithHelloAckAndCallbacks(cfg Config, bipc *BizhawkIPC, api *AP
 		launchBizhawk:             launchBizhawk,
 		launchBizhawkForConfig:    launchBizhawkForConfig,
 		setRestartMode:            setRestartMode,
+		configProfile:             cfg["config_profile"],
 	}
 	c.progressTracking = NewProgressTrackingAPI(api, c)
 	return c
@@ -100,6 +112,35 @@ func payloadBool(payload any, key string) bool {
 	return ok && b
 }
 
+// payloadStringMap converts a decoded JSON object of strings, ignoring other values.
+func payloadStringMap(v any) map[string]string {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, val := range m {
+		if s, ok := val.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
+
+// swapWithSkipSave copies a swap command with skip_save set.
+func swapWithSkipSave(cmd protocol.Command) protocol.Command {
+	payload := map[string]any{"skip_save": true}
+	if m, ok := cmd.Payload.(map[string]any); ok {
+		for k, v := range m {
+			if k != "skip_save" {
+				payload[k] = v
+			}
+		}
+	}
+	cmd.Payload = payload
+	return cmd
+}
+
 // OnBizhawkReady runs a swap that arrived before Lua IPC was ready.
 func (c *Controller) OnBizhawkReady(ctx context.Context) {
 	c.mu.Lock()
@@ -118,7 +159,7 @@ func (c *Controller) SetRestartBizhawkCallback(restartFunc func()) {
 }
 
 // SetBizhawkCallbacks sets the callback functions for BizHawk control
-func (c *Controller) SetBizhawkCallbacks(closeFunc func(), terminateForConfigFunc func(), launchFunc func(), launchForConfigFunc func(), setRestartModeFunc func(bool)) {
+func (c *Controller) SetBizhawkCallbacks(closeFunc func(), terminateForConfigFunc func() error, launchFunc func(), launchForConfigFunc func(), setRestartModeFunc func(bool)) {
 	c.closeBizhawk = closeFunc
 	c.terminateBizhawkForConfig = terminateForConfigFunc
 	c.launchBizhawk = launchFunc
@@ -126,6 +167,13 @@ func (c *Controller) SetBizhawkCallbacks(closeFunc func(), terminateForConfigFun
 	c.setRestartMode = setRestartModeFunc
 }
 
+// OnSwapResult registers fn to be told the outcome of each swap: nil once Lua
+// acked the SWAP (BizHawk loaded game), or the reason it was nacked. Swaps
+// deferred until Lua is ready report when they finally run.
+func (c *Controller) OnSwapResult(fn func(game, instanceID string, err error)) {
+	c.onSwapResult = fn
+}
+
 // Handle processes a single incoming command. It launches goroutines for
 // commands that should run asynchronously (keeps original behavior).
 func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
@@ -171,14 +219,29 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 			id := swapCmd.ID
 			game := ""
 			instanceID := ""
+			profile := ""
+			saveOwner := ""
 			skipSave := payloadBool(swapCmd.Payload, "skip_save")
 			if m, ok := swapCmd.Payload.(map[string]any); ok {
 				if g, ok := m["game"].(string); ok {
 					game = g
 				}
+				if o, ok := m["save_owner"].(string); ok {
+					saveOwner = o
+				}
 				if iid, ok := m["instance_id"].(string); ok {
 					instanceID = iid
 				}
+				if p, ok := m["config_profile"].(string); ok {
+					profile = p
+				}
+			}
+			// Local copies, so reporting stays with this swap.
+			sendAck, sendNack := sendAck, sendNack
+			if fn := c.onSwapResult; fn != nil {
+				ack, nack := sendAck, sendNack
+				sendAck = func(id string) { ack(id); fn(game, instanceID, nil) }
+				sendNack = func(id, reason string) { nack(id, reason); fn(game, instanceID, errors.New(reason)) }
 			}
 			if game == "" {
 				log.Printf("swap command has empty game — acking without sending SWAP to Lua (check server assignment / admin games)")
@@ -240,12 +303,30 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 				}
 			}
 			if !skipSave {
-				if err := c.EnsureSaveState(oldInstanceID, instanceID); err != nil {
+				if err := c.EnsureSaveState(oldInstanceID, instanceID, saveOwner); err != nil {
 					sendNack(id, "save state orchestration failed: "+err.Error())
 					return
 				}
 			}
 
+			if profile != "" && profile != c.activeConfigProfile() {
+				// BizHawk restarts on the game's config profile; the swap then
+				// runs from pendingSwap on Lua HELLO, with saves already handled.
+				c.mu.Lock()
+				c.pendingSwap = swapWithSkipSave(swapCmd)
+				c.mu.Unlock()
+				restarted, err := c.ApplyConfigProfile(profile)
+				if err != nil {
+					log.Printf("config profile %q for %s: %v", profile, game, err)
+				}
+				if restarted {
+					return
+				}
+				c.mu.Lock()
+				c.pendingSwap = protocol.Command{}
+				c.mu.Unlock()
+			}
+
 			if err := c.bipc.SendSwap(ctx, game, instanceID); err != nil {
 				sendNack(id, err.Error())
 				return
@@ -253,8 +334,12 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 			sendAck(id)
 		}(cmd)
 	case protocol.CmdClearSaves:
+		keepSaveRAM := false
+		if pl, ok := cmd.Payload.(map[string]any); ok {
+			keepSaveRAM, _ = pl["keep_saveram"].(bool)
+		}
 		go func(id string) {
-			c.ClearSaves()
+			c.ClearSaves(!keepSaveRAM)
 			if err := c.bipc.SendRestart(ctx); err != nil {
 				sendNack(id, err.Error())
 				return
@@ -276,94 +361,10 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 			c.helloAck = nil // prevent multiple signals
 		}
 
-		go func(payload any) {
-			required := make(map[string]struct{})
-			// Build set of instance games we need
-			games := make(map[string]struct{})
-			var mainGames []protocol.GameEntry
-
-			if m, ok := payload.(map[string]any); ok {
-				// Parse and cache main_games first
-				if mg, ok := m["main_games"].([]any); ok {
-					for _, mei := range mg {
-						if em, ok := mei.(map[string]any); ok {
-							var entry protocol.GameEntry
-							if f, ok := em["file"].(string); ok {
-								entry.File = f
-							}
-							if extras, ok := em["extra_files"].([]any); ok {
-								for _, ex := range extras {
-									if exs, ok := ex.(string); ok {
-										entry.ExtraFiles = append(entry.ExtraFiles, exs)
-									}
-								}
-							}
-							if entry.File != "" {
-								mainGames = append(mainGames, entry)
-							}
-						}
-					}
-				}
-				// Update the cached main games
-				c.SetMainGames(mainGames)
-
-				if gis, ok := m["game_instances"].([]any); ok {
-					for _, gi := range gis {
-						if im, ok := gi.(map[string]any); ok {
-							if g, ok2 := im["game"].(string); ok2 && g != "" {
-								games[g] = struct{}{}
-								required[g] = struct{}{}
-							}
-						}
-					}
-				}
-				if gg, ok := m["games"].([]any); ok {
-					for _, gi := range gg {
-						if g, ok := gi.(string); ok {
-							games[g] = struct{}{}
-							required[g] = struct{}{}
-						}
-					}
-				}
-				// extras from main_games when primary is in instanceGames
-				for _, entry := range mainGames {
-					if _, isActive := games[entry.File]; isActive {
-						for _, extra := range entry.ExtraFiles {
-							required[extra] = struct{}{}
-						}
-					}
-				}
-			}
-			var wg sync.WaitGroup
-			errCh := make(chan error, 8)
-			for name := range required {
-				n := name
-				wg.Add(1)
-				go func(fname string) {
-					defer wg.Done()
-					ctx2, cancel2 := context.WithTimeout(ctx, 60*time.Second)
-					defer cancel2()
-					if err := c.progressTracking.EnsureFileWithProgress(ctx2, fname); err != nil {
-						errCh <- fmt.Errorf("failed to download %s: %w", fname, err)
-						return
-					}
-					log.Printf("games_update: ensured file %s", fname)
-				}(n)
-			}
-			wg.Wait()
-			close(errCh)
-			errList := []string{}
-			for e := range errCh {
-				log.Printf("games_update error: %v", e)
-				errList = append(errList, e.Error())
-			}
-			hasFiles := len(errList) == 0
-			ackPayload := map[string]any{"has_files": hasFiles}
-			if !hasFiles {
-				ackPayload["errors"] = errList
-			}
-			_ = c.writeJSON(protocol.Command{Cmd: protocol.CmdGamesUpdateAck, ID: fmt.Sprintf("%d", time.Now().UnixNano()), Payload: ackPayload})
-		}(cmd.Payload)
+		c.mu.Lock()
+		c.lastGamesUpdate = cmd.Payload
+		c.mu.Unlock()
+		go c.ensureGameFiles(ctx, cmd.Payload)
 	case protocol.CmdMessage:
 		go func(id string) {
 			message := ""
@@ -415,10 +416,14 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 			defer c.ipcMu.Unlock()
 			log.Printf("handling request_save command")
 			instanceID := ""
+			saveOwner := ""
 			if m, ok := cmd.Payload.(map[string]any); ok {
 				if iid, ok := m["instance_id"].(string); ok {
 					instanceID = iid
 				}
+				if o, ok := m["save_owner"].(string); ok {
+					saveOwner = o
+				}
 			}
 			log.Printf("request_save for instanceID=%s", instanceID)
 			if instanceID == "" {
@@ -444,7 +449,7 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 
 			// Upload the save state
 			log.Printf("about to upload save state for instanceID=%s", instanceID)
-			if err := c.api.UploadSaveState(instanceID); err != nil {
+			if err := c.api.UploadSaveState(instanceID, saveOwner); err != nil {
 				log.Printf("UploadSaveState failed: %v", err)
 				sendNack(id, "upload failed: "+err.Error())
 				return
@@ -452,6 +457,44 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 			log.Printf("save state uploaded for instanceID=%s", instanceID)
 			sendAck(id)
 		}(cmd.ID)
+	case protocol.CmdScreenshot:
+		go func(id string) {
+			c.ipcMu.Lock()
+			defer c.ipcMu.Unlock()
+			if !c.bipc.IsReady() {
+				log.Printf("IPC not ready, cannot send SCREENSHOT command")
+				sendNack(id, "IPC not ready")
+				return
+			}
+			if err := os.MkdirAll("./screenshots", 0755); err != nil {
+				sendNack(id, "screenshot failed: "+err.Error())
+				return
+			}
+			// BizHawk resolves relative paths against its own directory, so hand it an absolute one.
+			path, err := filepath.Abs(filepath.Join("screenshots", fmt.Sprintf("%d.png", time.Now().UnixNano())))
+			if err != nil {
+				sendNack(id, "screenshot failed: "+err.Error())
+				return
+			}
+			defer func() { _ = os.Remove(path) }()
+			ctx2, cancel2 := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel2()
+			if err := c.bipc.SendScreenshot(ctx2, path); err != nil {
+				log.Printf("SendScreenshot failed: %v", err)
+				sendNack(id, "screenshot failed: "+err.Error())
+				return
+			}
+			if err := waitForFileStable(path, 2*time.Second); err != nil {
+				sendNack(id, "screenshot failed: "+err.Error())
+				return
+			}
+			if err := c.api.UploadScreenshot(c.cfg["name"], path); err != nil {
+				log.Printf("UploadScreenshot failed: %v", err)
+				sendNack(id, "upload failed: "+err.Error())
+				return
+			}
+			sendAck(id)
+		}(cmd.ID)
 	case protocol.CmdStateUpdate:
 		// Handle plugin settings updates
 		go func() {
@@ -494,7 +537,7 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 					log.Printf("Reloading plugin %s: syncing files and reloading in BizHawk", pluginName)
 
 					// Create plugin sync manager
-					httpClient := &http.Client{Timeout: 0}
+					httpClient := c.cfg.serverHTTPClient()
 					pluginSyncManager := NewPluginSyncManager(c.api, httpClient, c.cfg)
 
 					// Sync the specific plugin (redownload files)
@@ -518,6 +561,48 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 			}
 		}()
 		sendAck(cmd.ID)
+	case protocol.CmdPluginSync:
+		// A plugin was enabled or disabled: resync files (downloading or
+		// removing it), then have BizHawk load or unload it.
+		go func() {
+			payload, ok := cmd.Payload.(map[string]any)
+			if !ok {
+				return
+			}
+			pluginName, _ := payload["plugin_name"].(string)
+			status, _ := payload["status"].(string)
+			if pluginName == "" {
+				return
+			}
+			pluginSyncManager := NewPluginSyncManager(c.api, c.cfg.serverHTTPClient(), c.cfg)
+			if _, err := pluginSyncManager.SyncPlugins(); err != nil {
+				log.Printf("failed to sync plugins for %s: %v", pluginName, err)
+				return
+			}
+			ctx2, cancel2 := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel2()
+			if protocol.PluginStatus(status) == protocol.PluginStatusEnabled {
+				if err := c.bipc.SendPluginReload(ctx2, pluginName); err != nil {
+					log.Printf("failed to send PLUGIN_RELOAD command to BizHawk for %s: %v", pluginName, err)
+				}
+				return
+			}
+			// The plugin's settings.kv is gone, so BizHawk reads it as disabled
+			// and unloads it.
+			if err := c.bipc.SendPluginSettings(ctx2, pluginName); err != nil {
+				log.Printf("failed to send PLUGIN_SETTINGS command to BizHawk for %s: %v", pluginName, err)
+			}
+		}()
+		sendAck(cmd.ID)
+	case protocol.CmdResync:
+		go func(id string) {
+			if err := c.Resync(ctx); err != nil {
+				log.Printf("resync failed: %v", err)
+				sendNack(id, err.Error())
+				return
+			}
+			sendAck(id)
+		}(cmd.ID)
 	case protocol.CmdFullscreenToggle:
 		go func(id string) {
 			log.Printf("handling fullscreen toggle command")
@@ -530,6 +615,19 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 			log.Printf("fullscreen toggle executed (Alt+Enter)")
 			sendAck(id)
 		}(cmd.ID)
+	case protocol.CmdApplyConfigProfile:
+		profile := ""
+		if pl, ok := cmd.Payload.(map[string]any); ok {
+			profile, _ = pl["profile"].(string)
+		}
+		go func(id string) {
+			if _, err := c.ApplyConfigProfile(profile); err != nil {
+				log.Printf("apply config profile %q failed: %v", profile, err)
+				sendNack(id, err.Error())
+				return
+			}
+			sendAck(id)
+		}(cmd.ID)
 	case protocol.CmdCheckConfig, protocol.CmdUpdateConfig:
 		sendAck(cmd.ID)
 	default:
@@ -537,7 +635,9 @@ func (c *Controller) Handle(ctx context.Context, cmd protocol.Command) {
 	}
 }
 
-func (c *Controller) EnsureSaveState(oldInstanceID, instanceID string) error {
+// EnsureSaveState uploads the save of oldInstanceID and downloads the save of
+// instanceID; a non-empty saveOwner keeps both as that player's own saves.
+func (c *Controller) EnsureSaveState(oldInstanceID, instanceID, saveOwner string) error {
 	log.Println("Ensuring save state for instanceID:", instanceID)
 
 	// Create saves directory if it doesn't exist
@@ -546,38 +646,45 @@ func (c *Controller) EnsureSaveState(oldInstanceID, instanceID string) error {
 		return err
 	}
 
+	// 1. Upload old instance if it exists (current player's save state). It runs
+	// alongside the download but must finish before the swap is acknowledged,
+	// otherwise the server would treat a lost save as a clean swap.
+	uploadErr := make(chan error, 1)
 	if oldInstanceID != "" {
-		// 1. Upload old instance if it exists (current player's save state)
 		go func() {
 			log.Printf("Uploading save state for old instance: %s", oldInstanceID)
-			err := c.api.UploadSaveState(oldInstanceID)
+			err := c.api.UploadSaveState(oldInstanceID, saveOwner)
 			if err != nil {
 				log.Printf("Failed to upload old save state for instance %s: %v", oldInstanceID, err)
+				err = fmt.Errorf("upload save state for instance %s: %w", oldInstanceID, err)
 			} else {
 				log.Printf("Successfully uploaded save state for instance %s", oldInstanceID)
 			}
+			uploadErr <- err
 		}()
+	} else {
+		uploadErr <- nil
 	}
 	if instanceID == "" {
 		log.Println("No instanceID provided, skipping save state orchestration")
-		return nil
+		return <-uploadErr
 	}
 
 	// 2. Download new instance save state (synchronous, blocking)
 	log.Printf("Downloading save state for new instance: %s", instanceID)
-	err := c.api.EnsureSaveState(instanceID)
+	err := c.api.EnsureSaveState(instanceID, saveOwner)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrFileLocked) {
 			log.Printf("Save state for instance %s not available on server (this is OK, Lua will create one): %v", instanceID, err)
 		} else {
 			log.Printf("Failed to download save state for instance %s: %v", instanceID, err)
-			return err
+			return errors.Join(err, <-uploadErr)
 		}
 	} else {
 		log.Printf("Successfully downloaded save state for instance %s", instanceID)
 	}
 
-	return nil
+	return <-uploadErr
 }
 
 // GetState returns the current game, instance ID and pending file
@@ -597,6 +704,164 @@ func (c *Controller) GetMainGames() []protocol.GameEntry {
 	return result
 }
 
+// ensureGameFiles downloads every file a games_update payload needs (active
+// games, their extra files and extra_dirs) and reports the result to the
+// server with games_update_ack. It returns the per-file errors.
+func (c *Controller) ensureGameFiles(ctx context.Context, payload any) []string {
+	required := make(map[string]struct{})
+	// Build set of instance games we need
+	games := make(map[string]struct{})
+	var mainGames []protocol.GameEntry
+
+	if m, ok := payload.(map[string]any); ok {
+		// Parse and cache main_games first
+		if mg, ok := m["main_games"].([]any); ok {
+			for _, mei := range mg {
+				if em, ok := mei.(map[string]any); ok {
+					var entry protocol.GameEntry
+					if f, ok := em["file"].(string); ok {
+						entry.File = f
+					}
+					if extras, ok := em["extra_files"].([]any); ok {
+						for _, ex := range extras {
+							if exs, ok := ex.(string); ok {
+								entry.ExtraFiles = append(entry.ExtraFiles, exs)
+							}
+						}
+					}
+					if dirs, ok := em["extra_dirs"].([]any); ok {
+						for _, d := range dirs {
+							if ds, ok := d.(string); ok {
+								entry.ExtraDirs = append(entry.ExtraDirs, ds)
+							}
+						}
+					}
+					entry.URLs = payloadStringMap(em["urls"])
+					entry.Checksums = payloadStringMap(em["checksums"])
+					if entry.File != "" {
+						mainGames = append(mainGames, entry)
+					}
+				}
+			}
+		}
+		// Update the cached main games
+		c.SetMainGames(mainGames)
+
+		if gis, ok := m["game_instances"].([]any); ok {
+			instanceGames := make(map[string]string, len(gis))
+			for _, gi := range gis {
+				if im, ok := gi.(map[string]any); ok {
+					if g, ok2 := im["game"].(string); ok2 && g != "" {
+						games[g] = struct{}{}
+						required[g] = struct{}{}
+						if iid, ok3 := im["id"].(string); ok3 && iid != "" {
+							instanceGames[iid] = g
+						}
+					}
+				}
+			}
+			c.mu.Lock()
+			c.instanceGames = instanceGames
+			c.mu.Unlock()
+		}
+		if gg, ok := m["games"].([]any); ok {
+			for _, gi := range gg {
+				if g, ok := gi.(string); ok {
+					games[g] = struct{}{}
+					required[g] = struct{}{}
+				}
+			}
+		}
+		// extras from main_games when primary is in instanceGames
+		for _, entry := range mainGames {
+			if _, isActive := games[entry.File]; isActive {
+				for _, extra := range entry.ExtraFiles {
+					required[extra] = struct{}{}
+				}
+			}
+		}
+	}
+	// extra_dirs are expanded into their files so each is mirrored like an extra file.
+	var dirErrs []string
+	// A prestage (POST /api/prestage) also pulls every enabled plugin.
+	prestage := payloadBool(payload, "prestage")
+	if prestage {
+		if _, err := NewPluginSyncManager(c.api, c.cfg.serverHTTPClient(), c.cfg).SyncPlugins(); err != nil {
+			log.Printf("games_update: prestage plugin sync: %v", err)
+			dirErrs = append(dirErrs, fmt.Sprintf("failed to sync plugins: %v", err))
+		}
+	}
+	for _, entry := range mainGames {
+		if _, isActive := games[entry.File]; !isActive {
+			continue
+		}
+		for _, dir := range entry.ExtraDirs {
+			files, err := c.api.FileTree(ctx, dir)
+			if err != nil {
+				log.Printf("games_update: list extra dir %s: %v", dir, err)
+				dirErrs = append(dirErrs, fmt.Sprintf("failed to list %s: %v", dir, err))
+				continue
+			}
+			for _, f := range files {
+				required[f] = struct{}{}
+			}
+		}
+	}
+	var wg sync.WaitGroup
+	// Buffer every possible error so workers never block before wg.Wait returns.
+	errCh := make(chan error, len(required))
+	var checksumMu sync.Mutex
+	checksums := make(map[string]string)
+	// Bound parallel downloads so a large catalog doesn't saturate the link.
+	sem := make(chan struct{}, max(c.cfg.GetInt("max_concurrent_downloads", defaultMaxConcurrentDownloads), 1))
+	for name := range required {
+		n := name
+		wg.Add(1)
+		go func(fname string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errCh <- fmt.Errorf("failed to download %s: %w", fname, ctx.Err())
+				return
+			}
+			ctx2, cancel2 := context.WithTimeout(ctx, 60*time.Second)
+			defer cancel2()
+			if err := c.progressTracking.EnsureFileWithProgress(ctx2, fname); err != nil {
+				errCh <- fmt.Errorf("failed to download %s: %w", fname, err)
+				return
+			}
+			log.Printf("games_update: ensured file %s", fname)
+			sum, err := romFileSHA256(fname)
+			if err != nil {
+				log.Printf("games_update: checksum %s: %v", fname, err)
+				return
+			}
+			checksumMu.Lock()
+			checksums[fname] = sum
+			checksumMu.Unlock()
+		}(n)
+	}
+	wg.Wait()
+	close(errCh)
+	errList := dirErrs
+	for e := range errCh {
+		log.Printf("games_update error: %v", e)
+		errList = append(errList, e.Error())
+	}
+	hasFiles := len(errList) == 0
+	ackPayload := map[string]any{"has_files": hasFiles, "checksums": checksums}
+	if !hasFiles {
+		ackPayload["errors"] = errList
+	}
+	if prestage {
+		ackPayload["prestage"] = true
+	}
+	_ = c.writeJSON(protocol.Command{Cmd: protocol.CmdGamesUpdateAck, ID: fmt.Sprintf("%d", time.Now().UnixNano()), Payload: ackPayload})
+	return errList
+}
+
 // SetMainGames updates the cached main games list
 func (c *Controller) SetMainGames(mainGames []protocol.GameEntry) {
 	c.mu.Lock()
@@ -606,6 +871,19 @@ func (c *Controller) SetMainGames(mainGames []protocol.GameEntry) {
 	copy(c.mainGames, mainGames)
 }
 
+// externalSource returns the external URL and expected sha256 the catalog gives
+// for file, or empty strings when it is only served by the server.
+func (c *Controller) externalSource(file string) (url, sum string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, entry := range c.mainGames {
+		if u := entry.URLs[file]; u != "" {
+			return u, entry.Checksums[file]
+		}
+	}
+	return "", ""
+}
+
 // GetExtraFilesForGame returns the extra files for a given primary game file
 func (c *Controller) GetExtraFilesForGame(game string) []string {
 	c.mu.RLock()
@@ -639,16 +917,34 @@ func clearDir(dir string) {
 	}
 }
 
-// ClearSaves removes all save files from the ./saves directory and BizHawk SaveRAM directories
-func (c *Controller) ClearSaves() {
+// saveRAMDirs finds every directory named SaveRAM under bizhawkDir, so
+// systems BizHawk adds later are covered without a hardcoded list.
+func saveRAMDirs(bizhawkDir string) []string {
+	var dirs []string
+	_ = filepath.WalkDir(bizhawkDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if strings.EqualFold(d.Name(), "SaveRAM") {
+			dirs = append(dirs, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return dirs
+}
+
+// ClearSaves removes all save files from the ./saves directory and, when
+// clearSaveRAM is set, from every BizHawk SaveRAM directory.
+func (c *Controller) ClearSaves(clearSaveRAM bool) {
 	// Clear local saves directory
 	clearDir("./saves")
 
-	// Clear BizHawk SaveRAM directories
-	bizhawkDir := filepath.Dir(c.cfg["bizhawk_path"])
-	subdirs := []string{"Gameboy/SaveRAM", "GBA/SaveRAM", "N64/SaveRAM", "NES/SaveRAM", "SNES/SaveRAM", "PSX/SaveRAM"}
-	for _, subdir := range subdirs {
-		clearDir(filepath.Join(bizhawkDir, subdir))
+	if !clearSaveRAM || c.cfg["bizhawk_path"] == "" {
+		return
+	}
+	for _, dir := range saveRAMDirs(filepath.Dir(c.cfg["bizhawk_path"])) {
+		clearDir(dir)
 	}
 }
 
@@ -719,7 +1015,7 @@ func (c *Controller) verifySaveWithRetry(instanceID string) error {
 		if attempt > 0 {
 			time.Sleep(200 * time.Millisecond)
 		}
-		if err := verifySaveFilePath(filename); err != nil {
+		if err := verifySaveFilePath(filename, c.cfg.requireZipSaves()); err != nil {
 			lastErr = err
 			log.Printf("save verify instanceID=%s attempt %d: %v", instanceID, attempt+1, err)
 			continue
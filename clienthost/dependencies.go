@@ -146,12 +146,12 @@ func installBizHawkManaged(dataDir string, progress func(string)) error {
 			}
 		}
 	}
-	url, err := installer.GetBizHawkDownloadURLForVersion(SupportedBizHawkVersion)
+	url, sum, err := installer.GetBizHawkAssetForVersion(SupportedBizHawkVersion)
 	if err != nil {
 		return err
 	}
 	bh := deps.NewBizHawkInstaller()
-	if err := bh.InstallBizHawk(url, installDir, progress); err != nil {
+	if err := bh.InstallBizHawkVerified(url, sum, installDir, progress); err != nil {
 		return err
 	}
 	_, err = ResolveEmuHawkPath(dataDir)
@@ -21,6 +21,11 @@ func (b *BizHawkInstaller) InstallBizHawk(downloadURL, installDir string, progre
 	return b.impl.InstallBizHawk(downloadURL, installDir, progress)
 }
 
+// InstallBizHawkVerified is InstallBizHawk with the archive checked against sha256 (hex)
+func (b *BizHawkInstaller) InstallBizHawkVerified(downloadURL, sha256, installDir string, progress func(msg string)) error {
+	return b.impl.InstallBizHawkVerified(downloadURL, sha256, installDir, progress)
+}
+
 // GetBizHawkDownloadURL returns the default BizHawk download URL for the current platform
 func GetBizHawkDownloadURL() string {
 	return installer.GetBizHawkDownloadURL()
@@ -0,0 +1,19 @@
+//go:build windows
+
+package clienthost
+
+import "golang.org/x/sys/windows"
+
+// diskFreeBytes returns the bytes available to this user on the volume
+// holding dir.
+func diskFreeBytes(dir string) (int64, error) {
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var avail, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &avail, &total, &free); err != nil {
+		return 0, err
+	}
+	return int64(avail), nil
+}
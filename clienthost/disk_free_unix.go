@@ -0,0 +1,15 @@
+//go:build unix
+
+package clienthost
+
+import "golang.org/x/sys/unix"
+
+// diskFreeBytes returns the bytes available to this user on the volume
+// holding dir.
+func diskFreeBytes(dir string) (int64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
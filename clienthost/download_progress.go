@@ -16,9 +16,45 @@ type ProgressTracker struct {
 	startTime  time.Time
 	lastUpdate time.Time
 	width      int // width of progress bar
+	done       bool
+	err        error
+	onChange   func() // set by DownloadProgressManager; called without mu held
 	mu         sync.Mutex
 }
 
+// DownloadProgress is a point-in-time view of one file download for UIs.
+type DownloadProgress struct {
+	File        string
+	Downloaded  int64
+	Total       int64
+	BytesPerSec float64
+	Done        bool
+	Err         string
+}
+
+// Percent returns completion in [0, 100]; 0 when the size is unknown.
+func (p DownloadProgress) Percent() float64 {
+	if p.Total <= 0 {
+		if p.Done {
+			return 100
+		}
+		return 0
+	}
+	pct := float64(p.Downloaded) / float64(p.Total) * 100
+	if pct > 100 {
+		pct = 100
+	}
+	return pct
+}
+
+// Detail formats bytes downloaded / total and the transfer rate.
+func (p DownloadProgress) Detail() string {
+	if p.Err != "" {
+		return "error: " + p.Err
+	}
+	return fmt.Sprintf("%s / %s  %s/s  %3.0f%%", formatBytes(p.Downloaded), formatBytes(p.Total), formatBytes(int64(p.BytesPerSec)), p.Percent())
+}
+
 // NewProgressTracker creates a new progress tracker for a file download
 func NewProgressTracker(filename string, totalSize int64) *ProgressTracker {
 	return &ProgressTracker{
@@ -61,11 +97,36 @@ func (pt *ProgressTracker) Update(bytes int64) {
 	now := time.Now()
 
 	// Update display every 100ms to avoid flickering
+	changed := false
 	if now.Sub(pt.lastUpdate) >= 100*time.Millisecond || pt.downloaded >= pt.totalSize {
 		pt.display()
 		pt.lastUpdate = now
+		changed = true
 	}
+	onChange := pt.onChange
 	pt.mu.Unlock()
+	if changed && onChange != nil {
+		onChange()
+	}
+}
+
+// snapshot returns the tracker's current progress.
+func (pt *ProgressTracker) snapshot() DownloadProgress {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	p := DownloadProgress{
+		File:       pt.filename,
+		Downloaded: pt.downloaded,
+		Total:      pt.totalSize,
+		Done:       pt.done,
+	}
+	if elapsed := time.Since(pt.startTime).Seconds(); elapsed > 0 && !pt.done {
+		p.BytesPerSec = float64(pt.downloaded) / elapsed
+	}
+	if pt.err != nil {
+		p.Err = pt.err.Error()
+	}
+	return p
 }
 
 // display shows the current progress in pacman style
@@ -126,6 +187,7 @@ func (pt *ProgressTracker) Finish() {
 		pt.downloaded = pt.totalSize
 		pt.display()
 	}
+	pt.done = true
 	pt.mu.Unlock()
 	fmt.Println()
 }
@@ -142,6 +204,8 @@ func (pt *ProgressTracker) GetDownloaded() int64 {
 func (pt *ProgressTracker) Error(err error) {
 	pt.mu.Lock()
 	fmt.Printf("\r %-50s ERROR: %v\n", pt.filename, err)
+	pt.done = true
+	pt.err = err
 	pt.mu.Unlock()
 }
 
@@ -169,7 +233,11 @@ func formatDuration(d time.Duration) string {
 // DownloadProgressManager manages multiple concurrent downloads
 type DownloadProgressManager struct {
 	activeDownloads map[string]*ProgressTracker
-	mu              sync.Mutex
+	// batch holds every download started since the manager was last idle, in
+	// start order, so listeners can show finished files next to running ones.
+	batch    []*ProgressTracker
+	listener func([]DownloadProgress)
+	mu       sync.Mutex
 }
 
 // NewDownloadProgressManager creates a new download progress manager
@@ -182,12 +250,46 @@ func NewDownloadProgressManager() *DownloadProgressManager {
 // StartDownload begins tracking a new download
 func (dpm *DownloadProgressManager) StartDownload(filename string, totalSize int64) *ProgressTracker {
 	tracker := NewProgressTracker(filename, totalSize)
+	tracker.onChange = dpm.notify
 	dpm.mu.Lock()
+	if len(dpm.activeDownloads) == 0 {
+		dpm.batch = nil
+	}
 	dpm.activeDownloads[filename] = tracker
+	dpm.batch = append(dpm.batch, tracker)
 	dpm.mu.Unlock()
+	dpm.notify()
 	return tracker
 }
 
+// SetListener registers fn to receive the current batch on every progress
+// change; nil removes it. fn runs on download goroutines.
+func (dpm *DownloadProgressManager) SetListener(fn func([]DownloadProgress)) {
+	dpm.mu.Lock()
+	dpm.listener = fn
+	dpm.mu.Unlock()
+}
+
+// Snapshot returns progress for every download in the current batch.
+func (dpm *DownloadProgressManager) Snapshot() []DownloadProgress {
+	dpm.mu.Lock()
+	defer dpm.mu.Unlock()
+	out := make([]DownloadProgress, 0, len(dpm.batch))
+	for _, t := range dpm.batch {
+		out = append(out, t.snapshot())
+	}
+	return out
+}
+
+func (dpm *DownloadProgressManager) notify() {
+	dpm.mu.Lock()
+	fn := dpm.listener
+	dpm.mu.Unlock()
+	if fn != nil {
+		fn(dpm.Snapshot())
+	}
+}
+
 // FinishDownload completes tracking for a download
 func (dpm *DownloadProgressManager) FinishDownload(filename string) {
 	dpm.mu.Lock()
@@ -198,6 +300,7 @@ func (dpm *DownloadProgressManager) FinishDownload(filename string) {
 	dpm.mu.Unlock()
 	if exists && tracker != nil {
 		tracker.Finish()
+		dpm.notify()
 	}
 }
 
@@ -211,8 +314,14 @@ func (dpm *DownloadProgressManager) ErrorDownload(filename string, err error) {
 	dpm.mu.Unlock()
 	if exists && tracker != nil {
 		tracker.Error(err)
+		dpm.notify()
 	}
 }
 
 // Global progress manager instance
 var globalProgressManager = NewDownloadProgressManager()
+
+// SetDownloadProgressListener routes ROM download progress to fn (nil to stop).
+func SetDownloadProgressListener(fn func([]DownloadProgress)) {
+	globalProgressManager.SetListener(fn)
+}
@@ -0,0 +1,43 @@
+package clienthost
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestDownloadProgressManagerListener(t *testing.T) {
+	dpm := NewDownloadProgressManager()
+	var mu sync.Mutex
+	var last []DownloadProgress
+	dpm.SetListener(func(p []DownloadProgress) {
+		mu.Lock()
+		last = p
+		mu.Unlock()
+	})
+
+	a := dpm.StartDownload("a.zip", 100)
+	dpm.StartDownload("b.zip", 50)
+	a.Update(100)
+	dpm.FinishDownload("a.zip")
+	dpm.ErrorDownload("b.zip", errors.New("boom"))
+
+	mu.Lock()
+	got := last
+	mu.Unlock()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 files in batch, got %+v", got)
+	}
+	if got[0].File != "a.zip" || !got[0].Done || got[0].Percent() != 100 {
+		t.Fatalf("a.zip progress = %+v", got[0])
+	}
+	if got[1].File != "b.zip" || !got[1].Done || got[1].Err != "boom" {
+		t.Fatalf("b.zip progress = %+v", got[1])
+	}
+
+	// Once idle, the next download starts a fresh batch.
+	dpm.StartDownload("c.zip", 10)
+	if snap := dpm.Snapshot(); len(snap) != 1 || snap[0].File != "c.zip" {
+		t.Fatalf("expected new batch with c.zip, got %+v", snap)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -28,7 +27,15 @@ func NewBizHawkInstaller() *BizHawkInstaller {
 }
 
 // InstallBizHawk downloads and installs BizHawk to the specified directory
+// without a known checksum.
 func (b *BizHawkInstaller) InstallBizHawk(downloadURL, installDir string, progress func(msg string)) error {
+	return b.InstallBizHawkVerified(downloadURL, "", installDir, progress)
+}
+
+// InstallBizHawkVerified is InstallBizHawk that checks the downloaded archive
+// against sha256 (hex) before extracting it. An empty sha256 skips the check
+// with a warning through progress.
+func (b *BizHawkInstaller) InstallBizHawkVerified(downloadURL, sha256, installDir string, progress func(msg string)) error {
 	if progress != nil {
 		progress("Downloading BizHawk...")
 	}
@@ -36,11 +43,24 @@ func (b *BizHawkInstaller) InstallBizHawk(downloadURL, installDir string, progre
 	archiveFile := filepath.Base(downloadURL)
 	archivePath := filepath.Join(os.TempDir(), archiveFile)
 
-	if err := b.downloader.DownloadFile(downloadURL, archivePath, nil); err != nil {
+	if err := b.downloader.DownloadFileWithRetry(downloadURL, archivePath, nil, progress); err != nil {
 		return fmt.Errorf("failed to download BizHawk: %w", err)
 	}
 	defer func() { _ = os.Remove(archivePath) }()
 
+	if sha256 == "" {
+		if progress != nil {
+			progress("Warning: no checksum published for " + archiveFile + ", skipping verification")
+		}
+	} else {
+		if progress != nil {
+			progress("Verifying BizHawk download...")
+		}
+		if err := b.downloader.VerifySHA256(archivePath, sha256); err != nil {
+			return fmt.Errorf("failed to verify BizHawk: %w", err)
+		}
+	}
+
 	if progress != nil {
 		progress("Extracting BizHawk...")
 	}
@@ -213,71 +233,50 @@ func GetBizHawkPlatformSuffix() string {
 func GetBizHawkReleaseByTag(tag string) (*Release, error) {
 	tag = strings.TrimPrefix(tag, "v")
 	url := fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", bizhawkAPIURL, bizhawkRepoOwner, bizhawkRepoName, tag)
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := client.Do(req)
+	release, err := fetchRelease(&http.Client{Timeout: 30 * time.Second}, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch release: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to decode release JSON: %w", err)
-	}
-	return &release, nil
+	return release, nil
 }
 
 // GetBizHawkLatestRelease fetches the latest BizHawk release from GitHub
 func GetBizHawkLatestRelease() (*Release, error) {
 	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", bizhawkAPIURL, bizhawkRepoOwner, bizhawkRepoName)
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := client.Do(req)
+	release, err := fetchRelease(&http.Client{Timeout: 30 * time.Second}, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to decode release JSON: %w", err)
-	}
-
-	return &release, nil
+	return release, nil
 }
 
 // GetBizHawkDownloadURLForVersion returns the download URL for a pinned BizHawk version.
 func GetBizHawkDownloadURLForVersion(version string) (string, error) {
+	url, _, err := GetBizHawkAssetForVersion(version)
+	return url, err
+}
+
+// GetBizHawkAssetForVersion returns the download URL for a pinned BizHawk
+// version and the archive's published SHA-256. The checksum is "" when the
+// release lists none or the release lookup fails and the URL is guessed.
+func GetBizHawkAssetForVersion(version string) (url, sha256 string, err error) {
 	release, err := GetBizHawkReleaseByTag(version)
 	if err != nil {
-		return fallbackBizHawkDownloadURL(version), nil
+		return fallbackBizHawkDownloadURL(version), "", nil
+	}
+	asset := FindBizHawkAsset(release)
+	if asset == nil {
+		return fallbackBizHawkDownloadURL(version), "", nil
+	}
+	sha256, err = FindAssetSHA256(checksumClient, release, asset)
+	if err != nil {
+		return "", "", err
 	}
+	return asset.DownloadURL, sha256, nil
+}
+
+// FindBizHawkAsset picks the release zip for this platform, or nil.
+func FindBizHawkAsset(release *Release) *Asset {
 	platformSuffix := GetBizHawkPlatformSuffix()
 	tagName := strings.TrimPrefix(release.TagName, "v")
 	patterns := []string{
@@ -286,15 +285,15 @@ func GetBizHawkDownloadURLForVersion(version string) (string, error) {
 	}
 	for _, pattern := range patterns {
 		if asset := release.FindAssetByName(pattern); asset != nil {
-			return asset.DownloadURL, nil
+			return asset
 		}
 	}
-	for _, a := range release.Assets {
+	for i, a := range release.Assets {
 		if strings.Contains(a.Name, platformSuffix) && strings.HasSuffix(a.Name, ".zip") {
-			return a.DownloadURL, nil
+			return &release.Assets[i]
 		}
 	}
-	return fallbackBizHawkDownloadURL(version), nil
+	return nil
 }
 
 func fallbackBizHawkDownloadURL(version string) string {
@@ -0,0 +1,113 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// checksumAssetNames are release-wide checksum files, in sha256sum format,
+// looked for when an asset has no digest or .sha256 companion.
+var checksumAssetNames = []string{"checksums.txt", "SHA256SUMS", "sha256sums.txt", "SHA256SUMS.txt"}
+
+// checksumClient fetches checksum assets for the package-level helpers.
+var checksumClient = &http.Client{Timeout: 30 * time.Second}
+
+// checksumMaxBytes caps how much of a checksum asset is read.
+const checksumMaxBytes = 1 << 20
+
+// FindAssetSHA256 returns the expected SHA-256 (lowercase hex) of asset in r.
+// It uses the asset's API digest when GitHub provides one, then a
+// "<name>.sha256" asset, then a release-wide checksum file. It returns "" with
+// no error when the release publishes no checksum for the asset.
+func FindAssetSHA256(client *http.Client, r *Release, asset *Asset) (string, error) {
+	if hexSum, ok := strings.CutPrefix(asset.Digest, "sha256:"); ok && hexSum != "" {
+		return strings.ToLower(hexSum), nil
+	}
+	candidates := []string{asset.Name + ".sha256"}
+	candidates = append(candidates, checksumAssetNames...)
+	for _, name := range candidates {
+		sums := r.FindAssetByName(name)
+		if sums == nil {
+			continue
+		}
+		var data []byte
+		err := withRetry(context.Background(), "checksum download", nil, func() error {
+			resp, err := client.Get(sums.DownloadURL)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = resp.Body.Close() }()
+			if resp.StatusCode != http.StatusOK {
+				return newStatusError(resp)
+			}
+			data, err = io.ReadAll(io.LimitReader(resp.Body, checksumMaxBytes))
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch %s: %w", name, err)
+		}
+		if sum := parseChecksumFile(data, asset.Name); sum != "" {
+			return sum, nil
+		}
+	}
+	return "", nil
+}
+
+// parseChecksumFile finds name's hash in sha256sum output ("<hex>  <name>",
+// optionally "*<name>"); a file holding a single bare hash matches any name.
+func parseChecksumFile(data []byte, name string) string {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	var bare []string
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || !isSHA256Hex(fields[0]) {
+			continue
+		}
+		if len(fields) == 1 {
+			bare = append(bare, fields[0])
+			continue
+		}
+		if strings.TrimPrefix(fields[len(fields)-1], "*") == name {
+			return strings.ToLower(fields[0])
+		}
+	}
+	if len(bare) == 1 {
+		return strings.ToLower(bare[0])
+	}
+	return ""
+}
+
+func isSHA256Hex(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// VerifySHA256 hashes the file at path and compares it with want (hex).
+func (d *Downloader) VerifySHA256(path, want string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open download for verification: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return fmt.Errorf("failed to read download for verification: %w", err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch for %s (%d bytes): got sha256 %s, expected %s; the download is truncated or corrupted, try again", path, n, got, strings.ToLower(want))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindAssetSHA256Sources(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	other := strings.Repeat("cd", 32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.zip.sha256":
+			_, _ = w.Write([]byte(sum + "\n"))
+		case "/checksums.txt":
+			_, _ = w.Write([]byte(other + "  a.zip\n" + sum + " *b.zip\n"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, tc := range []struct {
+		name   string
+		assets []Asset
+		want   string
+	}{
+		{"digest", []Asset{{Name: "a.zip", Digest: "sha256:" + strings.ToUpper(sum)}}, sum},
+		{"companion", []Asset{{Name: "a.zip"}, {Name: "a.zip.sha256", DownloadURL: srv.URL + "/a.zip.sha256"}}, sum},
+		{"release file", []Asset{{Name: "b.zip"}, {Name: "checksums.txt", DownloadURL: srv.URL + "/checksums.txt"}}, sum},
+		{"none", []Asset{{Name: "a.zip"}}, ""},
+	} {
+		rel := &Release{Assets: tc.assets}
+		got, err := FindAssetSHA256(srv.Client(), rel, &rel.Assets[0])
+		if err != nil || got != tc.want {
+			t.Fatalf("%s: got %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestInstallBizHawkVerifiedRejectsMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("truncated"))
+	}))
+	t.Cleanup(srv.Close)
+
+	b := &BizHawkInstaller{downloader: &Downloader{httpClient: srv.Client()}}
+	installDir := t.TempDir()
+	wrong := sha256.Sum256([]byte("complete archive"))
+	var msgs []string
+	err := b.InstallBizHawkVerified(srv.URL+"/BizHawk-test.zip", hex.EncodeToString(wrong[:]), installDir, func(msg string) { msgs = append(msgs, msg) })
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("err = %v", err)
+	}
+	if !strings.Contains(strings.Join(msgs, "\n"), "Verifying BizHawk download") {
+		t.Fatalf("progress messages %q", msgs)
+	}
+	if _, err := os.Stat(filepath.Join(os.TempDir(), "BizHawk-test.zip")); !os.IsNotExist(err) {
+		t.Fatalf("corrupt archive left behind: %v", err)
+	}
+}
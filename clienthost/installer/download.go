@@ -1,12 +1,15 @@
 package installer
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 )
 
 // Downloader handles file downloads
@@ -21,40 +24,100 @@ func NewDownloader() *Downloader {
 	}
 }
 
-// DownloadFile downloads a file from a URL to a destination path
+// DownloadFile downloads a file from a URL to a destination path.
+// Interrupted downloads resume from dest+".part"; transient failures are
+// retried silently (see DownloadFileWithRetry).
 func (d *Downloader) DownloadFile(url, dest string, progress func(current, total int64)) error {
+	return d.DownloadFileWithRetry(url, dest, progress, nil)
+}
+
+// DownloadFileWithRetry is DownloadFile with transient failures and rate
+// limits retried with backoff; each retry resumes from the .part file and is
+// reported through notify when that is non-nil.
+func (d *Downloader) DownloadFileWithRetry(url, dest string, progress func(current, total int64), notify func(msg string)) error {
 	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
+	return withRetry(context.Background(), "download of "+filepath.Base(dest), notify, func() error {
+		return ResumeDownload(context.Background(), d.httpClient, url, dest, progress)
+	})
+}
 
-	resp, err := d.httpClient.Get(url)
+// ResumeDownload downloads url to dest via dest+".part". If the .part file already exists it
+// requests only the missing bytes with a Range header and appends them, restarting from scratch
+// when the server answers 200 instead of 206. The .part file is renamed to dest only once its
+// size matches the expected total; on a short read it is kept so the next call can resume.
+func ResumeDownload(ctx context.Context, client *http.Client, url, dest string, progress func(current, total int64)) error {
+	part := dest + ".part"
+	var offset int64
+	if fi, err := os.Stat(part); err == nil && !fi.IsDir() {
+		offset = fi.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed: status %s", resp.Status)
+	var total int64 = -1
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		start, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return fmt.Errorf("unexpected Content-Range %q for resume at %d", resp.Header.Get("Content-Range"), offset)
+		}
+		total = size
+		if total < 0 && resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+		flags |= os.O_APPEND
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The .part file is stale or already larger than the remote file; start over.
+		_ = resp.Body.Close()
+		if err := os.Remove(part); err != nil {
+			return fmt.Errorf("failed to discard stale partial download: %w", err)
+		}
+		return ResumeDownload(ctx, client, url, dest, progress)
+	case resp.StatusCode == http.StatusOK:
+		// Server ignored the range (or none was sent): full download.
+		offset = 0
+		total = resp.ContentLength
+		flags |= os.O_TRUNC
+	default:
+		return fmt.Errorf("download failed: %w", newStatusError(resp))
 	}
 
-	total := resp.ContentLength
-	out, err := os.Create(dest)
+	out, err := os.OpenFile(part, flags, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
-	defer func() { _ = out.Close() }()
 
-	var current int64
+	current := offset
+	if progress != nil {
+		progress(current, total)
+	}
 	buf := make([]byte, 32*1024) // 32KB buffer
+	var copyErr error
 	for {
 		nr, er := resp.Body.Read(buf)
 		if nr > 0 {
 			nw, ew := out.Write(buf[0:nr])
 			if ew != nil {
-				return fmt.Errorf("write error: %w", ew)
+				copyErr = fmt.Errorf("write error: %w", ew)
+				break
 			}
 			if nr != nw {
-				return fmt.Errorf("short write")
+				copyErr = fmt.Errorf("short write")
+				break
 			}
 			current += int64(nw)
 			if progress != nil {
@@ -63,15 +126,52 @@ func (d *Downloader) DownloadFile(url, dest string, progress func(current, total
 		}
 		if er != nil {
 			if er != io.EOF {
-				return fmt.Errorf("read error: %w", er)
+				copyErr = fmt.Errorf("read error: %w", er)
 			}
 			break
 		}
 	}
-
+	if err := out.Close(); err != nil && copyErr == nil {
+		copyErr = fmt.Errorf("failed to close file: %w", err)
+	}
+	if copyErr != nil {
+		return copyErr
+	}
+	if total >= 0 && current != total {
+		return fmt.Errorf("incomplete download: got %d of %d bytes: %w", current, total, io.ErrUnexpectedEOF)
+	}
+	if err := os.Rename(part, dest); err != nil {
+		return fmt.Errorf("failed to finalize download: %w", err)
+	}
 	return nil
 }
 
+// parseContentRange parses "bytes start-end/size" and returns start and size (-1 when "*").
+func parseContentRange(v string) (start, size int64, err error) {
+	rest, ok := strings.CutPrefix(v, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("unsupported Content-Range %q", v)
+	}
+	rng, sz, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q", v)
+	}
+	first, _, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed Content-Range %q", v)
+	}
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return 0, 0, err
+	}
+	size = -1
+	if sz != "*" {
+		if size, err = strconv.ParseInt(sz, 10, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, size, nil
+}
+
 // GetAssetNameForPlatform returns the expected asset name for the current platform
 func GetAssetNameForPlatform(component string) string {
 	// Assets are named like: bizshuffle-server-windows-amd64.zip or bizshuffle-desktop-windows-amd64.zip
@@ -0,0 +1,67 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumeDownloadAppendsWithRange(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	var gotRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRange = r.Header.Get("Range")
+		http.ServeContent(w, r, "rom.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	dest := filepath.Join(t.TempDir(), "rom.bin")
+	if err := os.WriteFile(dest+".part", content[:300], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ResumeDownload(context.Background(), srv.Client(), srv.URL, dest, nil); err != nil {
+		t.Fatal(err)
+	}
+	if gotRange != "bytes=300-" {
+		t.Fatalf("expected range request, got %q", gotRange)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("resumed file differs (len %d want %d)", len(got), len(content))
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Fatal("expected .part file to be renamed")
+	}
+}
+
+func TestResumeDownloadRestartsOnFullResponse(t *testing.T) {
+	content := []byte("complete file body")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(srv.Close)
+
+	dest := filepath.Join(t.TempDir(), "rom.bin")
+	if err := os.WriteFile(dest+".part", []byte("stale partial data that is long"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ResumeDownload(context.Background(), srv.Client(), srv.URL, dest, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("expected full download to replace partial, got %q", got)
+	}
+}
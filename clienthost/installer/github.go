@@ -1,6 +1,7 @@
 package installer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,12 +26,15 @@ type Asset struct {
 	Name        string `json:"name"`
 	DownloadURL string `json:"browser_download_url"`
 	Size        int64  `json:"size"`
+	Digest      string `json:"digest"` // "sha256:<hex>" when GitHub has computed it
 }
 
 // GitHubClient handles GitHub API interactions
 type GitHubClient struct {
 	httpClient *http.Client
 	baseURL    string
+	// Progress, when set, is told about failed attempts that will be retried.
+	Progress func(msg string)
 }
 
 // NewGitHubClient creates a new GitHub API client
@@ -43,32 +47,46 @@ func NewGitHubClient() *GitHubClient {
 	}
 }
 
-// GetLatestRelease fetches the latest release from GitHub
+// GetLatestRelease fetches the latest release from GitHub, retrying transient
+// failures and rate limits with backoff.
 func (g *GitHubClient) GetLatestRelease() (*Release, error) {
 	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", g.baseURL, githubRepoOwner, githubRepoName)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := g.httpClient.Do(req)
+	release, err := fetchRelease(g.httpClient, url, g.Progress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
-	}
+	return release, nil
+}
 
+// fetchRelease GETs a release from the GitHub API through withRetry.
+func fetchRelease(client *http.Client, url string, progress func(msg string)) (*Release, error) {
 	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to decode release JSON: %w", err)
-	}
+	err := withRetry(context.Background(), "GitHub release request", progress, func() error {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("GitHub API returned %w: %s", newStatusError(resp), string(body))
+		}
 
+		if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+			return fmt.Errorf("failed to decode release JSON: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return &release, nil
 }
 
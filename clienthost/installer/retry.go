@@ -0,0 +1,119 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryAttempts is how many times a GitHub request or download is tried
+// before its error is returned.
+const retryAttempts = 4
+
+var (
+	// retryBaseDelay is the wait before the second attempt; it doubles for
+	// each attempt after that, up to retryMaxDelay.
+	retryBaseDelay = 2 * time.Second
+	retryMaxDelay  = 30 * time.Second
+	// retryMaxAfter caps how long a Retry-After header may make us wait;
+	// longer rate-limit windows fail straight away.
+	retryMaxAfter = 2 * time.Minute
+)
+
+// statusError is a non-success HTTP response.
+type statusError struct {
+	Code       int
+	Status     string
+	RetryAfter time.Duration // from the Retry-After header, 0 when absent
+	// RateLimited is set for 429, and for a 403 that GitHub marks as a rate
+	// limit with Retry-After or an exhausted X-RateLimit-Remaining.
+	RateLimited bool
+}
+
+func (e *statusError) Error() string {
+	return "status " + e.Status
+}
+
+// newStatusError builds a statusError from resp, parsing Retry-After as
+// either delay-seconds or an HTTP date.
+func newStatusError(resp *http.Response) *statusError {
+	e := &statusError{Code: resp.StatusCode, Status: resp.Status}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		} else if at, err := http.ParseTime(v); err == nil {
+			e.RetryAfter = max(time.Until(at), 0)
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		e.RateLimited = true
+	case http.StatusForbidden:
+		e.RateLimited = resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"
+	}
+	return e
+}
+
+// retryDelay reports whether err is worth another attempt and how long to wait
+// first. Only transient failures are retried: network and read errors, 5xx
+// responses and rate limits (honoring Retry-After). Other 4xx responses and
+// local errors (bad requests, files, malformed responses) fail straight away.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+	delay := min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	var se *statusError
+	if !errors.As(err, &se) {
+		return delay, isNetworkError(err)
+	}
+	switch {
+	case se.RateLimited:
+		if se.RetryAfter > retryMaxAfter {
+			return 0, false
+		}
+		if se.RetryAfter > 0 {
+			delay = se.RetryAfter
+		}
+		return delay, true
+	case se.Code >= 500:
+		return delay, true
+	}
+	return 0, false
+}
+
+// isNetworkError reports whether err came from the connection rather than
+// from this machine: a failed or reset connection, or a body cut short.
+func isNetworkError(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withRetry runs fn up to retryAttempts times with exponential backoff.
+// Before each retry it reports "<what> failed (...), retrying in Ns" through
+// progress when that is non-nil.
+func withRetry(ctx context.Context, what string, progress func(msg string), fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		delay, ok := retryDelay(err, attempt)
+		if !ok || attempt == retryAttempts {
+			return err
+		}
+		if progress != nil {
+			progress(fmt.Sprintf("%s failed (%v), retrying in %s (attempt %d/%d)...", what, err, delay.Round(time.Second), attempt+1, retryAttempts))
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
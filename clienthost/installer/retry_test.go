@@ -0,0 +1,113 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func shortRetryDelays(t *testing.T) {
+	t.Helper()
+	base, maxDelay := retryBaseDelay, retryMaxDelay
+	retryBaseDelay, retryMaxDelay = time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() { retryBaseDelay, retryMaxDelay = base, maxDelay })
+}
+
+func TestGetLatestReleaseRetriesRateLimit(t *testing.T) {
+	shortRetryDelays(t)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"tag_name":"v1.2.3"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var msgs []string
+	g := &GitHubClient{httpClient: srv.Client(), baseURL: srv.URL, Progress: func(msg string) { msgs = append(msgs, msg) }}
+	rel, err := g.GetLatestRelease()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.TagName != "v1.2.3" || calls != 3 {
+		t.Fatalf("tag %q after %d calls", rel.TagName, calls)
+	}
+	if len(msgs) != 2 || !strings.Contains(msgs[0], "retrying") {
+		t.Fatalf("progress messages %q", msgs)
+	}
+}
+
+func TestGetLatestReleaseGivesUpOnNotFound(t *testing.T) {
+	shortRetryDelays(t)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	g := &GitHubClient{httpClient: srv.Client(), baseURL: srv.URL}
+	if _, err := g.GetLatestRelease(); err == nil || calls != 1 {
+		t.Fatalf("err %v after %d calls", err, calls)
+	}
+}
+
+func TestDownloadFileWithRetryResumesAfterServerError(t *testing.T) {
+	shortRetryDelays(t)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("archive"))
+	}))
+	t.Cleanup(srv.Close)
+
+	dest := filepath.Join(t.TempDir(), "BizHawk.zip")
+	var msgs []string
+	d := &Downloader{httpClient: srv.Client()}
+	if err := d.DownloadFileWithRetry(srv.URL, dest, nil, func(msg string) { msgs = append(msgs, msg) }); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(dest); err != nil || string(got) != "archive" {
+		t.Fatalf("downloaded %q, %v", got, err)
+	}
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "download of BizHawk.zip failed (download failed: status 503") {
+		t.Fatalf("progress messages %q", msgs)
+	}
+}
+
+func TestRetryDelayOnlyRetriesTransientErrors(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network", &net.OpError{Op: "read", Err: errors.New("connection reset")}, true},
+		{"short body", fmt.Errorf("incomplete download: %w", io.ErrUnexpectedEOF), true},
+		{"server error", &statusError{Code: http.StatusBadGateway}, true},
+		{"rate limit", &statusError{Code: http.StatusForbidden, RateLimited: true}, true},
+		{"forbidden", &statusError{Code: http.StatusForbidden}, false},
+		{"not found", &statusError{Code: http.StatusNotFound}, false},
+		{"local file", fmt.Errorf("failed to create file: %w", os.ErrPermission), false},
+		{"bad range", errors.New(`unexpected Content-Range "bytes 5-9/10" for resume at 3`), false},
+	} {
+		if _, got := retryDelay(c.err, 1); got != c.want {
+			t.Errorf("%s: retry = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
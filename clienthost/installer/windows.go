@@ -52,7 +52,7 @@ func (v *VCRedistInstaller) InstallVCRedist(progress func(msg string)) error {
 		progress("Downloading VC++ redistributable...")
 	}
 
-	if err := v.downloader.DownloadFile(url, vcPath, nil); err != nil {
+	if err := v.downloader.DownloadFileWithRetry(url, vcPath, nil, progress); err != nil {
 		return fmt.Errorf("failed to download VC++ redistributable: %w", err)
 	}
 	defer func() { _ = os.Remove(vcPath) }()
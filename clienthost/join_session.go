@@ -3,7 +3,6 @@ package clienthost
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -22,6 +21,12 @@ type JoinOptions struct {
 	PlayerName    string
 	OnStatus      func(string)
 	OnBizhawkLost func()
+	// OnDownloadProgress receives ROM download progress while the session runs.
+	OnDownloadProgress func([]DownloadProgress)
+	// OnConnState receives server connection changes, including each reconnect attempt.
+	OnConnState func(state ConnState, attempt int)
+	// OnSwapResult is told the outcome of each swap from the server.
+	OnSwapResult func(game, instanceID string, err error)
 }
 
 func joinStatus(opts JoinOptions, msg string) {
@@ -85,12 +90,17 @@ func StartJoinSession(parent context.Context, dataDir string, opts JoinOptions)
 	}
 
 	joinStatus(opts, "Reserving Lua IPC port…")
-	bipc, err := NewBizhawkIPC(dataDir)
+	ipcPort, err := cfg.IPCPort()
 	if err != nil {
 		return nil, err
 	}
+	bipc, err := NewBizhawkIPCOnPort(dataDir, ipcPort)
+	if err != nil {
+		return nil, err
+	}
+	bipc.SetCommandTimeouts(cfg.ipcCommandTimeouts())
 
-	httpClient := &http.Client{Timeout: 0}
+	httpClient := cfg.serverHTTPClient()
 	wsURL, serverHTTP, err := BuildWSAndHTTP(opts.ServerURL, cfg)
 	if err != nil {
 		return nil, err
@@ -128,6 +138,10 @@ func StartJoinSession(parent context.Context, dataDir string, opts JoinOptions)
 		bipc:         bipc,
 	}
 
+	if opts.OnDownloadProgress != nil {
+		SetDownloadProgressListener(opts.OnDownloadProgress)
+	}
+
 	if err := bipc.Start(ctx); err != nil {
 		session.Stop()
 		return nil, err
@@ -145,6 +159,23 @@ func StartJoinSession(parent context.Context, dataDir string, opts JoinOptions)
 
 	pluginSync := NewPluginSyncManager(api, httpClient, cfg)
 	_, _ = pluginSync.SyncPlugins()
+	// Plugins may have changed while the server was unreachable.
+	wsClient.OnReconnect(func() {
+		if _, err := pluginSync.SyncPlugins(); err != nil {
+			fmt.Fprintf(os.Stderr, "plugin sync after reconnect: %v\n", err)
+		}
+	})
+	if opts.OnConnState != nil {
+		wsClient.OnConnStateChange(opts.OnConnState)
+	}
+	// Config profile switches restart BizHawk without ending the session.
+	wsClient.OnController(func(ctrl *Controller) {
+		ctrl.SetBizhawkCallbacks(bhController.Terminate, bhController.TerminateForConfig, nil,
+			func() { bhController.RelaunchForConfig(ctx) }, bhController.SetRestartMode)
+		if opts.OnSwapResult != nil {
+			ctrl.OnSwapResult(opts.OnSwapResult)
+		}
+	})
 
 	joinStatus(opts, fmt.Sprintf("Joining %s as %s…", opts.ServerURL, opts.PlayerName))
 	helloDone := make(chan struct{})
@@ -184,9 +215,37 @@ func (s *JoinSession) Stop() {
 		if s.bhController != nil {
 			s.bhController.Terminate()
 		}
+		SetDownloadProgressListener(nil)
 	})
 }
 
+// VoteSkip casts this player's vote to skip the current sync-mode game.
+func (s *JoinSession) VoteSkip() error {
+	if s == nil || s.wsClient == nil {
+		return fmt.Errorf("not joined")
+	}
+	return s.wsClient.SendVoteSkip()
+}
+
+// ResyncFiles wipes the cached ROMs and downloads the session's files again,
+// keeping the ones BizHawk is using. It returns once every download finished.
+func (s *JoinSession) ResyncFiles(ctx context.Context) error {
+	if s == nil || s.wsClient == nil || s.wsClient.GetController() == nil {
+		return fmt.Errorf("not joined")
+	}
+	return s.wsClient.GetController().Resync(ctx)
+}
+
+// LoadLocalSave loads a downloaded instance save into BizHawk.
+func (s *JoinSession) LoadLocalSave(instanceID string) error {
+	if s == nil || s.wsClient == nil || s.wsClient.GetController() == nil {
+		return fmt.Errorf("not joined")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return s.wsClient.GetController().LoadLocalSave(ctx, instanceID)
+}
+
 // StopJoinSession stops a session after a brief settle delay (for re-join).
 func StopJoinSession(s *JoinSession) {
 	if s == nil {
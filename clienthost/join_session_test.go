@@ -2,6 +2,8 @@ package clienthost
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"testing"
@@ -37,3 +39,31 @@ func TestEnsureServerLuaAndPortFile(t *testing.T) {
 		t.Fatal("empty port file")
 	}
 }
+
+func TestNewBizhawkIPCOnPinnedPort(t *testing.T) {
+	dir := t.TempDir()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	taken := ln.Addr().(*net.TCPAddr).Port
+	if _, err := NewBizhawkIPCOnPort(dir, taken); err == nil {
+		t.Fatal("expected pinned port that is in use to fail")
+	}
+	_ = ln.Close()
+
+	bipc, err := NewBizhawkIPCOnPort(dir, taken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bipc.Port() != taken {
+		t.Fatalf("port %d, want %d", bipc.Port(), taken)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "lua_server_port.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != fmt.Sprintf("%d\n", taken) {
+		t.Fatalf("port file %q", b)
+	}
+}
@@ -0,0 +1,91 @@
+package clienthost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LocalSave describes an instance save state downloaded to ./saves.
+type LocalSave struct {
+	InstanceID string
+	Size       int64
+	ModTime    time.Time
+}
+
+// Detail formats the save's size and modification time for display.
+func (s LocalSave) Detail() string {
+	return formatBytes(s.Size) + " · " + s.ModTime.Format("2006-01-02 15:04:05")
+}
+
+// ListLocalSaves returns the *.state files in dir, newest first. A missing
+// directory is treated as empty.
+func ListLocalSaves(dir string) ([]LocalSave, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var saves []LocalSave
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".state")
+		if e.IsDir() || !ok || id == "" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		saves = append(saves, LocalSave{InstanceID: id, Size: info.Size(), ModTime: info.ModTime()})
+	}
+	sort.Slice(saves, func(i, j int) bool { return saves[i].ModTime.After(saves[j].ModTime) })
+	return saves, nil
+}
+
+// GameForInstance returns the game of an instance as last announced by the
+// server, falling back to the current game for the current instance.
+func (c *Controller) GameForInstance(instanceID string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if g := c.instanceGames[instanceID]; g != "" {
+		return g
+	}
+	if instanceID == c.currentInstanceID {
+		return c.currentGame
+	}
+	return ""
+}
+
+// LoadLocalSave opens the instance's game in BizHawk and loads its local save
+// state without saving the running game first. The next swap from the server
+// restores the assigned instance.
+func (c *Controller) LoadLocalSave(ctx context.Context, instanceID string) error {
+	if _, err := os.Stat(filepath.Join("./saves", instanceID+".state")); err != nil {
+		return fmt.Errorf("save for %s: %w", instanceID, err)
+	}
+	game := c.GameForInstance(instanceID)
+	if game == "" {
+		return fmt.Errorf("unknown game for instance %s", instanceID)
+	}
+	c.ipcMu.Lock()
+	defer c.ipcMu.Unlock()
+	if c.bipc == nil || !c.bipc.IsReady() {
+		return fmt.Errorf("BizHawk is not ready")
+	}
+	log.Printf("loading local save for instance=%s game=%s", instanceID, game)
+	if err := c.bipc.SendLoad(ctx, game, instanceID); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.currentGame = game
+	c.currentInstanceID = instanceID
+	c.mu.Unlock()
+	return nil
+}
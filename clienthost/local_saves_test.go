@@ -0,0 +1,67 @@
+package clienthost
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListLocalSavesMissingDir(t *testing.T) {
+	saves, err := ListLocalSaves(filepath.Join(t.TempDir(), "saves"))
+	if err != nil {
+		t.Fatalf("missing dir: %v", err)
+	}
+	if len(saves) != 0 {
+		t.Fatalf("expected no saves, got %v", saves)
+	}
+}
+
+func TestListLocalSavesNewestFirst(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for name, mtime := range map[string]time.Time{
+		"zelda.state": old,
+		"mario.state": time.Now(),
+		"notes.txt":   time.Now(),
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir.state"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	saves, err := ListLocalSaves(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saves) != 2 || saves[0].InstanceID != "mario" || saves[1].InstanceID != "zelda" {
+		t.Fatalf("unexpected saves: %+v", saves)
+	}
+	if saves[0].Size != 4 {
+		t.Fatalf("size = %d, want 4", saves[0].Size)
+	}
+}
+
+func TestGameForInstance(t *testing.T) {
+	c := &Controller{
+		instanceGames:     map[string]string{"zelda": "zelda.zip"},
+		currentGame:       "mario.nes",
+		currentInstanceID: "mario",
+	}
+	if g := c.GameForInstance("zelda"); g != "zelda.zip" {
+		t.Fatalf("zelda game = %q", g)
+	}
+	if g := c.GameForInstance("mario"); g != "mario.nes" {
+		t.Fatalf("current instance game = %q", g)
+	}
+	if g := c.GameForInstance("ghost"); g != "" {
+		t.Fatalf("unknown instance game = %q", g)
+	}
+}
@@ -0,0 +1,91 @@
+package clienthost
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// oneShotTimeout bounds a RunOneShot without an explicit timeout, covering
+// the BizHawk launch, the join and any ROM download.
+const oneShotTimeout = 2 * time.Minute
+
+// OneShotOptions configures RunOneShot.
+type OneShotOptions struct {
+	ServerURL  string
+	PlayerName string
+	// ExpectGame and ExpectInstance, when set, must match the first swap.
+	ExpectGame     string
+	ExpectInstance string
+	// Timeout bounds the whole run; 0 means oneShotTimeout.
+	Timeout  time.Duration
+	OnStatus func(string)
+}
+
+// RunOneShot joins as a player, waits until BizHawk has loaded the first swap
+// (Lua acked the SWAP over IPC), checks it against the expected game and
+// instance, then stops the session and BizHawk. A nil error means the swap
+// loaded and matched.
+func RunOneShot(parent context.Context, dataDir string, opts OneShotOptions) error {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = oneShotTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	type swapResult struct {
+		game, instanceID string
+		err              error
+	}
+	results := make(chan swapResult, 1)
+	lost := make(chan struct{}, 1)
+	sess, err := StartJoinSession(ctx, dataDir, JoinOptions{
+		ServerURL:  opts.ServerURL,
+		PlayerName: opts.PlayerName,
+		OnStatus:   opts.OnStatus,
+		OnBizhawkLost: func() {
+			select {
+			case lost <- struct{}{}:
+			default:
+			}
+		},
+		OnSwapResult: func(game, instanceID string, err error) {
+			select {
+			case results <- swapResult{game, instanceID, err}:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("join: %w", err)
+	}
+	defer sess.Stop()
+	if opts.OnStatus != nil {
+		opts.OnStatus("Waiting for the first swap…")
+	}
+
+	select {
+	case r := <-results:
+		return checkOneShotSwap(opts, r.game, r.instanceID, r.err)
+	case <-lost:
+		return fmt.Errorf("BizHawk closed before the first swap loaded")
+	case <-ctx.Done():
+		return fmt.Errorf("no swap loaded within %s", timeout)
+	}
+}
+
+// checkOneShotSwap compares the first swap's outcome with the expected game
+// and instance.
+func checkOneShotSwap(opts OneShotOptions, game, instanceID string, err error) error {
+	if err != nil {
+		return fmt.Errorf("swap to %q failed: %w", game, err)
+	}
+	if opts.ExpectGame != "" && game != opts.ExpectGame {
+		return fmt.Errorf("swapped to game %q, want %q", game, opts.ExpectGame)
+	}
+	if opts.ExpectInstance != "" && instanceID != opts.ExpectInstance {
+		return fmt.Errorf("swapped to instance %q, want %q", instanceID, opts.ExpectInstance)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package clienthost
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckOneShotSwap(t *testing.T) {
+	opts := OneShotOptions{ExpectGame: "a.nes", ExpectInstance: "i1"}
+	if err := checkOneShotSwap(opts, "a.nes", "i1", nil); err != nil {
+		t.Fatalf("matching swap: %v", err)
+	}
+	if err := checkOneShotSwap(OneShotOptions{}, "b.sfc", "", nil); err != nil {
+		t.Fatalf("no expectations: %v", err)
+	}
+	for name, err := range map[string]error{
+		"wrong game":     checkOneShotSwap(opts, "b.sfc", "i1", nil),
+		"wrong instance": checkOneShotSwap(opts, "a.nes", "i2", nil),
+		"nacked":         checkOneShotSwap(opts, "a.nes", "i1", errors.New("nack: rom missing")),
+	} {
+		if err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -104,9 +104,12 @@ func (psm *PluginSyncManager) fetchServerPlugins() (map[string]protocol.Plugin,
 		return nil, fmt.Errorf("decoding plugins JSON: %w", err)
 	}
 
+	// Disabled plugins are left out so SyncPlugins removes their local copy.
 	enabled := make(map[string]protocol.Plugin)
 	for name, p := range payload.Plugins {
-		enabled[name] = p
+		if p.Status == protocol.PluginStatusEnabled {
+			enabled[name] = p
+		}
 	}
 	return enabled, nil
 }
@@ -3,11 +3,14 @@ package clienthost
 import (
 	"context"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/michael4d45/bizshuffle/clienthost/installer"
 )
 
 // ProgressTrackingAPI extends the API with progress tracking and extra files support
@@ -57,6 +60,17 @@ func (ea *ProgressTrackingAPI) ensureFileWithProgressInternal(ctx context.Contex
 		return err
 	}
 
+	// Prefer the catalog's external host; the server stays the fallback.
+	if ea.controller != nil {
+		if url, sum := ea.controller.externalSource(name); url != "" {
+			err := ea.fetchExternal(ctx, url, dest, name, sum)
+			if err == nil {
+				return nil
+			}
+			log.Printf("external download of %s failed, using server: %v", name, err)
+		}
+	}
+
 	// build URL
 	fetch := ea.BaseURL
 	if len(fetch) > 0 && fetch[len(fetch)-1] == '/' {
@@ -67,7 +81,7 @@ func (ea *ProgressTrackingAPI) ensureFileWithProgressInternal(ctx context.Contex
 	// try up to 3 times
 	var lastErr error
 	for i := 0; i < 3; i++ {
-		if err := ea.downloadFileWithProgress(ctx, fetch, dest, name); err != nil {
+		if err := ea.downloadFileWithProgress(ctx, ea.HTTPClient, fetch, dest, name); err != nil {
 			lastErr = err
 			time.Sleep(500 * time.Millisecond)
 			continue
@@ -77,47 +91,48 @@ func (ea *ProgressTrackingAPI) ensureFileWithProgressInternal(ctx context.Contex
 	return lastErr
 }
 
-// downloadFileWithProgress downloads a file with progress tracking
-func (ea *ProgressTrackingAPI) downloadFileWithProgress(ctx context.Context, url, dest, displayName string) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
+// fetchExternal downloads name from an external host and keeps it only if it
+// matches sum; without a checksum the external copy cannot be trusted and is
+// not fetched.
+func (ea *ProgressTrackingAPI) fetchExternal(ctx context.Context, url, dest, name, sum string) error {
+	if sum == "" {
+		return fmt.Errorf("no checksum to verify %s", url)
+	}
+	// The server's client may pin the server's certificate, so use the default one.
+	if err := ea.downloadFileWithProgress(ctx, http.DefaultClient, url, dest, name); err != nil {
+		_ = os.Remove(dest + ".part")
 		return err
 	}
-
-	resp, err := ea.HTTPClient.Do(req)
+	got, err := romFileSHA256(name)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("bad status: %s", resp.Status)
+	if !strings.EqualFold(got, sum) {
+		_ = os.Remove(dest)
+		return fmt.Errorf("checksum mismatch: got %s, want %s", got, sum)
 	}
+	return nil
+}
 
-	// Get content length for progress tracking
-	contentLength := resp.ContentLength
-
-	// Start progress tracking
-	tracker := globalProgressManager.StartDownload(displayName, contentLength)
-	defer globalProgressManager.FinishDownload(displayName)
-
-	// Create progress reader
-	progressReader := NewProgressReader(resp.Body, tracker)
-
-	// Create output file
-	out, err := os.Create(dest)
-	if err != nil {
-		globalProgressManager.ErrorDownload(displayName, err)
+// downloadFileWithProgress downloads a file with progress tracking, resuming a previous partial download.
+func (ea *ProgressTrackingAPI) downloadFileWithProgress(ctx context.Context, client *http.Client, url, dest, displayName string) error {
+	var tracker *ProgressTracker
+	var reported int64
+	err := installer.ResumeDownload(ctx, client, url, dest, func(current, total int64) {
+		if tracker == nil {
+			// Start progress tracking once the response tells us the size
+			tracker = globalProgressManager.StartDownload(displayName, total)
+		}
+		tracker.Update(current - reported)
+		reported = current
+	})
+	if tracker == nil {
 		return err
 	}
-	defer func() { _ = out.Close() }()
-
-	// Copy with progress tracking
-	_, err = io.Copy(out, progressReader)
 	if err != nil {
 		globalProgressManager.ErrorDownload(displayName, err)
 		return err
 	}
-
+	globalProgressManager.FinishDownload(displayName)
 	return nil
 }
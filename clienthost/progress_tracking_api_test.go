@@ -0,0 +1,55 @@
+package clienthost
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestEnsureFilePrefersVerifiedExternalURL(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	srv := httptest.NewServer(http.StripPrefix("/files/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("server:" + r.URL.Path))
+	})))
+	defer srv.Close()
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("cdn"))
+	}))
+	defer cdn.Close()
+	sum := sha256.Sum256([]byte("cdn"))
+	good := hex.EncodeToString(sum[:])
+
+	cfg := Config{}
+	c := NewController(cfg, nil, NewAPI(srv.URL, srv.Client(), cfg), func(protocol.Command) error { return nil })
+	c.SetMainGames([]protocol.GameEntry{
+		{File: "a.nes", URLs: map[string]string{"a.nes": cdn.URL + "/a.nes"}, Checksums: map[string]string{"a.nes": good}},
+		{File: "b.nes", URLs: map[string]string{"b.nes": cdn.URL + "/b.nes"}, Checksums: map[string]string{"b.nes": "0000"}},
+		{File: "c.nes", URLs: map[string]string{"c.nes": cdn.URL + "/c.nes"}},
+	})
+
+	for name, want := range map[string]string{
+		"a.nes": "cdn",
+		"b.nes": "server:b.nes", // checksum mismatch falls back to the server
+		"c.nes": "server:c.nes", // no checksum to verify the external copy
+	} {
+		if err := c.progressTracking.EnsureFileWithProgress(context.Background(), name); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got, err := os.ReadFile(filepath.Join("roms", name)); err != nil || string(got) != want {
+			t.Fatalf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+}
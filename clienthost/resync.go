@@ -0,0 +1,126 @@
+package clienthost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoGamesUpdate is returned by Resync before the server has sent games_update.
+var ErrNoGamesUpdate = errors.New("no games_update received yet")
+
+// Resync wipes the cached ROMs under ./roms and downloads everything the last
+// games_update asked for again, reporting the result to the server like a
+// normal games_update. Files of the game BizHawk is currently running (its
+// ROM, extra files and extra dirs) and any in-flight download are kept.
+func (c *Controller) Resync(ctx context.Context) error {
+	c.mu.RLock()
+	payload := c.lastGamesUpdate
+	c.mu.RUnlock()
+	if payload == nil {
+		return ErrNoGamesUpdate
+	}
+
+	keepFiles, keepDirs := c.romsInUse()
+	removed, err := clearRoms("./roms", keepFiles, keepDirs)
+	if err != nil {
+		return fmt.Errorf("clear roms: %w", err)
+	}
+	log.Printf("resync: removed %d cached ROM files (kept %d in use), downloading again", removed, len(keepFiles))
+
+	if errs := c.ensureGameFiles(ctx, payload); len(errs) > 0 {
+		return fmt.Errorf("resync: %d file(s) failed: %s", len(errs), strings.Join(errs, "; "))
+	}
+	log.Printf("resync: complete")
+	return nil
+}
+
+// romsInUse returns the ROM paths (relative to ./roms, slash-separated) that
+// must survive a resync: the current game with its extra files, the file being
+// downloaded for a swap, and the current game's extra dirs.
+func (c *Controller) romsInUse() (files map[string]bool, dirs []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	files = make(map[string]bool)
+	for _, f := range []string{c.currentGame, c.pendingFile} {
+		if f != "" {
+			files[f] = true
+		}
+	}
+	for _, entry := range c.mainGames {
+		if entry.File != c.currentGame || c.currentGame == "" {
+			continue
+		}
+		for _, extra := range entry.ExtraFiles {
+			files[extra] = true
+		}
+		dirs = append(dirs, entry.ExtraDirs...)
+	}
+	return files, dirs
+}
+
+// clearRoms removes every file under root except keepFiles and files inside
+// keepDirs, then prunes directories left empty. It returns how many files were
+// removed; a missing root is treated as empty.
+func clearRoms(root string, keepFiles map[string]bool, keepDirs []string) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if keepFiles[rel] || inRomDirs(rel, keepDirs) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+	pruneEmptyDirs(root)
+	return removed, nil
+}
+
+// inRomDirs reports whether rel lies inside one of dirs.
+func inRomDirs(rel string, dirs []string) bool {
+	for _, dir := range dirs {
+		dir = strings.Trim(filepath.ToSlash(dir), "/")
+		if dir != "" && strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneEmptyDirs removes empty subdirectories of root, deepest first.
+func pruneEmptyDirs(root string) {
+	var dirs []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i]) // fails harmlessly while the directory still has files
+	}
+}
@@ -0,0 +1,78 @@
+package clienthost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestResyncRedownloadsAndKeepsCurrentGame(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	srv := httptest.NewServer(http.StripPrefix("/files/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("server:" + r.URL.Path))
+	})))
+	defer srv.Close()
+
+	for name, data := range map[string]string{
+		"a.nes":          "running",
+		"a.cue":          "running extra",
+		"b.nes":          "corrupt",
+		"stale/junk.nes": "junk",
+	} {
+		path := filepath.Join("roms", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	acks := make(chan protocol.Command, 4)
+	cfg := Config{}
+	c := NewController(cfg, nil, NewAPI(srv.URL, srv.Client(), cfg), func(cmd protocol.Command) error {
+		acks <- cmd
+		return nil
+	})
+	if err := c.Resync(context.Background()); !errors.Is(err, ErrNoGamesUpdate) {
+		t.Fatalf("resync before games_update: %v", err)
+	}
+
+	c.lastGamesUpdate = map[string]any{
+		"games":      []any{"a.nes", "b.nes"},
+		"main_games": []any{map[string]any{"file": "a.nes", "extra_files": []any{"a.cue"}}},
+	}
+	c.currentGame = "a.nes"
+	c.mainGames = []protocol.GameEntry{{File: "a.nes", ExtraFiles: []string{"a.cue"}}}
+
+	if err := c.Resync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"a.nes": "running",
+		"a.cue": "running extra",
+		"b.nes": "server:b.nes",
+	} {
+		if got, err := os.ReadFile(filepath.Join("roms", name)); err != nil || string(got) != want {
+			t.Fatalf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join("roms", "stale")); !os.IsNotExist(err) {
+		t.Fatalf("stale directory not removed: %v", err)
+	}
+	if ack := <-acks; ack.Cmd != protocol.CmdGamesUpdateAck || !payloadBool(ack.Payload, "has_files") {
+		t.Fatalf("unexpected ack %+v", ack)
+	}
+}
@@ -0,0 +1,63 @@
+package clienthost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUploadSaveStateRetriesTransientFailures(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	if err := os.MkdirAll("saves", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("saves", "i1.state"), []byte("raw state"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls atomic.Int32
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := Config{"save_upload_attempts": "3", "save_upload_backoff_ms": "1"}
+	api := NewAPI(srv.URL, srv.Client(), cfg)
+	if err := api.UploadSaveState("i1", ""); err != nil {
+		t.Fatalf("upload after retries: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("calls = %d, want 3", n)
+	}
+
+	calls.Store(0)
+	status = http.StatusBadRequest
+	if err := api.UploadSaveState("i1", ""); err == nil {
+		t.Fatal("expected client error to fail")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("client error retried: calls = %d", n)
+	}
+
+	calls.Store(-5)
+	status = http.StatusBadGateway
+	if err := api.UploadSaveState("i1", ""); err == nil {
+		t.Fatal("expected failure after exhausting attempts")
+	}
+	if n := calls.Load(); n != -2 {
+		t.Fatalf("attempts made = %d, want 3", n+5)
+	}
+}
@@ -9,9 +9,14 @@ import (
 
 const clientSaveMaxBytes = 32 << 20
 
-// verifySaveFileBytes checks that data is a valid BizHawk savestate.
-func verifySaveFileBytes(data []byte) error {
-	result := savestate.VerifyBizHawkSavestate(data, savestate.VerifyOptions{MaxFileBytes: clientSaveMaxBytes})
+// verifySaveFileBytes checks that data is a usable BizHawk savestate. Any
+// non-empty file passes unless requireZip is set; ZIP-based states are always
+// fully verified since a broken one is certainly corrupt.
+func verifySaveFileBytes(data []byte, requireZip bool) error {
+	result := savestate.VerifyBizHawkSavestate(data, savestate.VerifyOptions{
+		MaxFileBytes: clientSaveMaxBytes,
+		AllowRaw:     !requireZip,
+	})
 	if !result.OK {
 		return fmt.Errorf("invalid save (%s): %s", result.Code, result.Message)
 	}
@@ -19,10 +24,10 @@ func verifySaveFileBytes(data []byte) error {
 }
 
 // verifySaveFilePath reads and validates a local .state file.
-func verifySaveFilePath(path string) error {
+func verifySaveFilePath(path string, requireZip bool) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	return verifySaveFileBytes(data)
+	return verifySaveFileBytes(data, requireZip)
 }
@@ -0,0 +1,48 @@
+package clienthost
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/savestate"
+)
+
+func TestVerifySaveFilePathRawStates(t *testing.T) {
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "raw.state")
+	if err := os.WriteFile(raw, []byte("raw core dump"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.state")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifySaveFilePath(raw, false); err != nil {
+		t.Fatalf("raw state rejected by default: %v", err)
+	}
+	if err := verifySaveFilePath(raw, true); err == nil {
+		t.Fatal("raw state accepted with verify_zip_saves")
+	}
+	if err := verifySaveFilePath(empty, false); err == nil {
+		t.Fatal("empty state accepted")
+	}
+
+	zipState, err := savestate.BuildMinimalBizHawkSavestate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifySaveFileBytes(zipState, true); err != nil {
+		t.Fatalf("zip state rejected: %v", err)
+	}
+}
+
+func TestConfigRequireZipSaves(t *testing.T) {
+	if (Config{}).requireZipSaves() {
+		t.Fatal("zip saves should not be required by default")
+	}
+	if !(Config{"verify_zip_saves": "true"}).requireZipSaves() {
+		t.Fatal("verify_zip_saves=true not honored")
+	}
+}
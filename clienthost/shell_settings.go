@@ -11,6 +11,23 @@ type ShellSettings struct {
 	HostPort   int
 	ServerURL  string
 	PlayerName string
+	Theme      string // ThemeSystem, ThemeLight or ThemeDark
+}
+
+// Desktop theme choices stored under config.json "theme".
+const (
+	ThemeSystem = "system"
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+)
+
+// normalizeTheme maps unknown or empty values to ThemeSystem.
+func normalizeTheme(v string) string {
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case ThemeLight, ThemeDark:
+		return v
+	}
+	return ThemeSystem
 }
 
 // DefaultShellSettings returns defaults for a new shell (hostPort 0 = pick a free port).
@@ -20,6 +37,7 @@ func DefaultShellSettings() ShellSettings {
 		HostPort:   0,
 		ServerURL:  "http://127.0.0.1:8080",
 		PlayerName: "",
+		Theme:      ThemeSystem,
 	}
 }
 
@@ -45,6 +63,7 @@ func mergeShellSettings(partial ShellSettings) ShellSettings {
 		HostPort:   normalizeHostPort(partial.HostPort, def.HostPort),
 		ServerURL:  serverURL,
 		PlayerName: strings.TrimSpace(partial.PlayerName),
+		Theme:      normalizeTheme(partial.Theme),
 	}
 }
 
@@ -69,9 +88,12 @@ func shellFromConfig(cfg Config) ShellSettings {
 		HostPort:   hostPort,
 		ServerURL:  serverURL,
 		PlayerName: strings.TrimSpace(cfg["name"]),
+		Theme:      cfg["theme"],
 	})
 }
 
+// applyShellToConfig writes the form fields; the theme is saved on its own by
+// SaveShellTheme so form saves never reset it.
 func applyShellToConfig(cfg Config, settings ShellSettings) {
 	cfg["bind_host"] = settings.BindHost
 	cfg["host_port"] = strconv.Itoa(settings.HostPort)
@@ -127,3 +149,15 @@ func SaveShellSettingsForm(dataDir, bindHost, serverURL, playerName string, host
 	_ = SaveConfig(dataDir, cfg)
 	return next
 }
+
+// SaveShellTheme persists the desktop theme choice and returns it normalized.
+func SaveShellTheme(dataDir, theme string) string {
+	cfg, err := LoadConfig(dataDir)
+	if err != nil {
+		cfg = Config{}
+	}
+	theme = normalizeTheme(theme)
+	cfg["theme"] = theme
+	_ = SaveConfig(dataDir, cfg)
+	return theme
+}
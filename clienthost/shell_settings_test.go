@@ -51,3 +51,17 @@ func TestShellSettingsDefaultsWhenMissing(t *testing.T) {
 		t.Fatal("expected no config.json until save")
 	}
 }
+
+func TestShellThemeSurvivesFormSave(t *testing.T) {
+	dir := t.TempDir()
+	if got := SaveShellTheme(dir, "Dark"); got != ThemeDark {
+		t.Fatalf("saved theme %q", got)
+	}
+	SaveShellSettingsForm(dir, "127.0.0.1", "http://127.0.0.1:8080", "Alice", 0)
+	if loaded := LoadShellSettings(dir); loaded.Theme != ThemeDark {
+		t.Fatalf("got %+v", loaded)
+	}
+	if got := SaveShellTheme(dir, "sepia"); got != ThemeSystem {
+		t.Fatalf("unknown theme saved as %q", got)
+	}
+}
@@ -0,0 +1,84 @@
+package clienthost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// windowGeometryKeys maps the client config keys for BizHawk's main window to
+// the EmuHawk config.ini fields they set, with the smallest accepted value.
+var windowGeometryKeys = []struct {
+	cfgKey, iniKey string
+	least          int
+}{
+	{"bizhawk_window_x", "MainWndx", 0},
+	{"bizhawk_window_y", "MainWndy", 0},
+}
+
+// WindowGeometry returns the config.ini fields for the configured
+// "bizhawk_window_*" keys; unset keys are left out. x and y must be at least 0
+// (EmuHawk ignores negative positions).
+func (c Config) WindowGeometry() (map[string]int, error) {
+	geom := map[string]int{}
+	for _, k := range windowGeometryKeys {
+		v := strings.TrimSpace(c[k.cfgKey])
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < k.least {
+			return nil, fmt.Errorf("invalid %s %q: must be an integer >= %d", k.cfgKey, v, k.least)
+		}
+		geom[k.iniKey] = n
+	}
+	return geom, nil
+}
+
+// applyWindowGeometry writes geom into EmuHawk's config.ini at path, keeping
+// every other field. A position also turns on SaveWindowPosition, which
+// EmuHawk needs to honour MainWndx/MainWndy. EmuHawk saves its own window
+// position on exit, so this runs before every launch. A missing config.ini is
+// left alone: EnsureBizhawkFiles treats its absence as a first run and
+// installs the full file, after which the next launch applies the geometry.
+func applyWindowGeometry(path string, geom map[string]int) error {
+	if len(geom) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	for k, n := range geom {
+		fields[k] = json.RawMessage(strconv.Itoa(n))
+	}
+	_, hasX := geom["MainWndx"]
+	_, hasY := geom["MainWndy"]
+	if hasX || hasY {
+		fields["SaveWindowPosition"] = json.RawMessage("true")
+	}
+	out, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package clienthost
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWindowGeometry(t *testing.T) {
+	geom, err := Config{"bizhawk_window_x": "100", "bizhawk_window_y": " 40 "}.WindowGeometry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(geom) != 2 || geom["MainWndx"] != 100 || geom["MainWndy"] != 40 {
+		t.Fatalf("geometry %v", geom)
+	}
+	for _, bad := range []Config{
+		{"bizhawk_window_x": "-5"},
+		{"bizhawk_window_y": "-1"},
+		{"bizhawk_window_y": "top"},
+	} {
+		if _, err := bad.WindowGeometry(); err == nil {
+			t.Fatalf("%v: expected error", bad)
+		}
+	}
+}
+
+func TestApplyWindowGeometryKeepsOtherFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(path, []byte(`{"MainWndx": 5, "SaveWindowPosition": false, "SoundVolume": 40}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyWindowGeometry(path, map[string]int{"MainWndx": 200, "MainWndy": 50}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"MainWndx": 200.0, "MainWndy": 50.0, "SoundVolume": 40.0,
+		"SaveWindowPosition": true,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%s = %v, want %v (%s)", k, got[k], v, data)
+		}
+	}
+
+	// A missing config.ini is left for EnsureBizhawkFiles to install.
+	missing := filepath.Join(t.TempDir(), "config.ini")
+	if err := applyWindowGeometry(missing, map[string]int{"MainWndx": 200}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Fatalf("config.ini created before first-run setup: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package clienthost
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectDelayDoublesWithJitterUpToCap(t *testing.T) {
+	base, maxDelay := 500*time.Millisecond, 4*time.Second
+	lo := func() float64 { return 0 }
+	hi := func() float64 { return 0.999999 }
+	cases := []struct {
+		attempt int
+		full    time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{4, 4 * time.Second},
+		{10, 4 * time.Second},
+		{200, 4 * time.Second},
+	}
+	for _, c := range cases {
+		if got := reconnectDelay(c.attempt, base, maxDelay, lo); got != c.full/2 {
+			t.Fatalf("attempt %d min delay = %s, want %s", c.attempt, got, c.full/2)
+		}
+		got := reconnectDelay(c.attempt, base, maxDelay, hi)
+		if got <= c.full/2 || got > c.full {
+			t.Fatalf("attempt %d max delay = %s, want within (%s, %s]", c.attempt, got, c.full/2, c.full)
+		}
+	}
+}
+
+func TestWSReconnectPolicyDefaultsAndOverrides(t *testing.T) {
+	base, maxDelay := Config{}.wsReconnectPolicy()
+	if base != 500*time.Millisecond || maxDelay != 30*time.Second {
+		t.Fatalf("defaults = %s, %s", base, maxDelay)
+	}
+	base, maxDelay = Config{"ws_reconnect_base_ms": "2000", "ws_reconnect_max_ms": "100"}.wsReconnectPolicy()
+	if base != 2*time.Second || maxDelay != 2*time.Second {
+		t.Fatalf("cap below base should clamp to base, got %s, %s", base, maxDelay)
+	}
+}
+
+func TestStatusUpdateReportsFreeDiskSpace(t *testing.T) {
+	t.Chdir(t.TempDir())
+	w := NewWSClient("ws://example.invalid/ws", nil, nil)
+	status := w.statusUpdate(true)
+	if !status.BizhawkReady || status.BizhawkHung {
+		t.Fatalf("status %+v", status)
+	}
+	if status.SavesFreeBytes == nil || *status.SavesFreeBytes <= 0 {
+		t.Fatalf("saves_free_bytes %v", status.SavesFreeBytes)
+	}
+}
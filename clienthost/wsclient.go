@@ -2,8 +2,10 @@ package clienthost
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"math/rand"
 	"net"
 	"runtime"
 	"sync"
@@ -44,10 +46,40 @@ type WSClient struct {
 	// name is the player name for hello messages
 	name string
 
+	// tlsConfig is used for wss:// dials; nil keeps default verification
+	tlsConfig *tls.Config
+
 	// helloAck signals when hello has been acknowledged by server
 	helloAck chan struct{}
+
+	// connection state and current reconnect attempt (protected by connMu)
+	connState ConnState
+	attempt   int
+	// onConnState is notified of every state change; set before Start
+	onConnState func(state ConnState, attempt int)
+	// onReconnect runs after every connection except the first; set before Start
+	onReconnect func()
+	// onController configures the controller before it handles commands; set before Start
+	onController func(*Controller)
+
+	// reconnect backoff: first delay, doubled per failed attempt up to reconnectMax
+	reconnectBase time.Duration
+	reconnectMax  time.Duration
 }
 
+// ConnState is the websocket connection state reported to the GUI.
+type ConnState string
+
+const (
+	// ConnStateDisconnected means the client is stopped or has not started.
+	ConnStateDisconnected ConnState = "disconnected"
+	// ConnStateReconnecting means the connection dropped or a dial failed and
+	// the client is waiting out its backoff before the next attempt.
+	ConnStateReconnecting ConnState = "reconnecting"
+	// ConnStateConnected means the websocket is open.
+	ConnStateConnected ConnState = "connected"
+)
+
 // NewWSClient creates a client for wsURL.
 // The client does nothing until Start() is called.
 func NewWSClient(wsURL string, api *API, bipc *BizhawkIPC) *WSClient {
@@ -56,20 +88,73 @@ func NewWSClient(wsURL string, api *API, bipc *BizhawkIPC) *WSClient {
 		sendCh:   make(chan protocol.Command, 64),
 		api:      api,
 		bipc:     bipc,
-		helloAck: make(chan struct{}),
+		helloAck:  make(chan struct{}),
+		connState: ConnStateDisconnected,
 	}
 }
 
-// GetConnectionStatus returns whether the client is connected to the server and whether BizHawk is ready.
-func (w *WSClient) GetConnectionStatus() (connected, bizhawkReady bool) {
+// OnConnStateChange registers fn to be called whenever the connection state or
+// reconnect attempt changes. Call before Start.
+func (w *WSClient) OnConnStateChange(fn func(state ConnState, attempt int)) {
+	w.onConnState = fn
+}
+
+// OnReconnect registers fn to run in the background each time the client
+// reconnects after a dropped connection. Call before Start.
+func (w *WSClient) OnReconnect(fn func()) {
+	w.onReconnect = fn
+}
+
+// OnController registers fn to configure the command controller (for example
+// its BizHawk callbacks) before it handles any command. Call before Start.
+func (w *WSClient) OnController(fn func(*Controller)) {
+	w.onController = fn
+}
+
+func (w *WSClient) setConnState(state ConnState, attempt int) {
+	w.connMu.Lock()
+	changed := w.connState != state || w.attempt != attempt
+	w.connState = state
+	w.attempt = attempt
+	fn := w.onConnState
+	w.connMu.Unlock()
+	if changed && fn != nil {
+		fn(state, attempt)
+	}
+}
+
+// ConnectionState returns the connection state and current reconnect attempt.
+func (w *WSClient) ConnectionState() (ConnState, int) {
+	w.connMu.Lock()
+	defer w.connMu.Unlock()
+	return w.connState, w.attempt
+}
+
+// reconnectDelay returns how long to wait before reconnect attempt n (1-based):
+// base doubled per attempt and capped at maxDelay, with "equal jitter" so the
+// result lands in [d/2, d]. jitter returns a value in [0, 1).
+func reconnectDelay(n int, base, maxDelay time.Duration, jitter func() float64) time.Duration {
+	d := base
+	for i := 1; i < n && d < maxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, maxDelay)
+	half := d / 2
+	return half + time.Duration(jitter()*float64(d-half))
+}
+
+// GetConnectionStatus returns whether the client is connected to the server, whether
+// BizHawk is ready, and the current reconnect attempt (0 unless reconnecting).
+func (w *WSClient) GetConnectionStatus() (connected, bizhawkReady bool, reconnectAttempt int) {
 	w.connMu.Lock()
 	connected = w.conn != nil
+	reconnectAttempt = w.attempt
 	w.connMu.Unlock()
 
 	if w.bipc != nil {
 		bizhawkReady = w.bipc.IsReady()
 	}
-	return connected, bizhawkReady
+	return connected, bizhawkReady, reconnectAttempt
 }
 
 // GetController returns the active controller if connected.
@@ -79,7 +164,7 @@ func (w *WSClient) GetController() *Controller {
 
 // SendBizhawkReadinessUpdate sends an update to the server about BizHawk readiness status.
 func (w *WSClient) SendBizhawkReadinessUpdate(ready bool) error {
-	connected, _ := w.GetConnectionStatus()
+	connected, _, _ := w.GetConnectionStatus()
 	obslog.Event(obslog.WS, "bizhawk_ready_update", map[string]string{
 		"ready":         fmt.Sprintf("%v", ready),
 		"ws_connected":  fmt.Sprintf("%v", connected),
@@ -89,13 +174,59 @@ func (w *WSClient) SendBizhawkReadinessUpdate(ready bool) error {
 		// Hello on connect includes bizhawk_ready; avoid queueing status_update before WS is up.
 		return nil
 	}
-	update := protocol.Command{
-		Cmd: protocol.CmdStatusUpdate,
-		Payload: map[string]any{
-			"bizhawk_ready": ready,
-		},
+	return w.Send(protocol.Command{Cmd: protocol.CmdStatusUpdate, Payload: w.statusUpdate(ready)})
+}
+
+// statusUpdate builds the status_update payload reporting ready as BizHawk's
+// readiness.
+func (w *WSClient) statusUpdate(ready bool) protocol.StatusUpdate {
+	status := protocol.StatusUpdate{BizhawkReady: ready}
+	if !ready && w.bipc != nil && w.bipc.IsHung() {
+		// Lets the admin UI tell a hung BizHawk from a closed one.
+		status.BizhawkHung = true
+	}
+	if w.controller != nil {
+		status.Game, status.InstanceID, status.PendingFile = w.controller.GetState()
+	}
+	if free, err := diskFreeBytes("./saves"); err == nil {
+		status.SavesFreeBytes = &free
+	} else if free, err := diskFreeBytes("."); err == nil {
+		status.SavesFreeBytes = &free
+	}
+	return status
+}
+
+// defaultStatusIntervalSecs is how often the status_update heartbeat is sent
+// unless "status_interval_secs" says otherwise (0 disables it).
+const defaultStatusIntervalSecs = 15
+
+// statusLoop sends a status_update heartbeat every interval while connected.
+func (w *WSClient) statusLoop(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		connected, ready, _ := w.GetConnectionStatus()
+		if !connected {
+			continue
+		}
+		cmd := protocol.Command{Cmd: protocol.CmdStatusUpdate, Payload: w.statusUpdate(ready)}
+		if err := w.SendWithTimeout(cmd, 2*time.Second); err != nil {
+			log.Printf("wsclient: status heartbeat: %v", err)
+		}
+	}
+}
+
+// SendVoteSkip votes to skip the game currently played in sync mode.
+func (w *WSClient) SendVoteSkip() error {
+	if connected, _, _ := w.GetConnectionStatus(); !connected {
+		return fmt.Errorf("not connected to server")
 	}
-	return w.Send(update)
+	return w.Send(protocol.Command{Cmd: protocol.CmdVoteSkip})
 }
 
 // Start begins the connection and goroutines. It waits for hello acknowledgment before returning.
@@ -105,6 +236,8 @@ func (w *WSClient) Start(parent context.Context, cfg Config) {
 	}
 
 	w.name = cfg["name"]
+	w.tlsConfig = cfg.tlsClientConfig()
+	w.reconnectBase, w.reconnectMax = cfg.wsReconnectPolicy()
 
 	ctx, cancel := context.WithCancel(parent)
 	w.ctx = ctx
@@ -122,7 +255,13 @@ func (w *WSClient) Start(parent context.Context, cfg Config) {
 		return w.SendWithTimeout(cmd, 2*time.Second)
 	}
 	w.controller = NewControllerWithHelloAck(cfg, w.bipc, w.api, sendFunc, w.helloAck)
+	if w.onController != nil {
+		w.onController(w.controller)
+	}
 	go w.runController(ctx, w.controller)
+	if secs := cfg.GetInt("status_interval_secs", defaultStatusIntervalSecs); secs > 0 {
+		go w.statusLoop(ctx, time.Duration(secs)*time.Second)
+	}
 
 	// wait for hello acknowledgment or context cancellation
 	log.Printf("wsclient: waiting for hello acknowledgment from server...")
@@ -168,6 +307,7 @@ func (w *WSClient) Stop() {
 	// Reset context state so Start() can be called again
 	w.ctx = nil
 	w.cancel = nil
+	w.setConnState(ConnStateDisconnected, 0)
 }
 
 // Send enqueues a command for sending. Returns error if client is stopped.
@@ -204,14 +344,19 @@ func (w *WSClient) SendWithTimeout(cmd protocol.Command, timeout time.Duration)
 }
 
 // run manages the websocket connection.
-// It reconnects automatically if the connection drops.
+// It reconnects automatically if the connection drops, backing off
+// exponentially (with jitter, up to ws_reconnect_max_ms) between attempts.
+// Every connection re-sends hello; reconnections also run onReconnect.
 func (w *WSClient) run() {
 	defer w.wg.Done()
 	dialer := websocket.Dialer{
 		NetDial:          (&net.Dialer{Timeout: 5 * time.Second}).Dial,
 		HandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:  w.tlsConfig,
 	}
 
+	attempt := 0
+	everConnected := false
 	for {
 		// stop if context is canceled
 		select {
@@ -221,20 +366,28 @@ func (w *WSClient) run() {
 		default:
 		}
 
+		if attempt > 0 {
+			delay := reconnectDelay(attempt, w.reconnectBase, w.reconnectMax, rand.Float64)
+			w.setConnState(ConnStateReconnecting, attempt)
+			log.Printf("wsclient: reconnect attempt %d in %s", attempt, delay.Round(time.Millisecond))
+			select {
+			case <-time.After(delay):
+			case <-w.ctx.Done():
+				return
+			}
+		}
+
 		// try to connect
 		conn, resp, err := dialer.Dial(w.wsURL, nil)
 		if err != nil {
-			log.Printf("wsclient: dial error: %v; retrying in 2s", err)
+			log.Printf("wsclient: dial error: %v", err)
 			obslog.Event(obslog.WS, "dial_failed", map[string]string{
-				"ws_url": w.wsURL,
-				"error":  err.Error(),
+				"ws_url":  w.wsURL,
+				"error":   err.Error(),
+				"attempt": fmt.Sprintf("%d", attempt),
 			})
-			select {
-			case <-time.After(2 * time.Second):
-				continue
-			case <-w.ctx.Done():
-				return
-			}
+			attempt++
+			continue
 		}
 		if resp != nil && resp.Body != nil {
 			_ = resp.Body.Close()
@@ -246,6 +399,12 @@ func (w *WSClient) run() {
 		w.connMu.Lock()
 		w.conn = conn
 		w.connMu.Unlock()
+		attempt = 0
+		w.setConnState(ConnStateConnected, 0)
+		if everConnected && w.onReconnect != nil {
+			go w.onReconnect()
+		}
+		everConnected = true
 
 		// start writer goroutine
 		writeDone := make(chan struct{})
@@ -266,6 +425,7 @@ func (w *WSClient) run() {
 		if err := w.Send(hello); err != nil {
 			log.Printf("wsclient: failed to send hello: %v", err)
 			_ = conn.Close()
+			attempt = 1
 			continue
 		}
 		log.Printf("wsclient: sent hello as %s (bizhawk_ready: %v)", w.name, bizhawkReady)
@@ -298,7 +458,8 @@ func (w *WSClient) run() {
 		default:
 		}
 
-		// loop and reconnect
+		// loop and reconnect after the first backoff delay
+		attempt = 1
 	}
 }
 
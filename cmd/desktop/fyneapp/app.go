@@ -3,7 +3,9 @@ package fyneapp
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,6 +29,7 @@ type Options struct {
 	DataDir        string
 	LoadSettings   func() clienthost.ShellSettings
 	SaveSettings   func(bindHost, serverURL, playerName string, hostPort int)
+	SaveTheme      func(theme string)
 	VersionLabel   func() string
 	CheckUpdates   func(ctx context.Context) (UpdateInfo, error)
 	OpenDataDir    func()
@@ -34,7 +37,7 @@ type Options struct {
 	StopServer     func()
 	HostedURL      func() string
 	OpenBrowser    func(url string)
-	StartJoin      func(ctx context.Context, serverURL, playerName string, onStatus, onLost func(string)) (*clienthost.JoinSession, error)
+	StartJoin      func(ctx context.Context, join clienthost.JoinOptions) (*clienthost.JoinSession, error)
 	StopJoin       func()
 	DepsSnapshot   func(dataDir string) clienthost.DependenciesSnapshot
 	InstallDep     func(dataDir string, id clienthost.DependencyID, progress func(string)) error
@@ -44,7 +47,12 @@ type Options struct {
 // Run starts the BizShuffle desktop shell (Host / Join).
 func Run(opts Options) {
 	a := app.NewWithID("com.bizshuffle.desktop")
-	a.Settings().SetTheme(ui.NewTheme())
+	// Apply the saved theme before the window is created so it never flashes.
+	themeMode := clienthost.ThemeSystem
+	if opts.LoadSettings != nil {
+		themeMode = opts.LoadSettings().Theme
+	}
+	a.Settings().SetTheme(ui.NewThemeForMode(themeMode))
 
 	w := a.NewWindow("BizShuffle")
 	w.Resize(ui.WindowDefaultSize())
@@ -57,6 +65,8 @@ func Run(opts Options) {
 	w.SetContent(sh.root)
 
 	var serverStop func()
+	var joinSess *clienthost.JoinSession
+	var downloadsGen int
 	var saveTimer *time.Timer
 	var saveMu sync.Mutex
 	depsBlocked := func() bool {
@@ -96,6 +106,7 @@ func Run(opts Options) {
 		sh.portEntry.SetText(strconv.Itoa(s.HostPort))
 		sh.serverURLEntry.SetText(s.ServerURL)
 		sh.playerNameEntry.SetText(s.PlayerName)
+		sh.themeSelect.SetSelected(themeOptionLabel(s.Theme))
 	}
 
 	var refreshDeps func()
@@ -179,6 +190,52 @@ func Run(opts Options) {
 		applyUI()
 	}
 
+	savesDir := filepath.Join(opts.DataDir, "saves")
+	var savesSig string
+	var loadSave func(instanceID string)
+	refreshSaves := func(force bool) {
+		saves, err := clienthost.ListLocalSaves(savesDir)
+		sig := savesSignature(saves, err)
+		if !force && sig == savesSig {
+			return
+		}
+		savesSig = sig
+		renderSavesPanel(sh, saves, err, st.joined, loadSave)
+	}
+	loadSave = func(instanceID string) {
+		sess := joinSess
+		if sess == nil {
+			return
+		}
+		st.setStatus("Loading "+instanceID+" into BizHawk…", ui.StatusSeverityInfo)
+		applyUI()
+		go func() {
+			err := sess.LoadLocalSave(instanceID)
+			fyne.Do(func() {
+				if err != nil {
+					st.setStatus("Load failed: "+err.Error(), ui.StatusSeverityError)
+				} else {
+					st.setStatus("Loaded "+instanceID+" into BizHawk", ui.StatusSeveritySuccess)
+				}
+				applyUI()
+			})
+		}()
+	}
+	// Poll the saves folder; downloads land there during a session.
+	stopSavesPoll := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopSavesPoll:
+				return
+			case <-ticker.C:
+				fyne.Do(func() { refreshSaves(false) })
+			}
+		}
+	}()
+
 	onFieldChange := func() { scheduleSave() }
 	sh.hostEntry.OnChanged = func(string) { onFieldChange() }
 	sh.portEntry.OnChanged = func(string) { onFieldChange() }
@@ -243,6 +300,9 @@ func Run(opts Options) {
 				fyne.Do(func() {
 					st.busy = false
 					st.hosting = false
+					st.joined = false
+					joinSess = nil
+					refreshSaves(true)
 					sh.stopHostBtn.Enable()
 					sh.hostBtn.Enable()
 					st.setStatus("Host stopped", ui.StatusSeverityInfo)
@@ -285,15 +345,61 @@ func Run(opts Options) {
 					applyUI()
 				})
 			}
-			onLost := func(msg string) {
+			onLost := func() {
+				fyne.Do(func() {
+					st.joined = false
+					joinSess = nil
+					refreshSaves(true)
+					st.setStatus("BizHawk closed — disconnected from server", ui.StatusSeverityWarning)
+					applyUI()
+				})
+			}
+			onProgress := func(progress []clienthost.DownloadProgress) {
 				fyne.Do(func() {
-					st.setStatus(msg, ui.StatusSeverityWarning)
+					downloadsGen++
+					renderDownloads(sh, progress)
+					if downloadsDone(progress) {
+						// Leave the finished list up briefly, unless a new batch starts.
+						gen := downloadsGen
+						time.AfterFunc(3*time.Second, func() {
+							fyne.Do(func() {
+								if gen == downloadsGen {
+									renderDownloads(sh, nil)
+								}
+							})
+						})
+					}
+				})
+			}
+			onConn := func(state clienthost.ConnState, attempt int) {
+				fyne.Do(func() {
+					if !st.joined {
+						return
+					}
+					switch state {
+					case clienthost.ConnStateReconnecting:
+						st.setStatus(fmt.Sprintf("Reconnecting to %s (attempt %d)…", serverURL, attempt), ui.StatusSeverityWarning)
+					case clienthost.ConnStateConnected:
+						st.setStatus("Reconnected to "+serverURL+" as "+playerName, ui.StatusSeveritySuccess)
+					default:
+						return
+					}
 					applyUI()
 				})
 			}
-			_, err := opts.StartJoin(context.Background(), serverURL, playerName, onStatus, onLost)
+			sess, err := opts.StartJoin(context.Background(), clienthost.JoinOptions{
+				ServerURL:          serverURL,
+				PlayerName:         playerName,
+				OnStatus:           onStatus,
+				OnBizhawkLost:      onLost,
+				OnDownloadProgress: onProgress,
+				OnConnState:        onConn,
+			})
 			fyne.Do(func() {
 				st.busy = false
+				joinSess = sess
+				st.joined = err == nil && sess != nil
+				refreshSaves(true)
 				if err != nil {
 					st.setStatus("Join failed: "+err.Error(), ui.StatusSeverityError)
 				} else {
@@ -304,6 +410,46 @@ func Run(opts Options) {
 		}()
 	}
 
+	sh.voteSkipBtn.OnTapped = func() {
+		sess := joinSess
+		if sess == nil {
+			return
+		}
+		go func() {
+			err := sess.VoteSkip()
+			fyne.Do(func() {
+				if err != nil {
+					st.setStatus("Vote skip failed: "+err.Error(), ui.StatusSeverityError)
+				} else {
+					st.setStatus("Voted to skip the current game", ui.StatusSeverityInfo)
+				}
+				applyUI()
+			})
+		}()
+	}
+
+	sh.resyncBtn.OnTapped = func() {
+		sess := joinSess
+		if sess == nil || st.resyncing {
+			return
+		}
+		st.resyncing = true
+		st.setStatus("Resyncing files: clearing cached ROMs and downloading again...", ui.StatusSeverityInfo)
+		applyUI()
+		go func() {
+			err := sess.ResyncFiles(context.Background())
+			fyne.Do(func() {
+				st.resyncing = false
+				if err != nil {
+					st.setStatus("Resync failed: "+err.Error(), ui.StatusSeverityError)
+				} else {
+					st.setStatus("Resync complete: all session files downloaded", ui.StatusSeveritySuccess)
+				}
+				applyUI()
+			})
+		}()
+	}
+
 	runUpdateCheck := func() {
 		if opts.CheckUpdates == nil {
 			return
@@ -343,14 +489,23 @@ func Run(opts Options) {
 	sh.openDataBtn.Importance = widget.LowImportance
 
 	applySettings()
+	sh.themeSelect.OnChanged = func(label string) {
+		mode := strings.ToLower(label)
+		a.Settings().SetTheme(ui.NewThemeForMode(mode))
+		if opts.SaveTheme != nil {
+			opts.SaveTheme(mode)
+		}
+	}
 	if opts.VersionLabel != nil {
 		sh.versionLabel.SetText(opts.VersionLabel())
 	}
 	st.depsChecking = true
 	refreshDeps()
+	refreshSaves(true)
 	applyUI()
 
 	w.SetOnClosed(func() {
+		close(stopSavesPoll)
 		opts.StopJoin()
 		if serverStop != nil {
 			serverStop()
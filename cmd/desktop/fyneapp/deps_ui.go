@@ -68,5 +68,6 @@ func updateDepsPanelVisibility(w *shellWidgets, snap clienthost.DependenciesSnap
 	if depsPanelNeeded(snap, depsChecking) {
 		sections = append(sections, w.depsPanel.Root)
 	}
+	sections = append(sections, w.savesPanel.Root)
 	ui.SetPageSections(w.pageBox, sections...)
 }
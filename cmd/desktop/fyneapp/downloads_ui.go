@@ -0,0 +1,55 @@
+package fyneapp
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/michael4d45/bizshuffle/clienthost"
+	"github.com/michael4d45/bizshuffle/cmd/desktop/fyneapp/ui"
+)
+
+// downloadsDone reports whether every download in the batch has finished.
+func downloadsDone(progress []clienthost.DownloadProgress) bool {
+	for _, p := range progress {
+		if !p.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// renderDownloads shows an overall bar plus one pacman-style row per file;
+// an empty batch hides the view.
+func renderDownloads(w *shellWidgets, progress []clienthost.DownloadProgress) {
+	if len(progress) == 0 {
+		w.downloadsBox.Objects = nil
+		w.downloadsBox.Hide()
+		return
+	}
+	var downloaded, total int64
+	finished := 0
+	for _, p := range progress {
+		downloaded += p.Downloaded
+		total += p.Total
+		if p.Done {
+			finished++
+		}
+	}
+	bar := widget.NewProgressBar()
+	if total > 0 {
+		bar.SetValue(float64(downloaded) / float64(total))
+	}
+	title := fmt.Sprintf("Downloading ROMs (%d of %d done)", finished, len(progress))
+	if finished == len(progress) {
+		title = fmt.Sprintf("Downloads complete (%d files)", len(progress))
+	}
+	objects := []fyne.CanvasObject{ui.NewMuted(title), bar}
+	for _, p := range progress {
+		objects = append(objects, ui.NewInspectorRow(p.File, p.Detail(), nil))
+	}
+	w.downloadsBox.Objects = objects
+	w.downloadsBox.Show()
+	w.downloadsBox.Refresh()
+}
@@ -0,0 +1,53 @@
+package fyneapp
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/michael4d45/bizshuffle/clienthost"
+	"github.com/michael4d45/bizshuffle/cmd/desktop/fyneapp/ui"
+)
+
+// savesSignature identifies a listing so polling only re-renders on change.
+func savesSignature(saves []clienthost.LocalSave, err error) string {
+	if err != nil {
+		return "error:" + err.Error()
+	}
+	var b strings.Builder
+	for _, s := range saves {
+		fmt.Fprintf(&b, "%s|%d|%d;", s.InstanceID, s.Size, s.ModTime.UnixNano())
+	}
+	return b.String()
+}
+
+func renderSavesPanel(
+	w *shellWidgets,
+	saves []clienthost.LocalSave,
+	err error,
+	canLoad bool,
+	onLoad func(instanceID string),
+) {
+	if err != nil {
+		w.savesPanel.SetBody(ui.NewMuted("Could not read saves folder: " + err.Error()))
+		return
+	}
+	if len(saves) == 0 {
+		w.savesPanel.SetBody(ui.NewMuted("No saves downloaded yet."))
+		return
+	}
+	var rows []fyne.CanvasObject
+	for _, save := range saves {
+		id := save.InstanceID
+		load := widget.NewButton("Load into BizHawk", func() { onLoad(id) })
+		load.Importance = widget.LowImportance
+		if !canLoad {
+			load.Disable()
+		}
+		rows = append(rows, ui.NewInspectorRow(id, save.Detail(), load))
+	}
+	w.savesPanel.SetBody(container.NewVBox(rows...))
+}
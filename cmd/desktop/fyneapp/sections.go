@@ -1,12 +1,28 @@
 package fyneapp
 
 import (
+	"strings"
+
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
 
 	"github.com/michael4d45/bizshuffle/cmd/desktop/fyneapp/ui"
 )
 
+// themeOptions are the theme picker labels; lowercased they are the
+// clienthost.Theme* values saved in config.json.
+var themeOptions = []string{"System", "Light", "Dark"}
+
+// themeOptionLabel returns the picker label for a saved theme value.
+func themeOptionLabel(theme string) string {
+	for _, label := range themeOptions {
+		if strings.EqualFold(label, theme) {
+			return label
+		}
+	}
+	return themeOptions[0]
+}
+
 // buildShell constructs the desktop layout and returns widget handles.
 func buildShell() *shellWidgets {
 	w := &shellWidgets{
@@ -19,10 +35,13 @@ func buildShell() *shellWidgets {
 		hostBtn:         widget.NewButton("Host (server + admin)", nil),
 		stopHostBtn:     widget.NewButton("Stop host", nil),
 		joinBtn:         widget.NewButton("Join", nil),
+		voteSkipBtn:     widget.NewButton("Vote skip", nil),
+		resyncBtn:       widget.NewButton("Resync files", nil),
 		versionLabel:    widget.NewLabel(""),
 		updateBtn:       widget.NewButton("Download update", nil),
 		checkUpdatesBtn: widget.NewButton("Check updates", nil),
 		openDataBtn:     widget.NewButton("Open data folder", nil),
+		themeSelect:     widget.NewSelect(themeOptions, nil),
 	}
 
 	w.serverURLEntry.SetPlaceHolder("http://127.0.0.1:8080")
@@ -30,9 +49,12 @@ func buildShell() *shellWidgets {
 	w.stopHostBtn.Importance = widget.LowImportance
 	w.stopHostBtn.Hide()
 	w.joinBtn.Importance = widget.HighImportance
+	w.voteSkipBtn.Hide()
+	w.resyncBtn.Hide()
 	w.hostBtn.Importance = widget.HighImportance
 	w.updateBtn.Importance = widget.HighImportance
 	w.updateBtn.Hide()
+	w.themeSelect.PlaceHolder = "Theme"
 
 	hostForm := widget.NewForm(
 		widget.NewFormItem("Bind host", w.hostEntry),
@@ -56,20 +78,24 @@ func buildShell() *shellWidgets {
 		"Connect as a player with BizHawk",
 		nil,
 		joinForm,
-		ui.NewActionBar(w.joinBtn),
+		ui.NewActionBar(w.resyncBtn, w.voteSkipBtn, w.joinBtn),
 	)
 	w.joinPanelRoot = joinPanel.Root
 	w.hostJoinRow = container.NewGridWithColumns(2, w.hostPanelRoot, w.joinPanelRoot)
 
 	w.depsPanel = ui.NewSectionPanel("Dependencies", "Required before joining", nil, nil, nil)
+	w.savesPanel = ui.NewSectionPanel("Local saves", "Instance saves downloaded to this machine", nil, nil, nil)
 
 	w.pageBox = container.NewVBox()
 	ui.SetPageSections(w.pageBox, w.hostJoinRow)
 
 	header := ui.NewHeaderSurface("BizShuffle", nil)
 	footerLeft := container.NewHBox(w.versionLabel, w.checkUpdatesBtn, w.updateBtn)
-	footer := ui.NewFooterRow(footerLeft, w.openDataBtn)
+	footer := ui.NewFooterRow(footerLeft, container.NewHBox(w.themeSelect, w.openDataBtn))
+	w.downloadsBox = container.NewVBox()
+	w.downloadsBox.Hide()
 	bottom := container.NewVBox(
+		container.NewPadded(w.downloadsBox),
 		container.NewPadded(w.status),
 		footer,
 	)
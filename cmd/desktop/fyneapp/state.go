@@ -13,6 +13,8 @@ type shellState struct {
 	installing   bool
 	depsChecking bool
 	hosting      bool
+	joined       bool
+	resyncing    bool
 
 	statusText string
 	statusSev  ui.StatusSeverity
@@ -40,6 +42,18 @@ func (s *shellState) apply(w *shellWidgets, depsBlocked func() bool) {
 	} else {
 		w.hostBtn.Enable()
 	}
+	if s.joined {
+		w.voteSkipBtn.Show()
+		w.resyncBtn.Show()
+	} else {
+		w.voteSkipBtn.Hide()
+		w.resyncBtn.Hide()
+	}
+	if s.resyncing {
+		w.resyncBtn.Disable()
+	} else {
+		w.resyncBtn.Enable()
+	}
 	if s.hosting {
 		w.stopHostBtn.Show()
 	} else {
@@ -51,7 +65,8 @@ func (s *shellState) apply(w *shellWidgets, depsBlocked func() bool) {
 type shellWidgets struct {
 	root fyne.CanvasObject
 
-	status *widget.Label
+	status       *widget.Label
+	downloadsBox *fyne.Container
 
 	hostEntry       *widget.Entry
 	portEntry       *widget.Entry
@@ -60,14 +75,18 @@ type shellWidgets struct {
 	hostBtn         *widget.Button
 	stopHostBtn     *widget.Button
 	joinBtn         *widget.Button
+	voteSkipBtn     *widget.Button
+	resyncBtn       *widget.Button
 	versionLabel    *widget.Label
 	updateBtn       *widget.Button
 	checkUpdatesBtn *widget.Button
 	openDataBtn     *widget.Button
+	themeSelect     *widget.Select
 
 	pageBox              *fyne.Container
 	hostJoinRow          fyne.CanvasObject
 	hostPanelRoot        fyne.CanvasObject
 	joinPanelRoot        fyne.CanvasObject
 	depsPanel *ui.SectionPanel
+	savesPanel *ui.SectionPanel
 }
@@ -12,11 +12,30 @@ func NewTheme() fyne.Theme {
 	return &bizTheme{base: theme.DefaultTheme()}
 }
 
+// NewThemeForMode returns the desktop theme following the OS setting for
+// "system" (or anything unknown), or pinned to "light" or "dark".
+func NewThemeForMode(mode string) fyne.Theme {
+	t := &bizTheme{base: theme.DefaultTheme()}
+	switch mode {
+	case "light":
+		v := theme.VariantLight
+		t.variant = &v
+	case "dark":
+		v := theme.VariantDark
+		t.variant = &v
+	}
+	return t
+}
+
 type bizTheme struct {
-	base fyne.Theme
+	base    fyne.Theme
+	variant *fyne.ThemeVariant // nil follows the OS
 }
 
 func (t *bizTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if t.variant != nil {
+		variant = *t.variant
+	}
 	switch name {
 	case theme.ColorNameBackground:
 		c := t.base.Color(name, variant)
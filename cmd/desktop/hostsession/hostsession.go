@@ -48,24 +48,27 @@ func NormalizeBindHost(raw string) (string, error) {
 }
 
 // LocalAdminURL returns a URL suitable for opening admin in a local browser.
-func LocalAdminURL(bindHost string, port int) string {
+// scheme is "http" or "https" (serverhost.Server.Scheme).
+func LocalAdminURL(scheme, bindHost string, port int) string {
 	switch bindHost {
 	case "0.0.0.0", "::", "[::]":
-		return fmt.Sprintf("http://127.0.0.1:%d/", port)
+		return fmt.Sprintf("%s://127.0.0.1:%d/", scheme, port)
 	default:
-		return fmt.Sprintf("http://%s:%d/", bindHost, port)
+		return fmt.Sprintf("%s://%s:%d/", scheme, bindHost, port)
 	}
 }
 
-// HostedURL returns the HTTP URL for this hosted session, if running.
+// HostedURL returns the base URL for this hosted session, if running; https
+// when the persisted TLS settings are on.
 func (s *Session) HostedURL() string {
 	if s == nil || s.server == nil {
 		return ""
 	}
+	scheme := s.server.Scheme()
 	if s.bindHost == "0.0.0.0" || s.bindHost == "::" || s.bindHost == "[::]" {
-		return fmt.Sprintf("http://127.0.0.1:%d", s.bindPort)
+		return fmt.Sprintf("%s://127.0.0.1:%d", scheme, s.bindPort)
 	}
-	return fmt.Sprintf("http://%s:%d", s.bindHost, s.bindPort)
+	return fmt.Sprintf("%s://%s:%d", scheme, s.bindHost, s.bindPort)
 }
 
 // IsRunning reports whether a host session is active.
@@ -129,10 +132,13 @@ func (s *Session) Start(ctx context.Context, bindHost string, hostPort int) (Sta
 		},
 	}
 	s.httpSrv = httpSrv
+	server := s.server
 	s.serveWG.Add(1)
 	go func() {
 		defer s.serveWG.Done()
-		err := httpSrv.Serve(ln)
+		// Serve honours cert_file/key_file and tls persisted in state.json,
+		// like bizshuffle-server.
+		err := server.Serve(httpSrv, ln)
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Printf("hostsession: serve ended: %v", err)
 		}
@@ -145,7 +151,7 @@ func (s *Session) Start(ctx context.Context, bindHost string, hostPort int) (Sta
 
 	s.bindHost = host
 	s.bindPort = actualPort
-	s.adminURL = LocalAdminURL(host, actualPort)
+	s.adminURL = LocalAdminURL(s.server.Scheme(), host, actualPort)
 
 	return StartResult{
 		AdminURL: s.adminURL,
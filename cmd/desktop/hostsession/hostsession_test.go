@@ -1,15 +1,18 @@
 package hostsession
 
 import (
+	"crypto/tls"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 )
 
 func TestLocalAdminURL(t *testing.T) {
-	if u := LocalAdminURL("0.0.0.0", 8080); u != "http://127.0.0.1:8080/" {
+	if u := LocalAdminURL("http", "0.0.0.0", 8080); u != "http://127.0.0.1:8080/" {
 		t.Fatalf("got %q", u)
 	}
-	if u := LocalAdminURL("127.0.0.1", 9090); u != "http://127.0.0.1:9090/" {
+	if u := LocalAdminURL("https", "127.0.0.1", 9090); u != "https://127.0.0.1:9090/" {
 		t.Fatalf("got %q", u)
 	}
 }
@@ -41,3 +44,34 @@ func TestStartPortZero(t *testing.T) {
 		t.Fatalf("port %d", res.HostPort)
 	}
 }
+
+func TestStartServesPersistedTLS(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("state.json", []byte(`{"tls": true, "players": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var sess Session
+	if _, err := sess.Start(t.Context(), "127.0.0.1", 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sess.Stop() })
+
+	url := sess.HostedURL()
+	if !strings.HasPrefix(url, "https://") {
+		t.Fatalf("hosted URL %q, want https", url)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	res, err := client.Get(url + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.TLS == nil {
+		t.Fatal("hosted session not served over TLS")
+	}
+}
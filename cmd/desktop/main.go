@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
@@ -17,6 +19,14 @@ import (
 )
 
 func main() {
+	oneshot := flag.Bool("oneshot", false, "without the GUI: join, wait for BizHawk to load the first swap, check it and exit 0 (match) or 1")
+	serverURL := flag.String("server", "", "server URL for -oneshot (default: the saved join setting)")
+	playerName := flag.String("name", "", "player name for -oneshot (default: the saved join setting)")
+	expectGame := flag.String("expect-game", "", "with -oneshot, the game the first swap must load")
+	expectInstance := flag.String("expect-instance", "", "with -oneshot, the instance the first swap must load")
+	timeout := flag.Duration("timeout", 0, "with -oneshot, fail if no swap loaded within this long (default 2m)")
+	flag.Parse()
+
 	dataDir, err := clienthost.DefaultDataDir()
 	if err != nil {
 		log.Fatal(err)
@@ -36,6 +46,37 @@ func main() {
 	}
 	defer obslog.Close()
 
+	if *oneshot {
+		settings := clienthost.LoadShellSettings(dataDir)
+		opts := clienthost.OneShotOptions{
+			ServerURL:      settings.ServerURL,
+			PlayerName:     settings.PlayerName,
+			ExpectGame:     *expectGame,
+			ExpectInstance: *expectInstance,
+			Timeout:        *timeout,
+			OnStatus:       func(msg string) { log.Printf("oneshot: %s", msg) },
+		}
+		if *serverURL != "" {
+			opts.ServerURL = *serverURL
+		}
+		if *playerName != "" {
+			opts.PlayerName = *playerName
+		}
+		code := 0
+		if err := clienthost.RunOneShot(context.Background(), dataDir, opts); err != nil {
+			log.Printf("oneshot: %v", err)
+			fmt.Fprintf(os.Stderr, "oneshot: %v\n", err)
+			code = 1
+		} else {
+			log.Printf("oneshot: first swap loaded as expected")
+		}
+		obslog.Close()
+		if logFile != nil {
+			_ = logFile.Close()
+		}
+		os.Exit(code)
+	}
+
 	var hostSess hostsession.Session
 	var joinSession *clienthost.JoinSession
 	var joinMu sync.Mutex
@@ -46,6 +87,7 @@ func main() {
 		SaveSettings: func(bindHost, serverURL, playerName string, hostPort int) {
 			clienthost.SaveShellSettingsForm(dataDir, bindHost, serverURL, playerName, hostPort)
 		},
+		SaveTheme: func(theme string) { clienthost.SaveShellTheme(dataDir, theme) },
 		VersionLabel: func() string {
 			return updates.VersionLabel(updates.State{Version: updates.Version})
 		},
@@ -86,7 +128,8 @@ func main() {
 		},
 		HostedURL:   func() string { return hostSess.HostedURL() },
 		OpenBrowser: openBrowser,
-		StartJoin: func(ctx context.Context, serverURL, playerName string, onStatus, onLost func(string)) (*clienthost.JoinSession, error) {
+		StartJoin: func(ctx context.Context, opts clienthost.JoinOptions) (*clienthost.JoinSession, error) {
+			serverURL, playerName := opts.ServerURL, opts.PlayerName
 			obslog.WarnJoinHostPortMismatch(serverURL, hostSess.HostedURL())
 			joinMu.Lock()
 			if joinSession != nil {
@@ -94,24 +137,20 @@ func main() {
 				joinSession = nil
 			}
 			joinMu.Unlock()
-			opts := clienthost.JoinOptions{
-				ServerURL:  serverURL,
-				PlayerName: playerName,
-				OnStatus:   onStatus,
-				OnBizhawkLost: func() {
-					if onLost != nil {
-						onLost("BizHawk closed — disconnected from server")
+			onLost := opts.OnBizhawkLost
+			opts.OnBizhawkLost = func() {
+				if onLost != nil {
+					onLost()
+				}
+				go func() {
+					joinMu.Lock()
+					sess := joinSession
+					joinSession = nil
+					joinMu.Unlock()
+					if sess != nil {
+						clienthost.StopJoinSession(sess)
 					}
-					go func() {
-						joinMu.Lock()
-						sess := joinSession
-						joinSession = nil
-						joinMu.Unlock()
-						if sess != nil {
-							clienthost.StopJoinSession(sess)
-						}
-					}()
-				},
+				}()
 			}
 			sess, err := clienthost.StartJoinSession(ctx, dataDir, opts)
 			if err != nil {
@@ -154,16 +193,28 @@ func main() {
 }
 
 // setupFileLogging sends standard library log output to dataDir/desktop.log.
+// The previous run's log is first zipped into dataDir/logs, keeping the newest
+// "log_archives" (config.json, default 10) archives. "log_format": "json"
+// writes one JSON object per line instead of plain text.
 // On Windows release builds use -H windowsgui so there is no console for stderr.
 func setupFileLogging(dataDir string) *os.File {
 	path := filepath.Join(dataDir, "desktop.log")
+	cfg, _ := clienthost.LoadConfig(dataDir)
+	rotateErr := obslog.RotateLog(path, filepath.Join(dataDir, "logs"), cfg.GetInt("log_archives", obslog.DefaultLogArchives))
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
 	if err != nil {
 		log.Printf("logging: cannot open %s: %v", path, err)
 		return nil
 	}
-	log.SetOutput(f)
+	if cfg["log_format"] == obslog.LogFormatJSON {
+		obslog.SetJSONLogging(f)
+	} else {
+		log.SetOutput(f)
+	}
 	log.Printf("logging to %s", path)
+	if rotateErr != nil {
+		log.Printf("logging: rotate previous log: %v", rotateErr)
+	}
 	return f
 }
 
@@ -4,6 +4,7 @@ go 1.26.0
 
 require (
 	github.com/michael4d45/bizshuffle/clienthost v0.0.0
+	github.com/michael4d45/bizshuffle/obslog v0.0.0
 	github.com/michael4d45/bizshuffle/serverhost v0.0.0
 )
 
@@ -19,7 +20,6 @@ require (
 	github.com/klauspost/compress v1.18.0 // indirect
 	github.com/lufia/plan9stats v0.0.0-20250317134145-8bc96cf8fc35 // indirect
 	github.com/michael4d45/bizshuffle/assets v0.0.0 // indirect
-	github.com/michael4d45/bizshuffle/obslog v0.0.0 // indirect
 	github.com/michael4d45/bizshuffle/protocol v0.0.0 // indirect
 	github.com/michael4d45/bizshuffle/savestate v0.0.0 // indirect
 	github.com/otiai10/gosseract v2.2.1+incompatible // indirect
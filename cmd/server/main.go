@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/michael4d45/bizshuffle/clienthost"
+	"github.com/michael4d45/bizshuffle/obslog"
 	"github.com/michael4d45/bizshuffle/serverhost"
 )
 
@@ -21,8 +27,23 @@ func main() {
 	dataDir := flag.String("data-dir", defaultDir, "server data directory")
 	host := flag.String("host", "0.0.0.0", "host to bind")
 	port := flag.Int("port", 8080, "port to bind")
+	adminToken := flag.String("admin-token", "", "require this token for admin routes and the admin websocket (persisted; \"-\" clears it)")
+	adminCmd := flag.String("admin-cmd", "", "semicolon-separated admin commands to run once listening, e.g. \"mode save; start\" (JSON results on stdout)")
+	adminRepl := flag.Bool("admin-repl", false, "read admin commands from stdin, one per line, and print JSON results (\"help\" lists them)")
+	certFile := flag.String("cert-file", "", "PEM certificate for HTTPS/wss; requires -key-file (persisted; \"-\" clears both)")
+	keyFile := flag.String("key-file", "", "PEM private key for -cert-file (persisted)")
+	useTLS := flag.Bool("tls", false, "serve HTTPS; without -cert-file a self-signed certificate is generated under ./certs (persisted; -tls=false turns it off)")
+	logFormat := flag.String("log-format", "text", "log output format: text, or json for one JSON object per line")
 	flag.Parse()
 
+	switch *logFormat {
+	case "text":
+	case obslog.LogFormatJSON:
+		obslog.SetJSONLogging(os.Stderr)
+	default:
+		log.Fatalf("invalid -log-format %q (want text or json)", *logFormat)
+	}
+
 	if err := os.MkdirAll(*dataDir, 0o755); err != nil {
 		log.Fatal(err)
 	}
@@ -45,21 +66,75 @@ func main() {
 		}
 	}
 	s.SetPort(chosenPort)
+	switch *adminToken {
+	case "":
+	case "-":
+		s.SetAdminToken("")
+	default:
+		s.SetAdminToken(*adminToken)
+	}
+
+	switch {
+	case *certFile == "-":
+		s.SetTLSFiles("", "")
+	case *certFile != "" || *keyFile != "":
+		s.SetTLSFiles(*certFile, *keyFile)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "tls" {
+			s.SetTLS(*useTLS)
+		}
+	})
 
 	addr := fmt.Sprintf("%s:%d", chosenHost, chosenPort)
 	mux := http.NewServeMux()
 	s.RegisterRoutes(mux)
 
 	srv := &http.Server{Addr: addr, Handler: mux}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
 	go func() {
-		log.Printf("BizShuffle server listening at http://%s", addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("BizShuffle server listening at %s://%s", s.Scheme(), addr)
+		if err := s.Serve(srv, ln); err != nil && err != http.ErrServerClosed {
 			log.Fatal(err)
 		}
 	}()
 
+	if *adminCmd != "" {
+		enc := json.NewEncoder(os.Stdout)
+		for _, line := range strings.Split(*adminCmd, ";") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			if err := enc.Encode(s.AdminCommand(line)); err != nil {
+				log.Printf("admin-cmd: %v", err)
+			}
+		}
+	}
+	if *adminRepl {
+		go func() {
+			if err := s.RunAdminCommands(os.Stdin, os.Stdout); err != nil {
+				log.Printf("admin-repl: %v", err)
+			}
+		}()
+	}
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-	<-sig
-	_ = srv.Close()
+	select {
+	case <-sig:
+	case <-s.ShutdownRequested():
+		log.Printf("shutdown requested via /api/shutdown")
+	}
+
+	s.BeginShutdown()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("http shutdown: %v", err)
+		_ = srv.Close()
+	}
+	s.Shutdown()
 }
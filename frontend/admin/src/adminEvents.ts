@@ -0,0 +1,102 @@
+import type {
+  Command,
+  CommandFailedEvent,
+  FileStateEvent,
+  PlayerEvent,
+  PluginStatusEvent,
+  ServerState,
+  SwapEnabledEvent,
+  SwapEvent,
+} from "./protocol-types.js";
+
+/**
+ * Applies a granular admin event to the cached state so the dashboard updates
+ * before the next state_update refetch. Returns null for other commands.
+ */
+export function applyAdminEvent(state: ServerState, cmd: Command): ServerState | null {
+  switch (cmd.cmd) {
+    case "player_connected":
+    case "player_disconnected": {
+      const e = cmd.payload as PlayerEvent;
+      const prev = state.players[e.player];
+      if (!prev) return null;
+      const connected = cmd.cmd === "player_connected";
+      const bizhawk_ready = connected ? (e.bizhawk_ready ?? false) : false;
+      return {
+        ...state,
+        players: { ...state.players, [e.player]: { ...prev, connected, bizhawk_ready } },
+      };
+    }
+    case "swap_performed": {
+      const e = cmd.payload as SwapEvent;
+      const prev = state.players[e.player];
+      if (!prev) return null;
+      return {
+        ...state,
+        players: {
+          ...state.players,
+          [e.player]: { ...prev, game: e.game, instance_id: e.instance_id },
+        },
+      };
+    }
+    case "file_state_changed": {
+      const e = cmd.payload as FileStateEvent;
+      return {
+        ...state,
+        game_instances: state.game_instances?.map((inst) =>
+          inst.id === e.instance_id
+            ? { ...inst, file_state: e.file_state, pending_player: e.pending_player }
+            : inst
+        ),
+      };
+    }
+    case "plugin_status_changed": {
+      const e = cmd.payload as PluginStatusEvent;
+      const prev = state.plugins?.[e.plugin];
+      if (!prev) return null;
+      return { ...state, plugins: { ...state.plugins, [e.plugin]: { ...prev, status: e.status } } };
+    }
+    case "swap_enabled_changed": {
+      const e = cmd.payload as SwapEnabledEvent;
+      return {
+        ...state,
+        swap_enabled: e.enabled,
+        next_swap_at: e.enabled ? state.next_swap_at : undefined,
+      };
+    }
+    case "command_failed": {
+      const { player, ...last_failure } = cmd.payload as CommandFailedEvent;
+      const prev = state.players[player];
+      if (!prev) return null;
+      return { ...state, players: { ...state.players, [player]: { ...prev, last_failure } } };
+    }
+    default:
+      return null;
+  }
+}
+
+/** Short log line for an admin event, or null if cmd is not one. */
+export function describeAdminEvent(cmd: Command): string | null {
+  switch (cmd.cmd) {
+    case "player_connected":
+      return `${(cmd.payload as PlayerEvent).player} connected`;
+    case "player_disconnected":
+      return `${(cmd.payload as PlayerEvent).player} disconnected`;
+    case "swap_performed": {
+      const e = cmd.payload as SwapEvent;
+      return `${e.player} swapped to ${e.game}${e.instance_id ? ` (${e.instance_id})` : ""}`;
+    }
+    case "plugin_status_changed": {
+      const e = cmd.payload as PluginStatusEvent;
+      return `plugin ${e.plugin} ${e.status}`;
+    }
+    case "swap_enabled_changed":
+      return (cmd.payload as SwapEnabledEvent).enabled ? "swaps enabled" : "swaps disabled";
+    case "command_failed": {
+      const e = cmd.payload as CommandFailedEvent;
+      return `${e.player} failed ${e.cmd || "command"}: ${e.reason}`;
+    }
+    default:
+      return null;
+  }
+}
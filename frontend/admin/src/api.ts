@@ -1,4 +1,11 @@
-import type { GameEntry, GameSwapInstance, Plugin, ServerState } from "./types.js";
+import type {
+  GameEntry,
+  GameSwapInstance,
+  InstanceMatch,
+  Plugin,
+  ScreenshotInfo,
+  ServerState,
+} from "./types.js";
 
 export type ShareUrls = {
   lan: string[];
@@ -6,6 +13,23 @@ export type ShareUrls = {
   local_only: boolean;
 };
 
+const adminTokenKey = "bizshuffle.admin_token";
+
+/** Admin token from ?token= (remembered in localStorage) or a previous visit. */
+export function adminToken(): string {
+  const fromUrl = new URLSearchParams(location.search).get("token");
+  if (fromUrl) {
+    localStorage.setItem(adminTokenKey, fromUrl);
+    return fromUrl;
+  }
+  return localStorage.getItem(adminTokenKey) ?? "";
+}
+
+function authHeaders(extra?: Record<string, string>): Record<string, string> {
+  const token = adminToken();
+  return token ? { ...extra, "X-Admin-Token": token } : { ...extra };
+}
+
 export async function fetchShareUrls(): Promise<ShareUrls> {
   return fetchJson<ShareUrls>("/api/share_urls");
 }
@@ -13,28 +37,54 @@ export async function fetchShareUrls(): Promise<ShareUrls> {
 export async function fetchState(): Promise<ServerState> {
   const res = await fetch("/state.json");
   if (!res.ok) throw new Error(`state.json ${res.status}`);
-  const body = (await res.json()) as { state: ServerState };
-  return body.state;
+  const body = (await res.json()) as {
+    state: ServerState;
+    rom_mismatches?: Record<string, string[]>;
+  };
+  return { ...body.state, rom_mismatches: body.rom_mismatches };
 }
 
 export async function post(path: string, body?: unknown): Promise<Response> {
   return fetch(path, {
     method: "POST",
-    headers: body !== undefined ? { "Content-Type": "application/json" } : undefined,
+    headers: authHeaders(body !== undefined ? { "Content-Type": "application/json" } : undefined),
     body: body !== undefined ? JSON.stringify(body) : undefined,
   });
 }
 
 export async function postForm(path: string, form: FormData): Promise<Response> {
-  return fetch(path, { method: "POST", body: form });
+  return fetch(path, { method: "POST", headers: authHeaders(), body: form });
 }
 
-async function del(path: string): Promise<Response> {
-  return fetch(path, { method: "DELETE" });
+export async function patch(path: string, body: unknown): Promise<Response> {
+  return fetch(path, {
+    method: "PATCH",
+    headers: authHeaders({ "Content-Type": "application/json" }),
+    body: JSON.stringify(body),
+  });
+}
+
+export async function del(path: string): Promise<Response> {
+  return fetch(path, { method: "DELETE", headers: authHeaders() });
+}
+
+/** Error body returned by the REST API: {"error": {"code", "message"}}. */
+export type ApiErrorBody = { error: { code: string; message: string } };
+
+/** Human-readable detail of a failed response; falls back to the raw body for non-API routes. */
+export async function errorDetail(res: Response): Promise<string> {
+  const text = (await res.text()).trim();
+  try {
+    const body = JSON.parse(text) as Partial<ApiErrorBody>;
+    if (body.error?.message) return body.error.message;
+  } catch {
+    // plain-text error
+  }
+  return text;
 }
 
 export async function fetchJson<T>(path: string): Promise<T> {
-  const res = await fetch(path);
+  const res = await fetch(path, { headers: authHeaders() });
   if (!res.ok) throw new Error(`${path} ${res.status}`);
   return (await res.json()) as T;
 }
@@ -64,6 +114,68 @@ export async function fetchFilesList(): Promise<string[]> {
     .filter(Boolean);
 }
 
+export async function searchInstances(q: string): Promise<InstanceMatch[]> {
+  const body = await fetchJson<{ instances: InstanceMatch[] }>(
+    `/api/instances/search?q=${encodeURIComponent(q)}`
+  );
+  return body.instances ?? [];
+}
+
+/** Asks the player's client for a screenshot and returns it once stored. */
+export async function requestScreenshot(player: string): Promise<ScreenshotInfo> {
+  const res = await post("/api/request_screenshot", { player });
+  if (!res.ok) throw new Error((await errorDetail(res)) || `screenshot ${res.status}`);
+  const body = (await res.json()) as { screenshot: ScreenshotInfo };
+  return body.screenshot;
+}
+
+/** Screenshot URL usable as an <img> src, which cannot carry the admin header. */
+export function screenshotSrc(shot: ScreenshotInfo): string {
+  const token = adminToken();
+  return token ? `${shot.url}?token=${encodeURIComponent(token)}` : shot.url;
+}
+
+/** Outcome of POST /api/players/{player}/swap. */
+export type PlayerSwapResult = {
+  swapped: boolean;
+  player: string;
+  game?: string;
+  instance_id?: string;
+  reason?: "no_available_games" | "race_won" | "saves_pending" | "swaps_disabled";
+  message?: string;
+};
+
+export async function swapPlayerNow(player: string): Promise<PlayerSwapResult> {
+  const res = await post(`/api/players/${encodeURIComponent(player)}/swap`);
+  if (!res.ok) throw new Error((await errorDetail(res)) || `swap ${res.status}`);
+  return (await res.json()) as PlayerSwapResult;
+}
+
+/** GET /api/players/pings: last RTT per connected player and those with no recent pong. */
+export type PlayerPings = { pings: Record<string, number>; stale: string[] };
+
+export async function fetchPlayerPings(): Promise<PlayerPings> {
+  return fetchJson<PlayerPings>("/api/players/pings");
+}
+
+/** Asks a player's client to wipe its ROM cache and download it again; resolves once done. */
+export async function resyncPlayerFiles(player: string): Promise<void> {
+  const res = await post(`/api/players/${encodeURIComponent(player)}/resync`);
+  if (!res.ok) throw new Error((await errorDetail(res)) || `resync ${res.status}`);
+}
+
+/** Downloads GET /api/session/export as session.json. */
+export async function downloadSessionExport(): Promise<void> {
+  const res = await fetch("/api/session/export", { headers: authHeaders() });
+  if (!res.ok) throw new Error(`/api/session/export ${res.status}`);
+  const url = URL.createObjectURL(await res.blob());
+  const a = document.createElement("a");
+  a.href = url;
+  a.download = "session.json";
+  a.click();
+  URL.revokeObjectURL(url);
+}
+
 export async function getPluginDetails(name: string): Promise<Plugin> {
   return fetchJson(`/api/plugins/${encodeURIComponent(name)}`);
 }
@@ -99,7 +211,9 @@ export async function removeCompletedInstance(player: string, instance: string):
   );
 }
 
+/** Body of POST /api/message; omitted styling uses the client defaults. */
 export type MessagePayload = {
+  player?: string;
   message: string;
   duration?: number;
   x?: number;
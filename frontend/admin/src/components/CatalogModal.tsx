@@ -16,10 +16,17 @@ export function CatalogModal({ open, mainGames, onClose, onSave }: Props) {
   const [entries, setEntries] = useState<GameEntry[]>([]);
   const [primary, setPrimary] = useState("");
   const [extras, setExtras] = useState<string[]>([]);
+  const [extraDirs, setExtraDirs] = useState<string[]>([]);
 
   useEffect(() => {
     if (open) {
-      setEntries(mainGames.map((g) => ({ ...g, extra_files: [...(g.extra_files ?? [])] })));
+      setEntries(
+        mainGames.map((g) => ({
+          ...g,
+          extra_files: [...(g.extra_files ?? [])],
+          extra_dirs: g.extra_dirs ? [...g.extra_dirs] : undefined,
+        }))
+      );
       void refresh();
     }
   }, [open, mainGames, refresh]);
@@ -32,15 +39,28 @@ export function CatalogModal({ open, mainGames, onClose, onSave }: Props) {
 
   const availablePrimary = files.filter((f) => !usedFiles.has(f) || f === primary);
   const availableExtras = files.filter((f) => f !== primary);
+  const availableDirs = Array.from(
+    new Set(
+      files.flatMap((f) => {
+        const parts = f.split("/").slice(0, -1);
+        return parts.map((_, i) => parts.slice(0, i + 1).join("/"));
+      })
+    )
+  ).sort();
 
   const addEntry = () => {
     if (!primary) return;
     setEntries((prev) => [
       ...prev,
-      { file: primary, extra_files: extras.length ? [...extras] : undefined },
+      {
+        file: primary,
+        extra_files: extras.length ? [...extras] : undefined,
+        extra_dirs: extraDirs.length ? [...extraDirs] : undefined,
+      },
     ]);
     setPrimary("");
     setExtras([]);
+    setExtraDirs([]);
   };
 
   return (
@@ -70,7 +90,7 @@ export function CatalogModal({ open, mainGames, onClose, onSave }: Props) {
       }
     >
       <p className="mb-3 text-xs text-slate-500">
-        Catalog entries and extra files clients download alongside the primary ROM.
+        Catalog entries and extra files or folders clients download alongside the primary ROM.
       </p>
       <div className="mb-4 max-h-48 space-y-2 overflow-y-auto scrollbar-thin">
         {entries.length === 0 ? (
@@ -85,6 +105,7 @@ export function CatalogModal({ open, mainGames, onClose, onSave }: Props) {
                 <p className="font-mono text-sm text-slate-200">{g.file}</p>
                 <p className="text-[11px] text-slate-500">
                   {g.extra_files?.length ? `extra: ${g.extra_files.join(", ")}` : "no extra files"}
+                  {g.extra_dirs?.length ? ` · folders: ${g.extra_dirs.join(", ")}` : ""}
                 </p>
               </div>
               <Button
@@ -124,6 +145,21 @@ export function CatalogModal({ open, mainGames, onClose, onSave }: Props) {
             ))}
           </select>
         </div>
+        <div>
+          <FieldLabel>Extra folders</FieldLabel>
+          <select
+            multiple
+            className="mt-1 max-h-24 w-full rounded-lg border border-slate-700 bg-slate-950/80 px-2 py-1 text-xs text-slate-100"
+            value={extraDirs}
+            onChange={(e) => setExtraDirs(Array.from(e.target.selectedOptions, (o) => o.value))}
+          >
+            {availableDirs.map((d) => (
+              <option key={d} value={d}>
+                {d}/
+              </option>
+            ))}
+          </select>
+        </div>
         <Button variant="secondary" disabled={!primary} onClick={addEntry}>
           Add entry
         </Button>
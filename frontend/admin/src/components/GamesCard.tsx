@@ -1,6 +1,6 @@
 import { useState } from "react";
 import type { AdminTrigger } from "../adminActions.js";
-import { postForm } from "../api.js";
+import { postForm, postGames } from "../api.js";
 import { countPlayersCompletedGame, countPlayersOnGame } from "../gameStats.js";
 import type { GameEntry, ServerState } from "../types.js";
 import { CatalogModal } from "./CatalogModal.js";
@@ -16,6 +16,7 @@ type Props = {
 
 export function GamesCard({ state, trigger, pushLog, refreshState }: Props) {
   const [romFile, setRomFile] = useState<File | null>(null);
+  const [extractZip, setExtractZip] = useState(false);
   const [catalogOpen, setCatalogOpen] = useState(false);
   const [expanded, setExpanded] = useState(false);
 
@@ -23,12 +24,31 @@ export function GamesCard({ state, trigger, pushLog, refreshState }: Props) {
     if (!romFile) return;
     const form = new FormData();
     form.append("file", romFile);
+    if (extractZip) form.append("extract", "true");
     const res = await postForm("/upload", form);
-    pushLog(res.ok ? "ROM uploaded" : `upload failed ${res.status}`);
+    if (res.ok && extractZip) {
+      const body = (await res.json()) as { files?: string[] };
+      pushLog(`Extracted ${(body.files ?? []).length} ROMs`);
+    } else {
+      pushLog(res.ok ? "ROM uploaded" : `upload failed ${res.status}`);
+    }
     setRomFile(null);
     await refreshState();
   };
 
+  const saveCatalog = async (main_games: GameEntry[]) => {
+    const res = await postGames({ main_games });
+    if (!res.ok) {
+      pushLog(`catalog save failed ${res.status}`);
+      return;
+    }
+    const body = (await res.json()) as { missing_files?: string[]; renamed?: Record<string, string> };
+    const missing = body.missing_files ?? [];
+    pushLog(missing.length ? `Catalog saved; missing under roms/: ${missing.join(", ")}` : "Catalog saved");
+    for (const [from, to] of Object.entries(body.renamed ?? {})) pushLog(`Migrated ${from} → ${to}`);
+    await refreshState();
+  };
+
   const toggleGame = async (file: string, enabled: boolean) => {
     const games = new Set(state?.games ?? []);
     if (enabled) games.add(file);
@@ -76,6 +96,14 @@ export function GamesCard({ state, trigger, pushLog, refreshState }: Props) {
               className="max-w-full flex-1 text-xs text-slate-400 file:mr-3 file:rounded-md file:border-0 file:bg-slate-700 file:px-2.5 file:py-1.5 file:text-xs file:font-medium file:text-slate-200 hover:file:bg-slate-600"
               onChange={(e) => setRomFile(e.target.files?.[0] ?? null)}
             />
+            <label className="flex items-center gap-1.5 text-xs text-slate-400">
+              <input
+                type="checkbox"
+                checked={extractZip}
+                onChange={(e) => setExtractZip(e.target.checked)}
+              />
+              Extract zip
+            </label>
             <Button variant="primary" onClick={() => void uploadRom()} disabled={!romFile}>
               Upload
             </Button>
@@ -155,7 +183,7 @@ export function GamesCard({ state, trigger, pushLog, refreshState }: Props) {
         open={catalogOpen}
         mainGames={[...(state?.main_games ?? [])]}
         onClose={() => setCatalogOpen(false)}
-        onSave={(main_games: GameEntry[]) => void trigger("/api/games", { main_games })}
+        onSave={(main_games: GameEntry[]) => void saveCatalog(main_games)}
       />
     </>
   );
@@ -0,0 +1,53 @@
+import { useEffect, useState } from "react";
+import { searchInstances } from "../api.js";
+import type { InstanceMatch } from "../types.js";
+import { Input } from "./ui.js";
+
+type Props = {
+  id: string;
+  value: string;
+  onChange: (instanceId: string) => void;
+};
+
+/** Text input that suggests instances matching the typed ID or game name. */
+export function InstanceSearchInput({ id, value, onChange }: Props) {
+  const [matches, setMatches] = useState<InstanceMatch[]>([]);
+
+  useEffect(() => {
+    let cancelled = false;
+    const timer = setTimeout(() => {
+      searchInstances(value)
+        .then((m) => {
+          if (!cancelled) setMatches(m);
+        })
+        .catch(() => {
+          if (!cancelled) setMatches([]);
+        });
+    }, 150);
+    return () => {
+      cancelled = true;
+      clearTimeout(timer);
+    };
+  }, [value]);
+
+  const listId = `${id}-matches`;
+  return (
+    <>
+      <Input
+        id={id}
+        list={listId}
+        value={value}
+        onChange={(e) => onChange(e.target.value)}
+        placeholder="Search instance or game"
+        autoComplete="off"
+      />
+      <datalist id={listId}>
+        {matches.map((m) => (
+          <option key={m.id} value={m.id}>
+            {m.game} · {m.assignee ? `held by ${m.assignee}` : "unassigned"} · {m.file_state}
+          </option>
+        ))}
+      </datalist>
+    </>
+  );
+}
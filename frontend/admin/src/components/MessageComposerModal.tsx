@@ -1,5 +1,5 @@
 import { useState } from "react";
-import { defaultMessageComposer, post, type MessagePayload } from "../api.js";
+import { defaultMessageComposer, errorDetail, post, type MessagePayload } from "../api.js";
 import { Modal } from "./Modal.js";
 import { Button, FieldLabel, Input } from "./ui.js";
 
@@ -26,9 +26,8 @@ export function MessageComposerModal({ open, target, onClose, onSent }: Props) {
       fg: draft.fg,
       bg: draft.bg,
     };
-    const path = target.type === "player" ? "/api/message_player" : "/api/message_all";
     const body = target.type === "player" ? { ...payload, player: target.player } : payload;
-    const res = await post(path, body);
+    const res = await post("/api/message", body);
     if (res.ok) {
       onSent(
         target.type === "player"
@@ -45,7 +44,8 @@ export function MessageComposerModal({ open, target, onClose, onSent }: Props) {
         bg: d.bg,
       }));
     } else {
-      onSent("message send failed");
+      const detail = await errorDetail(res);
+      onSent(detail ? `message send failed: ${detail}` : "message send failed");
     }
   };
 
@@ -1,19 +1,29 @@
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import type { AdminTrigger } from "../adminActions.js";
+import type { PlayerPings } from "../api.js";
 import {
   addCompletedGame,
   addCompletedInstance,
+  fetchPlayerPings,
   post,
   removeCompletedGame,
   removeCompletedInstance,
+  requestScreenshot,
+  resyncPlayerFiles,
+  screenshotSrc,
+  swapPlayerNow,
 } from "../api.js";
 import { playerCompletionCount } from "../gameStats.js";
-import { playerStatusBadge } from "../status.js";
-import type { Player, ServerState } from "../types.js";
+import { formatBytes, playerStatusBadge } from "../status.js";
+import { gameTimeLeftDisplay } from "../swapDisplay.js";
+import type { Player, ScreenshotInfo, ServerState } from "../types.js";
 import { useOptionalPlayerDrag } from "../PlayerDragContext.js";
+import { useNowMs } from "../useNowMs.js";
 import { ConfigModal } from "./ConfigModal.js";
 import { MessageComposerModal } from "./MessageComposerModal.js";
+import { Modal } from "./Modal.js";
 import { DraggablePlayerChip } from "./DraggablePlayerChip.js";
+import { InstanceSearchInput } from "./InstanceSearchInput.js";
 import { ActionRow, Badge, Button, Card, EmptyState, FieldLabel, Input, Select } from "./ui.js";
 
 type Props = {
@@ -37,6 +47,33 @@ export function PlayersCard({ state, trigger, pushLog, refreshState }: Props) {
     { type: "player"; player: string } | { type: "all" } | null
   >(null);
   const [configPlayer, setConfigPlayer] = useState<string | null>(null);
+  const [screenshot, setScreenshot] = useState<{ player: string; shot: ScreenshotInfo } | null>(
+    null
+  );
+
+  const [pings, setPings] = useState<PlayerPings | null>(null);
+  const now = useNowMs(
+    Object.values(state?.players ?? {}).some((p) => p.connected && p.game_deadline_at)
+  );
+
+  // Polling also tells the server to ping players every few seconds.
+  useEffect(() => {
+    let cancelled = false;
+    async function load() {
+      try {
+        const data = await fetchPlayerPings();
+        if (!cancelled) setPings(data);
+      } catch {
+        if (!cancelled) setPings(null);
+      }
+    }
+    void load();
+    const id = setInterval(() => void load(), 5_000);
+    return () => {
+      cancelled = true;
+      clearInterval(id);
+    };
+  }, []);
 
   const players = sortedPlayers(state);
   const isSync = state?.mode !== "save";
@@ -50,6 +87,39 @@ export function PlayersCard({ state, trigger, pushLog, refreshState }: Props) {
     await refreshState();
   };
 
+  const swapNow = async (name: string) => {
+    try {
+      const res = await swapPlayerNow(name);
+      pushLog(
+        res.swapped
+          ? `${name} swapped to ${res.instance_id ? `${res.instance_id} (${res.game})` : res.game}`
+          : `${name} not swapped: ${res.message ?? res.reason}`
+      );
+      await refreshState();
+    } catch (e) {
+      pushLog(`swap ${name} failed: ${e instanceof Error ? e.message : String(e)}`);
+    }
+  };
+
+  const takeScreenshot = async (name: string) => {
+    pushLog(`requesting screenshot from ${name}`);
+    try {
+      setScreenshot({ player: name, shot: await requestScreenshot(name) });
+    } catch (e) {
+      pushLog(`screenshot from ${name} failed: ${e instanceof Error ? e.message : String(e)}`);
+    }
+  };
+
+  const resyncFiles = async (name: string) => {
+    pushLog(`resyncing files for ${name}`);
+    try {
+      await resyncPlayerFiles(name);
+      pushLog(`${name} files resynced`);
+    } catch (e) {
+      pushLog(`resync ${name} failed: ${e instanceof Error ? e.message : String(e)}`);
+    }
+  };
+
   const openConfig = async (name: string) => {
     setConfigPlayer(name);
     await trigger("/api/check_player_config", { player: name });
@@ -65,9 +135,15 @@ export function PlayersCard({ state, trigger, pushLog, refreshState }: Props) {
             <Button variant="ghost" onClick={() => setMessageTarget({ type: "all" })}>
               Message all
             </Button>
+            <Button variant="ghost" onClick={() => void trigger("/api/players/pause_all")}>
+              Pause all
+            </Button>
+            <Button variant="ghost" onClick={() => void trigger("/api/players/resume_all")}>
+              Resume all
+            </Button>
             <Button
               variant="ghost"
-              onClick={() => void trigger("/api/players/remove_all_completions")}
+              onClick={() => void trigger("/api/players/reset_completions")}
             >
               Clear completions
             </Button>
@@ -128,15 +204,58 @@ export function PlayersCard({ state, trigger, pushLog, refreshState }: Props) {
                         {completions > 0 ? (
                           <Badge variant="neutral">{completions} completed</Badge>
                         ) : null}
-                        {p.ping_ms != null ? (
+                        {p.team ? <Badge variant="neutral">Team {p.team}</Badge> : null}
+                        {p.prestage === "pending" ? (
+                          <Badge variant="warn">Staging files…</Badge>
+                        ) : p.prestage === "failed" ? (
+                          <span title={p.prestage_errors?.join("\n")}>
+                            <Badge variant="err">Staging failed</Badge>
+                          </span>
+                        ) : p.prestage === "ready" ? (
+                          <Badge variant="ok">Staged</Badge>
+                        ) : null}
+                        {state?.rom_mismatches?.[name]?.length ? (
+                          <span
+                            title={`ROM differs from the server's copy:\n${state.rom_mismatches[name].join("\n")}`}
+                          >
+                            <Badge variant="err">ROM mismatch</Badge>
+                          </span>
+                        ) : null}
+                        {p.status?.pending_file ? (
+                          <Badge variant="warn">Downloading {p.status.pending_file}</Badge>
+                        ) : null}
+                        {p.status?.saves_free_bytes != null ? (
+                          <span
+                            className="font-mono text-[11px] text-slate-400"
+                            title="Free disk space for saves (last heartbeat)"
+                          >
+                            {formatBytes(p.status.saves_free_bytes)} free
+                          </span>
+                        ) : null}
+                        {p.last_failure ? (
+                          <span
+                            title={`${p.last_failure.reason} (${new Date(p.last_failure.at * 1000).toLocaleTimeString()})`}
+                          >
+                            <Badge variant="warn">{p.last_failure.cmd || "command"} failed</Badge>
+                          </span>
+                        ) : null}
+                        {pings?.stale.includes(name) ? (
+                          <span
+                            className="font-mono text-[11px] text-amber-400"
+                            title="No pong from this player recently"
+                          >
+                            {pings.pings[name] ?? p.ping_ms ?? "?"}ms stale
+                          </span>
+                        ) : (pings?.pings[name] ?? p.ping_ms) != null ? (
                           <span className="font-mono text-[11px] text-slate-500">
-                            {p.ping_ms}ms
+                            {pings?.pings[name] ?? p.ping_ms}ms
                           </span>
                         ) : null}
                       </div>
                       <p className="mt-1 text-xs text-slate-500">
                         {p.game ?? "—"}
                         {p.instance_id ? ` · ${p.instance_id}` : ""}
+                        {p.game_deadline_at ? ` · ${gameTimeLeftDisplay(p, now)} left` : ""}
                       </p>
                       <Button
                         variant="ghost"
@@ -253,25 +372,25 @@ export function PlayersCard({ state, trigger, pushLog, refreshState }: Props) {
                       ) : null}
                     </div>
                     <ActionRow className="lg:flex-col lg:items-stretch">
-                      <Select
-                        value={selections[name] ?? ""}
-                        onChange={(e) => setSelections((s) => ({ ...s, [name]: e.target.value }))}
-                      >
-                        <option value="">
-                          {isSync ? "— select game —" : "— select instance —"}
-                        </option>
-                        {isSync
-                          ? (state?.games ?? []).map((g) => (
-                              <option key={g} value={g}>
-                                {g}
-                              </option>
-                            ))
-                          : (state?.game_instances ?? []).map((i) => (
-                              <option key={i.id} value={i.id}>
-                                {i.id} — {i.game}
-                              </option>
-                            ))}
-                      </Select>
+                      {isSync ? (
+                        <Select
+                          value={selections[name] ?? ""}
+                          onChange={(e) => setSelections((s) => ({ ...s, [name]: e.target.value }))}
+                        >
+                          <option value="">— select game —</option>
+                          {(state?.games ?? []).map((g) => (
+                            <option key={g} value={g}>
+                              {g}
+                            </option>
+                          ))}
+                        </Select>
+                      ) : (
+                        <InstanceSearchInput
+                          id={`swap-instance-${name}`}
+                          value={selections[name] ?? ""}
+                          onChange={(v) => setSelections((s) => ({ ...s, [name]: v }))}
+                        />
+                      )}
                       <Button
                         variant="secondary"
                         disabled={!selections[name]}
@@ -281,16 +400,62 @@ export function PlayersCard({ state, trigger, pushLog, refreshState }: Props) {
                       </Button>
                       <Button
                         variant="ghost"
-                        onClick={() => void trigger("/api/random_swap", { player: name })}
+                        onClick={() => void swapNow(name)}
                       >
-                        Random
+                        Swap now
                       </Button>
+                      {p.team ? (
+                        <Button
+                          variant="ghost"
+                          onClick={() => void trigger("/api/swap/group", { group: p.team })}
+                        >
+                          Swap team
+                        </Button>
+                      ) : null}
+                      <Button
+                        variant="ghost"
+                        onClick={() => {
+                          const team = prompt(`Team for ${name} (empty for none)`, p.team ?? "");
+                          if (team !== null) {
+                            void trigger(`/api/players/${encodeURIComponent(name)}/team`, { team });
+                          }
+                        }}
+                      >
+                        Team
+                      </Button>
+                      {!isSync && p.instance_id ? (
+                        <Button
+                          variant="ghost"
+                          onClick={() =>
+                            void trigger("/api/request_save", {
+                              player: name,
+                              instance_id: p.instance_id,
+                            })
+                          }
+                        >
+                          Save
+                        </Button>
+                      ) : null}
                       <Button
                         variant="ghost"
                         onClick={() => setMessageTarget({ type: "player", player: name })}
                       >
                         Message
                       </Button>
+                      <Button
+                        variant="ghost"
+                        disabled={!p.connected}
+                        onClick={() => void takeScreenshot(name)}
+                      >
+                        Screenshot
+                      </Button>
+                      <Button
+                        variant="ghost"
+                        disabled={!p.connected}
+                        onClick={() => void resyncFiles(name)}
+                      >
+                        Resync files
+                      </Button>
                       <Button
                         variant="ghost"
                         onClick={() => void trigger("/api/fullscreen_toggle", { player: name })}
@@ -306,6 +471,14 @@ export function PlayersCard({ state, trigger, pushLog, refreshState }: Props) {
                       >
                         Remove
                       </Button>
+                      <Button
+                        variant="danger"
+                        onClick={() =>
+                          void trigger("/api/remove_player", { player: name, ban: true })
+                        }
+                      >
+                        Remove &amp; ban
+                      </Button>
                     </ActionRow>
                   </div>
                 </li>
@@ -313,6 +486,22 @@ export function PlayersCard({ state, trigger, pushLog, refreshState }: Props) {
             })}
           </ul>
         )}
+
+        {state?.banned_players?.length ? (
+          <div className="mt-3 flex flex-wrap items-center gap-2 text-xs">
+            <span className="text-slate-500">Banned</span>
+            {state.banned_players.map((name) => (
+              <Button
+                key={name}
+                variant="ghost"
+                title="Unban"
+                onClick={() => void trigger(`/api/players/${encodeURIComponent(name)}/unban`)}
+              >
+                {name} ✕
+              </Button>
+            ))}
+          </div>
+        ) : null}
       </Card>
 
       <MessageComposerModal
@@ -321,6 +510,22 @@ export function PlayersCard({ state, trigger, pushLog, refreshState }: Props) {
         onClose={() => setMessageTarget(null)}
         onSent={(msg) => pushLog(msg)}
       />
+      <Modal
+        open={screenshot !== null}
+        title={screenshot ? `Screenshot: ${screenshot.player}` : "Screenshot"}
+        onClose={() => setScreenshot(null)}
+        wide
+      >
+        {screenshot ? (
+          <a href={screenshotSrc(screenshot.shot)} target="_blank" rel="noreferrer">
+            <img
+              src={screenshotSrc(screenshot.shot)}
+              alt={`Screenshot from ${screenshot.player}`}
+              className="w-full rounded-lg [image-rendering:pixelated]"
+            />
+          </a>
+        ) : null}
+      </Modal>
       <ConfigModal
         open={configPlayer !== null}
         playerName={configPlayer ?? ""}
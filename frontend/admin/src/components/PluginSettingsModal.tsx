@@ -1,5 +1,5 @@
 import { useEffect, useRef, useState } from "react";
-import { getPluginDetails, getPluginSettings, postPluginSettings } from "../api.js";
+import { errorDetail, getPluginDetails, getPluginSettings, postPluginSettings } from "../api.js";
 import type { Plugin } from "../types.js";
 import { Modal } from "./Modal.js";
 import { PluginSettingField } from "./PluginSettingField.js";
@@ -82,7 +82,7 @@ export function PluginSettingsModal({
     try {
       const res = await postPluginSettings(pluginName, settings);
       if (!res.ok) {
-        const detail = (await res.text()).trim();
+        const detail = await errorDetail(res);
         onLog(
           detail ? `failed to save plugin settings: ${detail}` : "failed to save plugin settings"
         );
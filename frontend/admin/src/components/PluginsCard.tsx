@@ -1,6 +1,6 @@
 import { useEffect, useRef, useState } from "react";
 import type { AdminTrigger } from "../adminActions.js";
-import { fetchJson } from "../api.js";
+import { del, fetchJson } from "../api.js";
 import type { Plugin } from "../types.js";
 import { PluginSettingsModal } from "./PluginSettingsModal.js";
 import { ActionRow, Badge, Button, Card, EmptyState } from "./ui.js";
@@ -108,14 +108,20 @@ export function PluginsCard({ trigger, pushLog }: Props) {
                     <p className="mt-1 font-mono text-[10px] text-slate-600">
                       v{p.version} · {p.author} · BizHawk {p.bizhawk_version}
                     </p>
+                    {p.last_error ? (
+                      <p className="mt-1 break-words font-mono text-[11px] text-rose-400">
+                        {p.last_error_player ? `${p.last_error_player}: ` : ""}
+                        {p.last_error}
+                      </p>
+                    ) : null}
                   </div>
                   <ActionRow>
                     <Button
                       variant={p.status === "enabled" ? "ghost" : "primary"}
                       onClick={() =>
-                        void trigger(`/api/plugins/${encodeURIComponent(name)}/settings`, {
-                          status: p.status === "enabled" ? "disabled" : "enabled",
-                        }).then(() => loadPlugins())
+                        void trigger(
+                          `/api/plugins/${encodeURIComponent(name)}/${p.status === "enabled" ? "disable" : "enable"}`
+                        ).then(() => loadPlugins())
                       }
                     >
                       {p.status === "enabled" ? "Disable" : "Enable"}
@@ -134,9 +140,7 @@ export function PluginsCard({ trigger, pushLog }: Props) {
                     <Button
                       variant="danger"
                       onClick={async () => {
-                        await fetch(`/api/plugins/${encodeURIComponent(name)}`, {
-                          method: "DELETE",
-                        });
+                        await del(`/api/plugins/${encodeURIComponent(name)}`);
                         await loadPlugins();
                       }}
                     >
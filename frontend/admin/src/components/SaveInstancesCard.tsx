@@ -1,6 +1,6 @@
 import { useState } from "react";
 import type { AdminTrigger } from "../adminActions.js";
-import { post } from "../api.js";
+import { errorDetail, patch, post } from "../api.js";
 import { useGamesPersist } from "../hooks/useGamesPersist.js";
 import { usePlayerDrag } from "../PlayerDragContext.js";
 import {
@@ -58,6 +58,16 @@ export function SaveInstancesCard({
     }
   };
 
+  const setLocked = async (id: string, locked: boolean) => {
+    const res = await patch(`/api/instances/${encodeURIComponent(id)}`, { locked });
+    if (!res.ok) {
+      pushLog(`Lock ${id} failed: ${await errorDetail(res)}`);
+      return;
+    }
+    pushLog(`${locked ? "Locked" : "Unlocked"} ${id}`);
+    await refreshState();
+  };
+
   const removeInstance = async (id: string) => {
     const next = instances.filter((i) => i.id !== id);
     if (await persistGames({ game_instances: next, main_games: [...(state?.main_games ?? [])] })) {
@@ -122,6 +132,7 @@ export function SaveInstancesCard({
                         {assigned ? "assigned" : "unassigned"}
                       </span>
                       {completed > 0 ? <Badge variant="warn">{completed} completed</Badge> : null}
+                      {inst.locked ? <Badge variant="info">locked</Badge> : null}
                     </div>
                     {assigned ? (
                       <div className="mt-2">
@@ -140,6 +151,13 @@ export function SaveInstancesCard({
                     >
                       Mark done all
                     </Button>
+                    <Button
+                      variant={inst.locked ? "primary" : "ghost"}
+                      title="Locked instances are never dealt by automatic swaps"
+                      onClick={() => void setLocked(inst.id, !inst.locked)}
+                    >
+                      {inst.locked ? "Unlock" : "Lock"}
+                    </Button>
                     <Button variant="danger" onClick={() => void removeInstance(inst.id)}>
                       Remove
                     </Button>
@@ -1,5 +1,6 @@
 import { useEffect, useState } from "react";
 import type { AdminTrigger } from "../adminActions.js";
+import { downloadSessionExport } from "../api.js";
 import { intervalError, intervalValid } from "../intervalUtils.js";
 import { SESSION_BUTTONS } from "../sessionButtons.js";
 import { intervalDisplay, nextSwapDisplay } from "../swapDisplay.js";
@@ -18,16 +19,41 @@ const PRIMARY_PATHS = new Set(["/api/start", "/api/pause", "/api/do_swap"]);
 export function SessionCard({ state, trigger, nowMs }: Props) {
   const [intervalMin, setIntervalMin] = useState(5);
   const [intervalMax, setIntervalMax] = useState(10);
+  const [reassignOnImport, setReassignOnImport] = useState(false);
+  const [maxPlayers, setMaxPlayers] = useState(0);
+  const [rejectWhenFull, setRejectWhenFull] = useState(false);
+  const [previewSecs, setPreviewSecs] = useState(0);
 
   useEffect(() => {
     if (state?.min_interval_secs) setIntervalMin(state.min_interval_secs);
     if (state?.max_interval_secs) setIntervalMax(state.max_interval_secs);
   }, [state?.min_interval_secs, state?.max_interval_secs]);
 
+  useEffect(() => {
+    setMaxPlayers(state?.max_players ?? 0);
+    setRejectWhenFull(Boolean(state?.reject_when_full));
+  }, [state?.max_players, state?.reject_when_full]);
+
+  useEffect(() => {
+    setPreviewSecs(state?.swap_preview_secs ?? 0);
+  }, [state?.swap_preview_secs]);
+
+  const importSession = async (file: File | undefined) => {
+    if (!file) return;
+    const preset: unknown = JSON.parse(await file.text());
+    const path = reassignOnImport ? "/api/session/import?reassign=true" : "/api/session/import";
+    await trigger(path, preset);
+  };
+
   const draft = { min: intervalMin, max: intervalMax };
   const err = intervalError(draft);
   const valid = intervalValid(draft);
 
+  const inLobby = Boolean(state?.lobby_enabled && !state.running && !state.paused);
+  const lobbyRoster = Object.values(state?.players ?? {})
+    .filter((p) => p.connected)
+    .sort((a, b) => a.name.localeCompare(b.name));
+
   const primary = SESSION_BUTTONS.filter((b) => PRIMARY_PATHS.has(b.path));
   const toggles = SESSION_BUTTONS.filter((b) => "toggle" in b);
   const other = SESSION_BUTTONS.filter((b) => !PRIMARY_PATHS.has(b.path) && !("toggle" in b));
@@ -41,10 +67,40 @@ export function SessionCard({ state, trigger, nowMs }: Props) {
         <Badge variant={state?.running ? "ok" : "err"}>
           {state?.running ? "Running" : "Stopped"}
         </Badge>
+        {state?.paused ? <Badge variant="warn">Players paused</Badge> : null}
+        {state?.not_ready_players?.length ? (
+          <Badge variant="warn">BizHawk not ready: {state.not_ready_players.join(", ")}</Badge>
+        ) : null}
         {state?.swap_enabled === false ? <Badge variant="warn">Auto swaps off</Badge> : null}
         {state?.countdown_enabled ? <Badge variant="info">Countdown on</Badge> : null}
+        {inLobby ? <Badge variant="info">Lobby open</Badge> : null}
       </div>
 
+      {inLobby ? (
+        <div className="mt-3 space-y-2">
+          <p className="text-[11px] font-medium uppercase tracking-wide text-slate-500">
+            Lobby ({lobbyRoster.length} joined)
+          </p>
+          {lobbyRoster.length ? (
+            <ul className="space-y-1 text-sm">
+              {lobbyRoster.map((p) => (
+                <li key={p.name} className="flex items-center gap-2">
+                  <span>{p.name}</span>
+                  <Badge variant={p.bizhawk_ready ? "ok" : p.bizhawk_hung ? "err" : "warn"}>
+                    {p.bizhawk_ready ? "Ready" : p.bizhawk_hung ? "BizHawk hung" : "BizHawk not ready"}
+                  </Badge>
+                </li>
+              ))}
+            </ul>
+          ) : (
+            <p className="text-sm text-slate-500">Waiting for players to join…</p>
+          )}
+          <Button variant="primary" onClick={() => void trigger("/api/session/start")}>
+            Start session
+          </Button>
+        </div>
+      ) : null}
+
       <Divider />
 
       <ShareAddresses />
@@ -60,9 +116,26 @@ export function SessionCard({ state, trigger, nowMs }: Props) {
         >
           <option value="sync">Sync swap (all same game)</option>
           <option value="save">Save swap (per-player saves)</option>
+          <option value="race">Race (first to complete wins)</option>
+          <option value="bingo">Bingo (complete a line of the board)</option>
+          <option value="manual">Manual (admin assigns every game)</option>
         </Select>
       </div>
 
+      {state?.mode === "save" ? (
+        <div className="space-y-2">
+          <FieldLabel htmlFor="session-swap-strategy">Swap strategy</FieldLabel>
+          <Select
+            id="session-swap-strategy"
+            value={state.swap_strategy ?? "round_robin"}
+            onChange={(e) => void trigger("/api/swap_strategy", { swap_strategy: e.target.value })}
+          >
+            <option value="round_robin">Round-robin (shuffled)</option>
+            <option value="derangement">Derangement (nobody keeps their instance)</option>
+          </Select>
+        </div>
+      ) : null}
+
       <Divider />
 
       <p className="mb-2 text-[11px] font-medium uppercase tracking-wide text-slate-500">
@@ -117,6 +190,32 @@ export function SessionCard({ state, trigger, nowMs }: Props) {
         </>
       ) : null}
 
+      <p className="mb-2 mt-4 text-[11px] font-medium uppercase tracking-wide text-slate-500">
+        Session preset
+      </p>
+      <ActionRow>
+        <Button variant="ghost" onClick={() => void downloadSessionExport()}>
+          Export
+        </Button>
+        <input
+          type="file"
+          accept="application/json,.json"
+          className="max-w-full flex-1 text-xs text-slate-400 file:mr-3 file:rounded-md file:border-0 file:bg-slate-700 file:px-2.5 file:py-1.5 file:text-xs file:font-medium file:text-slate-200 hover:file:bg-slate-600"
+          onChange={(e) => {
+            void importSession(e.target.files?.[0]);
+            e.target.value = "";
+          }}
+        />
+        <label className="flex items-center gap-1.5 text-xs text-slate-400">
+          <input
+            type="checkbox"
+            checked={reassignOnImport}
+            onChange={(e) => setReassignOnImport(e.target.checked)}
+          />
+          Reassign players
+        </label>
+      </ActionRow>
+
       <Divider />
 
       <p className="mb-1 text-[11px] font-medium uppercase tracking-wide text-slate-500">
@@ -161,6 +260,91 @@ export function SessionCard({ state, trigger, nowMs }: Props) {
         </div>
       </div>
       {err ? <p className="mt-2 text-xs text-rose-400">{err}</p> : null}
+      <div className="mt-2 space-y-2">
+        <FieldLabel htmlFor="interval-distribution">Distribution</FieldLabel>
+        <Select
+          id="interval-distribution"
+          value={state?.interval_distribution ?? "uniform"}
+          onChange={(e) => void trigger("/api/interval", { interval_distribution: e.target.value })}
+        >
+          <option value="uniform">Uniform (every delay equally likely)</option>
+          <option value="front_loaded">Front-loaded (more short delays)</option>
+        </Select>
+      </div>
+
+      <Divider />
+
+      <p className="mb-2 text-[11px] font-medium uppercase tracking-wide text-slate-500">
+        Swap preview (“Next up” lead, 0 = instant)
+      </p>
+      <div className="grid grid-cols-2 gap-2">
+        <div>
+          <FieldLabel htmlFor="swap-preview-secs">Seconds</FieldLabel>
+          <Input
+            id="swap-preview-secs"
+            type="number"
+            min={0}
+            max={30}
+            value={previewSecs}
+            onChange={(e) => setPreviewSecs(+e.target.value)}
+          />
+        </div>
+        <div className="flex items-end">
+          <Button
+            variant="primary"
+            className="w-full"
+            disabled={previewSecs < 0 || previewSecs > 30}
+            onClick={() => void trigger("/api/swap_preview_secs", { swap_preview_secs: previewSecs })}
+          >
+            Save
+          </Button>
+        </div>
+      </div>
+
+      <Divider />
+
+      <p className="mb-1 text-[11px] font-medium uppercase tracking-wide text-slate-500">
+        Max players (0 = no limit)
+      </p>
+      <p className="mb-2 font-mono text-xs text-slate-500">
+        Connected: {lobbyRoster.length}
+        {state?.waitlist?.length ? ` · Waitlist: ${state.waitlist.join(", ")}` : ""}
+      </p>
+      <div className="grid grid-cols-2 gap-2 sm:grid-cols-[1fr_1fr_auto]">
+        <div>
+          <FieldLabel htmlFor="max-players">Limit</FieldLabel>
+          <Input
+            id="max-players"
+            type="number"
+            min={0}
+            value={maxPlayers}
+            onChange={(e) => setMaxPlayers(+e.target.value)}
+          />
+        </div>
+        <label className="flex items-end gap-2 text-xs text-slate-400">
+          <input
+            type="checkbox"
+            checked={rejectWhenFull}
+            onChange={(e) => setRejectWhenFull(e.target.checked)}
+          />
+          Reject when full
+        </label>
+        <div className="flex items-end">
+          <Button
+            variant="primary"
+            className="w-full"
+            disabled={maxPlayers < 0}
+            onClick={() =>
+              void trigger("/api/max_players", {
+                max_players: maxPlayers,
+                reject_when_full: rejectWhenFull,
+              })
+            }
+          >
+            Save
+          </Button>
+        </div>
+      </div>
     </Card>
   );
 }
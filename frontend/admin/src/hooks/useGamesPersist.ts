@@ -1,10 +1,10 @@
-import { postGames, type GamesPayload } from "../api.js";
+import { errorDetail, postGames, type GamesPayload } from "../api.js";
 
 export function useGamesPersist(onLog: (msg: string) => void) {
   return async function persistGames(payload: GamesPayload) {
     const res = await postGames(payload);
     if (!res.ok) {
-      const detail = (await res.text()).trim();
+      const detail = await errorDetail(res);
       onLog(`save games failed: ${res.status}${detail ? ` — ${detail}` : ""}`);
       return false;
     }
@@ -8,6 +8,8 @@ export type CommandName =
   | "status_update"
   | "lua_command"
   | "config_response"
+  | "plugin_error"
+  | "vote_skip"
   | "hello_admin"
   | "ping"
   | "start"
@@ -21,7 +23,14 @@ export type CommandName =
   | "fullscreen_toggle"
   | "check_config"
   | "update_config"
-  | "state_update";
+  | "state_update"
+  | "player_connected"
+  | "player_disconnected"
+  | "swap_performed"
+  | "file_state_changed"
+  | "plugin_status_changed"
+  | "swap_enabled_changed"
+  | "command_failed";
 
 export interface Command {
   cmd: CommandName;
@@ -29,9 +38,85 @@ export interface Command {
   payload?: unknown;
 }
 
+/** Payload of player_connected / player_disconnected. */
+export interface PlayerEvent {
+  player: string;
+  bizhawk_ready?: boolean;
+}
+
+/** Payload of swap_performed (sent after the player acks). */
+export interface SwapEvent {
+  player: string;
+  game: string;
+  instance_id?: string;
+  mode?: ServerState["mode"];
+}
+
+/** Payload of file_state_changed. */
+export interface FileStateEvent {
+  instance_id: string;
+  file_state: FileState;
+  pending_player?: string;
+}
+
+/** Payload of plugin_status_changed. */
+export interface PluginStatusEvent {
+  plugin: string;
+  status: PluginStatus;
+}
+
+/** Payload of swap_enabled_changed. */
+export interface SwapEnabledEvent {
+  enabled: boolean;
+}
+
+/** Payload of status_update: the client's heartbeat. */
+export interface StatusUpdate {
+  bizhawk_ready: boolean;
+  bizhawk_hung?: boolean;
+  game?: string;
+  instance_id?: string;
+  pending_file?: string;
+  /** Free disk space where the client keeps saves; absent if unknown. */
+  saves_free_bytes?: number;
+}
+
+/** A command a player's client nacked. */
+export interface CommandFailure {
+  /** Empty when the server was not waiting on that command. */
+  cmd?: CommandName;
+  command_id: string;
+  reason: string;
+  /** Unix epoch seconds. */
+  at: number;
+}
+
+/** Payload of command_failed. */
+export interface CommandFailedEvent extends CommandFailure {
+  player: string;
+}
+
 export interface GameEntry {
   file: string;
   extra_files?: string[];
+  extra_dirs?: string[];
+  weight?: number;
+  /** Client BizHawk config profile this game runs with. */
+  config_profile?: string;
+  /** External download URL per main/extra file, tried before the server. */
+  urls?: Record<string, string>;
+  /** sha256 hex digest per file, used to verify external downloads. */
+  checksums?: Record<string, string>;
+  max_seconds_per_game?: number;
+}
+
+export type GameEventAction = "mark_completed" | "swap_player" | "swap_all" | "announce";
+
+/** Reaction to a Lua game_event; an empty game matches every game. */
+export interface GameEventRule {
+  event: string;
+  game?: string;
+  actions: GameEventAction[];
 }
 
 export interface Player {
@@ -45,6 +130,22 @@ export interface Player {
   completed_games?: string[];
   completed_instances?: string[];
   config_values?: Record<string, unknown>;
+  min_interval_secs?: number;
+  max_interval_secs?: number;
+  next_swap_at?: number;
+  rom_checksums?: Record<string, string>;
+  /** BizHawk stopped answering IPC liveness pings (hung, not closed). */
+  bizhawk_hung?: boolean;
+  game_deadline_at?: number;
+  /** Group for POST /api/swap/group; random save-mode swaps stay within it. */
+  team?: string;
+  last_failure?: CommandFailure;
+  status?: StatusUpdate;
+  /** Unix epoch seconds the last status_update arrived. */
+  status_at?: number;
+  /** Progress in the last POST /api/prestage. */
+  prestage?: "pending" | "ready" | "failed";
+  prestage_errors?: string[];
 }
 
 export type FileState = "none" | "pending" | "ready";
@@ -54,6 +155,24 @@ export interface GameSwapInstance {
   game: string;
   file_state: FileState;
   pending_player?: string;
+  /** Never dealt by automatic swaps; explicit assignment still works. */
+  locked?: boolean;
+}
+
+/** One result from GET /api/instances/search. */
+export interface InstanceMatch {
+  id: string;
+  game: string;
+  assignee?: string;
+  file_state: FileState;
+}
+
+/** One stored screenshot from GET /api/screenshots/{player}. */
+export interface ScreenshotInfo {
+  name: string;
+  size: number;
+  mod_time: number;
+  url: string;
 }
 
 export type PluginStatus = "disabled" | "enabled" | "loading" | "error";
@@ -66,17 +185,27 @@ export interface Plugin {
   author: string;
   status: PluginStatus;
   settings_meta?: Record<string, { type: string; options?: string[] }>;
+  last_error?: string;
+  last_error_player?: string;
 }
 
 export interface ServerState {
   running: boolean;
   swap_enabled: boolean;
-  mode?: "sync" | "save";
+  paused?: boolean;
+  not_ready_players?: string[];
+  mode?: "sync" | "save" | "race" | "bingo" | "manual";
   host?: string;
   port?: number;
+  /** Redacted by /state.json; present only in the persisted file. */
+  admin_token?: string;
   next_swap_at?: number;
   min_interval_secs?: number;
   max_interval_secs?: number;
+  /** How scheduled delays are drawn from [min, max]; absent means uniform. */
+  interval_distribution?: "uniform" | "front_loaded";
+  /** Reactions to Lua game_event commands, applied in order. */
+  game_event_rules?: GameEventRule[];
   main_games?: GameEntry[];
   plugins?: Record<string, Plugin>;
   players: Record<string, Player>;
@@ -85,6 +214,32 @@ export interface ServerState {
   game_instances?: GameSwapInstance[];
   prevent_same_game_swap: boolean;
   countdown_enabled: boolean;
+  /** Seconds players see "Next up: <game>" before full/random/rotate swaps. */
+  swap_preview_secs?: number;
+  /** Hold joiners without a game until POST /api/session/start. */
+  lobby_enabled?: boolean;
+  banned_players?: string[];
+  /** Connected-player cap; 0 or absent means no limit. */
+  max_players?: number;
+  /** Close hellos beyond max_players instead of waitlisting them. */
+  reject_when_full?: boolean;
+  /** Players waiting, in order, for a slot under max_players. */
+  waitlist?: string[];
   swap_seed?: number;
+  swap_counter?: number;
+  order_mode?: "random" | "sequential";
+  swap_strategy?: "round_robin" | "derangement";
+  save_versions?: number;
+  per_player_saves?: boolean;
+  vote_skip_percent?: number;
+  lua_swap_cooldown_secs?: number;
+  race_winner?: string;
+  bingo_board?: string[];
+  bingo_winners?: string[];
   config_keys?: string[];
+  ping_interval_secs?: number;
+  read_timeout_secs?: number;
+  /** Per player, ROM files whose checksum differs from the server's roms/ copy.
+   * Sent beside the state by /state.json, not persisted. */
+  rom_mismatches?: Record<string, string[]>;
 }
@@ -2,6 +2,8 @@ export const SESSION_BUTTONS = [
   { label: "Start", path: "/api/start" },
   { label: "Pause", path: "/api/pause" },
   { label: "Do Swap", path: "/api/do_swap" },
+  { label: "Undo Swap", path: "/api/swap/undo" },
+  { label: "Rotate Saves", path: "/api/swap/rotate" },
   { label: "Auto Swaps", path: "/api/toggle_swaps", toggle: "swap_enabled" as const },
   {
     label: "Better Random",
@@ -9,5 +11,12 @@ export const SESSION_BUTTONS = [
     toggle: "prevent_same_game_swap" as const,
   },
   { label: "Countdown", path: "/api/toggle_countdown", toggle: "countdown_enabled" as const },
+  { label: "Lobby", path: "/api/toggle_lobby", toggle: "lobby_enabled" as const },
+  {
+    label: "Per-player Saves",
+    path: "/api/toggle_per_player_saves",
+    toggle: "per_player_saves" as const,
+  },
+  { label: "Prestage Files", path: "/api/prestage" },
   { label: "Clear Saves", path: "/api/clear_saves" },
 ] as const;
@@ -10,9 +10,21 @@ export function playerCounts(state: ServerState | null) {
 export function playerStatusBadge(p: Player): { label: string; variant: "ok" | "warn" | "err" } {
   if (!p.connected) return { label: "Offline", variant: "err" };
   if (p.bizhawk_ready) return { label: "Ready", variant: "ok" };
+  if (p.bizhawk_hung) return { label: "BizHawk hung", variant: "err" };
   return { label: "Connected", variant: "warn" };
 }
 
+/** Human-readable byte count, e.g. "1.5 GB". */
+export function formatBytes(n: number): string {
+  const units = ["B", "KB", "MB", "GB", "TB"];
+  let i = 0;
+  while (n >= 1024 && i < units.length - 1) {
+    n /= 1024;
+    i++;
+  }
+  return `${n.toFixed(i === 0 ? 0 : 1)} ${units[i]}`;
+}
+
 export function formatUpdatedAt(iso: string | undefined): string {
   if (!iso) return "—";
   const d = new Date(iso);
@@ -1,8 +1,18 @@
-import type { ServerState } from "./types.js";
+import type { Player, ServerState } from "./types.js";
 
 export function nextSwapDisplay(state: ServerState | null, nowMs = Date.now()): string {
   if (!state?.next_swap_at) return "—";
-  const diff = Math.floor(state.next_swap_at - nowMs / 1000);
+  return countdownDisplay(state.next_swap_at, nowMs);
+}
+
+/** Time left on the player's current game under its max_seconds_per_game, or "" without a limit. */
+export function gameTimeLeftDisplay(player: Player, nowMs = Date.now()): string {
+  if (!player.game_deadline_at) return "";
+  return countdownDisplay(player.game_deadline_at, nowMs);
+}
+
+function countdownDisplay(atSecs: number, nowMs: number): string {
+  const diff = Math.floor(atSecs - nowMs / 1000);
   if (diff <= 0) return "Due";
   const hrs = Math.floor(diff / 3600);
   const mins = Math.floor((diff % 3600) / 60);
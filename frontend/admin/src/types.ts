@@ -2,7 +2,9 @@ export type {
   Command,
   GameEntry,
   GameSwapInstance,
+  InstanceMatch,
   Player,
   Plugin,
+  ScreenshotInfo,
   ServerState,
 } from "./protocol-types.js";
@@ -1,7 +1,8 @@
 import { useEffect, useState } from "react";
 import { useToast } from "./components/Toast.js";
 import type { Command, ServerState } from "./types.js";
-import { fetchState, post } from "./api.js";
+import { adminToken, errorDetail, fetchState, post } from "./api.js";
+import { applyAdminEvent, describeAdminEvent } from "./adminEvents.js";
 
 export function wsUrl(): string {
   const proto = location.protocol === "https:" ? "wss:" : "ws:";
@@ -27,7 +28,7 @@ export function useAdmin() {
   async function trigger(path: string, body?: unknown) {
     const res = await post(path, body);
     if (!res.ok) {
-      const detail = (await res.text()).trim();
+      const detail = await errorDetail(res);
       pushLog(`${path} failed: ${res.status}${detail ? ` — ${detail}` : ""}`);
       showToast("Action failed", "err");
     } else {
@@ -54,7 +55,7 @@ export function useAdmin() {
         JSON.stringify({
           cmd: "hello_admin",
           id: String(Date.now()),
-          payload: { name: "admin-ui" },
+          payload: { name: "admin-ui", token: adminToken() },
         } satisfies Command)
       );
       pushLog("admin WS connected");
@@ -63,6 +64,16 @@ export function useAdmin() {
       try {
         const cmd = JSON.parse(ev.data as string) as Command;
         if (cmd.cmd === "state_update") void refreshState();
+        const line = describeAdminEvent(cmd);
+        if (line) pushLog(line);
+        setState((prev) => (prev ? (applyAdminEvent(prev, cmd) ?? prev) : prev));
+        if (cmd.cmd === "plugin_error") {
+          const p = cmd.payload as { plugin: string; player?: string; error: string };
+          if (p.error) {
+            pushLog(`plugin ${p.plugin} error${p.player ? ` (${p.player})` : ""}: ${p.error}`);
+            showToast(`Plugin ${p.plugin} failed to load`, "err");
+          }
+        }
       } catch {
         /* ignore */
       }
@@ -0,0 +1,80 @@
+package obslog
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogFormatJSON is the log_format value that turns on SetJSONLogging.
+const LogFormatJSON = "json"
+
+// jsonLogRecord is one line written by JSONLogWriter.
+type jsonLogRecord struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	File    string `json:"file,omitempty"`
+	Message string `json:"message"`
+}
+
+// shortFilePrefix matches the "file.go:123: " prefix added by log.Lshortfile.
+var shortFilePrefix = regexp.MustCompile(`^([^\s:]+\.go:\d+): `)
+
+// JSONLogWriter re-encodes standard log lines as one JSON object per line with
+// time, level, file and message fields. It expects the logger to use only
+// log.Lshortfile (see SetJSONLogging); the timestamp is taken at write time.
+type JSONLogWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+	now func() time.Time
+}
+
+// NewJSONLogWriter returns a JSONLogWriter that writes to out.
+func NewJSONLogWriter(out io.Writer) *JSONLogWriter {
+	return &JSONLogWriter{out: out, now: time.Now}
+}
+
+// Write encodes p, one log call's output, as a JSON line.
+func (w *JSONLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	rec := jsonLogRecord{Time: w.now().UTC().Format(time.RFC3339Nano)}
+	if m := shortFilePrefix.FindStringSubmatch(msg); m != nil {
+		rec.File = m[1]
+		msg = msg[len(m[0]):]
+	}
+	rec.Message = msg
+	rec.Level = logLevel(msg)
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+	b = append(b, '\n')
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.out.Write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// logLevel infers a level from the conventions already used in log messages.
+func logLevel(msg string) string {
+	upper := strings.ToUpper(msg)
+	switch {
+	case strings.HasPrefix(upper, "[ERROR]"), strings.Contains(upper, " ERROR:"), strings.Contains(upper, " ERROR "):
+		return "error"
+	case strings.Contains(upper, "WARNING"), strings.HasPrefix(upper, "[WARN"):
+		return "warn"
+	}
+	return "info"
+}
+
+// SetJSONLogging sends the standard logger's output to out as JSON lines.
+func SetJSONLogging(out io.Writer) {
+	log.SetFlags(log.Lshortfile)
+	log.SetOutput(NewJSONLogWriter(out))
+}
@@ -0,0 +1,37 @@
+package obslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLogWriterEncodesLogLines(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLogWriter(&buf)
+	w.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	l := log.New(w, "", log.Lshortfile)
+	l.Printf("connected to %s", "ws://x")
+	l.Printf("[ERROR] could not determine player")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %q", len(lines), buf.String())
+	}
+	var rec jsonLogRecord
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Time != "2026-01-02T03:04:05Z" || rec.Level != "info" || rec.Message != "connected to ws://x" || !strings.HasPrefix(rec.File, "jsonlog_test.go:") {
+		t.Fatalf("record = %+v", rec)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Level != "error" {
+		t.Fatalf("level = %q", rec.Level)
+	}
+}
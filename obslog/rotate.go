@@ -0,0 +1,96 @@
+package obslog
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultLogArchives is how many rotated logs RotateLog keeps when keep <= 0.
+const DefaultLogArchives = 10
+
+// RotateLog compresses the log at path into dir/<name>-<timestamp>.zip and truncates it,
+// so each run starts a fresh file. After a successful rotation only the newest keep
+// archives for that log are left in dir. An empty or missing log is left alone.
+func RotateLog(path, dir string, keep int) error {
+	if keep <= 0 {
+		keep = DefaultLogArchives
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	stamp := info.ModTime().Format("20060102-150405.000")
+	archive := filepath.Join(dir, base+"-"+stamp+".zip")
+	if err := zipFile(path, archive); err != nil {
+		_ = os.Remove(archive)
+		return fmt.Errorf("zip %s: %w", path, err)
+	}
+	if err := os.Truncate(path, 0); err != nil {
+		return err
+	}
+	return pruneLogArchives(dir, base, keep)
+}
+
+// zipFile writes src as the single Deflate-compressed entry of a new zip at dst.
+func zipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     filepath.Base(src),
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err == nil {
+		_, err = io.Copy(w, in)
+	}
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// pruneLogArchives deletes all but the newest keep <base>-*.zip files in dir.
+// Archive names embed a sortable timestamp, so name order is age order.
+func pruneLogArchives(dir, base string, keep int) error {
+	matches, err := filepath.Glob(filepath.Join(dir, base+"-*.zip"))
+	if err != nil {
+		return err
+	}
+	if len(matches) <= keep {
+		return nil
+	}
+	sort.Strings(matches)
+	for _, old := range matches[:len(matches)-keep] {
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
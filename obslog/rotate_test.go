@@ -0,0 +1,51 @@
+package obslog
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateLogKeepsNewestArchives(t *testing.T) {
+	dir := t.TempDir()
+	logs := filepath.Join(dir, "logs")
+	if err := os.MkdirAll(logs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 15 {
+		name := filepath.Join(logs, fmt.Sprintf("desktop-20200101-0000%02d.000.zip", i))
+		if err := os.WriteFile(name, []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "desktop.log")
+	if err := os.WriteFile(path, []byte("previous run\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RotateLog(path, logs, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(logs, "desktop-*.zip"))
+	if len(matches) != DefaultLogArchives {
+		t.Fatalf("%d archives left, want %d", len(matches), DefaultLogArchives)
+	}
+	if _, err := os.Stat(filepath.Join(logs, "desktop-20200101-000004.000.zip")); !os.IsNotExist(err) {
+		t.Fatal("oldest archives should be pruned")
+	}
+	newest := matches[len(matches)-1]
+	zr, err := zip.OpenReader(newest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = zr.Close() }()
+	if len(zr.File) != 1 || zr.File[0].Method != zip.Deflate {
+		t.Fatalf("archive entries = %+v", zr.File)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Fatalf("log not truncated: %v %v", info, err)
+	}
+}
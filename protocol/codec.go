@@ -8,12 +8,16 @@ import (
 var clientToServer = map[CommandName]bool{
 	CmdHello: true, CmdAck: true, CmdNack: true, CmdGamesUpdateAck: true,
 	CmdStatusUpdate: true, CmdTypeLua: true, CmdConfigResponse: true, CmdHelloAdmin: true,
+	CmdHelloSpectator: true,
 }
 
 var serverToClient = map[CommandName]bool{
 	CmdPing: true, CmdResume: true, CmdPause: true, CmdSwap: true, CmdMessage: true,
-	CmdGamesUpdate: true, CmdClearSaves: true, CmdRequestSave: true, CmdPluginReload: true,
+	CmdGamesUpdate: true, CmdClearSaves: true, CmdRequestSave: true, CmdPluginReload: true, CmdPluginSync: true, CmdResync: true, CmdApplyConfigProfile: true,
 	CmdFullscreenToggle: true, CmdCheckConfig: true, CmdUpdateConfig: true, CmdStateUpdate: true,
+	CmdPlayerConnected: true, CmdPlayerDisconnected: true, CmdSwapPerformed: true,
+	CmdFileStateChanged: true, CmdPluginStatusChanged: true, CmdSwapEnabledChanged: true, CmdScreenshot: true,
+	CmdCommandFailed: true,
 }
 
 func EncodeCommand(cmd Command) (string, error) {
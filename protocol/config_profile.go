@@ -0,0 +1,21 @@
+package protocol
+
+// DefaultConfigProfile is the config profile holding the config.ini BizHawk
+// had before any profile was applied.
+const DefaultConfigProfile = "default"
+
+// ValidConfigProfile reports whether name can be used as a config profile:
+// 1-64 ASCII letters, digits, '-' or '_', so it is safe as a file suffix.
+func ValidConfigProfile(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -9,7 +9,7 @@ func ParseLuaPluginCommand(line string) (*LuaCommand, error) {
 		return nil, err
 	}
 	switch cmd.Kind {
-	case LuaCmdSwap, LuaCmdSwapMe, LuaCmdMessage:
+	case LuaCmdSwap, LuaCmdSwapMe, LuaCmdMessage, LuaCmdVoteSkip, LuaCmdCompleted, LuaCmdGameEvent:
 		return cmd, nil
 	default:
 		return nil, fmt.Errorf("unknown lua kind: %s", cmd.Kind)
@@ -22,3 +22,23 @@ func TestParseLuaPluginRejectsSave(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestParseLuaPluginCompleted(t *testing.T) {
+	cmd, err := ParseLuaPluginCommand("CMD|completed|game=a.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Kind != LuaCmdCompleted || cmd.Fields["game"] != "a.zip" {
+		t.Fatalf("got %+v", cmd)
+	}
+}
+
+func TestParseLuaPluginGameEvent(t *testing.T) {
+	cmd, err := ParseLuaPluginCommand(`CMD|game_event|event=game_over;detail=lives\=0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Kind != LuaCmdGameEvent || cmd.Fields["event"] != "game_over" || cmd.Fields["detail"] != "lives=0" {
+		t.Fatalf("got %+v", cmd)
+	}
+}
@@ -0,0 +1,23 @@
+package protocol
+
+import "strings"
+
+// SaveOwnerSeparator joins an instance ID and a player name in per-player save
+// keys; instance IDs may not contain it.
+const SaveOwnerSeparator = "__"
+
+// SaveKey returns the name, without ".state", under which the save for
+// instanceID is stored: the instance ID itself, or <instance>__<owner> when
+// saves are kept per player (ServerState.PerPlayerSaves).
+func SaveKey(instanceID, owner string) string {
+	if owner == "" {
+		return instanceID
+	}
+	return instanceID + SaveOwnerSeparator + owner
+}
+
+// SplitSaveKey is the inverse of SaveKey; owner is empty for shared saves.
+func SplitSaveKey(key string) (instanceID, owner string) {
+	instanceID, owner, _ = strings.Cut(key, SaveOwnerSeparator)
+	return instanceID, owner
+}
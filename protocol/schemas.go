@@ -20,6 +20,11 @@ const (
 	CmdStatusUpdate   CommandName = "status_update"
 	CmdTypeLua        CommandName = "lua_command"
 	CmdConfigResponse CommandName = "config_response"
+	// CmdPluginError reports a Lua-side plugin load/init error ({plugin, error});
+	// an empty error clears it. The server relays it to admins unchanged.
+	CmdPluginError CommandName = "plugin_error"
+	// CmdVoteSkip is a player's vote to skip the current game in sync mode.
+	CmdVoteSkip CommandName = "vote_skip"
 
 	// From Server to Client
 	CmdPing             CommandName = "ping"
@@ -34,12 +39,34 @@ const (
 	CmdFullscreenToggle CommandName = "fullscreen_toggle"
 	CmdCheckConfig      CommandName = "check_config"
 	CmdUpdateConfig     CommandName = "update_config"
+	// CmdScreenshot asks the client to capture BizHawk's screen and upload it.
+	CmdScreenshot CommandName = "screenshot"
+	// CmdPluginSync tells clients a plugin was enabled or disabled
+	// ({plugin_name, status}) so they resync plugin files and reload it.
+	CmdPluginSync CommandName = "plugin_sync"
+	// CmdResync asks the client to wipe its cached ROMs and download everything
+	// the last games_update needs again; it acks once the files are back.
+	CmdResync CommandName = "resync"
+	// CmdApplyConfigProfile ({profile}) makes the client switch BizHawk's
+	// config.ini to the named profile, restarting BizHawk if it changed.
+	CmdApplyConfigProfile CommandName = "apply_config_profile"
 
 	// From Admin to Server
 	CmdHelloAdmin CommandName = "hello_admin"
 
+	// From Spectator to Server: watch the session without joining as a player
+	CmdHelloSpectator CommandName = "hello_spectator"
+
 	// From Server to Admin
 	CmdStateUpdate CommandName = "state_update"
+	// Granular admin events, sent alongside the coarse state_update.
+	CmdPlayerConnected     CommandName = "player_connected"
+	CmdPlayerDisconnected  CommandName = "player_disconnected"
+	CmdSwapPerformed       CommandName = "swap_performed"
+	CmdFileStateChanged    CommandName = "file_state_changed"
+	CmdPluginStatusChanged CommandName = "plugin_status_changed"
+	CmdSwapEnabledChanged  CommandName = "swap_enabled_changed"
+	CmdCommandFailed       CommandName = "command_failed"
 )
 
 type LuaCmd string
@@ -48,8 +75,40 @@ const (
 	LuaCmdSwap    LuaCmd = "swap"
 	LuaCmdSwapMe  LuaCmd = "swap_me"
 	LuaCmdMessage LuaCmd = "message"
+	// LuaCmdPluginError is sent by server.lua when a plugin fails to load or init.
+	LuaCmdPluginError LuaCmd = "plugin_error"
+	// LuaCmdVoteSkip lets a plugin cast the player's skip vote.
+	LuaCmdVoteSkip LuaCmd = "vote_skip"
+	// LuaCmdCompleted marks the player's current game (or the game/instance
+	// fields, when given) as completed.
+	LuaCmdCompleted LuaCmd = "completed"
+	// LuaCmdGameEvent reports something that happened in the player's game
+	// (event=death, level_up, game_over, ...); see GameEventRule.
+	LuaCmdGameEvent LuaCmd = "game_event"
+)
+
+// GameEventAction is what the server does when a GameEventRule matches.
+type GameEventAction string
+
+const (
+	// GameEventActionMarkCompleted - add the game to the player's completed games
+	GameEventActionMarkCompleted GameEventAction = "mark_completed"
+	// GameEventActionSwapPlayer - random swap for the reporting player
+	GameEventActionSwapPlayer GameEventAction = "swap_player"
+	// GameEventActionSwapAll - full swap, debounced like a Lua "swap"
+	GameEventActionSwapAll GameEventAction = "swap_all"
+	// GameEventActionAnnounce - show "<player>: <event>" to every player
+	GameEventActionAnnounce GameEventAction = "announce"
 )
 
+// GameEventRule reacts to Lua game_event commands whose event matches Event
+// and, when Game is set, whose game matches Game.
+type GameEventRule struct {
+	Event   string            `json:"event"`
+	Game    string            `json:"game,omitempty"`
+	Actions []GameEventAction `json:"actions"`
+}
+
 // GameMode enumerates the available game swapping modes. Use string constants
 // so callers can use the literal values directly.
 type GameMode string
@@ -59,6 +118,45 @@ const (
 	GameModeSync GameMode = "sync"
 	// GameModeSave - players play different games and perform save upload/download orchestration on swap
 	GameModeSave GameMode = "save"
+	// GameModeRace - like sync, but the first player to complete a game wins and swaps stop
+	GameModeRace GameMode = "race"
+	// GameModeBingo - like sync, but games come from a shared bingo board and completing a
+	// row, column or diagonal of the board announces bingo
+	GameModeBingo GameMode = "bingo"
+	// GameModeManual - the server never picks games; players only get one when an admin assigns it
+	GameModeManual GameMode = "manual"
+)
+
+// OrderMode controls how the next game is chosen from the available games list.
+type OrderMode string
+
+const (
+	// OrderModeRandom - pick a deterministic random game using the swap seed (default)
+	OrderModeRandom OrderMode = "random"
+	// OrderModeSequential - advance through the games list in order, wrapping at the end
+	OrderModeSequential OrderMode = "sequential"
+)
+
+// SwapStrategy controls how a save-mode full swap deals instances to players.
+type SwapStrategy string
+
+const (
+	// SwapStrategyRoundRobin - each player in turn takes the first suitable instance
+	// from a shuffled list (default)
+	SwapStrategyRoundRobin SwapStrategy = "round_robin"
+	// SwapStrategyDerangement - nobody keeps their current instance whenever some
+	// assignment allows it
+	SwapStrategyDerangement SwapStrategy = "derangement"
+)
+
+// IntervalDistribution controls how swap delays are drawn from [min, max].
+type IntervalDistribution string
+
+const (
+	// IntervalDistributionUniform - every delay in the range is equally likely (default)
+	IntervalDistributionUniform IntervalDistribution = "uniform"
+	// IntervalDistributionFrontLoaded - short delays are more likely than long ones
+	IntervalDistributionFrontLoaded IntervalDistribution = "front_loaded"
 )
 
 // FileState tracks the state of save files for instances
@@ -148,10 +246,78 @@ type Command struct {
 	ID      string      `json:"id"`
 }
 
+// PlayerEvent is the payload of player_connected and player_disconnected.
+type PlayerEvent struct {
+	Player       string `json:"player"`
+	BizhawkReady bool   `json:"bizhawk_ready,omitempty"`
+}
+
+// SwapEvent is the payload of swap_performed, sent once a player acks a swap.
+type SwapEvent struct {
+	Player     string   `json:"player"`
+	Game       string   `json:"game"`
+	InstanceID string   `json:"instance_id,omitempty"`
+	Mode       GameMode `json:"mode,omitempty"`
+}
+
+// FileStateEvent is the payload of file_state_changed.
+type FileStateEvent struct {
+	InstanceID    string    `json:"instance_id"`
+	FileState     FileState `json:"file_state"`
+	PendingPlayer string    `json:"pending_player,omitempty"`
+}
+
+// PluginStatusEvent is the payload of plugin_status_changed.
+type PluginStatusEvent struct {
+	Plugin string       `json:"plugin"`
+	Status PluginStatus `json:"status"`
+}
+
+// SwapEnabledEvent is the payload of swap_enabled_changed.
+type SwapEnabledEvent struct {
+	Enabled bool `json:"enabled"`
+}
+
+// StatusUpdate is the payload of status_update, sent by player clients when
+// BizHawk readiness changes and as a periodic heartbeat.
+type StatusUpdate struct {
+	BizhawkReady bool `json:"bizhawk_ready"`
+	// BizhawkHung is set with BizhawkReady false when BizHawk stopped
+	// answering IPC liveness pings.
+	BizhawkHung bool   `json:"bizhawk_hung,omitempty"`
+	Game        string `json:"game,omitempty"`
+	InstanceID  string `json:"instance_id,omitempty"`
+	// PendingFile is the ROM the client is downloading for a swap, if any.
+	PendingFile string `json:"pending_file,omitempty"`
+	// SavesFreeBytes is the free disk space where the client keeps saves;
+	// nil when the client could not tell.
+	SavesFreeBytes *int64 `json:"saves_free_bytes,omitempty"`
+}
+
+// CommandFailure records a command a player's client nacked.
+type CommandFailure struct {
+	// Cmd is the nacked command; empty when the server was not waiting on it
+	// (fire-and-forget sends, or a nack after the wait timed out).
+	Cmd       CommandName `json:"cmd,omitempty"`
+	CommandID string      `json:"command_id"`
+	Reason    string      `json:"reason"`
+	// At is the unix epoch seconds the nack was received.
+	At int64 `json:"at"`
+}
+
+// CommandFailedEvent is the payload of command_failed.
+type CommandFailedEvent struct {
+	Player string `json:"player"`
+	CommandFailure
+}
+
 // ServerState is persisted on the server
 type ServerState struct {
 	Running     bool `json:"running"`
 	SwapEnabled bool `json:"swap_enabled"`
+	// Paused is set when an admin paused the players' emulators; clients that
+	// connect while paused are paused too.
+	Paused bool `json:"paused,omitempty"`
 	// Mode controls the high-level server swap behavior.
 	Mode GameMode `json:"mode,omitempty"`
 	// Host is an optional persisted listen host (e.g. "0.0.0.0" or "127.0.0.1").
@@ -162,6 +328,16 @@ type ServerState struct {
 	// If present, the server can use this value when a --port flag isn't
 	// provided on the command line.
 	Port int `json:"port,omitempty"`
+	// AdminToken, when set, must accompany admin REST calls and hello_admin
+	// websocket handshakes. It is redacted from /state.json.
+	AdminToken string `json:"admin_token,omitempty"`
+	// CertFile and KeyFile are PEM paths; when both are set the server serves
+	// HTTPS (and wss:// websockets) instead of plain HTTP.
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	// TLS serves HTTPS even without CertFile/KeyFile, using a self-signed
+	// certificate generated under ./certs.
+	TLS bool `json:"tls,omitempty"`
 	// NextSwapAt is the unix epoch seconds when the next scheduled swap will occur.
 	// It is updated by the server scheduler and persisted so the UI can display it.
 	NextSwapAt      int64 `json:"next_swap_at,omitempty"`
@@ -183,10 +359,65 @@ type ServerState struct {
 	PreventSameGameSwap bool `json:"prevent_same_game_swap"`
 	// CountdownEnabled enables a 3-2-1 countdown before auto swaps
 	CountdownEnabled bool `json:"countdown_enabled"`
+	// SwapPreviewSecs is how long players see "Next up: <game>" before full,
+	// random and rotate swaps are sent; 0 swaps instantly
+	SwapPreviewSecs int `json:"swap_preview_secs,omitempty"`
+	// LobbyEnabled holds joining players in a lobby, with no game assigned,
+	// until the session is started (POST /api/session/start)
+	LobbyEnabled bool `json:"lobby_enabled,omitempty"`
+	// BannedPlayers lists player names whose hello is rejected with a close frame
+	BannedPlayers []string `json:"banned_players,omitempty"`
+	// MaxPlayers caps how many players may be connected at once; 0 means no limit
+	MaxPlayers int `json:"max_players,omitempty"`
+	// RejectWhenFull closes hellos beyond MaxPlayers instead of waitlisting them
+	RejectWhenFull bool `json:"reject_when_full,omitempty"`
+	// Waitlist lists players, in order, waiting for a slot under MaxPlayers
+	Waitlist []string `json:"waitlist,omitempty"`
 	// SwapSeed is used for deterministic random game selection in sync mode
 	SwapSeed int64 `json:"swap_seed,omitempty"`
+	// SwapCounter increments on every swap and on every state load; it is mixed into
+	// SwapSeed so a restart never replays the same selection sequence
+	SwapCounter int64 `json:"swap_counter,omitempty"`
+	// OrderMode selects how the next game is picked; empty means random
+	OrderMode OrderMode `json:"order_mode,omitempty"`
+	// SwapStrategy selects how save-mode full swaps assign instances; empty means round-robin
+	SwapStrategy SwapStrategy `json:"swap_strategy,omitempty"`
+	// SaveVersions is how many previous copies of each instance's save the server
+	// keeps under ./saves/<id>/ for rollback; 0 means the default (3), -1 disables.
+	SaveVersions int `json:"save_versions,omitempty"`
+	// PerPlayerSaves keeps one save per (instance, player) in save mode, stored
+	// as <instance>__<player>.state, instead of one save shared by the instance.
+	PerPlayerSaves bool `json:"per_player_saves,omitempty"`
+	// NotReadyPlayers lists connected players whose BizHawk had not reported
+	// ready when the last save-mode swap started; cleared as they become ready.
+	NotReadyPlayers []string `json:"not_ready_players,omitempty"`
+	// LuaSwapCooldownSecs is the minimum gap between full swaps requested by Lua
+	// plugins; 0 means the default (5), -1 disables the guard.
+	LuaSwapCooldownSecs int `json:"lua_swap_cooldown_secs,omitempty"`
+	// VoteSkipPercent is the share of connected players (1-100) whose votes skip the
+	// current sync-mode game; 0 means a simple majority.
+	VoteSkipPercent int `json:"vote_skip_percent,omitempty"`
+	// RaceWinner is the player who completed a game first in race mode; swaps are frozen while set
+	RaceWinner string `json:"race_winner,omitempty"`
+	// BingoBoard is the shared bingo board in bingo mode: game files in row-major order,
+	// a square number of them (at most 5x5)
+	BingoBoard []string `json:"bingo_board,omitempty"`
+	// BingoWinners lists players who completed a bingo line, in order
+	BingoWinners []string `json:"bingo_winners,omitempty"`
 	// ConfigKeys defines the BizHawk config keys that can be managed via the UI
 	ConfigKeys []string `json:"config_keys,omitempty"`
+	// PingIntervalSecs is how often the server pings each player websocket;
+	// 0 means the default (30).
+	PingIntervalSecs int `json:"ping_interval_secs,omitempty"`
+	// ReadTimeoutSecs drops a player websocket when no pong arrives for this
+	// long; 0 means the default (60).
+	ReadTimeoutSecs int `json:"read_timeout_secs,omitempty"`
+	// IntervalDistribution selects how swap delays are drawn from the min/max
+	// interval range; empty means uniform
+	IntervalDistribution IntervalDistribution `json:"interval_distribution,omitempty"`
+	// GameEventRules lists the reactions to Lua game_event commands, applied
+	// in order
+	GameEventRules []GameEventRule `json:"game_event_rules,omitempty"`
 }
 
 // GameEntry describes a single catalog entry in the server's main game list.
@@ -195,6 +426,23 @@ type ServerState struct {
 type GameEntry struct {
 	File       string   `json:"file"`
 	ExtraFiles []string `json:"extra_files,omitempty"`
+	// ExtraDirs lists folders under roms/ whose whole contents clients mirror.
+	ExtraDirs []string `json:"extra_dirs,omitempty"`
+	// Weight biases random selection toward this game; 0 or unset counts as 1.
+	Weight int `json:"weight,omitempty"`
+	// ConfigProfile names the client BizHawk config profile to run this game
+	// with; empty means the client's default config.ini.
+	ConfigProfile string `json:"config_profile,omitempty"`
+	// URLs maps this entry's main or extra files to an external http(s) URL
+	// (CDN or file host) clients download from before falling back to the
+	// server's /files/.
+	URLs map[string]string `json:"urls,omitempty"`
+	// Checksums maps files to their sha256 hex digest; clients only keep an
+	// external download that matches it.
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// MaxSecondsPerGame swaps a player off this game once they have played
+	// it this long, regardless of the swap interval; 0 means no limit.
+	MaxSecondsPerGame int `json:"max_seconds_per_game,omitempty"`
 }
 
 // Player represents a connected client
@@ -213,13 +461,53 @@ type Player struct {
 	CompletedInstances []string `json:"completed_instances,omitempty"`
 	// ConfigValues stores the player's BizHawk config values for managed keys
 	ConfigValues map[string]any `json:"config_values,omitempty"`
+	// MinIntervalSecs/MaxIntervalSecs override the global swap interval for this player.
+	// When either is set the player also gets individual random swaps on their own timer.
+	MinIntervalSecs int `json:"min_interval_secs,omitempty"`
+	MaxIntervalSecs int `json:"max_interval_secs,omitempty"`
+	// NextSwapAt is the unix epoch seconds of this player's next individual swap.
+	NextSwapAt int64 `json:"next_swap_at,omitempty"`
+	// RomChecksums maps ROM file names to the sha256 hex digest reported by the client
+	RomChecksums map[string]string `json:"rom_checksums,omitempty"`
+	// BizhawkHung is set while the client reports BizHawk stopped answering
+	// IPC liveness pings with the connection still open.
+	BizhawkHung bool `json:"bizhawk_hung,omitempty"`
+	// GameDeadlineAt is the unix epoch seconds at which the player's current
+	// game reaches its max_seconds_per_game; 0 when the game has no limit.
+	GameDeadlineAt int64 `json:"game_deadline_at,omitempty"`
+	// Team groups players for group-scoped swaps (POST /api/swap/group); empty
+	// means no team. Random save-mode swaps only take instances that are free or
+	// held by a player on the same team.
+	Team string `json:"team,omitempty"`
+	// LastFailure is the most recent command this player's client nacked.
+	LastFailure *CommandFailure `json:"last_failure,omitempty"`
+	// Status is the client's last status_update and StatusAt the unix epoch
+	// seconds it arrived.
+	Status   *StatusUpdate `json:"status,omitempty"`
+	StatusAt int64         `json:"status_at,omitempty"`
+	// Prestage is the player's progress in the last POST /api/prestage, with
+	// the download errors its client reported when it failed.
+	Prestage       PrestageStatus `json:"prestage,omitempty"`
+	PrestageErrors []string       `json:"prestage_errors,omitempty"`
 }
 
+// PrestageStatus is a player's progress downloading the full catalog.
+type PrestageStatus string
+
+const (
+	PrestagePending PrestageStatus = "pending"
+	PrestageReady   PrestageStatus = "ready"
+	PrestageFailed  PrestageStatus = "failed"
+)
+
 type GameSwapInstance struct {
 	ID            string    `json:"id"`
 	Game          string    `json:"game"`
 	FileState     FileState `json:"file_state"`
 	PendingPlayer string    `json:"pending_player,omitempty"`
+	// Locked instances are never dealt by automatic swaps; admins can still
+	// assign them explicitly
+	Locked bool `json:"locked,omitempty"`
 }
 
 // Plugin represents a Lua plugin that can be loaded into BizHawk
@@ -231,6 +519,13 @@ type Plugin struct {
 	Author         string                 `json:"author"`
 	Status         PluginStatus           `json:"status"`
 	SettingsMeta   map[string]SettingMeta `json:"settings_meta,omitempty"`
+	// LastError is the most recent Lua load/init error reported by a client;
+	// LastErrorPlayer names the reporting player. Both are part of the
+	// in-memory ServerState (and its admin broadcasts) but are never written
+	// to disk: plugins are left out of state.json and settings.kv keeps only
+	// the status, so the error is gone after a server restart.
+	LastError       string `json:"last_error,omitempty"`
+	LastErrorPlayer string `json:"last_error_player,omitempty"`
 }
 
 // SettingMeta defines metadata for a plugin setting to guide UI rendering
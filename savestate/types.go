@@ -4,6 +4,7 @@ type ErrorCode string
 
 const (
 	CodeFileTooLarge           ErrorCode = "FILE_TOO_LARGE"
+	CodeEmptySavestate         ErrorCode = "EMPTY_SAVESTATE"
 	CodeNotZipSavestate        ErrorCode = "NOT_ZIP_SAVESTATE"
 	CodeZipCorrupt             ErrorCode = "ZIP_CORRUPT"
 	CodeDuplicateLump          ErrorCode = "DUPLICATE_LUMP"
@@ -19,7 +20,10 @@ const (
 )
 
 type VerifyOptions struct {
-	MaxFileBytes          int64
+	MaxFileBytes int64
+	// AllowRaw accepts any non-empty file that isn't a ZIP, for cores that write raw
+	// savestates. Files that look like ZIPs are still fully verified.
+	AllowRaw              bool
 	ExpectedEmuVersion    string
 	ExpectedSyncSettings  string
 	ExpectedMovieInputLog []string
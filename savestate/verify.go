@@ -55,6 +55,12 @@ func VerifyBizHawkSavestate(input []byte, opts VerifyOptions) VerifyResult {
 		return fail(CodeFileTooLarge, fmt.Sprintf("save exceeds %d bytes", maxBytes), nil)
 	}
 	if !hasZipMagic(input) {
+		if opts.AllowRaw {
+			if len(input) == 0 {
+				return fail(CodeEmptySavestate, "savestate is empty", nil)
+			}
+			return VerifyResult{OK: true, FormatVersion: "raw"}
+		}
 		return fail(CodeNotZipSavestate, "file is not a ZIP-based BizHawk savestate", nil)
 	}
 	entries, err := openZipArchive(input)
@@ -35,3 +35,20 @@ func TestRejectNonZip(t *testing.T) {
 		t.Fatalf("%+v", result)
 	}
 }
+
+func TestAllowRawSavestate(t *testing.T) {
+	opts := VerifyOptions{AllowRaw: true}
+	if result := VerifyBizHawkSavestate([]byte{1, 2, 3, 4}, opts); !result.OK || result.FormatVersion != "raw" {
+		t.Fatalf("raw state rejected: %+v", result)
+	}
+	if result := VerifyBizHawkSavestate(nil, opts); result.OK || result.Code != CodeEmptySavestate {
+		t.Fatalf("empty state: %+v", result)
+	}
+	bad, err := BuildNonBizHawkZip()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := VerifyBizHawkSavestate(bad, opts); result.OK || result.Code != CodeMissingBizStateVersion {
+		t.Fatalf("zip still needs full verification: %+v", result)
+	}
+}
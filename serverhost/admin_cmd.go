@@ -0,0 +1,165 @@
+package serverhost
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// AdminResult is the JSON line printed for each admin CLI command.
+type AdminResult struct {
+	Command string `json:"command"`
+	OK      bool   `json:"ok"`
+	Result  any    `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// adminVerb maps a CLI verb onto the Server method that implements it.
+type adminVerb struct {
+	usage string
+	// run executes the verb for arg ("" when omitted) and returns its result.
+	run func(s *Server, arg string) (any, error)
+}
+
+func requireArg(arg, name string) error {
+	if arg == "" {
+		return fmt.Errorf("missing %s", name)
+	}
+	return nil
+}
+
+var adminVerbs = map[string]adminVerb{
+	"start": {"start", func(s *Server, _ string) (any, error) {
+		s.setRunning(true)
+		return "ok", nil
+	}},
+	"pause": {"pause", func(s *Server, _ string) (any, error) {
+		s.setRunning(false)
+		return "ok", nil
+	}},
+	"swap": {"swap", func(s *Server, _ string) (any, error) {
+		go func() {
+			if err := s.performSwap(); err != nil {
+				fmt.Printf("performSwap error: %v\n", err)
+			}
+		}()
+		return "ok", nil
+	}},
+	"swap-player": {"swap-player <name>", func(s *Server, arg string) (any, error) {
+		if err := requireArg(arg, "player"); err != nil {
+			return nil, err
+		}
+		go func() {
+			if err := s.performRandomSwapForPlayer(arg); err != nil {
+				fmt.Printf("performRandomSwapForPlayer error: %v\n", err)
+			}
+		}()
+		return "ok", nil
+	}},
+	"mode": {"mode [sync|save|race|bingo|manual]", func(s *Server, arg string) (any, error) {
+		if arg == "" {
+			var mode protocol.GameMode
+			s.withRLock(func() { mode = s.state.Mode })
+			return map[string]any{"mode": mode}, nil
+		}
+		if err := s.setMode(protocol.GameMode(arg)); err != nil {
+			return nil, err
+		}
+		return "ok", nil
+	}},
+	"add-player": {"add-player <name>", func(s *Server, arg string) (any, error) {
+		if err := requireArg(arg, "player"); err != nil {
+			return nil, err
+		}
+		s.addPlayer(arg)
+		return "ok", nil
+	}},
+	"remove-player": {"remove-player <name>", func(s *Server, arg string) (any, error) {
+		if err := requireArg(arg, "player"); err != nil {
+			return nil, err
+		}
+		s.removePlayer(arg, false)
+		return "ok", nil
+	}},
+	"swaps": {"swaps [on|off]", func(s *Server, arg string) (any, error) {
+		var enabled bool
+		switch arg {
+		case "":
+			enabled = s.setSwapEnabled(func(enabled bool) bool { return !enabled })
+		case "on", "off":
+			enabled = s.setSwapEnabled(func(bool) bool { return arg == "on" })
+		default:
+			return nil, fmt.Errorf("swaps takes on or off, got %q", arg)
+		}
+		return map[string]bool{"enabled": enabled}, nil
+	}},
+	"state": {"state", func(s *Server, _ string) (any, error) {
+		st := s.SnapshotState()
+		st.AdminToken = ""
+		return s.stateEnvelope(st), nil
+	}},
+}
+
+// AdminCommand runs one CLI command line ("verb [arg]") against the server
+// directly, bypassing the admin token since the caller is the server process
+// itself.
+func (s *Server) AdminCommand(line string) AdminResult {
+	line = strings.TrimSpace(line)
+	res := AdminResult{Command: line}
+	verb, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	if verb == "help" {
+		res.OK = true
+		res.Result = AdminCommandUsage()
+		return res
+	}
+	v, ok := adminVerbs[verb]
+	if !ok {
+		res.Error = fmt.Sprintf("unknown command %q (try help)", verb)
+		return res
+	}
+	result, err := v.run(s, arg)
+	if err != nil {
+		res.Error = err.Error() + "; usage: " + v.usage
+		return res
+	}
+	res.OK = true
+	res.Result = result
+	return res
+}
+
+// AdminCommandUsage lists the verbs AdminCommand accepts.
+func AdminCommandUsage() []string {
+	usage := []string{"help"}
+	for _, v := range adminVerbs {
+		usage = append(usage, v.usage)
+	}
+	sort.Strings(usage)
+	return usage
+}
+
+// RunAdminCommands reads one command per line from r and writes one JSON
+// AdminResult per line to w until EOF or "quit". Blank lines and lines
+// starting with # are skipped.
+func (s *Server) RunAdminCommands(r io.Reader, w io.Writer) error {
+	sc := bufio.NewScanner(r)
+	enc := json.NewEncoder(w)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "quit" || line == "exit" {
+			return nil
+		}
+		if err := enc.Encode(s.AdminCommand(line)); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,76 @@
+package serverhost
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestAdminCommandDispatch(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.SetAdminToken("secret")
+
+	if res := s.AdminCommand("mode save"); !res.OK {
+		t.Fatalf("mode save: %+v", res)
+	}
+	if res := s.AdminCommand("mode bogus"); res.OK {
+		t.Fatalf("mode bogus accepted: %+v", res)
+	}
+	if res := s.AdminCommand("add-player amy"); !res.OK {
+		t.Fatalf("add-player: %+v", res)
+	}
+	if res := s.AdminCommand("remove-player"); res.OK || !strings.Contains(res.Error, "usage") {
+		t.Fatalf("remove-player without name: %+v", res)
+	}
+	if res := s.AdminCommand("swaps off"); !res.OK {
+		t.Fatalf("swaps off: %+v", res)
+	}
+	if res := s.AdminCommand("swaps off"); !res.OK {
+		t.Fatalf("swaps off again: %+v", res)
+	}
+	if res := s.AdminCommand("frobnicate"); res.OK {
+		t.Fatalf("unknown verb accepted: %+v", res)
+	}
+
+	st := s.SnapshotState()
+	if st.Mode != protocol.GameModeSave {
+		t.Fatalf("mode = %q, want save", st.Mode)
+	}
+	if _, ok := st.Players["amy"]; !ok {
+		t.Fatalf("amy not added: %+v", st.Players)
+	}
+	if st.SwapEnabled {
+		t.Fatal("swaps off twice should leave swaps disabled")
+	}
+}
+
+func TestRunAdminCommandsPrintsJSONLines(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	in := strings.NewReader("# comment\n\nmode\nhelp\nquit\nstart\n")
+	var out bytes.Buffer
+	if err := s.RunAdminCommands(in, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 result lines, got %q", out.String())
+	}
+	var res AdminResult
+	if err := json.Unmarshal([]byte(lines[0]), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.OK || res.Command != "mode" {
+		t.Fatalf("mode result: %+v", res)
+	}
+	if s.SnapshotState().Running {
+		t.Fatal("commands after quit must not run")
+	}
+}
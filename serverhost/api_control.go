@@ -2,7 +2,9 @@ package serverhost
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"slices"
@@ -13,35 +15,108 @@ import (
 
 // apiStart toggles running=true and notifies clients
 func (s *Server) apiStart(w http.ResponseWriter, r *http.Request) {
+	s.setRunning(true)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		fmt.Printf("write response error: %v\n", err)
+	}
+}
+
+// apiPause toggles running=false and notifies clients
+func (s *Server) apiPause(w http.ResponseWriter, r *http.Request) {
+	s.setRunning(false)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		fmt.Printf("write response error: %v\n", err)
+	}
+}
+
+// setRunning starts or pauses the session, sends CmdResume or CmdPause to
+// every player and wakes the scheduler.
+func (s *Server) setRunning(running bool) {
 	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
-		st.Running = true
+		st.Running = running
+		st.Paused = !running
 	})
-	s.broadcastToPlayers(protocol.Command{Cmd: protocol.CmdResume, ID: fmt.Sprintf("%d", time.Now().UnixNano())})
+	cmd := protocol.CmdResume
+	if !running {
+		cmd = protocol.CmdPause
+	}
+	s.broadcastToPlayers(protocol.Command{Cmd: cmd, ID: fmt.Sprintf("%d", time.Now().UnixNano())})
 	select {
 	case s.schedulerCh <- struct{}{}:
 	default:
 	}
-	if _, err := w.Write([]byte("ok")); err != nil {
-		fmt.Printf("write response error: %v\n", err)
-	}
 }
 
-// apiPause toggles running=false and notifies clients
-func (s *Server) apiPause(w http.ResponseWriter, r *http.Request) {
+// setPlayersPaused records the global paused flag and sends CmdPause or
+// CmdResume to each connected player, returning delivery failures by player.
+func (s *Server) setPlayersPaused(paused bool) (sent []string, failed map[string]string) {
+	var players []protocol.Player
 	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
-		st.Running = false
+		st.Running = !paused
+		st.Paused = paused
+		for _, p := range st.Players {
+			if p.Connected {
+				players = append(players, p)
+			}
+		}
 	})
-	s.broadcastToPlayers(protocol.Command{Cmd: protocol.CmdPause, ID: fmt.Sprintf("%d", time.Now().UnixNano())})
+	cmdName := protocol.CmdResume
+	if paused {
+		cmdName = protocol.CmdPause
+	}
+	failed = map[string]string{}
+	for _, p := range players {
+		cmd := protocol.Command{Cmd: cmdName, ID: fmt.Sprintf("%d", time.Now().UnixNano())}
+		if err := s.sendToPlayer(p, cmd); err != nil {
+			failed[p.Name] = err.Error()
+			continue
+		}
+		sent = append(sent, p.Name)
+	}
+	slices.Sort(sent)
 	select {
 	case s.schedulerCh <- struct{}{}:
 	default:
 	}
-	if _, err := w.Write([]byte("ok")); err != nil {
-		fmt.Printf("write response error: %v\n", err)
+	return sent, failed
+}
+
+// apiPauseAll pauses every connected player's emulator and stops the session.
+func (s *Server) apiPauseAll(w http.ResponseWriter, r *http.Request) {
+	s.writePauseAllResult(w, r, true)
+}
+
+// apiResumeAll resumes every connected player's emulator and the session.
+func (s *Server) apiResumeAll(w http.ResponseWriter, r *http.Request) {
+	s.writePauseAllResult(w, r, false)
+}
+
+func (s *Server) writePauseAllResult(w http.ResponseWriter, r *http.Request, paused bool) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	sent, failed := s.setPlayersPaused(paused)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"result":  "ok",
+		"paused":  paused,
+		"players": sent,
+		"failed":  failed,
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
 	}
 }
 
 func (s *Server) apiClearSaves(w http.ResponseWriter, r *http.Request) {
+	// The body is optional; without it clients also wipe BizHawk SaveRAM.
+	var req struct {
+		KeepSaveRAM bool `json:"keep_saveram"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 	savesDir := "./saves"
 	if _, err := os.Stat(savesDir); err == nil {
 		trash := fmt.Sprintf("%s.trash.%d", savesDir, time.Now().Unix())
@@ -60,23 +135,18 @@ func (s *Server) apiClearSaves(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	_ = os.MkdirAll(savesDir, 0755)
-	s.broadcastToPlayers(protocol.Command{Cmd: protocol.CmdClearSaves, ID: fmt.Sprintf("%d", time.Now().UnixNano())})
+	s.broadcastToPlayers(protocol.Command{
+		Cmd:     protocol.CmdClearSaves,
+		Payload: map[string]any{"keep_saveram": req.KeepSaveRAM},
+		ID:      fmt.Sprintf("%d", time.Now().UnixNano()),
+	})
 	if _, err := w.Write([]byte("ok")); err != nil {
 		fmt.Printf("write response error: %v\n", err)
 	}
 }
 
 func (s *Server) apiToggleSwaps(w http.ResponseWriter, r *http.Request) {
-	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
-		st.SwapEnabled = !st.SwapEnabled
-		if !st.SwapEnabled {
-			st.NextSwapAt = 0
-		}
-	})
-	select {
-	case s.schedulerCh <- struct{}{}:
-	default:
-	}
+	s.setSwapEnabled(func(enabled bool) bool { return !enabled })
 	if _, err := w.Write([]byte("ok")); err != nil {
 		fmt.Printf("write response error: %v\n", err)
 	}
@@ -100,6 +170,26 @@ func (s *Server) apiToggleCountdown(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// apiTogglePerPlayerSaves toggles whether save mode keeps a save per
+// (instance, player) rather than one per instance.
+func (s *Server) apiTogglePerPlayerSaves(w http.ResponseWriter, r *http.Request) {
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.PerPlayerSaves = !st.PerPlayerSaves
+	})
+	if _, err := w.Write([]byte("ok")); err != nil {
+		fmt.Printf("write response error: %v\n", err)
+	}
+}
+
+func (s *Server) apiToggleLobby(w http.ResponseWriter, r *http.Request) {
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.LobbyEnabled = !st.LobbyEnabled
+	})
+	if _, err := w.Write([]byte("ok")); err != nil {
+		fmt.Printf("write response error: %v\n", err)
+	}
+}
+
 // apiMode sets or reads the swap mode
 func (s *Server) apiMode(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet {
@@ -118,8 +208,98 @@ func (s *Server) apiMode(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
 			return
 		}
+		if err := s.setMode(b.Mode); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := w.Write([]byte("ok")); err != nil {
+			fmt.Printf("write response error: %v\n", err)
+		}
+		return
+	}
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
+
+// setMode switches the swap mode, clearing race and bingo winners when it
+// changes.
+func (s *Server) setMode(mode protocol.GameMode) error {
+	if !knownGameMode(mode) {
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		if st.Mode != mode {
+			st.RaceWinner = ""
+			st.BingoWinners = nil
+		}
+		st.Mode = mode
+	})
+	return nil
+}
+
+// apiOrderMode sets or reads how the next game is picked (random or sequential)
+func (s *Server) apiOrderMode(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		var order protocol.OrderMode
+		s.withRLock(func() { order = s.state.OrderMode })
+		if order == "" {
+			order = protocol.OrderModeRandom
+		}
+		if err := json.NewEncoder(w).Encode(map[string]any{"order_mode": order}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method == http.MethodPost {
+		var b struct {
+			OrderMode protocol.OrderMode `json:"order_mode"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if b.OrderMode != protocol.OrderModeRandom && b.OrderMode != protocol.OrderModeSequential {
+			http.Error(w, "invalid order_mode", http.StatusBadRequest)
+			return
+		}
+		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			st.OrderMode = b.OrderMode
+		})
+		if _, err := w.Write([]byte("ok")); err != nil {
+			fmt.Printf("write response error: %v\n", err)
+		}
+		return
+	}
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
+
+// apiSwapStrategy sets or reads how save-mode full swaps assign instances
+// (round_robin or derangement)
+func (s *Server) apiSwapStrategy(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		var strategy protocol.SwapStrategy
+		s.withRLock(func() { strategy = s.state.SwapStrategy })
+		if strategy == "" {
+			strategy = protocol.SwapStrategyRoundRobin
+		}
+		if err := json.NewEncoder(w).Encode(map[string]any{"swap_strategy": strategy}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method == http.MethodPost {
+		var b struct {
+			SwapStrategy protocol.SwapStrategy `json:"swap_strategy"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if b.SwapStrategy != protocol.SwapStrategyRoundRobin && b.SwapStrategy != protocol.SwapStrategyDerangement {
+			http.Error(w, "invalid swap_strategy", http.StatusBadRequest)
+			return
+		}
 		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
-			st.Mode = b.Mode
+			st.SwapStrategy = b.SwapStrategy
 		})
 		if _, err := w.Write([]byte("ok")); err != nil {
 			fmt.Printf("write response error: %v\n", err)
@@ -145,9 +325,7 @@ func (s *Server) apiModeSetup(w http.ResponseWriter, r *http.Request) {
 		games := st.MainGames
 		for _, f := range files {
 			// if game not in catalog or is an extra file, add it
-			if !slices.ContainsFunc(games, func(g protocol.GameEntry) bool {
-				return g.File == f || slices.Contains(g.ExtraFiles, f)
-			}) {
+			if !catalogCovers(games, f) {
 				fmt.Println("Adding game to catalog:", f)
 				games = append(games, protocol.GameEntry{File: f})
 			}
@@ -179,6 +357,44 @@ func (s *Server) apiDoSwap(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// apiSwapPreview reports the assignment a full save-mode swap would make,
+// without touching state or notifying clients.
+func (s *Server) apiSwapPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h, ok := s.GetGameModeHandler().(*SaveModeHandler)
+	if !ok {
+		http.Error(w, "swap preview is only available in save mode", http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.PreviewSwap()); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// apiSwapRepair handles POST /api/swap/repair: clears duplicate instance
+// assignments and gives each displaced player a random swap in the background.
+func (s *Server) apiSwapRepair(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	displaced := s.repairInstanceAssignments()
+	if len(displaced) > 0 {
+		go s.reassignPlayers(displaced)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"result":    "ok",
+		"displaced": append([]string{}, displaced...),
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
 func (s *Server) apiRandomSwapForPlayer(w http.ResponseWriter, r *http.Request) {
 	var b struct {
 		PlayerName string `json:"player"`
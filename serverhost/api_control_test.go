@@ -4,7 +4,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/michael4d45/bizshuffle/protocol"
 )
@@ -56,3 +58,85 @@ func TestAPIToggleSwapsFlipsFlag(t *testing.T) {
 		t.Fatal("expected swap disabled")
 	}
 }
+
+func TestAPIPlayerIntervalSetsOverride(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["streamer"] = protocol.Player{Name: "streamer"}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	res, err := http.Post(srv.URL+"/api/players/streamer/interval", "application/json",
+		strings.NewReader(`{"min_interval_secs":30,"max_interval_secs":60}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", res.StatusCode)
+	}
+	p := s.SnapshotState().Players["streamer"]
+	if p.MinIntervalSecs != 30 || p.MaxIntervalSecs != 60 {
+		t.Fatalf("override not stored: %+v", p)
+	}
+	now := time.Now().Unix()
+	if p.NextSwapAt < now+29 || p.NextSwapAt > now+61 {
+		t.Fatalf("next swap %d outside override range from %d", p.NextSwapAt, now)
+	}
+
+	res, err = http.Post(srv.URL+"/api/players/ghost/interval", "application/json",
+		strings.NewReader(`{"min_interval_secs":30}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown player, got %d", res.StatusCode)
+	}
+}
+
+func TestAPIPauseAllAndResumeAll(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Running = true
+		st.Players = map[string]protocol.Player{
+			"amy":   {Name: "amy", Connected: true},
+			"bob":   {Name: "bob", Connected: true},
+			"carol": {Name: "carol"},
+		}
+	})
+	amy := registerPlayerWSClient(s, "amy")
+
+	rec := httptest.NewRecorder()
+	s.apiPauseAll(rec, httptest.NewRequest(http.MethodPost, "/api/players/pause_all", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pause_all status %d body %s", rec.Code, rec.Body)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"players":["amy"]`) || !strings.Contains(body, `"bob"`) {
+		t.Fatalf("pause_all body %s", body)
+	}
+	if cmd := <-amy.sendCh; cmd.Cmd != protocol.CmdPause {
+		t.Fatalf("amy got %q, want pause", cmd.Cmd)
+	}
+	if st := s.SnapshotState(); !st.Paused || st.Running {
+		t.Fatalf("after pause_all paused=%v running=%v", st.Paused, st.Running)
+	}
+
+	rec = httptest.NewRecorder()
+	s.apiResumeAll(rec, httptest.NewRequest(http.MethodPost, "/api/players/resume_all", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("resume_all status %d", rec.Code)
+	}
+	if cmd := <-amy.sendCh; cmd.Cmd != protocol.CmdResume {
+		t.Fatalf("amy got %q, want start", cmd.Cmd)
+	}
+	if st := s.SnapshotState(); st.Paused || !st.Running {
+		t.Fatalf("after resume_all paused=%v running=%v", st.Paused, st.Running)
+	}
+}
@@ -0,0 +1,63 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Stable error codes carried in API error responses. Clients should branch on
+// the code; the message is for people and may change.
+const (
+	errCodeMethodNotAllowed   = "method_not_allowed"
+	errCodeBadJSON            = "bad_json"
+	errCodeInvalidPath        = "invalid_path"
+	errCodeInvalidAction      = "invalid_action"
+	errCodeMissingField       = "missing_field"
+	errCodeInvalidValue       = "invalid_value"
+	errCodeNotFound           = "not_found"
+	errCodePlayerNotFound     = "player_not_found"
+	errCodePlayerNotConnected = "player_not_connected"
+	errCodeInstanceNotFound   = "instance_not_found"
+	errCodeConflict           = "conflict"
+	errCodeTimeout            = "timeout"
+	errCodeClientFailed       = "client_failed"
+	errCodeInternal           = "internal"
+	errCodeNotImplemented     = "not_implemented"
+)
+
+// apiError is the body of every API error response:
+// {"error": {"code": "...", "message": "..."}}.
+type apiError struct {
+	Error apiErrorBody `json:"error"`
+}
+
+type apiErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeAPIError replies with status and a JSON error envelope. It replaces
+// http.Error in API handlers so the admin UI can tell errors apart by code.
+func writeAPIError(w http.ResponseWriter, status int, code, msg string) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(apiError{Error: apiErrorBody{Code: code, Message: msg}}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// playerErrorCode maps the player sentinel errors to their API codes.
+func playerErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrPlayerNotFound):
+		return errCodePlayerNotFound
+	case errors.Is(err, ErrPlayerNotConnected), errors.Is(err, ErrPlayerDisconnected):
+		return errCodePlayerNotConnected
+	}
+	return errCodeConflict
+}
@@ -0,0 +1,45 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrorEnvelope(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	decode := func(rec *httptest.ResponseRecorder) apiError {
+		t.Helper()
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("Content-Type = %q", ct)
+		}
+		var body apiError
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decode error body: %v", err)
+		}
+		return body
+	}
+
+	rec := httptest.NewRecorder()
+	s.apiSwapPlayer(rec, httptest.NewRequest(http.MethodGet, "/api/swap_player", nil))
+	if body := decode(rec); rec.Code != http.StatusMethodNotAllowed || body.Error.Code != errCodeMethodNotAllowed {
+		t.Fatalf("GET swap_player: %d %+v", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.apiPlayerSwap(rec, httptest.NewRequest(http.MethodPost, "/api/players/ghost/swap", nil), "ghost")
+	if body := decode(rec); rec.Code != http.StatusNotFound || body.Error.Code != errCodePlayerNotFound || body.Error.Message == "" {
+		t.Fatalf("swap unknown player: %d %+v", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handlePlayerCompletedRoutes(rec, httptest.NewRequest(http.MethodPost, "/api/players/ghost/config_profile", strings.NewReader(`{"profile":"gb"}`)))
+	if body := decode(rec); rec.Code != http.StatusNotFound || body.Error.Code != errCodePlayerNotFound {
+		t.Fatalf("config_profile unknown player: %d %+v", rec.Code, body)
+	}
+}
@@ -3,6 +3,7 @@ package serverhost
 import (
 	"archive/zip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,8 +12,12 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
 )
 
 // handleFiles serves files under ./roms
@@ -28,34 +33,47 @@ func (s *Server) handlePluginFiles(w http.ResponseWriter, r *http.Request) {
 // handleUpload receives multipart file upload and writes to ./roms directory
 func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	err := r.ParseMultipartForm(32 << 20)
-	if err != nil {
-		http.Error(w, "parse multipart: "+err.Error(), http.StatusBadRequest)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
-	file, header, err := r.FormFile("file")
+	// Stream the file part to disk rather than buffering it in memory; ROMs
+	// and ROM zips can be far larger than any sensible in-memory limit.
+	upload, err := readMultipartUpload(r, "file", 0)
 	if err != nil {
-		http.Error(w, "file missing: "+err.Error(), http.StatusBadRequest)
+		writeUploadError(w, err, "file missing")
 		return
 	}
-	defer func() { _ = file.Close() }()
+	defer upload.Close()
 	dstDir := "./roms"
 	if err := os.MkdirAll(dstDir, 0755); err != nil {
-		http.Error(w, "failed to create roms dir: "+err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "failed to create roms dir: "+err.Error())
 		return
 	}
-	dstPath := filepath.Join(dstDir, filepath.Base(header.Filename))
-	out, err := os.Create(dstPath)
-	if err != nil {
-		http.Error(w, "create file: "+err.Error(), http.StatusInternalServerError)
+	// Zipped ROMs are valid games, so a zip is only unpacked when asked to.
+	if extract, _ := strconv.ParseBool(upload.FormValue("extract")); extract {
+		if !isZipUpload(upload.File, upload.Filename) {
+			writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "extract requested but upload is not a zip")
+			return
+		}
+		zr, err := zip.NewReader(upload.File, upload.Size)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "read zip: "+err.Error())
+			return
+		}
+		files, err := extractRomZip(zr, dstDir)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "extract zip: "+err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{"result": "ok", "files": files}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
 		return
 	}
-	defer func() { _ = out.Close() }()
-	if _, err := io.Copy(out, file); err != nil {
-		http.Error(w, "write file: "+err.Error(), http.StatusInternalServerError)
+	dstPath := filepath.Join(dstDir, filepath.Base(upload.Filename))
+	if err := upload.Keep(dstPath); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "write file: "+err.Error())
 		return
 	}
 	if _, err := w.Write([]byte("ok")); err != nil {
@@ -63,22 +81,110 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// isZipUpload reports whether an upload is a zip archive by extension or by
+// its local-file-header signature.
+func isZipUpload(file io.ReaderAt, name string) bool {
+	if strings.EqualFold(filepath.Ext(name), ".zip") {
+		return true
+	}
+	magic := make([]byte, 4)
+	n, _ := file.ReadAt(magic, 0)
+	return n == 4 && string(magic) == "PK\x03\x04"
+}
+
+// extractRomZip writes the files in zr under destDir and returns their paths
+// relative to destDir (forward slashes). Every entry is checked before anything
+// is written, so an archive with a path escaping destDir extracts nothing.
+func extractRomZip(zr *zip.Reader, destDir string) ([]string, error) {
+	root := filepath.Clean(destDir) + string(os.PathSeparator)
+	for _, f := range zr.File {
+		fpath := filepath.Join(destDir, f.Name)
+		if !strings.HasPrefix(fpath, root) {
+			return nil, fmt.Errorf("illegal file path: %s", f.Name)
+		}
+	}
+	files := []string{}
+	for _, f := range zr.File {
+		fpath := filepath.Join(destDir, f.Name)
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(fpath, 0755); err != nil {
+				return files, err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			return files, err
+		}
+		if err := extractZipEntry(f, fpath); err != nil {
+			return files, err
+		}
+		rel, _ := filepath.Rel(destDir, fpath)
+		files = append(files, filepath.ToSlash(rel))
+	}
+	return files, nil
+}
+
+func extractZipEntry(f *zip.File, fpath string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+	out, err := os.Create(fpath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 // handleFilesList returns a JSON list of files under ./roms
 func (s *Server) handleFilesList(w http.ResponseWriter, r *http.Request) {
 	files, err := s.getFilesList()
 	if err != nil {
-		http.Error(w, "failed to list files: "+err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "failed to list files: "+err.Error())
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(files); err != nil {
-		http.Error(w, "failed to encode files list: "+err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "failed to encode files list: "+err.Error())
 		return
 	}
 }
 
+// handleFilesTree serves GET /api/files/tree?dir=: every file under roms/{dir},
+// recursively, so clients can mirror a game's extra_dirs.
+func (s *Server) handleFilesTree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	dir := r.URL.Query().Get("dir")
+	files, err := romDirFiles(dir)
+	if err != nil {
+		status, code := http.StatusBadRequest, errCodeInvalidValue
+		if errors.Is(err, errRomNotFound) {
+			status, code = http.StatusNotFound, errCodeNotFound
+		}
+		writeAPIError(w, status, code, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"dir": dir, "files": files}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// getFilesList returns every file under ./roms; a missing or empty roms
+// directory yields an empty list.
 func (s *Server) getFilesList() ([]string, error) {
 	files := []string{}
+	if _, err := os.Stat("./roms"); os.IsNotExist(err) {
+		return files, nil
+	}
 	if err := filepath.Walk("./roms", func(p string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
@@ -106,7 +212,7 @@ func (s *Server) handleBizhawkFilesZip(w http.ResponseWriter, r *http.Request) {
 	}
 	dir := filepath.Join("./web", "BizhawkFiles")
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		http.Error(w, "BizhawkFiles not found", http.StatusNotFound)
+		writeAPIError(w, http.StatusNotFound, errCodeNotFound, "BizhawkFiles not found")
 		return
 	}
 
@@ -165,7 +271,7 @@ func (s *Server) handleBizhawkFilesZip(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Disposition", "attachment; filename=BizhawkFiles.zip")
 	if err := zipDir(dir, w); err != nil {
 		log.Printf("failed to stream BizhawkFiles.zip: %v", err)
-		http.Error(w, "failed to create zip", http.StatusInternalServerError)
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "failed to create zip")
 		return
 	}
 }
@@ -232,26 +338,26 @@ func (s *Server) launchInFileManager(path string) error {
 
 func (s *Server) handleOpenFolder(w http.ResponseWriter, r *http.Request, relDir, label string) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	absDir, err := filepath.Abs(relDir)
 	if err != nil {
-		http.Error(w, "failed to resolve "+label+" directory: "+err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "failed to resolve "+label+" directory: "+err.Error())
 		return
 	}
 	if err := os.MkdirAll(absDir, 0755); err != nil {
-		http.Error(w, "failed to create "+label+" directory: "+err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "failed to create "+label+" directory: "+err.Error())
 		return
 	}
 	if err := s.launchInFileManager(absDir); err != nil {
 		if strings.HasPrefix(err.Error(), "unsupported platform:") {
-			http.Error(w, err.Error(), http.StatusNotImplemented)
+			writeAPIError(w, http.StatusNotImplemented, errCodeNotImplemented, err.Error())
 			return
 		}
 		log.Printf("Failed to open %s folder: %v", label, err)
-		http.Error(w, "failed to open folder: "+err.Error(), http.StatusInternalServerError)
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "failed to open folder: "+err.Error())
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -269,3 +375,168 @@ func (s *Server) handleOpenRomsFolder(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleOpenPluginsFolder(w http.ResponseWriter, r *http.Request) {
 	s.handleOpenFolder(w, r, "./plugins", "plugins")
 }
+
+var (
+	errRomNotFound = errors.New("rom file not found")
+	errRomExists   = errors.New("rom file already exists")
+	errRomInUse    = errors.New("rom file is referenced by the catalog")
+)
+
+// romFilePath resolves name (slash-separated, relative to ./roms) and rejects
+// anything that would land outside ./roms.
+func romFilePath(name string) (string, error) {
+	root := filepath.Clean("./roms")
+	p := filepath.Join(root, filepath.FromSlash(name))
+	if name == "" || !strings.HasPrefix(p, root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	return p, nil
+}
+
+// romReferences lists where the catalog still uses file, e.g. "main_games".
+func romReferences(st *protocol.ServerState, file string) []string {
+	var refs []string
+	if catalogCovers(st.MainGames, file) {
+		refs = append(refs, "main_games")
+	}
+	if slices.Contains(st.Games, file) {
+		refs = append(refs, "games")
+	}
+	if slices.ContainsFunc(st.GameSwapInstances, func(inst protocol.GameSwapInstance) bool { return inst.Game == file }) {
+		refs = append(refs, "game_instances")
+	}
+	return refs
+}
+
+func romErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errRomNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errRomExists), errors.Is(err, errRomInUse):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// romErrorCode is the API error code matching romErrorStatus.
+func romErrorCode(err error) string {
+	switch {
+	case errors.Is(err, errRomNotFound):
+		return errCodeNotFound
+	case errors.Is(err, errRomExists), errors.Is(err, errRomInUse):
+		return errCodeConflict
+	}
+	return errCodeInternal
+}
+
+// apiFiles handles DELETE /api/files?name=... for a ROM no longer in the catalog.
+func (s *Server) apiFiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	name := r.URL.Query().Get("name")
+	path, err := romFilePath(name)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, err.Error())
+		return
+	}
+	if err := s.deleteRom(name, path); err != nil {
+		writeAPIError(w, romErrorStatus(err), romErrorCode(err), err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+func (s *Server) deleteRom(name, path string) error {
+	var refs []string
+	s.withRLock(func() { refs = romReferences(&s.state, name) })
+	if len(refs) > 0 {
+		return fmt.Errorf("%s (%s): %w", name, strings.Join(refs, ", "), errRomInUse)
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return fmt.Errorf("%s: %w", name, errRomNotFound)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("delete %s: %w", name, err)
+	}
+	return nil
+}
+
+// apiRenameFile handles POST /api/files/rename {from, to}, moving the ROM and
+// rewriting every catalog and player reference to it.
+func (s *Server) apiRenameFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	var b struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
+		return
+	}
+	from, err := romFilePath(b.From)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, err.Error())
+		return
+	}
+	to, err := romFilePath(b.To)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, err.Error())
+		return
+	}
+	if err := s.renameRom(b.From, b.To, from, to); err != nil {
+		writeAPIError(w, romErrorStatus(err), romErrorCode(err), err.Error())
+		return
+	}
+	s.broadcastGamesUpdate(nil)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+func (s *Server) renameRom(oldName, newName, oldPath, newPath string) error {
+	if oldName == newName {
+		return nil
+	}
+	var listed bool
+	s.withRLock(func() { listed = gameEntryHasFile(s.state.MainGames, newName) })
+	if listed {
+		return fmt.Errorf("%s is already in the catalog: %w", newName, errRomExists)
+	}
+	if info, err := os.Stat(oldPath); err != nil || info.IsDir() {
+		return fmt.Errorf("%s: %w", oldName, errRomNotFound)
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("%s: %w", newName, errRomExists)
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("rename %s: %w", oldName, err)
+	}
+
+	var result error
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		// The catalog may have changed while the file was being moved.
+		if gameEntryHasFile(st.MainGames, newName) {
+			result = fmt.Errorf("%s is already in the catalog: %w", newName, errRomExists)
+			return
+		}
+		s.migrateGameFile(st, oldName, newName)
+	})
+	if result != nil {
+		if err := os.Rename(newPath, oldPath); err != nil {
+			fmt.Printf("restore %s after failed rename: %v\n", oldName, err)
+		}
+	}
+	return result
+}
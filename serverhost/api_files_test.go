@@ -0,0 +1,296 @@
+package serverhost
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func zipBytes(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte("rom:" + name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func uploadRequest(t *testing.T, filename string, data []byte, extract bool) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if extract {
+		if err := mw.WriteField("extract", "true"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestHandleUploadExtractsZip(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	rec := httptest.NewRecorder()
+	s.handleUpload(rec, uploadRequest(t, "set.bin", zipBytes(t, "a.nes", "snes/b.sfc"), true))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	var out struct {
+		Files []string `json:"files"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Files) != 2 || out.Files[0] != "a.nes" || out.Files[1] != "snes/b.sfc" {
+		t.Fatalf("files = %v", out.Files)
+	}
+	if data, err := os.ReadFile(filepath.Join("roms", "snes", "b.sfc")); err != nil || string(data) != "rom:snes/b.sfc" {
+		t.Fatalf("extracted file: %q, %v", data, err)
+	}
+}
+
+func TestHandleUploadRejectsZipTraversal(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	rec := httptest.NewRecorder()
+	s.handleUpload(rec, uploadRequest(t, "set.zip", zipBytes(t, "ok.nes", "../evil.nes"), true))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	for _, p := range []string{"evil.nes", filepath.Join("roms", "ok.nes")} {
+		if _, err := os.Stat(p); err == nil {
+			t.Fatalf("%s written despite illegal entry", p)
+		}
+	}
+}
+
+func TestHandleUploadKeepsZipRomWithoutExtract(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	data := zipBytes(t, "game.nes")
+	rec := httptest.NewRecorder()
+	s.handleUpload(rec, uploadRequest(t, "game.zip", data, false))
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	if got, err := os.ReadFile(filepath.Join("roms", "game.zip")); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("zip rom not stored as-is: %v", err)
+	}
+}
+
+// streamingUploadRequest builds a multipart request whose file part is size
+// bytes of synthetic data, generated on the fly so the body is never held in
+// memory by the test either.
+func streamingUploadRequest(t *testing.T, target, field, filename string, size int64) *http.Request {
+	t.Helper()
+	pr, pw := io.Pipe()
+	// Unblock the writer if the handler stops reading early.
+	t.Cleanup(func() { _ = pr.Close() })
+	mw := multipart.NewWriter(pw)
+	go func() {
+		fw, err := mw.CreateFormFile(field, filename)
+		if err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+		chunk := bytes.Repeat([]byte{0xA5}, 64<<10)
+		for left := size; left > 0; {
+			n := min(left, int64(len(chunk)))
+			if _, err := fw.Write(chunk[:n]); err != nil {
+				_ = pw.CloseWithError(err)
+				return
+			}
+			left -= n
+		}
+		_ = pw.CloseWithError(mw.Close())
+	}()
+	req := httptest.NewRequest(http.MethodPost, target, pr)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestHandleUploadStreamsLargeFileWithBoundedMemory(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	const size = 100 << 20
+	req := streamingUploadRequest(t, "/upload", "file", "big.bin", size)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	rec := httptest.NewRecorder()
+	s.handleUpload(rec, req)
+	runtime.ReadMemStats(&after)
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 16<<20 {
+		t.Fatalf("upload allocated %d bytes for a %d byte file", alloc, size)
+	}
+	if fi, err := os.Stat(filepath.Join("roms", "big.bin")); err != nil || fi.Size() != size {
+		t.Fatalf("stored rom: %v, %v", fi, err)
+	}
+	if entries, _ := os.ReadDir(uploadTmpDir); len(entries) != 0 {
+		t.Fatalf("temp files left behind: %v", entries)
+	}
+}
+
+func TestHandleSaveUploadRejectsOversizedStream(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	rec := httptest.NewRecorder()
+	s.handleSaveUpload(rec, streamingUploadRequest(t, "/save/upload", "save", "big.state", saveUploadMaxBytes+1))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	if _, err := os.Stat(filepath.Join("saves", "big.state")); err == nil {
+		t.Fatal("oversized save written")
+	}
+	if entries, _ := os.ReadDir(uploadTmpDir); len(entries) != 0 {
+		t.Fatalf("temp files left behind: %v", entries)
+	}
+}
+
+func TestAPIFilesDeleteRefusesCatalogReference(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	for _, f := range []string{"used.nes", "stray.nes"} {
+		if err := os.WriteFile(filepath.Join("roms", f), []byte("rom"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.MainGames = []protocol.GameEntry{{File: "used.nes"}}
+	})
+
+	del := func(name string) int {
+		rec := httptest.NewRecorder()
+		s.apiFiles(rec, httptest.NewRequest(http.MethodDelete, "/api/files?name="+name, nil))
+		return rec.Code
+	}
+	if code := del("used.nes"); code != http.StatusConflict {
+		t.Fatalf("delete referenced: status %d", code)
+	}
+	if code := del("..%2Fstate.json"); code != http.StatusBadRequest {
+		t.Fatalf("delete outside roms: status %d", code)
+	}
+	if code := del("stray.nes"); code != http.StatusOK {
+		t.Fatalf("delete stray: status %d", code)
+	}
+	if code := del("stray.nes"); code != http.StatusNotFound {
+		t.Fatalf("delete again: status %d", code)
+	}
+	if _, err := os.Stat(filepath.Join("roms", "used.nes")); err != nil {
+		t.Fatalf("referenced rom removed: %v", err)
+	}
+}
+
+func TestAPIRenameFileUpdatesReferences(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	if err := os.WriteFile(filepath.Join("roms", "zeldaa.nes"), []byte("rom"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.MainGames = []protocol.GameEntry{{File: "zeldaa.nes"}}
+		st.Games = []string{"zeldaa.nes"}
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "z1", Game: "zeldaa.nes"}}
+		st.Players["amy"] = protocol.Player{Name: "amy", Game: "zeldaa.nes", InstanceID: "z1", CompletedGames: []string{"zeldaa.nes"}}
+	})
+	s.recordSwapApplied("amy", s.SnapshotState().Players["amy"])
+
+	rename := func(body string) int {
+		rec := httptest.NewRecorder()
+		s.apiRenameFile(rec, httptest.NewRequest(http.MethodPost, "/api/files/rename", strings.NewReader(body)))
+		return rec.Code
+	}
+	if code := rename(`{"from":"zeldaa.nes","to":"../zelda.nes"}`); code != http.StatusBadRequest {
+		t.Fatalf("rename outside roms: status %d", code)
+	}
+	if code := rename(`{"from":"zeldaa.nes","to":"nes/zelda.nes"}`); code != http.StatusOK {
+		t.Fatalf("rename: status %d", code)
+	}
+	if _, err := os.Stat(filepath.Join("roms", "nes", "zelda.nes")); err != nil {
+		t.Fatal(err)
+	}
+
+	st := s.SnapshotState()
+	amy := st.Players["amy"]
+	if st.MainGames[0].File != "nes/zelda.nes" || st.Games[0] != "nes/zelda.nes" || st.GameSwapInstances[0].Game != "nes/zelda.nes" {
+		t.Fatalf("catalog not updated: %+v %v %+v", st.MainGames, st.Games, st.GameSwapInstances)
+	}
+	if amy.Game != "nes/zelda.nes" || amy.CompletedGames[0] != "nes/zelda.nes" {
+		t.Fatalf("player not updated: %+v", amy)
+	}
+	if s.ShouldSendSwap(amy, false) {
+		t.Fatal("rename alone should not trigger a swap resend")
+	}
+	if code := rename(`{"from":"zeldaa.nes","to":"other.nes"}`); code != http.StatusNotFound {
+		t.Fatalf("rename missing: status %d", code)
+	}
+}
+
+func TestNewCreatesDirsAndListsEmptyRoms(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	for _, dir := range serverDirs {
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			t.Fatalf("%s not created: %v", dir, err)
+		}
+	}
+
+	// A roms dir removed after startup still lists as empty.
+	if err := os.Remove("./roms"); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.handleFilesList(rec, httptest.NewRequest(http.MethodGet, "/files/list.json", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("status %d body %q", rec.Code, rec.Body)
+	}
+}
@@ -3,21 +3,26 @@ package serverhost
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/michael4d45/bizshuffle/protocol"
 )
 
 // apiGames: GET returns games, POST accepts JSON body {"games":[...]}
+// main_games entries may carry an optional "weight" (default 1) used by random selection
+// and "urls"/"checksums" for downloads from an external host.
+// Renamed ROMs (an optional "renames" {from: to}, or matching checksums) keep
+// their instances, players and completions; see migrateGameFile.
 func (s *Server) apiGames(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet {
 		games, mainGames, gameInstances := s.SnapshotGames()
 		resp := map[string]any{"main_games": mainGames, "game_instances": gameInstances, "games": games}
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+			writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "failed to encode response: "+err.Error())
 			return
 		}
 		return
@@ -25,9 +30,33 @@ func (s *Server) apiGames(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodPost {
 		var raw map[string]any
 		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
-			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
 			return
 		}
+		if mg, ok := raw["main_games"]; ok {
+			b, _ := json.Marshal(mg)
+			var entries []protocol.GameEntry
+			if err := json.Unmarshal(b, &entries); err != nil {
+				writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "invalid main_games: "+err.Error())
+				return
+			}
+			for _, e := range entries {
+				if e.Weight < 0 {
+					writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "weight must not be negative: "+e.File)
+					return
+				}
+				if e.MaxSecondsPerGame < 0 {
+					writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "max_seconds_per_game must not be negative: "+e.File)
+					return
+				}
+				for file, u := range e.URLs {
+					if !validExternalURL(u) {
+						writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "url must be an absolute http(s) URL: "+file)
+						return
+					}
+				}
+			}
+		}
 		// Mutate state and persist via helper to centralize UpdatedAt + save
 		// First, capture old state to detect removals
 		var oldMainGames []protocol.GameEntry
@@ -39,6 +68,31 @@ func (s *Server) apiGames(w http.ResponseWriter, r *http.Request) {
 			copy(oldInstances, s.state.GameSwapInstances)
 		})
 
+		// Renamed ROMs: explicit "renames" {from: to} plus new main files whose
+		// checksum matches a dropped one. Their references are migrated below.
+		renames := make(map[string]string)
+		if mg, ok := raw["main_games"]; ok {
+			b, _ := json.Marshal(mg)
+			var entries []protocol.GameEntry
+			if err := json.Unmarshal(b, &entries); err == nil {
+				maps.Copy(renames, s.detectGameRenames(oldMainGames, entries))
+			}
+		}
+		if rn, ok := raw["renames"]; ok {
+			b, _ := json.Marshal(rn)
+			var explicit map[string]string
+			if err := json.Unmarshal(b, &explicit); err != nil {
+				writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "renames must map old to new file names")
+				return
+			}
+			for from, to := range explicit {
+				if from != "" && to != "" && from != to {
+					renames[from] = to
+				}
+			}
+		}
+
+		renamed := make(map[string]string)
 		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 			if gms, ok := raw["games"]; ok {
 				b, _ := json.Marshal(gms)
@@ -104,7 +158,7 @@ func (s *Server) apiGames(w http.ResponseWriter, r *http.Request) {
 					}
 					// Find removed game files from MainGames
 					for oldGame := range oldGameFiles {
-						if !newGameFiles[oldGame] {
+						if !newGameFiles[oldGame] && renames[oldGame] == "" {
 							removedGames[oldGame] = true
 						}
 					}
@@ -144,74 +198,128 @@ func (s *Server) apiGames(w http.ResponseWriter, r *http.Request) {
 					st.GameSwapInstances = instances
 				}
 			}
+			// Report only the renames that had something to migrate.
+			for from, to := range renames {
+				if gameReferenced(st, from) {
+					s.migrateGameFile(st, from, to)
+					renamed[from] = to
+				}
+			}
 		})
-		s.broadcastToPlayers(protocol.Command{Cmd: protocol.CmdGamesUpdate, Payload: map[string]any{
-			"game_instances": s.state.GameSwapInstances,
-			"main_games":     s.state.MainGames,
-			"games":          s.state.Games,
-		}, ID: fmt.Sprintf("%d", time.Now().UnixNano())})
-		if _, err := w.Write([]byte("ok")); err != nil {
-			fmt.Printf("write response error: %v\n", err)
+		s.broadcastGamesUpdate(nil)
+
+		// Missing files are kept (the admin may upload them next) but reported.
+		var referenced []string
+		var mainGames []protocol.GameEntry
+		s.withRLock(func() {
+			if _, ok := raw["main_games"]; ok {
+				referenced = append(referenced, catalogFiles(s.state.MainGames)...)
+				mainGames = slices.Clone(s.state.MainGames)
+			}
+			if _, ok := raw["games"]; ok {
+				referenced = append(referenced, s.state.Games...)
+			}
+			if _, ok := raw["game_instances"]; ok {
+				for _, inst := range s.state.GameSwapInstances {
+					referenced = append(referenced, inst.Game)
+				}
+			}
+		})
+		missingDirs := missingRomDirs(mainGames)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{"result": "ok", "missing_files": append(missingRoms(referenced), missingDirs...), "renamed": renamed}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
 		}
 		return
 	}
-	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 }
 
-// apiInterval: GET/POST to view or set interval seconds
+// apiInterval: GET/POST to view or set interval seconds and how delays are
+// drawn from that range. 0 (or an empty distribution) keeps the current value.
 func (s *Server) apiInterval(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet {
 		var minv, maxv int
+		var dist protocol.IntervalDistribution
 		s.withRLock(func() {
 			minv = s.state.MinIntervalSecs
 			maxv = s.state.MaxIntervalSecs
+			dist = s.state.IntervalDistribution
 		})
-		if err := json.NewEncoder(w).Encode(map[string]any{"min_interval_secs": minv, "max_interval_secs": maxv}); err != nil {
+		if dist == "" {
+			dist = protocol.IntervalDistributionUniform
+		}
+		if err := json.NewEncoder(w).Encode(map[string]any{"min_interval_secs": minv, "max_interval_secs": maxv, "interval_distribution": dist}); err != nil {
 			fmt.Printf("encode response error: %v\n", err)
 		}
 		return
 	}
 	if r.Method == http.MethodPost {
 		var b struct {
-			MinInterval int `json:"min_interval_secs"`
-			MaxInterval int `json:"max_interval_secs"`
+			MinInterval  int                           `json:"min_interval_secs"`
+			MaxInterval  int                           `json:"max_interval_secs"`
+			Distribution protocol.IntervalDistribution `json:"interval_distribution"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
 			return
 		}
+		if b.MinInterval < 0 || b.MaxInterval < 0 {
+			writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "min_interval_secs and max_interval_secs must not be negative")
+			return
+		}
+		if !knownIntervalDistribution(b.Distribution) {
+			writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, fmt.Sprintf("unknown interval_distribution %q (want uniform or front_loaded)", b.Distribution))
+			return
+		}
+		// Validate the range that results from merging with the current values,
+		// so setting only one bound cannot invert it.
+		var rangeErr error
 		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			minv, maxv := st.MinIntervalSecs, st.MaxIntervalSecs
 			if b.MinInterval != 0 {
-				st.MinIntervalSecs = b.MinInterval
+				minv = b.MinInterval
 			}
 			if b.MaxInterval != 0 {
-				st.MaxIntervalSecs = b.MaxInterval
+				maxv = b.MaxInterval
+			}
+			if minv > 0 && maxv > 0 && minv > maxv {
+				rangeErr = fmt.Errorf("min_interval_secs (%d) must not exceed max_interval_secs (%d)", minv, maxv)
+				return
+			}
+			st.MinIntervalSecs, st.MaxIntervalSecs = minv, maxv
+			if b.Distribution != "" {
+				st.IntervalDistribution = b.Distribution
 			}
 		})
+		if rangeErr != nil {
+			writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, rangeErr.Error())
+			return
+		}
 		if _, err := w.Write([]byte("ok")); err != nil {
 			fmt.Printf("write response error: %v\n", err)
 		}
 		return
 	}
-	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 }
 
 // apiMarkGameCompletedForAll: POST /api/games/{game}/mark_completed_all
 // Marks the specified game as completed for all players
 func (s *Server) apiMarkGameCompletedForAll(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	// Parse game from path: /api/games/{game}/mark_completed_all
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 4 || pathParts[0] != "api" || pathParts[1] != "games" || pathParts[3] != "mark_completed_all" {
-		http.Error(w, "invalid path", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
 		return
 	}
 	game := pathParts[2]
 	if game == "" {
-		http.Error(w, "missing game", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing game")
 		return
 	}
 
@@ -234,6 +342,7 @@ func (s *Server) apiMarkGameCompletedForAll(w http.ResponseWriter, r *http.Reque
 			}
 		}
 	})
+	s.checkBingo()
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
 		fmt.Printf("encode response error: %v\n", err)
@@ -244,18 +353,18 @@ func (s *Server) apiMarkGameCompletedForAll(w http.ResponseWriter, r *http.Reque
 // Marks the specified instance as completed for all players
 func (s *Server) apiMarkInstanceCompletedForAll(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	// Parse instance from path: /api/instances/{instance}/mark_completed_all
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 4 || pathParts[0] != "api" || pathParts[1] != "instances" || pathParts[3] != "mark_completed_all" {
-		http.Error(w, "invalid path", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
 		return
 	}
 	instance := pathParts[2]
 	if instance == "" {
-		http.Error(w, "missing instance", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing instance")
 		return
 	}
 
@@ -278,6 +387,7 @@ func (s *Server) apiMarkInstanceCompletedForAll(w http.ResponseWriter, r *http.R
 			}
 		}
 	})
+	s.checkBingo()
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
 		fmt.Printf("encode response error: %v\n", err)
@@ -290,7 +400,7 @@ func (s *Server) handleGameCompletedRoutes(w http.ResponseWriter, r *http.Reques
 	parts := strings.Split(path, "/")
 	if len(parts) < 2 {
 		// This might be a regular games request, let it fall through
-		http.Error(w, "invalid path", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
 		return
 	}
 	game := parts[0]
@@ -303,7 +413,7 @@ func (s *Server) handleGameCompletedRoutes(w http.ResponseWriter, r *http.Reques
 	if action == "mark_completed_all" && game != "" {
 		s.apiMarkGameCompletedForAll(w, r)
 	} else {
-		http.Error(w, "invalid action", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidAction, "invalid action")
 	}
 }
 
@@ -311,8 +421,12 @@ func (s *Server) handleGameCompletedRoutes(w http.ResponseWriter, r *http.Reques
 func (s *Server) handleInstanceCompletedRoutes(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/instances/")
 	parts := strings.Split(path, "/")
+	if len(parts) == 1 && parts[0] != "" {
+		s.apiPatchInstance(w, r, parts[0])
+		return
+	}
 	if len(parts) < 2 {
-		http.Error(w, "invalid path", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
 		return
 	}
 	instance := parts[0]
@@ -324,7 +438,11 @@ func (s *Server) handleInstanceCompletedRoutes(w http.ResponseWriter, r *http.Re
 
 	if action == "mark_completed_all" && instance != "" {
 		s.apiMarkInstanceCompletedForAll(w, r)
+	} else if action == "versions" && instance != "" {
+		s.apiSaveVersions(w, r, instance)
+	} else if action == "rollback" && instance != "" {
+		s.apiRollbackSave(w, r, instance)
 	} else {
-		http.Error(w, "invalid action", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidAction, "invalid action")
 	}
 }
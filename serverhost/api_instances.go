@@ -0,0 +1,400 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// validInstanceID matches IDs safe to use as ./saves/<id>.state file names.
+var validInstanceID = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+var (
+	errInstanceNotFound = errors.New("instance not found")
+	errInstanceExists   = errors.New("instance id already exists")
+	errInstancePending  = errors.New("instance save upload is pending")
+)
+
+// apiPatchInstance handles PATCH /api/instances/{id} with body
+// {"id"?: newID, "locked"?: bool}. A new id renames the instance (see
+// renameInstance); locked toggles whether automatic swaps may deal it.
+func (s *Server) apiPatchInstance(w http.ResponseWriter, r *http.Request, oldID string) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b struct {
+		ID     *string `json:"id"`
+		Locked *bool   `json:"locked"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if b.ID == nil && b.Locked == nil {
+		http.Error(w, "nothing to update (id or locked)", http.StatusBadRequest)
+		return
+	}
+	id := oldID
+	if b.ID != nil {
+		id = strings.TrimSpace(*b.ID)
+		if !validInstanceID.MatchString(id) {
+			http.Error(w, "invalid instance id (letters, digits, '-' and '_' only)", http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(id, protocol.SaveOwnerSeparator) {
+			http.Error(w, "invalid instance id ('"+protocol.SaveOwnerSeparator+"' is reserved for per-player saves)", http.StatusBadRequest)
+			return
+		}
+	}
+	err := s.renameInstance(oldID, id)
+	if err == nil && b.Locked != nil {
+		err = s.setInstanceLocked(id, *b.Locked)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errInstanceNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, errInstanceExists), errors.Is(err, errInstancePending):
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// setInstanceLocked sets an instance's Locked flag.
+func (s *Server) setInstanceLocked(id string, locked bool) error {
+	found := false
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		for i := range st.GameSwapInstances {
+			if st.GameSwapInstances[i].ID == id {
+				st.GameSwapInstances[i].Locked = locked
+				found = true
+				return
+			}
+		}
+	})
+	if !found {
+		return fmt.Errorf("%s: %w", id, errInstanceNotFound)
+	}
+	log.Printf("instance %s locked=%v", id, locked)
+	return nil
+}
+
+// fileMove is one rename done by renameInstance, kept for rollback.
+type fileMove struct{ from, to string }
+
+// moveIfExists renames from to to, appending it to moves; a missing from is
+// not an error.
+func moveIfExists(moves []fileMove, from, to string) ([]fileMove, error) {
+	if err := os.Rename(from, to); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return moves, nil
+		}
+		return moves, err
+	}
+	return append(moves, fileMove{from, to}), nil
+}
+
+// undoMoves reverts moves, newest first.
+func undoMoves(moves []fileMove) {
+	for i := len(moves) - 1; i >= 0; i-- {
+		if err := os.Rename(moves[i].to, moves[i].from); err != nil {
+			log.Printf("rename instance: roll back %s: %v", moves[i].to, err)
+		}
+	}
+}
+
+// movePlayerSaves renames the per-player saves (<id>__<player>.state) of
+// oldID, and their archived versions, to newID. Failures are only logged.
+func movePlayerSaves(moves []fileMove, oldID, newID string) []fileMove {
+	paths, err := filepath.Glob(filepath.Join("./saves", oldID+protocol.SaveOwnerSeparator+"*.state"))
+	if err != nil {
+		log.Printf("rename instance %s: list player saves: %v", oldID, err)
+		return moves
+	}
+	for _, p := range paths {
+		_, owner := protocol.SplitSaveKey(strings.TrimSuffix(filepath.Base(p), ".state"))
+		oldKey, newKey := protocol.SaveKey(oldID, owner), protocol.SaveKey(newID, owner)
+		if moves, err = moveIfExists(moves, p, filepath.Join("./saves", newKey+".state")); err != nil {
+			log.Printf("rename instance %s: move save of %s: %v", oldID, owner, err)
+			continue
+		}
+		if moves, err = moveIfExists(moves, saveVersionsDir(oldKey), saveVersionsDir(newKey)); err != nil {
+			log.Printf("rename instance %s: move save versions of %s: %v", oldID, owner, err)
+		}
+	}
+	return moves
+}
+
+// planInstanceRename checks that oldID can become newID in st and returns
+// the instance index and the players with their references rewritten.
+func planInstanceRename(st *protocol.ServerState, oldID, newID string) (int, map[string]protocol.Player, error) {
+	idx := -1
+	for i, inst := range st.GameSwapInstances {
+		switch inst.ID {
+		case oldID:
+			idx = i
+		case newID:
+			return -1, nil, fmt.Errorf("%s: %w", newID, errInstanceExists)
+		}
+	}
+	if idx < 0 {
+		return -1, nil, fmt.Errorf("%s: %w", oldID, errInstanceNotFound)
+	}
+	if st.GameSwapInstances[idx].FileState == protocol.FileStatePending {
+		return -1, nil, fmt.Errorf("%s: %w", oldID, errInstancePending)
+	}
+
+	players := make(map[string]protocol.Player, len(st.Players))
+	for name, p := range st.Players {
+		if p.InstanceID == oldID {
+			p.InstanceID = newID
+		}
+		for i, ci := range p.CompletedInstances {
+			if ci == oldID {
+				p.CompletedInstances = append([]string(nil), p.CompletedInstances...)
+				p.CompletedInstances[i] = newID
+			}
+		}
+		players[name] = p
+	}
+	if err := validateNoDuplicateInstanceAssignments(&protocol.ServerState{Players: players}); err != nil {
+		return -1, nil, err
+	}
+	return idx, players, nil
+}
+
+// renameInstance changes an instance ID, migrating its save file, archived
+// save versions, per-player saves and every player reference (assignment and
+// completions). The rename is checked under a read lock, the files are moved
+// without the lock, and the state change is applied afterwards; the moves
+// are rolled back if the state changed meanwhile so the rename no longer fits.
+func (s *Server) renameInstance(oldID, newID string) error {
+	if oldID == newID {
+		return nil
+	}
+	var result error
+	s.withRLock(func() {
+		_, _, result = planInstanceRename(&s.state, oldID, newID)
+	})
+	if result != nil {
+		return result
+	}
+
+	oldPath := filepath.Join("./saves", oldID+".state")
+	newPath := filepath.Join("./saves", newID+".state")
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("save file %s: %w", newPath, errInstanceExists)
+	}
+	if _, err := os.Stat(saveVersionsDir(newID)); err == nil {
+		return fmt.Errorf("save versions %s: %w", saveVersionsDir(newID), errInstanceExists)
+	}
+	moves, err := moveIfExists(nil, oldPath, newPath)
+	if err != nil {
+		return fmt.Errorf("move save file: %w", err)
+	}
+	if moves, err = moveIfExists(moves, saveVersionsDir(oldID), saveVersionsDir(newID)); err != nil {
+		log.Printf("rename instance %s: move save versions: %v", oldID, err)
+	}
+	moves = movePlayerSaves(moves, oldID, newID)
+
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		idx, players, err := planInstanceRename(st, oldID, newID)
+		if err != nil {
+			result = err
+			return
+		}
+		st.GameSwapInstances[idx].ID = newID
+		st.Players = players
+		// Keep the applied-swap record in step so the rename alone doesn't trigger a resend.
+		for name, key := range s.appliedSwapTarget {
+			p, ok := players[name]
+			if !ok || p.InstanceID != newID {
+				continue
+			}
+			prev := p
+			prev.InstanceID = oldID
+			if key == s.swapTargetKey(prev) {
+				s.appliedSwapTarget[name] = s.swapTargetKey(p)
+			}
+		}
+	})
+	if result != nil {
+		undoMoves(moves)
+	}
+	return result
+}
+
+// apiReorderInstances handles POST /api/instances/reorder with body {"ids": [...]},
+// which must list every existing instance ID exactly once.
+func (s *Server) apiReorderInstances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var result error
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		if len(b.IDs) != len(st.GameSwapInstances) {
+			result = fmt.Errorf("expected %d ids, got %d", len(st.GameSwapInstances), len(b.IDs))
+			return
+		}
+		byID := make(map[string]protocol.GameSwapInstance, len(st.GameSwapInstances))
+		for _, inst := range st.GameSwapInstances {
+			byID[inst.ID] = inst
+		}
+		ordered := make([]protocol.GameSwapInstance, 0, len(b.IDs))
+		for _, id := range b.IDs {
+			inst, ok := byID[id]
+			if !ok {
+				result = fmt.Errorf("unknown or duplicate instance id %q", id)
+				return
+			}
+			delete(byID, id)
+			ordered = append(ordered, inst)
+		}
+		if err := validateNoDuplicateInstanceAssignments(st); err != nil {
+			result = err
+			return
+		}
+		st.GameSwapInstances = ordered
+	})
+	if result != nil {
+		http.Error(w, result.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// InstanceMatch is one /api/instances/search result.
+type InstanceMatch struct {
+	ID        string             `json:"id"`
+	Game      string             `json:"game"`
+	Assignee  string             `json:"assignee,omitempty"`
+	FileState protocol.FileState `json:"file_state"`
+}
+
+// fuzzyScore rates how well query matches s, case-insensitively: an exact
+// match beats a prefix, which beats a substring (earlier is better), which
+// beats the query's characters merely appearing in order (tighter is better).
+// ok is false when the characters don't all appear in order.
+func fuzzyScore(query, s string) (score int, ok bool) {
+	q, t := strings.ToLower(query), strings.ToLower(s)
+	switch {
+	case q == "":
+		return 0, true
+	case q == t:
+		return 3000, true
+	case strings.HasPrefix(t, q):
+		return 2000, true
+	}
+	if i := strings.Index(t, q); i >= 0 {
+		return 1000 - i, true
+	}
+	qr := []rune(q)
+	qi, first, last := 0, -1, -1
+	for i, c := range []rune(t) {
+		if qi < len(qr) && c == qr[qi] {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			qi++
+		}
+	}
+	if qi < len(qr) {
+		return 0, false
+	}
+	return max(1, 500-(last-first+1-len(qr))), true
+}
+
+// searchInstances returns up to limit instances whose ID or game file name
+// fuzzy-matches query, best first, with each instance's current assignee.
+func searchInstances(instances []protocol.GameSwapInstance, players map[string]protocol.Player, query string, limit int) []InstanceMatch {
+	assignee := map[string]string{}
+	for name, p := range players {
+		if p.InstanceID != "" {
+			assignee[p.InstanceID] = name
+		}
+	}
+	type scored struct {
+		InstanceMatch
+		score int
+	}
+	var hits []scored
+	for _, inst := range instances {
+		idScore, idOK := fuzzyScore(query, inst.ID)
+		gameScore, gameOK := fuzzyScore(query, inst.Game)
+		if !idOK && !gameOK {
+			continue
+		}
+		hits = append(hits, scored{
+			InstanceMatch: InstanceMatch{ID: inst.ID, Game: inst.Game, Assignee: assignee[inst.ID], FileState: inst.FileState},
+			score:         max(idScore, gameScore),
+		})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	out := make([]InstanceMatch, 0, min(limit, len(hits)))
+	for _, h := range hits {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, h.InstanceMatch)
+	}
+	return out
+}
+
+// apiSearchInstances handles GET /api/instances/search?q=...&limit=N, listing
+// instances matching q by ID or game for the admin UI's instance picker.
+func (s *Server) apiSearchInstances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 200 {
+			http.Error(w, "limit must be 1-200", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	_, _, instances := s.SnapshotGames()
+	matches := searchInstances(instances, s.SnapshotPlayers(), strings.TrimSpace(r.URL.Query().Get("q")), limit)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"instances": matches}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
@@ -0,0 +1,265 @@
+package serverhost
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestAPIRenameInstanceMigratesSaveAndPlayers(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "zelda", Game: "zelda.zip", FileState: protocol.FileStateReady},
+			{ID: "mario", Game: "mario.zip", FileState: protocol.FileStateNone},
+		}
+		st.Players["bob"] = protocol.Player{Name: "bob", Game: "zelda.zip", InstanceID: "zelda"}
+		st.Players["amy"] = protocol.Player{Name: "amy", CompletedInstances: []string{"zelda"}}
+	})
+	if err := os.WriteFile(filepath.Join("saves", "zelda.state"), []byte("save"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	patch := func(id, body string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPatch, srv.URL+"/api/instances/"+id, bytes.NewBufferString(body))
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		return res.StatusCode
+	}
+
+	if code := patch("zelda", `{"id":"mario"}`); code != http.StatusConflict {
+		t.Fatalf("rename onto existing id: status %d", code)
+	}
+	if code := patch("ghost", `{"id":"x"}`); code != http.StatusNotFound {
+		t.Fatalf("rename unknown id: status %d", code)
+	}
+	if code := patch("zelda", `{"id":"../evil"}`); code != http.StatusBadRequest {
+		t.Fatalf("rename to unsafe id: status %d", code)
+	}
+	if code := patch("zelda", `{"id":"zelda-run"}`); code != http.StatusOK {
+		t.Fatalf("rename: status %d", code)
+	}
+
+	st := s.SnapshotState()
+	if st.GameSwapInstances[0].ID != "zelda-run" {
+		t.Fatalf("instance not renamed: %+v", st.GameSwapInstances)
+	}
+	if st.Players["bob"].InstanceID != "zelda-run" {
+		t.Fatalf("assignment not migrated: %q", st.Players["bob"].InstanceID)
+	}
+	if ci := st.Players["amy"].CompletedInstances; len(ci) != 1 || ci[0] != "zelda-run" {
+		t.Fatalf("completion not migrated: %v", ci)
+	}
+	if _, err := os.Stat(filepath.Join("saves", "zelda-run.state")); err != nil {
+		t.Fatalf("save not moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("saves", "zelda.state")); !os.IsNotExist(err) {
+		t.Fatal("old save still present")
+	}
+}
+
+func TestRenameInstanceMovesRollBack(t *testing.T) {
+	chdirToTemp(t)
+	if err := os.MkdirAll("saves", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"old.state", "old__amy.state"} {
+		if err := os.WriteFile(filepath.Join("saves", f), []byte(f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	moves, err := moveIfExists(nil, filepath.Join("saves", "old.state"), filepath.Join("saves", "new.state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moves, err = moveIfExists(moves, filepath.Join("saves", "missing.state"), filepath.Join("saves", "x.state")); err != nil || len(moves) != 1 {
+		t.Fatalf("missing source: %v %v", moves, err)
+	}
+	moves = movePlayerSaves(moves, "old", "new")
+	if _, err := os.Stat(filepath.Join("saves", "new__amy.state")); err != nil {
+		t.Fatal(err)
+	}
+
+	undoMoves(moves)
+	for _, f := range []string{"old.state", "old__amy.state"} {
+		if b, err := os.ReadFile(filepath.Join("saves", f)); err != nil || string(b) != f {
+			t.Fatalf("%s not restored: %q %v", f, b, err)
+		}
+	}
+	for _, f := range []string{"new.state", "new__amy.state"} {
+		if _, err := os.Stat(filepath.Join("saves", f)); err == nil {
+			t.Fatalf("%s left behind", f)
+		}
+	}
+}
+
+func TestAPIReorderInstances(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	for body, want := range map[string]int{
+		`{"ids":["c","a"]}`:     http.StatusBadRequest,
+		`{"ids":["c","a","a"]}`: http.StatusBadRequest,
+		`{"ids":["c","a","x"]}`: http.StatusBadRequest,
+		`{"ids":["c","a","b"]}`: http.StatusOK,
+	} {
+		res, err := http.Post(srv.URL+"/api/instances/reorder", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != want {
+			t.Fatalf("%s: status %d, want %d", body, res.StatusCode, want)
+		}
+	}
+	var got []string
+	for _, inst := range s.SnapshotState().GameSwapInstances {
+		got = append(got, inst.ID)
+	}
+	if len(got) != 3 || got[0] != "c" || got[1] != "a" || got[2] != "b" {
+		t.Fatalf("order %v", got)
+	}
+}
+
+func TestAPISearchInstancesRanksAndReportsAssignee(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "metroid-x9f2", Game: "Super Metroid.sfc", FileState: protocol.FileStateReady},
+			{ID: "zelda-a1b2", Game: "Zelda - Link to the Past.sfc", FileState: protocol.FileStateNone},
+			{ID: "mzx", Game: "Mega Man X.sfc", FileState: protocol.FileStatePending},
+		}
+		st.Players["bob"] = protocol.Player{Name: "bob", Game: "Zelda - Link to the Past.sfc", InstanceID: "zelda-a1b2"}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	search := func(q string) []InstanceMatch {
+		t.Helper()
+		res, err := http.Get(srv.URL + "/api/instances/search?q=" + url.QueryEscape(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = res.Body.Close() }()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("search %q: status %d", q, res.StatusCode)
+		}
+		var body struct {
+			Instances []InstanceMatch `json:"instances"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return body.Instances
+	}
+
+	got := search("zelda")
+	if len(got) != 1 || got[0].ID != "zelda-a1b2" || got[0].Assignee != "bob" || got[0].FileState != protocol.FileStateNone {
+		t.Fatalf("search zelda = %+v", got)
+	}
+	if got := search("MZX"); len(got) != 1 || got[0].ID != "mzx" {
+		t.Fatalf("search MZX = %+v", got)
+	}
+	// Both m...x matches come back, the tightest in-order match first.
+	got = search("mx")
+	if len(got) != 2 || got[0].ID != "mzx" {
+		t.Fatalf("search mx = %+v", got)
+	}
+	if got := search(""); len(got) != 3 {
+		t.Fatalf("empty query returned %d instances", len(got))
+	}
+	if got := search("qqq"); len(got) != 0 {
+		t.Fatalf("search qqq = %+v", got)
+	}
+}
+
+func TestLockedInstanceSkippedByAutoSwaps(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "tutorial", Game: "tutorial.zip", FileState: protocol.FileStateNone},
+			{ID: "mario", Game: "mario.zip", FileState: protocol.FileStateNone},
+		}
+		st.Players["bob"] = protocol.Player{Name: "bob"}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodPatch, srv.URL+"/api/instances/tutorial", bytes.NewBufferString(`{"locked":true}`))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK || !s.SnapshotState().GameSwapInstances[0].Locked {
+		t.Fatalf("lock: status %d state %+v", res.StatusCode, s.SnapshotState().GameSwapInstances)
+	}
+
+	h := &SaveModeHandler{server: s}
+	if p := h.GetPlayer("bob"); p.InstanceID != "mario" {
+		t.Fatalf("GetPlayer dealt %q", p.InstanceID)
+	}
+	if c := h.categorizeInstances(protocol.Player{Name: "bob"}, false); len(c.UnassignedDifferentGame) != 1 || c.UnassignedDifferentGame[0] != "mario" {
+		t.Fatalf("categorizeInstances %+v", c)
+	}
+	instances := s.SnapshotState().GameSwapInstances
+	players := []string{"amy", "bob"}
+	current := map[string]protocol.Player{"amy": {Name: "amy"}, "bob": {Name: "bob"}}
+	for _, strategy := range []protocol.SwapStrategy{protocol.SwapStrategyRoundRobin, protocol.SwapStrategyDerangement} {
+		for name, idx := range h.assignInstances(players, current, instances, false, strategy) {
+			if instances[idx].Locked {
+				t.Fatalf("%s: %s dealt locked instance", strategy, name)
+			}
+		}
+	}
+
+	// Explicit admin assignment still works.
+	if err := h.HandlePlayerSwap("bob", "", "tutorial"); err != nil {
+		t.Fatal(err)
+	}
+	if p := s.SnapshotState().Players["bob"]; p.InstanceID != "tutorial" {
+		t.Fatalf("admin assignment: %+v", p)
+	}
+
+	// The flag survives a games API round-trip.
+	body, _ := json.Marshal(map[string]any{"game_instances": s.SnapshotState().GameSwapInstances})
+	res, err = http.Post(srv.URL+"/api/games", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if !s.SnapshotState().GameSwapInstances[0].Locked {
+		t.Fatal("locked flag lost on /api/games round-trip")
+	}
+}
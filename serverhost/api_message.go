@@ -1,155 +1,181 @@
 package serverhost
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/michael4d45/bizshuffle/protocol"
 )
 
-// apiMessagePlayer: POST {player: ..., message: ..., duration: ..., x: ..., y: ..., fontsize: ..., fg: ..., bg: ...}
-func (s *Server) apiMessagePlayer(w http.ResponseWriter, r *http.Request) {
+// Limits for admin messages. Messages travel to BizHawk on a '|'-separated IPC
+// line, so the text may not contain '|' or line breaks.
+const (
+	maxMessageLen      = 200
+	maxMessageDuration = 600
+	maxMessageFontSize = 72
+)
+
+// messageRequest is the body of the message endpoints. Omitted styling fields
+// take the client's CmdMessage defaults (3s at 10,10, size 12, white on black).
+type messageRequest struct {
+	Player   string `json:"player,omitempty"`
+	Message  string `json:"message"`
+	Duration *int   `json:"duration,omitempty"`
+	X        *int   `json:"x,omitempty"`
+	Y        *int   `json:"y,omitempty"`
+	Fontsize *int   `json:"fontsize,omitempty"`
+	Fg       string `json:"fg,omitempty"`
+	Bg       string `json:"bg,omitempty"`
+}
+
+// validMessageColor reports whether c is "#RRGGBB" or "#AARRGGBB".
+func validMessageColor(c string) bool {
+	if len(c) != 7 && len(c) != 9 || c[0] != '#' {
+		return false
+	}
+	for _, r := range c[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// payload validates the request and returns the CmdMessage payload with
+// defaults filled in.
+func (m messageRequest) payload() (map[string]any, error) {
+	if m.Message == "" {
+		return nil, fmt.Errorf("missing message")
+	}
+	if len(m.Message) > maxMessageLen || strings.ContainsAny(m.Message, "|\r\n") {
+		return nil, fmt.Errorf("message must be at most %d characters without '|' or line breaks", maxMessageLen)
+	}
+	intOr := func(v *int, def int) int {
+		if v == nil {
+			return def
+		}
+		return *v
+	}
+	duration, x, y, fontsize := intOr(m.Duration, 3), intOr(m.X, countdownX), intOr(m.Y, countdownY), intOr(m.Fontsize, countdownFontSize)
+	if duration < 1 || duration > maxMessageDuration {
+		return nil, fmt.Errorf("duration must be 1-%d", maxMessageDuration)
+	}
+	if x < 0 || y < 0 {
+		return nil, fmt.Errorf("x and y must not be negative")
+	}
+	if fontsize < 1 || fontsize > maxMessageFontSize {
+		return nil, fmt.Errorf("fontsize must be 1-%d", maxMessageFontSize)
+	}
+	fg, bg := cmp.Or(m.Fg, countdownFG), cmp.Or(m.Bg, countdownBG)
+	if !validMessageColor(fg) || !validMessageColor(bg) {
+		return nil, fmt.Errorf("fg and bg must be #RRGGBB or #AARRGGBB")
+	}
+	return map[string]any{
+		"message":  m.Message,
+		"duration": duration,
+		"x":        x,
+		"y":        y,
+		"fontsize": fontsize,
+		"fg":       fg,
+		"bg":       bg,
+	}, nil
+}
+
+// decodeMessageRequest reads and validates a message request, answering the
+// error itself when ok is false.
+func decodeMessageRequest(w http.ResponseWriter, r *http.Request) (b messageRequest, payload map[string]any, ok bool) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	var b struct {
-		Player   string `json:"player"`
-		Message  string `json:"message"`
-		Duration int    `json:"duration,omitempty"`
-		X        int    `json:"x,omitempty"`
-		Y        int    `json:"y,omitempty"`
-		Fontsize int    `json:"fontsize,omitempty"`
-		Fg       string `json:"fg,omitempty"`
-		Bg       string `json:"bg,omitempty"`
+		return b, nil, false
 	}
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
 		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
-		return
+		return b, nil, false
 	}
-	if b.Player == "" {
-		http.Error(w, "missing player", http.StatusBadRequest)
+	payload, err := b.payload()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return b, nil, false
+	}
+	return b, payload, true
+}
+
+// apiMessage: POST /api/message {player?, message, duration?, x?, y?, fontsize?, fg?, bg?}
+// Shows message on one player's screen, or on every connected player's when
+// player is omitted.
+func (s *Server) apiMessage(w http.ResponseWriter, r *http.Request) {
+	b, payload, ok := decodeMessageRequest(w, r)
+	if !ok {
 		return
 	}
-	if b.Message == "" {
-		http.Error(w, "missing message", http.StatusBadRequest)
+	if b.Player == "" {
+		s.messageAll(w, payload)
 		return
 	}
+	s.messagePlayer(w, b.Player, payload)
+}
 
-	// Set defaults if not provided
-	if b.Duration == 0 {
-		b.Duration = 3
-	}
-	if b.X == 0 && b.Y == 0 { // Allow 0,0 but default to 10,10 if both are 0
-		b.X = 10
-		b.Y = 10
-	}
-	if b.Fontsize == 0 {
-		b.Fontsize = 12
-	}
-	if b.Fg == "" {
-		b.Fg = "#FFFFFF"
+// apiMessagePlayer: POST {player: ..., message: ..., duration: ..., x: ..., y: ..., fontsize: ..., fg: ..., bg: ...}
+func (s *Server) apiMessagePlayer(w http.ResponseWriter, r *http.Request) {
+	b, payload, ok := decodeMessageRequest(w, r)
+	if !ok {
+		return
 	}
-	if b.Bg == "" {
-		b.Bg = "#000000"
+	if b.Player == "" {
+		http.Error(w, "missing player", http.StatusBadRequest)
+		return
 	}
+	s.messagePlayer(w, b.Player, payload)
+}
 
-	// Send message command to the specific player
-	cmd := protocol.Command{
-		Cmd: protocol.CmdMessage,
-		Payload: map[string]any{
-			"message":  b.Message,
-			"duration": b.Duration,
-			"x":        b.X,
-			"y":        b.Y,
-			"fontsize": b.Fontsize,
-			"fg":       b.Fg,
-			"bg":       b.Bg,
-		},
-		ID: fmt.Sprintf("message-%d-%s", time.Now().UnixNano(), b.Player),
+// apiMessageAll: POST {message: ..., duration: ..., x: ..., y: ..., fontsize: ..., fg: ..., bg: ...}
+func (s *Server) apiMessageAll(w http.ResponseWriter, r *http.Request) {
+	_, payload, ok := decodeMessageRequest(w, r)
+	if !ok {
+		return
 	}
+	s.messageAll(w, payload)
+}
+
+func (s *Server) messagePlayer(w http.ResponseWriter, name string, payload map[string]any) {
 	var player protocol.Player
 	var ok bool
 	s.withRLock(func() {
-		player, ok = s.state.Players[b.Player]
+		player, ok = s.state.Players[name]
 	})
 	if !ok {
 		http.Error(w, "player not found", http.StatusNotFound)
 		return
 	}
-
-	err := s.sendToPlayer(player, cmd)
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
+	if !player.Connected {
+		http.Error(w, fmt.Sprintf("player %s: %v", name, ErrPlayerNotConnected), http.StatusConflict)
+		return
+	}
+	cmd := protocol.Command{
+		Cmd:     protocol.CmdMessage,
+		Payload: payload,
+		ID:      fmt.Sprintf("message-%d-%s", time.Now().UnixNano(), name),
+	}
+	if err := s.sendToPlayer(player, cmd); err != nil {
 		http.Error(w, fmt.Sprintf("failed to send message: %v", err), http.StatusInternalServerError)
 		return
 	}
-
+	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
 		fmt.Printf("encode response error: %v\n", err)
 	}
 }
 
-// apiMessageAll: POST {message: ..., duration: ..., x: ..., y: ..., fontsize: ..., fg: ..., bg: ...}
-func (s *Server) apiMessageAll(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	var b struct {
-		Message  string `json:"message"`
-		Duration int    `json:"duration,omitempty"`
-		X        int    `json:"x,omitempty"`
-		Y        int    `json:"y,omitempty"`
-		Fontsize int    `json:"fontsize,omitempty"`
-		Fg       string `json:"fg,omitempty"`
-		Bg       string `json:"bg,omitempty"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-	if b.Message == "" {
-		http.Error(w, "missing message", http.StatusBadRequest)
-		return
-	}
-
-	// Set defaults if not provided
-	if b.Duration == 0 {
-		b.Duration = 3
-	}
-	if b.X == 0 && b.Y == 0 { // Allow 0,0 but default to 10,10 if both are 0
-		b.X = 10
-		b.Y = 10
-	}
-	if b.Fontsize == 0 {
-		b.Fontsize = 12
-	}
-	if b.Fg == "" {
-		b.Fg = "#FFFFFF"
-	}
-	if b.Bg == "" {
-		b.Bg = "#000000"
-	}
-
-	// Send message command to all connected players
-	cmd := protocol.Command{
-		Cmd: protocol.CmdMessage,
-		Payload: map[string]any{
-			"message":  b.Message,
-			"duration": b.Duration,
-			"x":        b.X,
-			"y":        b.Y,
-			"fontsize": b.Fontsize,
-			"fg":       b.Fg,
-			"bg":       b.Bg,
-		},
-		ID: fmt.Sprintf("message-all-%d", time.Now().UnixNano()),
-	}
-	s.broadcastToPlayers(cmd)
-
+func (s *Server) messageAll(w http.ResponseWriter, payload map[string]any) {
+	s.broadcastToPlayers(protocol.Command{
+		Cmd:     protocol.CmdMessage,
+		Payload: payload,
+		ID:      fmt.Sprintf("message-all-%d", time.Now().UnixNano()),
+	})
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]any{
 		"result": "ok",
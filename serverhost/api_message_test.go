@@ -0,0 +1,80 @@
+package serverhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestAPIMessage(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["amy"] = protocol.Player{Name: "amy", Connected: true}
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+		st.Players["cat"] = protocol.Player{Name: "cat"}
+	})
+	amy := registerPlayerWSClient(s, "amy")
+	bob := registerPlayerWSClient(s, "bob")
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		s.apiMessage(rec, httptest.NewRequest(http.MethodPost, "/api/message", strings.NewReader(body)))
+		return rec.Code
+	}
+	recv := func(c *wsClient) map[string]any {
+		t.Helper()
+		select {
+		case cmd := <-c.sendCh:
+			if cmd.Cmd != protocol.CmdMessage {
+				t.Fatalf("got %s, want message", cmd.Cmd)
+			}
+			return cmd.Payload.(map[string]any)
+		case <-time.After(time.Second):
+			t.Fatal("no message sent")
+		}
+		return nil
+	}
+
+	if code := post(`{"player":"amy","message":"hi","x":0,"y":0}`); code != http.StatusOK {
+		t.Fatalf("player message: status %d", code)
+	}
+	p := recv(amy)
+	if p["message"] != "hi" || p["x"] != 0 || p["y"] != 0 || p["duration"] != 3 || p["fontsize"] != 12 || p["fg"] != "#FFFFFF" || p["bg"] != "#000000" {
+		t.Fatalf("payload = %v", p)
+	}
+	select {
+	case cmd := <-bob.sendCh:
+		t.Fatalf("bob got %v", cmd)
+	default:
+	}
+
+	if code := post(`{"message":"5 minute break","duration":10}`); code != http.StatusOK {
+		t.Fatalf("broadcast: status %d", code)
+	}
+	if p := recv(amy); p["duration"] != 10 {
+		t.Fatalf("broadcast payload = %v", p)
+	}
+	recv(bob)
+
+	for body, want := range map[string]int{
+		`{"player":"zed","message":"hi"}`: http.StatusNotFound,
+		`{"player":"cat","message":"hi"}`: http.StatusConflict,
+		`{"message":""}`:                  http.StatusBadRequest,
+		`{"message":"a|b"}`:               http.StatusBadRequest,
+		`{"message":"hi","duration":0}`:   http.StatusBadRequest,
+		`{"message":"hi","fontsize":500}`: http.StatusBadRequest,
+		`{"message":"hi","fg":"red"}`:     http.StatusBadRequest,
+		`{"message":"hi","bg":"#GG0000"}`: http.StatusBadRequest,
+		`{"message":"hi","x":-1}`:         http.StatusBadRequest,
+	} {
+		if code := post(body); code != want {
+			t.Errorf("%s: status %d, want %d", body, code, want)
+		}
+	}
+}
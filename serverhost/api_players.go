@@ -2,9 +2,14 @@ package serverhost
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/michael4d45/bizshuffle/protocol"
@@ -14,7 +19,7 @@ import (
 // If instance_id is provided, assign that instance to the player and swap to its game.
 func (s *Server) apiSwapPlayer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	var b struct {
@@ -23,7 +28,7 @@ func (s *Server) apiSwapPlayer(w http.ResponseWriter, r *http.Request) {
 		Game       string `json:"game"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
 		return
 	}
 	// Determine the game file to swap to. Prefer explicit game in request, otherwise use instance lookup.
@@ -57,21 +62,21 @@ func (s *Server) apiSwapPlayer(w http.ResponseWriter, r *http.Request) {
 			})
 		}
 		if !found {
-			http.Error(w, "instance not found", http.StatusBadRequest)
+			writeAPIError(w, http.StatusBadRequest, errCodeInstanceNotFound, "instance not found")
 			return
 		}
 	}
 
 	// If neither game nor instance provided, it's a bad request
 	if gameFile == "" {
-		http.Error(w, "missing game or instance_id", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing game or instance_id")
 		return
 	}
 
 	// Let the mode handler update server state appropriately for this player-level swap
 	handler := s.GetGameModeHandler()
 	if err := handler.HandlePlayerSwap(b.Player, gameFile, b.InstanceID); err != nil {
-		http.Error(w, "handler: "+err.Error(), http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "handler: "+err.Error())
 		return
 	}
 }
@@ -79,42 +84,94 @@ func (s *Server) apiSwapPlayer(w http.ResponseWriter, r *http.Request) {
 // apiRemovePlayer: POST {player: ...}
 func (s *Server) apiRemovePlayer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	var b struct {
 		Player string `json:"player"`
+		Ban    bool   `json:"ban"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
 		return
 	}
 	if b.Player == "" {
-		http.Error(w, "missing player", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing player")
 		return
 	}
-	var toClose *websocket.Conn
-	s.withConnLock(func() {
-		if cl, ok := s.playerClients[b.Player]; ok {
+	s.removePlayer(b.Player, b.Ban)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"result": "ok", "banned": b.Ban}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// isPlayerBanned reports whether name is on the ban list.
+func (s *Server) isPlayerBanned(name string) bool {
+	banned := false
+	s.withRLock(func() { banned = slices.Contains(s.state.BannedPlayers, name) })
+	return banned
+}
+
+// setPlayerBanned adds name to or removes it from the ban list. Banning also
+// closes the player's connection with a "banned" close frame; the player's
+// record is kept (marked disconnected) so they can be unbanned later.
+func (s *Server) setPlayerBanned(name string, banned bool) {
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.BannedPlayers = slices.DeleteFunc(st.BannedPlayers, func(n string) bool { return n == name })
+		if banned {
+			st.BannedPlayers = append(st.BannedPlayers, name)
+		}
+	})
+	if !banned {
+		log.Printf("Player %s unbanned", name)
+		return
+	}
+	log.Printf("Player %s banned", name)
+	var conn *websocket.Conn
+	s.withConnRLock(func() {
+		if cl, ok := s.playerClients[name]; ok {
 			for c, client := range s.conns {
 				if client == cl {
-					toClose = c
-					delete(s.conns, c)
-					s.liveConns.Delete(c)
+					conn = c
 					break
 				}
 			}
-			delete(s.playerClients, b.Player)
 		}
 	})
-	if toClose != nil {
-		_ = toClose.Close()
+	if conn != nil {
+		closeBanned(conn)
 	}
-	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
-		delete(st.Players, b.Player)
-	})
+}
+
+// closeBanned ends a banned player's websocket with a policy-violation close frame.
+func closeBanned(c *websocket.Conn) {
+	closePolicyViolation(c, "banned")
+}
+
+// closePolicyViolation ends a websocket with a policy-violation close frame
+// carrying reason, which the client reports to the user.
+func closePolicyViolation(c *websocket.Conn, reason string) {
+	msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+	if err := c.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second)); err != nil {
+		log.Printf("write close msg err: %v", err)
+	}
+	_ = c.Close()
+}
+
+// apiPlayerBan: POST /api/players/{player}/ban or /unban
+func (s *Server) apiPlayerBan(w http.ResponseWriter, r *http.Request, playerName string, banned bool) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	if playerName == "" {
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing player")
+		return
+	}
+	s.setPlayerBanned(playerName, banned)
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
+	if err := json.NewEncoder(w).Encode(map[string]any{"result": "ok", "player": playerName, "banned": banned}); err != nil {
 		fmt.Printf("encode response error: %v\n", err)
 	}
 }
@@ -122,14 +179,14 @@ func (s *Server) apiRemovePlayer(w http.ResponseWriter, r *http.Request) {
 // apiSwapAllToGame: POST {game:...}
 func (s *Server) apiSwapAllToGame(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	var b struct {
 		Game string `json:"game"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
 		return
 	}
 	var players []string
@@ -146,57 +203,94 @@ func (s *Server) apiSwapAllToGame(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// removePlayer disconnects name, drops them from state and, with ban, bans
+// the name. A freed slot goes to the waitlist.
+func (s *Server) removePlayer(name string, ban bool) {
+	if ban {
+		s.setPlayerBanned(name, true)
+	}
+	var toClose *websocket.Conn
+	s.withConnLock(func() {
+		if cl, ok := s.playerClients[name]; ok {
+			for c, client := range s.conns {
+				if client == cl {
+					toClose = c
+					delete(s.conns, c)
+					s.liveConns.Delete(c)
+					break
+				}
+			}
+			delete(s.playerClients, name)
+			cl.markClosed()
+		}
+	})
+	if toClose != nil {
+		_ = toClose.Close()
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		delete(st.Players, name)
+		delete(s.lastPong, name)
+	})
+	s.notePrestageAck(name, false, []string{prestageDisconnected})
+	s.promoteWaitlist()
+}
+
 // apiAddPlayer: POST {player:...}
 // Creates a new player that hasn't connected yet (connected=false)
 func (s *Server) apiAddPlayer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	var b struct {
 		Player string `json:"player"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
 		return
 	}
 	if b.Player == "" {
-		http.Error(w, "missing player", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing player")
 		return
 	}
+	s.addPlayer(b.Player)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// addPlayer registers name as a player that hasn't connected yet. Adding an
+// existing player is a no-op.
+func (s *Server) addPlayer(name string) {
 	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 		// Initialize Players map if nil
 		if st.Players == nil {
 			st.Players = make(map[string]protocol.Player)
 		}
 		// Check if player already exists
-		if _, ok := st.Players[b.Player]; ok {
-			// Player already exists, return success (idempotent)
+		if _, ok := st.Players[name]; ok {
 			return
 		}
 		// Create new player with connected=false
-		st.Players[b.Player] = protocol.Player{
-			Name:      b.Player,
+		st.Players[name] = protocol.Player{
+			Name:      name,
 			Connected: false,
 			HasFiles:  false,
 		}
 	})
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
-		fmt.Printf("encode response error: %v\n", err)
-	}
 }
 
 // apiAddCompletedGame: POST /api/players/{player}/completed_games with body {game: "..."}
 func (s *Server) apiAddCompletedGame(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	// Parse player from path: /api/players/{player}/completed_games
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 4 || pathParts[0] != "api" || pathParts[1] != "players" || pathParts[3] != "completed_games" {
-		http.Error(w, "invalid path", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
 		return
 	}
 	playerName := pathParts[2]
@@ -205,14 +299,27 @@ func (s *Server) apiAddCompletedGame(w http.ResponseWriter, r *http.Request) {
 		Game string `json:"game"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
 		return
 	}
 	if b.Game == "" {
-		http.Error(w, "missing game", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing game")
 		return
 	}
 
+	s.markGameCompleted(playerName, b.Game)
+	s.recordRaceCompletion(playerName, b.Game)
+	s.checkBingo()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// markGameCompleted appends game to the player's CompletedGames unless it is
+// already listed. It reports whether the list changed.
+func (s *Server) markGameCompleted(playerName, game string) bool {
+	added := false
 	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 		if st.Players == nil {
 			st.Players = make(map[string]protocol.Player)
@@ -222,37 +329,98 @@ func (s *Server) apiAddCompletedGame(w http.ResponseWriter, r *http.Request) {
 			p = protocol.Player{Name: playerName}
 		}
 		// Check if already in list
-		for _, cg := range p.CompletedGames {
-			if cg == b.Game {
-				return // Already completed
-			}
+		if slices.Contains(p.CompletedGames, game) {
+			return // Already completed
 		}
-		p.CompletedGames = append(p.CompletedGames, b.Game)
+		p.CompletedGames = append(p.CompletedGames, game)
 		st.Players[playerName] = p
+		added = true
 	})
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
-		fmt.Printf("encode response error: %v\n", err)
+	return added
+}
+
+// markInstanceCompleted appends instanceID to the player's CompletedInstances
+// unless it is already listed. It reports whether the list changed.
+func (s *Server) markInstanceCompleted(playerName, instanceID string) bool {
+	added := false
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		if st.Players == nil {
+			st.Players = make(map[string]protocol.Player)
+		}
+		p, ok := st.Players[playerName]
+		if !ok {
+			p = protocol.Player{Name: playerName}
+		}
+		// Check if already in list
+		if slices.Contains(p.CompletedInstances, instanceID) {
+			return // Already completed
+		}
+		p.CompletedInstances = append(p.CompletedInstances, instanceID)
+		st.Players[playerName] = p
+		added = true
+	})
+	return added
+}
+
+// markLuaCompletion handles a Lua "completed" command from playerName. The
+// optional game/instance fields name what was completed; otherwise the player's
+// current game and instance are used.
+func (s *Server) markLuaCompletion(playerName string, fields map[string]string) {
+	game := strings.TrimSpace(fields["game"])
+	instanceID := strings.TrimSpace(fields["instance"])
+	if game == "" || instanceID == "" {
+		var p protocol.Player
+		ok := false
+		s.withRLock(func() { p, ok = s.state.Players[playerName] })
+		if !ok {
+			log.Printf("[ERROR] LuaCmdCompleted: unknown player %s", playerName)
+			return
+		}
+		if game == "" {
+			game = p.Game
+		}
+		if instanceID == "" {
+			instanceID = p.InstanceID
+		}
+	}
+	if game == "" && instanceID == "" {
+		log.Printf("[ERROR] LuaCmdCompleted: %s has no current game", playerName)
+		return
 	}
+	changed := false
+	if game != "" && s.markGameCompleted(playerName, game) {
+		changed = true
+	}
+	if instanceID != "" && s.markInstanceCompleted(playerName, instanceID) {
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	log.Printf("Lua reported %s completed game=%q instance=%q", playerName, game, instanceID)
+	if game != "" {
+		s.recordRaceCompletion(playerName, game)
+	}
+	s.checkBingo()
 }
 
 // apiRemoveCompletedGame: DELETE /api/players/{player}/completed_games?game={game}
 func (s *Server) apiRemoveCompletedGame(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	// Parse player from path: /api/players/{player}/completed_games
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 4 || pathParts[0] != "api" || pathParts[1] != "players" || pathParts[3] != "completed_games" {
-		http.Error(w, "invalid path", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
 		return
 	}
 	playerName := pathParts[2]
 
 	game := r.URL.Query().Get("game")
 	if game == "" {
-		http.Error(w, "missing game parameter", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing game parameter")
 		return
 	}
 
@@ -277,13 +445,13 @@ func (s *Server) apiRemoveCompletedGame(w http.ResponseWriter, r *http.Request)
 // apiAddCompletedInstance: POST /api/players/{player}/completed_instances with body {instance: "..."}
 func (s *Server) apiAddCompletedInstance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	// Parse player from path: /api/players/{player}/completed_instances
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 4 || pathParts[0] != "api" || pathParts[1] != "players" || pathParts[3] != "completed_instances" {
-		http.Error(w, "invalid path", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
 		return
 	}
 	playerName := pathParts[2]
@@ -292,31 +460,16 @@ func (s *Server) apiAddCompletedInstance(w http.ResponseWriter, r *http.Request)
 		Instance string `json:"instance"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
 		return
 	}
 	if b.Instance == "" {
-		http.Error(w, "missing instance", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing instance")
 		return
 	}
 
-	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
-		if st.Players == nil {
-			st.Players = make(map[string]protocol.Player)
-		}
-		p, ok := st.Players[playerName]
-		if !ok {
-			p = protocol.Player{Name: playerName}
-		}
-		// Check if already in list
-		for _, ci := range p.CompletedInstances {
-			if ci == b.Instance {
-				return // Already completed
-			}
-		}
-		p.CompletedInstances = append(p.CompletedInstances, b.Instance)
-		st.Players[playerName] = p
-	})
+	s.markInstanceCompleted(playerName, b.Instance)
+	s.checkBingo()
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
 		fmt.Printf("encode response error: %v\n", err)
@@ -326,20 +479,20 @@ func (s *Server) apiAddCompletedInstance(w http.ResponseWriter, r *http.Request)
 // apiRemoveCompletedInstance: DELETE /api/players/{player}/completed_instances?instance={instance}
 func (s *Server) apiRemoveCompletedInstance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
 	// Parse player from path: /api/players/{player}/completed_instances
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 4 || pathParts[0] != "api" || pathParts[1] != "players" || pathParts[3] != "completed_instances" {
-		http.Error(w, "invalid path", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
 		return
 	}
 	playerName := pathParts[2]
 
 	instance := r.URL.Query().Get("instance")
 	if instance == "" {
-		http.Error(w, "missing instance parameter", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "missing instance parameter")
 		return
 	}
 
@@ -365,24 +518,73 @@ func (s *Server) apiRemoveCompletedInstance(w http.ResponseWriter, r *http.Reque
 // Removes all completed games and instances for all players
 func (s *Server) apiRemoveAllCompletions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.resetCompletions(true, true)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// apiResetCompletions: POST /api/players/reset_completions {scope?: "games"|"instances"|"both"}
+// Clears completed games and/or instances for every player; scope defaults to both.
+func (s *Server) apiResetCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		return
 	}
+	var req struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
+		return
+	}
+	var games, instances bool
+	switch req.Scope {
+	case "", "both":
+		games, instances = true, true
+	case "games":
+		games = true
+	case "instances":
+		instances = true
+	default:
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "scope must be games, instances or both")
+		return
+	}
+
+	clearedGames, clearedInstances := s.resetCompletions(games, instances)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"result":    "ok",
+		"cleared":   clearedGames + clearedInstances,
+		"games":     clearedGames,
+		"instances": clearedInstances,
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
 
+// resetCompletions clears every player's completed games and/or instances in
+// one state update and returns how many entries of each were removed.
+func (s *Server) resetCompletions(games, instances bool) (clearedGames, clearedInstances int) {
 	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
-		if st.Players == nil {
-			return
-		}
 		for playerName, player := range st.Players {
-			player.CompletedGames = []string{}
-			player.CompletedInstances = []string{}
+			if games {
+				clearedGames += len(player.CompletedGames)
+				player.CompletedGames = []string{}
+			}
+			if instances {
+				clearedInstances += len(player.CompletedInstances)
+				player.CompletedInstances = []string{}
+			}
 			st.Players[playerName] = player
 		}
 	})
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
-		fmt.Printf("encode response error: %v\n", err)
-	}
+	return clearedGames, clearedInstances
 }
 
 // handlePlayerCompletedRoutes routes player completed games/instances actions
@@ -390,7 +592,7 @@ func (s *Server) handlePlayerCompletedRoutes(w http.ResponseWriter, r *http.Requ
 	path := strings.TrimPrefix(r.URL.Path, "/api/players/")
 	parts := strings.Split(path, "/")
 	if len(parts) < 2 {
-		http.Error(w, "invalid path", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
 		return
 	}
 	action := parts[1]
@@ -407,7 +609,7 @@ func (s *Server) handlePlayerCompletedRoutes(w http.ResponseWriter, r *http.Requ
 		case http.MethodDelete:
 			s.apiRemoveCompletedGame(w, r)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 		}
 	case "completed_instances":
 		switch r.Method {
@@ -416,9 +618,191 @@ func (s *Server) handlePlayerCompletedRoutes(w http.ResponseWriter, r *http.Requ
 		case http.MethodDelete:
 			s.apiRemoveCompletedInstance(w, r)
 		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		}
+	case "interval":
+		s.apiPlayerInterval(w, r)
+	case "swap":
+		s.apiPlayerSwap(w, r, parts[0])
+	case "resync":
+		s.apiPlayerResync(w, r, parts[0])
+	case "config_profile":
+		s.apiPlayerConfigProfile(w, r, parts[0])
+	case "ban", "unban":
+		s.apiPlayerBan(w, r, parts[0], action == "ban")
+	case "team":
+		s.apiPlayerTeam(w, r, parts[0])
+	default:
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidAction, "invalid action")
+	}
+}
+
+// resyncTimeout bounds how long apiPlayerResync waits for a client to
+// download its whole game set again.
+const resyncTimeout = 10 * time.Minute
+
+// apiPlayerResync: POST /api/players/{player}/resync
+// Tells the player's client to wipe its cached ROMs and download them again,
+// and waits for the result.
+func (s *Server) apiPlayerResync(w http.ResponseWriter, r *http.Request, playerName string) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	var player protocol.Player
+	var ok bool
+	s.withRLock(func() { player, ok = s.state.Players[playerName] })
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, errCodePlayerNotFound, fmt.Sprintf("player %s: %v", playerName, ErrPlayerNotFound))
+		return
+	}
+	if !player.Connected {
+		writeAPIError(w, http.StatusConflict, errCodePlayerNotConnected, fmt.Sprintf("player %s: %v", playerName, ErrPlayerNotConnected))
+		return
+	}
+	cmd := protocol.Command{
+		Cmd: protocol.CmdResync,
+		ID:  fmt.Sprintf("resync-%d-%s", time.Now().UnixNano(), playerName),
+	}
+	res, err := s.sendAndWait(player, cmd, resyncTimeout)
+	switch {
+	case errors.Is(err, ErrTimeout):
+		writeAPIError(w, http.StatusGatewayTimeout, errCodeTimeout, "timed out waiting for resync")
+		return
+	case err != nil:
+		writeAPIError(w, http.StatusConflict, playerErrorCode(err), err.Error())
+		return
+	}
+	if strings.HasPrefix(res, "nack") {
+		writeAPIError(w, http.StatusBadGateway, errCodeClientFailed, "resync failed: "+strings.TrimPrefix(strings.TrimPrefix(res, "nack"), "|"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok", "player": playerName}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// swapDeclineReason maps a declined random swap to a stable reason code.
+func swapDeclineReason(err error) string {
+	switch {
+	case errors.Is(err, ErrNoAvailableGames):
+		return "no_available_games"
+	case errors.Is(err, ErrRaceWon):
+		return "race_won"
+	case errors.Is(err, ErrSavesPending):
+		return "saves_pending"
+	case errors.Is(err, ErrManualMode):
+		return "manual_mode"
+	case errors.Is(err, ErrSwapsDisabled):
+		return "swaps_disabled"
+	}
+	return ""
+}
+
+// apiPlayerSwap: POST /api/players/{player}/swap
+// Randomly swaps one player through the current mode, respecting completions, and
+// reports either the new assignment or why no swap happened.
+func (s *Server) apiPlayerSwap(w http.ResponseWriter, r *http.Request, playerName string) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	err := s.randomSwapForPlayer(playerName)
+	var resp map[string]any
+	switch {
+	case errors.Is(err, ErrPlayerNotFound):
+		writeAPIError(w, http.StatusNotFound, errCodePlayerNotFound, err.Error())
+		return
+	case isSwapDeclined(err):
+		resp = map[string]any{
+			"swapped": false,
+			"player":  playerName,
+			"reason":  swapDeclineReason(err),
+			"message": err.Error(),
 		}
+	case err != nil:
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
 	default:
-		http.Error(w, "invalid action", http.StatusBadRequest)
+		s.schedulePlayerSwap(playerName)
+		var p protocol.Player
+		s.withRLock(func() { p = s.state.Players[playerName] })
+		resp = map[string]any{
+			"swapped":     true,
+			"player":      playerName,
+			"game":        p.Game,
+			"instance_id": p.InstanceID,
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// apiPlayerInterval: GET/POST /api/players/{player}/interval
+// Views or sets the player's swap interval override. Posting both values as 0 clears the override.
+func (s *Server) apiPlayerInterval(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 || pathParts[0] != "api" || pathParts[1] != "players" || pathParts[3] != "interval" {
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidPath, "invalid path")
+		return
+	}
+	playerName := pathParts[2]
+
+	if r.Method == http.MethodGet {
+		var p protocol.Player
+		var ok bool
+		s.withRLock(func() {
+			p, ok = s.state.Players[playerName]
+		})
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, errCodePlayerNotFound, "player not found")
+			return
+		}
+		if err := json.NewEncoder(w).Encode(map[string]any{
+			"min_interval_secs": p.MinIntervalSecs,
+			"max_interval_secs": p.MaxIntervalSecs,
+			"next_swap_at":      p.NextSwapAt,
+		}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method == http.MethodPost {
+		var b struct {
+			MinInterval int `json:"min_interval_secs"`
+			MaxInterval int `json:"max_interval_secs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
+			return
+		}
+		if b.MinInterval < 0 || b.MaxInterval < 0 || (b.MinInterval > 0 && b.MaxInterval > 0 && b.MaxInterval < b.MinInterval) {
+			writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "invalid interval")
+			return
+		}
+		var found bool
+		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			p, ok := st.Players[playerName]
+			if !ok {
+				return
+			}
+			found = true
+			p.MinIntervalSecs = b.MinInterval
+			p.MaxIntervalSecs = b.MaxInterval
+			st.Players[playerName] = p
+		})
+		if !found {
+			writeAPIError(w, http.StatusNotFound, errCodePlayerNotFound, "player not found")
+			return
+		}
+		s.schedulePlayerSwap(playerName)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			fmt.Printf("write response error: %v\n", err)
+		}
+		return
 	}
+	writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
 }
@@ -0,0 +1,183 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func postPlayerSwap(t *testing.T, s *Server, player string) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.handlePlayerCompletedRoutes(rec, httptest.NewRequest(http.MethodPost, "/api/players/"+player+"/swap", nil))
+	var out map[string]any
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, out
+}
+
+func TestAPIPlayerSwapReportsAssignmentOrReason(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Games = []string{"a.zip", "b.zip"}
+		st.Players["p1"] = protocol.Player{Name: "p1", Game: "a.zip", CompletedGames: []string{"a.zip"}}
+	})
+
+	code, out := postPlayerSwap(t, s, "p1")
+	if code != http.StatusOK || out["swapped"] != true || out["game"] != "b.zip" {
+		t.Fatalf("status %d body %v", code, out)
+	}
+
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		p := st.Players["p1"]
+		p.CompletedGames = []string{"a.zip", "b.zip"}
+		st.Players["p1"] = p
+	})
+	code, out = postPlayerSwap(t, s, "p1")
+	if code != http.StatusOK || out["swapped"] != false || out["reason"] != "no_available_games" {
+		t.Fatalf("status %d body %v", code, out)
+	}
+	if g := s.SnapshotState().Players["p1"].Game; g != "b.zip" {
+		t.Fatalf("declined swap changed game to %q", g)
+	}
+
+	if code, _ := postPlayerSwap(t, s, "ghost"); code != http.StatusNotFound {
+		t.Fatalf("unknown player status %d", code)
+	}
+}
+
+func TestAPIPlayerSwapReportsRaceWon(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeRace
+		st.Games = []string{"a.zip", "b.zip"}
+		st.RaceWinner = "p2"
+		st.Players["p1"] = protocol.Player{Name: "p1", Game: "a.zip"}
+	})
+	code, out := postPlayerSwap(t, s, "p1")
+	if code != http.StatusOK || out["swapped"] != false || out["reason"] != "race_won" {
+		t.Fatalf("status %d body %v", code, out)
+	}
+}
+
+func TestAPIResetCompletionsScopes(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["p1"] = protocol.Player{Name: "p1", CompletedGames: []string{"a.zip", "b.zip"}, CompletedInstances: []string{"i1"}}
+		st.Players["p2"] = protocol.Player{Name: "p2", CompletedGames: []string{"a.zip"}, CompletedInstances: []string{"i2", "i3"}}
+	})
+
+	reset := func(body string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		s.apiResetCompletions(rec, httptest.NewRequest(http.MethodPost, "/api/players/reset_completions", strings.NewReader(body)))
+		var out map[string]any
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Code, out
+	}
+
+	if code, _ := reset(`{"scope":"everything"}`); code != http.StatusBadRequest {
+		t.Fatalf("bad scope status %d", code)
+	}
+	code, out := reset(`{"scope":"games"}`)
+	if code != http.StatusOK || out["cleared"] != float64(3) || out["instances"] != float64(0) {
+		t.Fatalf("status %d body %v", code, out)
+	}
+	st := s.SnapshotState()
+	if len(st.Players["p1"].CompletedGames) != 0 || len(st.Players["p2"].CompletedInstances) != 2 {
+		t.Fatalf("games scope touched wrong lists: %+v", st.Players)
+	}
+
+	// An empty body clears both lists.
+	code, out = reset("")
+	if code != http.StatusOK || out["cleared"] != float64(3) || out["games"] != float64(0) {
+		t.Fatalf("status %d body %v", code, out)
+	}
+	for name, p := range s.SnapshotState().Players {
+		if len(p.CompletedGames) != 0 || len(p.CompletedInstances) != 0 {
+			t.Fatalf("%s still has completions: %+v", name, p)
+		}
+	}
+}
+
+func TestBannedPlayerHelloRejected(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	hello := func(name string) (*websocket.Conn, error) {
+		t.Helper()
+		c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdHello, ID: "1", Payload: map[string]any{"name": name}}); err != nil {
+			t.Fatal(err)
+		}
+		_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var cmd protocol.Command
+		return c, c.ReadJSON(&cmd)
+	}
+	post := func(path, body string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post("/api/players/bob/ban", ""); code != http.StatusOK {
+		t.Fatalf("ban: status %d", code)
+	}
+	_, err := hello("bob")
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("banned hello: %v", err)
+	}
+	if _, ok := s.SnapshotPlayers()["bob"]; ok {
+		t.Fatal("banned player registered")
+	}
+
+	if code := post("/api/players/bob/unban", ""); code != http.StatusOK {
+		t.Fatalf("unban: status %d", code)
+	}
+	if _, err := hello("bob"); err != nil {
+		t.Fatalf("unbanned hello: %v", err)
+	}
+	if !s.SnapshotPlayers()["bob"].Connected {
+		t.Fatal("unbanned player not connected")
+	}
+
+	// Removing with ban kicks the live connection and blocks the name again.
+	if code := post("/api/remove_player", `{"player":"bob","ban":true}`); code != http.StatusOK {
+		t.Fatalf("remove+ban: status %d", code)
+	}
+	if banned := s.SnapshotState().BannedPlayers; len(banned) != 1 || banned[0] != "bob" {
+		t.Fatalf("banned = %v", banned)
+	}
+	if _, err := hello("bob"); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("hello after remove+ban: %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -77,6 +79,8 @@ func (s *Server) handlePluginAction(w http.ResponseWriter, r *http.Request) {
 						if sp.Status != "" {
 							p.Status = sp.Status
 						}
+						p.LastError = sp.LastError
+						p.LastErrorPlayer = sp.LastErrorPlayer
 					}
 				}
 			})
@@ -123,6 +127,12 @@ func (s *Server) handlePluginAction(w http.ResponseWriter, r *http.Request) {
 		s.handlePluginSettings(w, r, pluginName)
 	case "reload":
 		s.handlePluginReload(w, r, pluginName)
+	case "enable":
+		s.handlePluginSetStatus(w, r, pluginName, protocol.PluginStatusEnabled)
+	case "disable":
+		s.handlePluginSetStatus(w, r, pluginName, protocol.PluginStatusDisabled)
+	case "status":
+		s.handlePluginStatus(w, r, pluginName)
 	default:
 		http.Error(w, "unknown action: "+action, http.StatusBadRequest)
 	}
@@ -166,6 +176,13 @@ func (s *Server) handlePluginSettings(w http.ResponseWriter, r *http.Request, pl
 			return
 		}
 
+		// Checked before persisting, since these settings are also what the
+		// state_update broadcast pushes into every client's settings.kv.
+		if err := s.validatePluginSettings(pluginName, requestSettings); err != nil {
+			http.Error(w, "invalid setting: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		pluginDir := filepath.Join("./plugins", pluginName)
 		if err := os.MkdirAll(pluginDir, 0755); err != nil {
 			http.Error(w, "failed to create plugin dir: "+err.Error(), http.StatusInternalServerError)
@@ -180,6 +197,7 @@ func (s *Server) handlePluginSettings(w http.ResponseWriter, r *http.Request, pl
 
 		// Update plugin status in state
 		pluginStatus := protocol.PluginStatus(status)
+		statusChanged := false
 		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 			if st.Plugins == nil {
 				st.Plugins = make(map[string]protocol.Plugin)
@@ -192,9 +210,14 @@ func (s *Server) handlePluginSettings(w http.ResponseWriter, r *http.Request, pl
 					plugin = protocol.Plugin{Name: pluginName}
 				}
 			}
+			statusChanged = plugin.Status != pluginStatus
 			plugin.Status = pluginStatus
 			st.Plugins[pluginName] = plugin
 		})
+		if statusChanged {
+			s.emitAdminEvent(protocol.CmdPluginStatusChanged, protocol.PluginStatusEvent{Plugin: pluginName, Status: pluginStatus})
+			s.broadcastPluginSync(pluginName, pluginStatus)
+		}
 
 		// Broadcast settings update to connected clients
 		s.broadcastPluginSettingsUpdate(pluginName, requestSettings)
@@ -209,6 +232,74 @@ func (s *Server) handlePluginSettings(w http.ResponseWriter, r *http.Request, pl
 	}
 }
 
+// validatePluginSettings checks each value against the plugin's declared
+// SettingMeta: dropdown values must be one of the options, multiselect values
+// a comma-separated subset of them, and number values must parse as numbers.
+// Settings without metadata, and text settings, are accepted as-is.
+func (s *Server) validatePluginSettings(pluginName string, settings map[string]string) error {
+	plugin := s.loadPluginMetadata(pluginName)
+	for key, val := range settings {
+		meta, ok := plugin.SettingsMeta[key]
+		if !ok {
+			continue
+		}
+		switch meta.Type {
+		case "dropdown":
+			if len(meta.Options) > 0 && !slices.Contains(meta.Options, val) {
+				return fmt.Errorf("%s: %q is not one of %s", key, val, strings.Join(meta.Options, ", "))
+			}
+		case "multiselect":
+			for _, v := range strings.Split(val, ",") {
+				if v = strings.TrimSpace(v); v != "" && len(meta.Options) > 0 && !slices.Contains(meta.Options, v) {
+					return fmt.Errorf("%s: %q is not one of %s", key, v, strings.Join(meta.Options, ", "))
+				}
+			}
+		case "number":
+			if _, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err != nil {
+				return fmt.Errorf("%s: %q is not a number", key, val)
+			}
+		}
+	}
+	return nil
+}
+
+// handlePluginSetStatus enables or disables a plugin. settings.kv is written
+// before clients are told to resync, so the files they download already carry
+// the new status; clients drop disabled plugins and reload enabled ones.
+func (s *Server) handlePluginSetStatus(w http.ResponseWriter, r *http.Request, pluginName string, status protocol.PluginStatus) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, err := os.Stat(filepath.Join("./plugins", pluginName)); err != nil {
+		http.Error(w, "plugin not found", http.StatusNotFound)
+		return
+	}
+	var plugin protocol.Plugin
+	var ok bool
+	s.withRLock(func() {
+		plugin, ok = s.state.Plugins[pluginName]
+	})
+	if !ok {
+		plugin = *s.loadPluginMetadata(pluginName)
+	}
+	changed := plugin.Status != status
+	plugin.Status = status
+	if err := s.savePluginConfig(plugin); err != nil {
+		http.Error(w, "failed to save plugin status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if changed {
+		s.emitAdminEvent(protocol.CmdPluginStatusChanged, protocol.PluginStatusEvent{Plugin: pluginName, Status: status})
+	}
+	s.broadcastPluginSync(pluginName, status)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"name": pluginName, "status": status}); err != nil {
+		log.Printf("encode response error: %v", err)
+	}
+}
+
 // handlePluginReload broadcasts a reload command to all clients for the specified plugin
 func (s *Server) handlePluginReload(w http.ResponseWriter, r *http.Request, pluginName string) {
 	if r.Method != http.MethodPost {
@@ -231,3 +322,67 @@ func (s *Server) handlePluginReload(w http.ResponseWriter, r *http.Request, plug
 		log.Printf("encode response error: %v", err)
 	}
 }
+
+// recordPluginError stores the latest Lua-side error for a plugin as reported
+// by player and relays it to admins. An empty errMsg clears the error.
+func (s *Server) recordPluginError(pluginName, player, errMsg string) {
+	if errMsg != "" {
+		log.Printf("plugin %s error reported by %s: %s", pluginName, player, errMsg)
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		if st.Plugins == nil {
+			st.Plugins = make(map[string]protocol.Plugin)
+		}
+		plugin, exists := st.Plugins[pluginName]
+		if !exists {
+			if _, err := os.Stat(filepath.Join("./plugins", pluginName)); err != nil {
+				return
+			}
+			plugin = *s.loadPluginMetadata(pluginName)
+		}
+		plugin.LastError = errMsg
+		plugin.LastErrorPlayer = player
+		if errMsg == "" {
+			plugin.LastErrorPlayer = ""
+		}
+		st.Plugins[pluginName] = plugin
+	})
+	s.broadcastToAdmins(protocol.Command{
+		Cmd: protocol.CmdPluginError,
+		ID:  fmt.Sprintf("plugin-error-%d", time.Now().UnixNano()),
+		Payload: map[string]string{
+			"plugin": pluginName,
+			"player": player,
+			"error":  errMsg,
+		},
+	})
+}
+
+// handlePluginStatus returns a plugin's status and last reported error.
+func (s *Server) handlePluginStatus(w http.ResponseWriter, r *http.Request, pluginName string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var plugin protocol.Plugin
+	var ok bool
+	s.withRLock(func() {
+		plugin, ok = s.state.Plugins[pluginName]
+	})
+	if !ok {
+		if _, err := os.Stat(filepath.Join("./plugins", pluginName)); err != nil {
+			http.Error(w, "plugin not found", http.StatusNotFound)
+			return
+		}
+		plugin = *s.loadPluginMetadata(pluginName)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"name":              pluginName,
+		"status":            plugin.Status,
+		"last_error":        plugin.LastError,
+		"last_error_player": plugin.LastErrorPlayer,
+	}); err != nil {
+		log.Printf("encode plugin status error: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestPluginStatusReportsLastError(t *testing.T) {
+	chdirToTemp(t)
+	if err := os.MkdirAll("plugins/broken", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	admin := &wsClient{sendCh: make(chan protocol.Command, 8)}
+	s.withConnLock(func() { s.adminClients["ui"] = admin })
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	s.recordPluginError("broken", "bob", "load error: plugin.lua:3: unexpected symbol")
+	s.recordPluginError("ghost", "bob", "ignored")
+
+	relayed := false
+	timeout := time.After(2 * time.Second)
+	for !relayed {
+		select {
+		case cmd := <-admin.sendCh:
+			relayed = cmd.Cmd == protocol.CmdPluginError
+		case <-timeout:
+			t.Fatal("expected plugin_error to be relayed to admins")
+		}
+	}
+
+	res, err := http.Get(srv.URL + "/api/plugins/broken/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	var out struct {
+		Status          string `json:"status"`
+		LastError       string `json:"last_error"`
+		LastErrorPlayer string `json:"last_error_player"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.LastError != "load error: plugin.lua:3: unexpected symbol" || out.LastErrorPlayer != "bob" {
+		t.Fatalf("unexpected status %+v", out)
+	}
+
+	res, err = http.Get(srv.URL + "/api/plugins/ghost/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown plugin: status %d, want 404", res.StatusCode)
+	}
+
+	s.recordPluginError("broken", "bob", "")
+	if p := s.SnapshotState().Plugins["broken"]; p.LastError != "" || p.LastErrorPlayer != "" {
+		t.Fatalf("expected error cleared, got %+v", p)
+	}
+}
+
+func TestPluginLastErrorSurvivesStateSave(t *testing.T) {
+	chdirToTemp(t)
+	if err := os.MkdirAll("plugins/broken", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.recordPluginError("broken", "bob", "load error: plugin.lua:3: unexpected symbol")
+
+	// Outlast the 500ms save debounce, which rewrites state.Plugins from disk.
+	time.Sleep(800 * time.Millisecond)
+	if err := s.saveState(); err != nil {
+		t.Fatal(err)
+	}
+	p := s.SnapshotState().Plugins["broken"]
+	if p.LastError != "load error: plugin.lua:3: unexpected symbol" || p.LastErrorPlayer != "bob" {
+		t.Fatalf("error lost after save: %+v", p)
+	}
+}
+
+func TestPluginSettingsValidatedAgainstMeta(t *testing.T) {
+	chdirToTemp(t)
+	if err := os.MkdirAll("plugins/tracker", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	meta := "name = tracker\n" +
+		"setting.mode.type = dropdown\n" +
+		"setting.mode.options = swap,swap_me\n" +
+		"setting.interval.type = number\n"
+	if err := os.WriteFile("plugins/tracker/meta.kv", []byte(meta), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/plugins/tracker/settings", strings.NewReader(body))
+		s.handlePluginAction(rec, req)
+		return rec
+	}
+
+	for _, body := range []string{
+		`{"status":"enabled","mode":"explode"}`,
+		`{"status":"enabled","interval":"soon"}`,
+	} {
+		rec := post(body)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid setting") {
+			t.Fatalf("%s: status %d body %q", body, rec.Code, rec.Body)
+		}
+	}
+	if settings, _ := s.loadSettingsKV("plugins/tracker/settings.kv"); settings["mode"] != "" || settings["interval"] != "" {
+		t.Fatalf("invalid settings persisted: %v", settings)
+	}
+
+	if rec := post(`{"status":"enabled","mode":"swap_me","interval":"2.5","note":"anything"}`); rec.Code != http.StatusOK {
+		t.Fatalf("valid settings: status %d body %q", rec.Code, rec.Body)
+	}
+	settings, err := s.loadSettingsKV("plugins/tracker/settings.kv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings["mode"] != "swap_me" || settings["interval"] != "2.5" {
+		t.Fatalf("settings.kv = %v", settings)
+	}
+}
+
+func TestPluginEnableDisableResyncsClients(t *testing.T) {
+	chdirToTemp(t)
+	if err := os.MkdirAll("plugins/tracker", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("plugins/tracker/settings.kv", []byte("status = enabled\nmode = swap\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	alice := registerPlayerWSClient(s, "alice")
+
+	post := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handlePluginAction(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		return rec
+	}
+
+	rec := post("/api/plugins/tracker/disable")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("disable: status %d body %q", rec.Code, rec.Body)
+	}
+	var out struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "tracker" || out.Status != "disabled" {
+		t.Fatalf("response = %+v", out)
+	}
+	settings, err := s.loadSettingsKV("plugins/tracker/settings.kv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings["status"] != "disabled" || settings["mode"] != "swap" {
+		t.Fatalf("settings.kv = %v, want status disabled and other settings kept", settings)
+	}
+	select {
+	case cmd := <-alice.sendCh:
+		payload, _ := cmd.Payload.(map[string]any)
+		if cmd.Cmd != protocol.CmdPluginSync || payload["plugin_name"] != "tracker" || payload["status"] != protocol.PluginStatusDisabled {
+			t.Fatalf("unexpected command %+v", cmd)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected plugin_sync broadcast")
+	}
+
+	if rec := post("/api/plugins/tracker/enable"); rec.Code != http.StatusOK {
+		t.Fatalf("enable: status %d body %q", rec.Code, rec.Body)
+	}
+	if p := s.SnapshotState().Plugins["tracker"]; p.Status != protocol.PluginStatusEnabled {
+		t.Fatalf("state status = %q", p.Status)
+	}
+	if rec := post("/api/plugins/ghost/enable"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown plugin: status %d", rec.Code)
+	}
+}
@@ -1,12 +1,15 @@
 package serverhost
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/michael4d45/bizshuffle/protocol"
@@ -22,40 +25,50 @@ func (s *Server) handleSaveUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := r.ParseMultipartForm(saveUploadMaxBytes); err != nil {
-		http.Error(w, "parse multipart: "+err.Error(), http.StatusBadRequest)
-		return
+	// Advertise gzip support so clients can compress later uploads (RFC 7694).
+	w.Header().Set("Accept-Encoding", "gzip")
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "bad gzip body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = gz.Close() }()
+		r.Body = http.MaxBytesReader(w, gz, 2*saveUploadMaxBytes)
+		r.Header.Del("Content-Encoding")
 	}
 
-	file, header, err := r.FormFile("save")
+	upload, err := readMultipartUpload(r, "save", saveUploadMaxBytes)
 	if err != nil {
-		http.Error(w, "save file missing: "+err.Error(), http.StatusBadRequest)
+		writeUploadError(w, err, "save file missing")
 		return
 	}
-	defer func() { _ = file.Close() }()
+	defer upload.Close()
 
-	filename := r.FormValue("filename")
+	filename := upload.FormValue("filename")
 	if filename == "" {
-		filename = header.Filename
+		filename = upload.Filename
 	}
 	filename = filepath.Base(filename)
 
-	instanceID := filename
+	key := filename
 	if len(filename) > 6 && filename[len(filename)-6:] == ".state" {
-		instanceID = filename[:len(filename)-6]
+		key = filename[:len(filename)-6]
 	}
+	// Per-player saves are named <instance>__<player>.state; either kind
+	// resolves the instance's pending upload.
+	instanceID, _ := protocol.SplitSaveKey(key)
 
-	data, err := io.ReadAll(io.LimitReader(file, saveUploadMaxBytes+1))
+	// Verification needs the whole state, which is capped at saveUploadMaxBytes.
+	data, err := io.ReadAll(upload.File)
 	if err != nil {
 		http.Error(w, "read save: "+err.Error(), http.StatusBadRequest)
 		return
 	}
-	if len(data) > saveUploadMaxBytes {
-		http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
-		return
-	}
 
-	verified := savestate.VerifyBizHawkSavestate(data, savestate.VerifyOptions{MaxFileBytes: saveUploadMaxBytes})
+	// Raw (non-ZIP) states from cores that write them are accepted; clients decide
+	// whether to require ZIPs via verify_zip_saves.
+	verified := savestate.VerifyBizHawkSavestate(data, savestate.VerifyOptions{MaxFileBytes: saveUploadMaxBytes, AllowRaw: true})
 	if !verified.OK {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnprocessableEntity)
@@ -75,8 +88,11 @@ func (s *Server) handleSaveUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := s.archiveSaveVersion(key); err != nil {
+		fmt.Printf("archive previous save for %s: %v\n", key, err)
+	}
 	dstPath := filepath.Join(savesDir, filename)
-	if err := os.WriteFile(dstPath, data, 0o644); err != nil {
+	if err := upload.Keep(dstPath); err != nil {
 		s.setInstanceFileState(instanceID, protocol.FileStateNone)
 		http.Error(w, "write save file: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -91,6 +107,19 @@ func (s *Server) handleSaveUpload(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// saveOwner returns the player whose name namespaces player's saves, or ""
+// when saves are shared per instance (PerPlayerSaves off or not save mode).
+func (s *Server) saveOwner(player string) string {
+	var perPlayer bool
+	s.withRLock(func() {
+		perPlayer = s.state.PerPlayerSaves && s.state.Mode == protocol.GameModeSave
+	})
+	if !perPlayer {
+		return ""
+	}
+	return player
+}
+
 // handleSaveDownload serves save files from ./saves directory
 func (s *Server) handleSaveDownload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -115,10 +144,11 @@ func (s *Server) handleSaveDownload(w http.ResponseWriter, r *http.Request) {
 	filename = filepath.Base(filename)
 
 	// Extract instance ID from filename (remove .state extension)
-	instanceID := filename
+	key := filename
 	if len(filename) > 6 && filename[len(filename)-6:] == ".state" {
-		instanceID = filename[:len(filename)-6]
+		key = filename[:len(filename)-6]
 	}
+	instanceID, owner := protocol.SplitSaveKey(key)
 
 	// Wait for file to be ready (handle pending state)
 	if err := s.waitForFileReady(instanceID); err != nil {
@@ -129,18 +159,60 @@ func (s *Server) handleSaveDownload(w http.ResponseWriter, r *http.Request) {
 	savePath := filepath.Join("./saves", filename)
 
 	// Check if file exists
+	// A player's own save says nothing about the instance's shared file state.
 	if _, err := os.Stat(savePath); os.IsNotExist(err) {
 		// Set state to none if file doesn't exist
-		s.setInstanceFileState(instanceID, protocol.FileStateNone)
+		if owner == "" {
+			s.setInstanceFileState(instanceID, protocol.FileStateNone)
+		}
 		http.Error(w, "save file not found", http.StatusNotFound)
 		return
 	}
-	s.setInstanceFileState(instanceID, protocol.FileStateReady)
+	if owner == "" {
+		s.setInstanceFileState(instanceID, protocol.FileStateReady)
+	}
+
+	w.Header().Set("Accept-Encoding", "gzip")
+	if acceptsGzip(r) {
+		serveSaveGzip(w, savePath)
+		return
+	}
 
 	// Serve the file
 	http.ServeFile(w, r, savePath)
 }
 
+// acceptsGzip reports whether the request advertises gzip in Accept-Encoding.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") && strings.TrimSpace(params) != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// serveSaveGzip streams a save file with Content-Encoding: gzip.
+func serveSaveGzip(w http.ResponseWriter, savePath string) {
+	f, err := os.Open(savePath)
+	if err != nil {
+		http.Error(w, "open save file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	gz := gzip.NewWriter(w)
+	if _, err := io.Copy(gz, f); err != nil {
+		fmt.Printf("write gzip save error: %v\n", err)
+	}
+	if err := gz.Close(); err != nil {
+		fmt.Printf("close gzip save error: %v\n", err)
+	}
+}
+
 // handleNoSaveState handles POST /save/no-save to indicate no save file exists for an instance
 func (s *Server) handleNoSaveState(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -170,6 +242,61 @@ func (s *Server) handleNoSaveState(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// apiRequestSave: POST {player, instance_id}
+// Asks a connected player to save and upload their current state, waiting for the client's ack.
+// instance_id defaults to the player's current instance when omitted.
+func (s *Server) apiRequestSave(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b struct {
+		Player     string `json:"player"`
+		InstanceID string `json:"instance_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if b.Player == "" {
+		http.Error(w, "missing player", http.StatusBadRequest)
+		return
+	}
+	instanceID := b.InstanceID
+	if instanceID == "" {
+		s.withRLock(func() { instanceID = s.state.Players[b.Player].InstanceID })
+	}
+	if instanceID == "" {
+		http.Error(w, "missing instance_id", http.StatusBadRequest)
+		return
+	}
+
+	res, err := s.RequestSaveAndWait(b.Player, instanceID, 30*time.Second)
+	switch {
+	case errors.Is(err, ErrPlayerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, ErrPlayerNotConnected):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, ErrTimeout):
+		http.Error(w, "timed out waiting for save", http.StatusGatewayTimeout)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if strings.HasPrefix(res, "nack") {
+		http.Error(w, "save rejected: "+strings.TrimPrefix(strings.TrimPrefix(res, "nack"), "|"), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok"}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
 // setInstanceFileState updates the file state for a given instance ID
 func (s *Server) setInstanceFileState(instanceID string, state protocol.FileState) {
 	s.setInstanceFileStateWithPlayer(instanceID, state, "")
@@ -177,6 +304,7 @@ func (s *Server) setInstanceFileState(instanceID string, state protocol.FileStat
 
 // setInstanceFileStateWithPlayer updates the file state for a given instance ID and sets the pending player
 func (s *Server) setInstanceFileStateWithPlayer(instanceID string, state protocol.FileState, pendingPlayer string) {
+	changed := false
 	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 		for i, instance := range st.GameSwapInstances {
 			if instance.ID == instanceID {
@@ -193,10 +321,18 @@ func (s *Server) setInstanceFileStateWithPlayer(instanceID string, state protoco
 				fmt.Println("Setting file state for instance", instanceID, "to", state, "pending player:", pendingPlayer)
 				st.GameSwapInstances[i].FileState = state
 				st.GameSwapInstances[i].PendingPlayer = pendingPlayer
+				changed = true
 				break
 			}
 		}
 	})
+	if changed {
+		s.emitAdminEvent(protocol.CmdFileStateChanged, protocol.FileStateEvent{
+			InstanceID:    instanceID,
+			FileState:     state,
+			PendingPlayer: pendingPlayer,
+		})
+	}
 }
 
 func (s *Server) RequestPendingSaves() {
@@ -0,0 +1,248 @@
+package serverhost
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+	"github.com/michael4d45/bizshuffle/savestate"
+)
+
+func TestAPIRequestSaveRejectsOfflinePlayer(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["bob"] = protocol.Player{Name: "bob", InstanceID: "i1"}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	for body, want := range map[string]int{
+		`{"player":"bob"}`:                      http.StatusConflict,
+		`{"player":"ghost","instance_id":"i1"}`: http.StatusNotFound,
+	} {
+		res, err := http.Post(srv.URL+"/api/request_save", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != want {
+			t.Fatalf("%s: status %d, want %d", body, res.StatusCode, want)
+		}
+	}
+}
+
+func TestAPIRequestSaveWaitsForAck(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true, InstanceID: "i1"}
+	})
+	client := registerPlayerWSClient(s, "bob")
+	go func() {
+		cmd := <-client.sendCh
+		if cmd.Cmd != protocol.CmdRequestSave {
+			return
+		}
+		s.withRLock(func() {
+			if ch, ok := s.pending[cmd.ID]; ok {
+				ch <- "ack"
+			}
+		})
+	}()
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	res, err := http.Post(srv.URL+"/api/request_save", "application/json", bytes.NewBufferString(`{"player":"bob"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status %d body %s", res.StatusCode, body)
+	}
+}
+
+func TestSaveDownloadAndUploadNegotiateGzip(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "i1", Game: "a.zip", FileState: protocol.FileStateNone}}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	save, err := savestate.BuildMinimalBizHawkSavestate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, _ := mw.CreateFormFile("save", "i1.state")
+	_, _ = fw.Write(save)
+	_ = mw.Close()
+	var gzBody bytes.Buffer
+	gz := gzip.NewWriter(&gzBody)
+	_, _ = gz.Write(form.Bytes())
+	_ = gz.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/save/upload", &gzBody)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Content-Encoding", "gzip")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("gzip upload status %d body %s", res.StatusCode, body)
+	}
+	if res.Header.Get("Accept-Encoding") != "gzip" {
+		t.Fatal("expected server to advertise gzip request support")
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/save/i1.state", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip response, got %q", res.Header.Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(zr)
+	if !bytes.Equal(got, save) {
+		t.Fatal("gzip download does not match uploaded save")
+	}
+}
+
+func TestSetInstanceFileStateEmitsAdminEvent(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "i1", Game: "a.nes", FileState: protocol.FileStateNone}}
+	})
+	admin := &wsClient{sendCh: make(chan protocol.Command, 8)}
+	s.withConnLock(func() { s.adminClients["ui"] = admin })
+
+	s.setInstanceFileStateWithPlayer("i1", protocol.FileStatePending, "amy")
+	for {
+		select {
+		case cmd := <-admin.sendCh:
+			if cmd.Cmd != protocol.CmdFileStateChanged {
+				continue
+			}
+			ev, ok := cmd.Payload.(protocol.FileStateEvent)
+			if !ok || ev.InstanceID != "i1" || ev.FileState != protocol.FileStatePending || ev.PendingPlayer != "amy" {
+				t.Fatalf("unexpected event: %+v", cmd.Payload)
+			}
+			return
+		case <-time.After(2 * time.Second):
+			t.Fatal("file_state_changed not sent to admin")
+		}
+	}
+}
+
+func TestPerPlayerSavesAreNamespacedByOwner(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.PerPlayerSaves = true
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "i1", Game: "a.zip", FileState: protocol.FileStatePending, PendingPlayer: "bob"},
+			{ID: "i2", Game: "b.zip", FileState: protocol.FileStateNone},
+		}
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	if _, cmd, err := s.requestSaveCommand("bob", "i1"); err != nil {
+		t.Fatal(err)
+	} else if owner := cmd.Payload.(map[string]string)["save_owner"]; owner != "bob" {
+		t.Fatalf("request_save save_owner = %q, want bob", owner)
+	}
+
+	save, err := savestate.BuildMinimalBizHawkSavestate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, _ := mw.CreateFormFile("save", "i2__bob.state")
+	_, _ = fw.Write(save)
+	_ = mw.Close()
+	res, err := http.Post(srv.URL+"/save/upload", mw.FormDataContentType(), &form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("upload status %d", res.StatusCode)
+	}
+	if _, err := os.Stat(filepath.Join("saves", "i2__bob.state")); err != nil {
+		t.Fatalf("per-player save not stored: %v", err)
+	}
+	if got := s.SnapshotState().GameSwapInstances[1].FileState; got != protocol.FileStateReady {
+		t.Fatalf("i2 file state = %q, want ready", got)
+	}
+
+	// Another player's missing save must not clear the instance's state.
+	res, err = http.Get(srv.URL + "/save/i2__carol.state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("carol download status %d, want 404", res.StatusCode)
+	}
+	if got := s.SnapshotState().GameSwapInstances[1].FileState; got != protocol.FileStateReady {
+		t.Fatalf("i2 file state after 404 = %q, want ready", got)
+	}
+
+	// i1 is free and first, but bob resumes the instance holding their save.
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameSwapInstances[0].FileState = protocol.FileStateNone
+		st.GameSwapInstances[0].PendingPlayer = ""
+	})
+	h := &SaveModeHandler{server: s}
+	if got := h.GetPlayer("bob"); got.InstanceID != "i2" {
+		t.Fatalf("bob got instance %q, want i2", got.InstanceID)
+	}
+	if got := h.GetPlayer("carol"); got.InstanceID != "i1" {
+		t.Fatalf("carol got instance %q, want i1", got.InstanceID)
+	}
+
+	orphans, err := s.findOrphanSaves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 0 {
+		t.Fatalf("per-player save listed as orphan: %+v", orphans)
+	}
+}
@@ -0,0 +1,286 @@
+package serverhost
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+const (
+	screenshotsDir          = "./screenshots"
+	screenshotUploadMaxSize = 8 << 20
+	// screenshotsKeep is how many screenshots are kept per player; older
+	// ones are pruned after each upload.
+	screenshotsKeep = 20
+)
+
+var pngMagic = []byte("\x89PNG\r\n\x1a\n")
+
+// ScreenshotInfo describes one stored screenshot for GET /api/screenshots/{player}.
+type ScreenshotInfo struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"`
+	URL     string `json:"url"`
+}
+
+// playerScreenshotDir returns the directory holding a player's screenshots.
+// Names that would escape screenshotsDir are rejected.
+func playerScreenshotDir(player string) (string, bool) {
+	if player == "" || player == "." || player == ".." || strings.ContainsAny(player, `/\`) {
+		return "", false
+	}
+	return filepath.Join(screenshotsDir, player), true
+}
+
+// listScreenshots returns a player's screenshots, newest first.
+func listScreenshots(player string) ([]ScreenshotInfo, error) {
+	dir, ok := playerScreenshotDir(player)
+	if !ok {
+		return nil, fmt.Errorf("invalid player name %q", player)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ScreenshotInfo{}, nil
+		}
+		return nil, err
+	}
+	out := []ScreenshotInfo{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".png" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, ScreenshotInfo{
+			Name:    e.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime().Unix(),
+			URL:     "/api/screenshots/" + url.PathEscape(player) + "/" + url.PathEscape(e.Name()),
+		})
+	}
+	// Names embed a sortable timestamp, so name order is age order.
+	slices.SortFunc(out, func(a, b ScreenshotInfo) int { return strings.Compare(b.Name, a.Name) })
+	return out, nil
+}
+
+// handleScreenshotRoutes serves /api/screenshots/{player}[/{file}]. POST to
+// /api/screenshots/{player} is the client upload and, like /save/upload, is not
+// admin-gated; listing and fetching screenshots require the admin token.
+func (s *Server) handleScreenshotRoutes(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/screenshots/")
+	parts := strings.Split(rest, "/")
+	player, err := url.PathUnescape(parts[0])
+	if err != nil || player == "" || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		s.handleScreenshotUpload(w, r, player)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+			s.apiListScreenshots(w, player)
+		})(w, r)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+			s.serveScreenshot(w, r, player, parts[1])
+		})(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeScreenshot stores data in dir under a new <timestamp>-<seq>.png name.
+// O_EXCL plus the sequence suffix keeps two uploads in the same millisecond
+// from overwriting each other while names stay sortable by age.
+func writeScreenshot(dir string, data []byte) (string, error) {
+	ts := time.Now().UTC().Format("20060102-150405.000")
+	for seq := 0; seq < 100; seq++ {
+		name := fmt.Sprintf("%s-%02d.png", ts, seq)
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(data); err != nil {
+			_ = f.Close()
+			_ = os.Remove(filepath.Join(dir, name))
+			return "", err
+		}
+		return name, f.Close()
+	}
+	return "", fmt.Errorf("no free screenshot name for %s", ts)
+}
+
+// pruneScreenshots removes all but the newest screenshotsKeep screenshots of player.
+func pruneScreenshots(player string) {
+	shots, err := listScreenshots(player)
+	if err != nil || len(shots) <= screenshotsKeep {
+		return
+	}
+	dir, _ := playerScreenshotDir(player)
+	for _, shot := range shots[screenshotsKeep:] {
+		if err := os.Remove(filepath.Join(dir, shot.Name)); err != nil {
+			fmt.Printf("prune screenshot %s for %s: %v\n", shot.Name, player, err)
+		}
+	}
+}
+
+// handleScreenshotUpload stores a PNG body as
+// ./screenshots/{player}/<timestamp>-<seq>.png. Only connected players may
+// upload, and each player keeps at most screenshotsKeep files.
+func (s *Server) handleScreenshotUpload(w http.ResponseWriter, r *http.Request, player string) {
+	var p protocol.Player
+	var known bool
+	s.withRLock(func() {
+		p, known = s.state.Players[player]
+	})
+	if !known {
+		http.Error(w, "unknown player", http.StatusNotFound)
+		return
+	}
+	if !p.Connected {
+		http.Error(w, "player not connected", http.StatusConflict)
+		return
+	}
+	dir, ok := playerScreenshotDir(player)
+	if !ok {
+		http.Error(w, "invalid player name", http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, screenshotUploadMaxSize))
+	if err != nil {
+		http.Error(w, "read body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !bytes.HasPrefix(data, pngMagic) {
+		http.Error(w, "screenshot is not a PNG", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		http.Error(w, "failed to create screenshots dir", http.StatusInternalServerError)
+		return
+	}
+	name, err := writeScreenshot(dir, data)
+	if err != nil {
+		http.Error(w, "failed to write screenshot", http.StatusInternalServerError)
+		return
+	}
+	pruneScreenshots(player)
+	fmt.Printf("Stored screenshot %s for %s (%d bytes)\n", name, player, len(data))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok", "name": name}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+func (s *Server) apiListScreenshots(w http.ResponseWriter, player string) {
+	shots, err := listScreenshots(player)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"screenshots": shots}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+func (s *Server) serveScreenshot(w http.ResponseWriter, r *http.Request, player, file string) {
+	dir, ok := playerScreenshotDir(player)
+	name, err := url.PathUnescape(file)
+	if !ok || err != nil || name != filepath.Base(name) || filepath.Ext(name) != ".png" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	http.ServeFile(w, r, filepath.Join(dir, name))
+}
+
+// RequestScreenshotAndWait sends screenshot to playerName and waits for the
+// client's ack, which it sends only after the upload has been stored.
+func (s *Server) RequestScreenshotAndWait(playerName string, timeout time.Duration) (string, error) {
+	var player protocol.Player
+	var ok bool
+	s.withRLock(func() {
+		player, ok = s.state.Players[playerName]
+	})
+	if !ok {
+		return "", fmt.Errorf("player %s: %w", playerName, ErrPlayerNotFound)
+	}
+	if !player.Connected {
+		return "", fmt.Errorf("player %s: %w", playerName, ErrPlayerNotConnected)
+	}
+	cmd := protocol.Command{
+		Cmd: protocol.CmdScreenshot,
+		ID:  fmt.Sprintf("screenshot-%d-%s", time.Now().UnixNano(), playerName),
+	}
+	return s.sendAndWait(player, cmd, timeout)
+}
+
+// apiRequestScreenshot handles POST /api/request_screenshot {player}: asks the
+// player's client for a screenshot and returns the newest stored one.
+func (s *Server) apiRequestScreenshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b struct {
+		Player string `json:"player"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if b.Player == "" {
+		http.Error(w, "missing player", http.StatusBadRequest)
+		return
+	}
+
+	res, err := s.RequestScreenshotAndWait(b.Player, 30*time.Second)
+	switch {
+	case errors.Is(err, ErrPlayerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, ErrPlayerNotConnected):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, ErrTimeout):
+		http.Error(w, "timed out waiting for screenshot", http.StatusGatewayTimeout)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if strings.HasPrefix(res, "nack") {
+		http.Error(w, "screenshot rejected: "+strings.TrimPrefix(strings.TrimPrefix(res, "nack"), "|"), http.StatusBadGateway)
+		return
+	}
+
+	shots, err := listScreenshots(b.Player)
+	if err != nil || len(shots) == 0 {
+		http.Error(w, "screenshot was not stored", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"result": "ok", "screenshot": shots[0]}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
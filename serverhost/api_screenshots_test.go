@@ -0,0 +1,150 @@
+package serverhost
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestRequestScreenshotStoresUpload(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	png := append(append([]byte{}, pngMagic...), "fake image data"...)
+	client := registerPlayerWSClient(s, "bob")
+	go func() {
+		cmd := <-client.sendCh
+		if cmd.Cmd != protocol.CmdScreenshot {
+			return
+		}
+		res, err := http.Post(srv.URL+"/api/screenshots/bob", "image/png", bytes.NewReader(png))
+		if err == nil {
+			_ = res.Body.Close()
+		}
+		s.withRLock(func() {
+			if ch, ok := s.pending[cmd.ID]; ok {
+				ch <- "ack"
+			}
+		})
+	}()
+
+	res, err := http.Post(srv.URL+"/api/request_screenshot", "application/json", bytes.NewBufferString(`{"player":"bob"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Result     string         `json:"result"`
+		Screenshot ScreenshotInfo `json:"screenshot"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK || out.Result != "ok" || out.Screenshot.URL == "" {
+		t.Fatalf("status %d body %+v", res.StatusCode, out)
+	}
+
+	res, err = http.Get(srv.URL + out.Screenshot.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK || !bytes.Equal(got, png) {
+		t.Fatalf("GET screenshot status %d, %d bytes", res.StatusCode, len(got))
+	}
+}
+
+func TestScreenshotUploadRejectsBadInput(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+		st.Players["carol"] = protocol.Player{Name: "carol"}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	for path, want := range map[string]int{
+		"/api/screenshots/bob":   http.StatusUnsupportedMediaType,
+		"/api/screenshots/carol": http.StatusConflict,
+		"/api/screenshots/ghost": http.StatusNotFound,
+	} {
+		res, err := http.Post(srv.URL+path, "image/png", bytes.NewBufferString("not a png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != want {
+			t.Fatalf("%s: status %d, want %d", path, res.StatusCode, want)
+		}
+	}
+}
+
+func TestScreenshotUploadKeepsNewestUniqueFiles(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	png := append(append([]byte{}, pngMagic...), "fake image data"...)
+	for i := 0; i < screenshotsKeep+5; i++ {
+		res, err := http.Post(srv.URL+"/api/screenshots/bob", "image/png", bytes.NewReader(png))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("upload %d: status %d", i, res.StatusCode)
+		}
+	}
+	shots, err := listScreenshots("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shots) != screenshotsKeep {
+		t.Fatalf("kept %d screenshots, want %d", len(shots), screenshotsKeep)
+	}
+}
+
+func TestRequestScreenshotNackWhenBizhawkNotReady(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+	})
+	client := registerPlayerWSClient(s, "bob")
+	go func() {
+		cmd := <-client.sendCh
+		s.withRLock(func() {
+			if ch, ok := s.pending[cmd.ID]; ok {
+				ch <- "nack|IPC not ready"
+			}
+		})
+	}()
+
+	rec := httptest.NewRecorder()
+	s.apiRequestScreenshot(rec, httptest.NewRequest(http.MethodPost, "/api/request_screenshot", bytes.NewBufferString(`{"player":"bob"}`)))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,330 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// sessionConfigVersion is the version written by GET /api/session/export.
+const sessionConfigVersion = 1
+
+var errSessionAssignmentsInUse = errors.New("players hold instances missing from the import")
+
+// SessionConfig is a shareable session preset: the catalog, instances, mode and
+// swap settings, without players, saves or plugin state.
+type SessionConfig struct {
+	Version              int                           `json:"version"`
+	Mode                 protocol.GameMode             `json:"mode"`
+	MainGames            []protocol.GameEntry          `json:"main_games"`
+	Games                []string                      `json:"games"`
+	GameSwapInstances    []protocol.GameSwapInstance   `json:"game_instances"`
+	MinIntervalSecs      int                           `json:"min_interval_secs,omitempty"`
+	MaxIntervalSecs      int                           `json:"max_interval_secs,omitempty"`
+	PreventSameGameSwap  bool                          `json:"prevent_same_game_swap"`
+	CountdownEnabled     bool                          `json:"countdown_enabled"`
+	SwapPreviewSecs      int                           `json:"swap_preview_secs,omitempty"`
+	IntervalDistribution protocol.IntervalDistribution `json:"interval_distribution,omitempty"`
+	ConfigKeys           []string                      `json:"config_keys,omitempty"`
+}
+
+// exportSession snapshots the current session settings. Instances are exported
+// without their file state, which only describes this server's saves.
+func (s *Server) exportSession() SessionConfig {
+	var cfg SessionConfig
+	s.withRLock(func() {
+		st := s.state
+		cfg = SessionConfig{
+			Version:              sessionConfigVersion,
+			Mode:                 st.Mode,
+			MainGames:            append([]protocol.GameEntry{}, st.MainGames...),
+			Games:                append([]string{}, st.Games...),
+			MinIntervalSecs:      st.MinIntervalSecs,
+			MaxIntervalSecs:      st.MaxIntervalSecs,
+			PreventSameGameSwap:  st.PreventSameGameSwap,
+			CountdownEnabled:     st.CountdownEnabled,
+			SwapPreviewSecs:      st.SwapPreviewSecs,
+			IntervalDistribution: st.IntervalDistribution,
+			ConfigKeys:           append([]string(nil), st.ConfigKeys...),
+		}
+		cfg.GameSwapInstances = make([]protocol.GameSwapInstance, 0, len(st.GameSwapInstances))
+		for _, inst := range st.GameSwapInstances {
+			cfg.GameSwapInstances = append(cfg.GameSwapInstances, protocol.GameSwapInstance{ID: inst.ID, Game: inst.Game, Locked: inst.Locked})
+		}
+	})
+	return cfg
+}
+
+// validateSessionConfig rejects presets the server could not run.
+func validateSessionConfig(cfg SessionConfig) error {
+	if cfg.Version < 1 || cfg.Version > sessionConfigVersion {
+		return fmt.Errorf("unsupported session version %d", cfg.Version)
+	}
+	if !knownGameMode(cfg.Mode) {
+		return fmt.Errorf("unknown mode %q", cfg.Mode)
+	}
+	if cfg.MinIntervalSecs < 0 || cfg.MaxIntervalSecs < 0 {
+		return fmt.Errorf("intervals must not be negative")
+	}
+	if cfg.MinIntervalSecs > 0 && cfg.MaxIntervalSecs > 0 && cfg.MinIntervalSecs > cfg.MaxIntervalSecs {
+		return fmt.Errorf("min_interval_secs %d exceeds max_interval_secs %d", cfg.MinIntervalSecs, cfg.MaxIntervalSecs)
+	}
+	if !knownIntervalDistribution(cfg.IntervalDistribution) {
+		return fmt.Errorf("unknown interval_distribution %q", cfg.IntervalDistribution)
+	}
+	files := map[string]bool{}
+	for _, g := range cfg.MainGames {
+		if g.File == "" {
+			return fmt.Errorf("main_games entry with empty file")
+		}
+		if files[g.File] {
+			return fmt.Errorf("duplicate main_games entry %q", g.File)
+		}
+		files[g.File] = true
+	}
+	// Games is the active rotation, picked from the catalog.
+	for _, g := range cfg.Games {
+		if !files[g] {
+			return fmt.Errorf("games entry %q is not in main_games", g)
+		}
+	}
+	ids := map[string]bool{}
+	for _, inst := range cfg.GameSwapInstances {
+		if inst.ID == "" || inst.Game == "" {
+			return fmt.Errorf("game_instances entries need an id and a game")
+		}
+		if ids[inst.ID] {
+			return fmt.Errorf("duplicate instance id %q", inst.ID)
+		}
+		ids[inst.ID] = true
+	}
+	return nil
+}
+
+// importSession validates cfg and applies it in one state update. Player
+// assignments are left alone unless reassign is set, in which case every player
+// is cleared and dealt a new game or instance afterwards. Without reassign, an
+// import that drops an instance a player holds is refused.
+func (s *Server) importSession(cfg SessionConfig, reassign bool) ([]string, error) {
+	if err := validateSessionConfig(cfg); err != nil {
+		return nil, err
+	}
+	var players []string
+	var result error
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		games := map[string]string{}
+		for _, inst := range cfg.GameSwapInstances {
+			games[inst.ID] = inst.Game
+		}
+		if !reassign {
+			// An instance is still in use if a player holds it and the preset
+			// drops it or points it at another game.
+			var held []string
+			for name, p := range st.Players {
+				if game, ok := games[p.InstanceID]; p.InstanceID != "" && (!ok || game != p.Game) {
+					held = append(held, fmt.Sprintf("%s (%s)", name, p.InstanceID))
+				}
+			}
+			if len(held) > 0 {
+				slices.Sort(held)
+				result = fmt.Errorf("%w: %s", errSessionAssignmentsInUse, strings.Join(held, ", "))
+				return
+			}
+		}
+
+		fileStates := map[string]protocol.GameSwapInstance{}
+		for _, inst := range st.GameSwapInstances {
+			fileStates[inst.ID] = inst
+		}
+		instances := make([]protocol.GameSwapInstance, 0, len(cfg.GameSwapInstances))
+		for _, inst := range cfg.GameSwapInstances {
+			next := protocol.GameSwapInstance{ID: inst.ID, Game: inst.Game, FileState: protocol.FileStateNone, Locked: inst.Locked}
+			// Keep the live file state of an instance that still runs the same game.
+			if old, ok := fileStates[inst.ID]; ok && old.Game == inst.Game {
+				next.FileState = old.FileState
+				next.PendingPlayer = old.PendingPlayer
+			}
+			instances = append(instances, next)
+		}
+
+		if st.Mode != cfg.Mode {
+			st.RaceWinner = ""
+			st.BingoWinners = nil
+		}
+		st.Mode = cfg.Mode
+		st.MainGames = append([]protocol.GameEntry{}, cfg.MainGames...)
+		st.Games = append([]string{}, cfg.Games...)
+		st.GameSwapInstances = instances
+		st.MinIntervalSecs = cfg.MinIntervalSecs
+		st.MaxIntervalSecs = cfg.MaxIntervalSecs
+		st.PreventSameGameSwap = cfg.PreventSameGameSwap
+		st.CountdownEnabled = cfg.CountdownEnabled
+		st.SwapPreviewSecs = min(max(cfg.SwapPreviewSecs, 0), maxSwapPreviewSecs)
+		st.IntervalDistribution = cfg.IntervalDistribution
+		st.ConfigKeys = append([]string(nil), cfg.ConfigKeys...)
+
+		if reassign {
+			for name, p := range st.Players {
+				p.Game = ""
+				p.InstanceID = ""
+				st.Players[name] = p
+				players = append(players, name)
+			}
+			slices.Sort(players)
+		}
+	})
+	if result != nil {
+		return nil, result
+	}
+	log.Printf("[session] imported %s preset: %d games, %d instances", cfg.Mode, len(cfg.MainGames), len(cfg.GameSwapInstances))
+	s.broadcastGamesUpdate(nil)
+	if reassign {
+		go func() {
+			if cfg.Mode == protocol.GameModeSave {
+				s.reassignPlayers(players)
+				return
+			}
+			// Shared-game modes deal everyone the same game through a full swap.
+			if err := s.performSwap(); err != nil {
+				log.Printf("[session] swap after import failed: %v", err)
+			}
+		}()
+	}
+	return players, nil
+}
+
+// apiSessionExport handles GET /api/session/export: the session preset as a
+// downloadable JSON file.
+func (s *Server) apiSessionExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="session.json"`)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s.exportSession()); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// apiSessionImport handles POST /api/session/import[?reassign=true] with a body
+// from /api/session/export.
+func (s *Server) apiSessionImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var cfg SessionConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	reassign := r.URL.Query().Get("reassign") == "true"
+	reassigned, err := s.importSession(cfg, reassign)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errSessionAssignmentsInUse) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	missing := append(missingRoms(catalogFiles(cfg.MainGames)), missingRomDirs(cfg.MainGames)...)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"result":        "ok",
+		"reassigned":    append([]string{}, reassigned...),
+		"missing_files": missing,
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+var errSessionRunning = errors.New("session already running")
+
+// inLobby reports whether joining players should wait without a game: the
+// lobby is enabled and the session has not started (or was stopped, not paused).
+func (s *Server) inLobby() bool {
+	var lobby bool
+	s.withRLock(func() {
+		lobby = s.state.LobbyEnabled && !s.state.Running && !s.state.Paused
+	})
+	return lobby
+}
+
+// startSession ends the lobby: it runs the mode's SetupState, marks the
+// session running, deals every connected player a game and sends the swaps.
+// It returns the players holding a game afterwards.
+func (s *Server) startSession() ([]string, error) {
+	var running bool
+	s.withRLock(func() { running = s.state.Running })
+	if running {
+		return nil, errSessionRunning
+	}
+	if err := s.GetGameModeHandler().SetupState(); err != nil {
+		return nil, err
+	}
+	var connected []string
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Running = true
+		st.Paused = false
+		for name, p := range st.Players {
+			if p.Connected {
+				connected = append(connected, name)
+			}
+		}
+	})
+	slices.Sort(connected)
+	s.broadcastGamesUpdate(nil)
+
+	var dealt []string
+	for _, name := range connected {
+		if p := s.AssignPlayerOnConnect(name); p.Game != "" {
+			dealt = append(dealt, name)
+		} else {
+			log.Printf("[session] %s has no game to start with", name)
+		}
+	}
+	log.Printf("[session] started with %d of %d connected players", len(dealt), len(connected))
+	s.sendSwapAll(SwapSendOptions{SkipSave: true})
+	s.broadcastToPlayers(protocol.Command{Cmd: protocol.CmdResume, ID: fmt.Sprintf("%d", time.Now().UnixNano())})
+	select {
+	case s.schedulerCh <- struct{}{}:
+	default:
+	}
+	return dealt, nil
+}
+
+// apiSessionStart handles POST /api/session/start: leaves the lobby and
+// deals games to the players who joined it.
+func (s *Server) apiSessionStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	players, err := s.startSession()
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errSessionRunning) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"result":  "ok",
+		"players": append([]string{}, players...),
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
@@ -0,0 +1,179 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestSessionExportImportRoundTrip(t *testing.T) {
+	chdirToTemp(t)
+	src := New()
+	stopStateSaverOnCleanup(t, src)
+	src.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.MainGames = []protocol.GameEntry{{File: "z1.nes"}, {File: "z2.nes", Weight: 2}}
+		st.Games = []string{"z1.nes", "z2.nes"}
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "z1a", Game: "z1.nes", FileState: protocol.FileStateReady}}
+		st.MinIntervalSecs, st.MaxIntervalSecs = 60, 120
+		st.CountdownEnabled = true
+		st.ConfigKeys = []string{"Sound"}
+		st.Players["amy"] = protocol.Player{Name: "amy", Game: "z1.nes", InstanceID: "z1a"}
+	})
+	rec := httptest.NewRecorder()
+	src.apiSessionExport(rec, httptest.NewRequest(http.MethodGet, "/api/session/export", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status %d", rec.Code)
+	}
+	exported := rec.Body.String()
+	if strings.Contains(exported, "amy") || strings.Contains(exported, "ready") {
+		t.Fatalf("export leaked players or file state: %s", exported)
+	}
+
+	dst := New()
+	stopStateSaverOnCleanup(t, dst)
+	dst.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Players["bob"] = protocol.Player{Name: "bob", Game: "old.nes"}
+	})
+	rec = httptest.NewRecorder()
+	dst.apiSessionImport(rec, httptest.NewRequest(http.MethodPost, "/api/session/import", strings.NewReader(exported)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import status %d body %s", rec.Code, rec.Body)
+	}
+	st := dst.SnapshotState()
+	if st.Mode != protocol.GameModeSave || len(st.MainGames) != 2 || st.MainGames[1].Weight != 2 ||
+		len(st.GameSwapInstances) != 1 || st.GameSwapInstances[0].FileState != protocol.FileStateNone ||
+		st.MinIntervalSecs != 60 || st.MaxIntervalSecs != 120 || !st.CountdownEnabled || len(st.ConfigKeys) != 1 {
+		t.Fatalf("imported state %+v", st)
+	}
+	if st.Players["bob"].Game != "old.nes" {
+		t.Fatalf("import without reassign touched bob: %+v", st.Players["bob"])
+	}
+}
+
+func TestSessionImportValidates(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "keep", Game: "a.nes"}}
+		st.Players["amy"] = protocol.Player{Name: "amy", Game: "a.nes", InstanceID: "keep"}
+	})
+
+	for body, want := range map[string]int{
+		`{"version":1,"mode":"chaos"}`: http.StatusBadRequest,
+		`{"version":9,"mode":"sync"}`:  http.StatusBadRequest,
+		`{"version":1,"mode":"sync","min_interval_secs":90,"max_interval_secs":30}`:                          http.StatusBadRequest,
+		`{"version":1,"mode":"sync","main_games":[{"file":"a.nes"}],"games":["a.nes","ghost.nes"]}`:          http.StatusBadRequest,
+		`{"version":1,"mode":"save","game_instances":[{"id":"x","game":"a.nes"},{"id":"x","game":"b.nes"}]}`: http.StatusBadRequest,
+		`{"version":1,"mode":"save","game_instances":[{"id":"other","game":"a.nes"}]}`:                       http.StatusConflict,
+		`{"version":1,"mode":"save","game_instances":[{"id":"keep","game":"b.nes"}]}`:                        http.StatusConflict,
+	} {
+		rec := httptest.NewRecorder()
+		s.apiSessionImport(rec, httptest.NewRequest(http.MethodPost, "/api/session/import", strings.NewReader(body)))
+		if rec.Code != want {
+			t.Fatalf("%s: status %d, want %d (%s)", body, rec.Code, want, rec.Body)
+		}
+	}
+	if st := s.SnapshotState(); st.GameSwapInstances[0].ID != "keep" {
+		t.Fatalf("rejected import changed state: %+v", st.GameSwapInstances)
+	}
+
+	rec := httptest.NewRecorder()
+	body := `{"version":1,"mode":"save","game_instances":[{"id":"other","game":"a.nes"}]}`
+	s.apiSessionImport(rec, httptest.NewRequest(http.MethodPost, "/api/session/import?reassign=true", strings.NewReader(body)))
+	var out struct {
+		Reassigned []string `json:"reassigned"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("reassign import status %d err %v", rec.Code, err)
+	}
+	if len(out.Reassigned) != 1 || out.Reassigned[0] != "amy" {
+		t.Fatalf("reassigned = %v", out.Reassigned)
+	}
+}
+
+func TestLobbyHoldsJoinersUntilSessionStart(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Games = []string{"a.zip"}
+		st.LobbyEnabled = true
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdHello, ID: "1", Payload: map[string]any{"name": "bob", "bizhawk_ready": true}}); err != nil {
+		t.Fatal(err)
+	}
+	swaps := make(chan protocol.Command, 4)
+	go func() {
+		for {
+			var cmd protocol.Command
+			if err := c.ReadJSON(&cmd); err != nil {
+				return
+			}
+			if cmd.Cmd == protocol.CmdSwap {
+				swaps <- cmd
+			}
+		}
+	}()
+	// waitForSwap waits up to d for the next swap sent to bob.
+	waitForSwap := func(d time.Duration) (protocol.Command, bool) {
+		select {
+		case cmd := <-swaps:
+			return cmd, true
+		case <-time.After(d):
+			return protocol.Command{}, false
+		}
+	}
+
+	if cmd, ok := waitForSwap(300 * time.Millisecond); ok {
+		t.Fatalf("swap sent in lobby: %+v", cmd)
+	}
+	if p := s.SnapshotState().Players["bob"]; !p.Connected || !p.BizhawkReady || p.Game != "" {
+		t.Fatalf("lobby player %+v", p)
+	}
+
+	rec := httptest.NewRecorder()
+	s.apiSessionStart(rec, httptest.NewRequest(http.MethodPost, "/api/session/start", nil))
+	var out struct {
+		Players []string `json:"players"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || len(out.Players) != 1 || out.Players[0] != "bob" {
+		t.Fatalf("start status %d players %v", rec.Code, out.Players)
+	}
+	cmd, ok := waitForSwap(5 * time.Second)
+	if !ok {
+		t.Fatal("no swap after session start")
+	}
+	if pl, _ := cmd.Payload.(map[string]any); pl["game"] != "a.zip" {
+		t.Fatalf("swap payload %+v", cmd.Payload)
+	}
+
+	rec = httptest.NewRecorder()
+	s.apiSessionStart(rec, httptest.NewRequest(http.MethodPost, "/api/session/start", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second start status %d", rec.Code)
+	}
+}
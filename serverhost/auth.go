@@ -0,0 +1,59 @@
+package serverhost
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// adminTokenHeader is the request header carrying the admin token. The token
+// may also be sent as "Authorization: Bearer <token>" or as a ?token= query
+// parameter (needed for browser websockets, which cannot set headers).
+const adminTokenHeader = "X-Admin-Token"
+
+// SetAdminToken sets (or clears, when empty) the token required on admin
+// routes and admin websocket hellos, and persists it.
+func (s *Server) SetAdminToken(token string) {
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.AdminToken = token
+	})
+}
+
+// requestAdminToken extracts the admin token supplied with r, if any.
+func requestAdminToken(r *http.Request) string {
+	if v := r.Header.Get(adminTokenHeader); v != "" {
+		return v
+	}
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(v)
+	}
+	return r.URL.Query().Get("token")
+}
+
+// adminTokenValid reports whether supplied matches the configured admin token.
+// When no token is configured every caller is treated as an admin.
+func (s *Server) adminTokenValid(supplied string) bool {
+	var want string
+	s.withRLock(func() {
+		want = s.state.AdminToken
+	})
+	if want == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(supplied), []byte(want)) == 1
+}
+
+// requireAdmin wraps an admin-only handler, answering 401 when the request
+// does not carry the configured admin token.
+func (s *Server) requireAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.adminTokenValid(requestAdminToken(r)) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="bizshuffle-admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
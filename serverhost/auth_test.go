@@ -0,0 +1,117 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestAdminTokenGuardsAdminRoutes(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.SetAdminToken("secret")
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	get := func(path string, hdr map[string]string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		for k, v := range hdr {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		return res
+	}
+
+	if res := get("/api/games", nil); res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("no token: status %d, want 401", res.StatusCode)
+	}
+	if res := get("/api/games", map[string]string{"X-Admin-Token": "wrong"}); res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("wrong token: status %d, want 401", res.StatusCode)
+	}
+	if res := get("/api/games", map[string]string{"X-Admin-Token": "secret"}); res.StatusCode != http.StatusOK {
+		t.Fatalf("header token: status %d, want 200", res.StatusCode)
+	}
+	if res := get("/api/games", map[string]string{"Authorization": "Bearer secret"}); res.StatusCode != http.StatusOK {
+		t.Fatalf("bearer token: status %d, want 200", res.StatusCode)
+	}
+	if res := get("/api/games?token=secret", nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("query token: status %d, want 200", res.StatusCode)
+	}
+
+	res, err := http.Get(srv.URL + "/state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("state.json should stay open, got %d", res.StatusCode)
+	}
+	var env struct {
+		State protocol.ServerState `json:"state"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+		t.Fatal(err)
+	}
+	if env.State.AdminToken != "" {
+		t.Fatal("admin token leaked through state.json")
+	}
+}
+
+func TestAdminTokenRejectsAdminWebsocket(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.SetAdminToken("secret")
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	dial := func(token string) *websocket.Conn {
+		t.Helper()
+		c, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		hello := protocol.Command{Cmd: protocol.CmdHelloAdmin, ID: "1", Payload: map[string]any{"name": "ui", "token": token}}
+		if err := c.WriteJSON(hello); err != nil {
+			t.Fatal(err)
+		}
+		_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		return c
+	}
+
+	c := dial("wrong")
+	_, _, err := c.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("expected policy-violation close, got %v", err)
+	}
+
+	dial("secret")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		s.withConnRLock(func() { n = len(s.adminClients) })
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("authorized admin was not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,203 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"slices"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// bingoMaxSize is the board edge length when the catalog has enough games.
+const bingoMaxSize = 5
+
+// generateBingoBoard shuffles games with seed and returns the largest square
+// board (up to 5x5) they fill, in row-major order.
+func generateBingoBoard(games []string, seed int64) []string {
+	pool := slices.Clone(games)
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	size := bingoMaxSize
+	for size*size > len(pool) {
+		size--
+	}
+	return pool[:size*size]
+}
+
+// bingoSize returns the edge length of a square board, or 0 if it isn't square.
+func bingoSize(board []string) int {
+	for n := 1; n*n <= len(board); n++ {
+		if n*n == len(board) {
+			return n
+		}
+	}
+	return 0
+}
+
+// bingoLines returns the square indexes of every row, column and diagonal.
+func bingoLines(size int) [][]int {
+	var lines [][]int
+	diag, anti := make([]int, size), make([]int, size)
+	for i := range size {
+		row, col := make([]int, size), make([]int, size)
+		for j := range size {
+			row[j] = i*size + j
+			col[j] = j*size + i
+		}
+		lines = append(lines, row, col)
+		diag[i] = i*size + i
+		anti[i] = i*size + size - 1 - i
+	}
+	return append(lines, diag, anti)
+}
+
+// bingoMarked returns the board squares player has completed, either directly
+// or through a completed instance of that game.
+func bingoMarked(st *protocol.ServerState, player protocol.Player) []bool {
+	done := map[string]bool{}
+	for _, g := range player.CompletedGames {
+		done[g] = true
+	}
+	for _, id := range player.CompletedInstances {
+		for _, inst := range st.GameSwapInstances {
+			if inst.ID == id {
+				done[inst.Game] = true
+			}
+		}
+	}
+	marked := make([]bool, len(st.BingoBoard))
+	for i, g := range st.BingoBoard {
+		marked[i] = done[g]
+	}
+	return marked
+}
+
+// hasBingo reports whether marked completes any line of a size x size board.
+func hasBingo(marked []bool, size int) bool {
+	for _, line := range bingoLines(size) {
+		if !slices.ContainsFunc(line, func(i int) bool { return !marked[i] }) {
+			return true
+		}
+	}
+	return false
+}
+
+// BingoModeHandler implements bingo mode: everyone swaps through the same board
+// of games like sync mode, and completing a line of the board announces bingo.
+type BingoModeHandler struct {
+	server *Server
+}
+
+func (h *BingoModeHandler) sync() *SyncModeHandler {
+	return &SyncModeHandler{server: h.server}
+}
+
+func (h *BingoModeHandler) HandleSwap() error {
+	return h.sync().HandleSwap()
+}
+
+func (h *BingoModeHandler) GetPlayer(player string) protocol.Player {
+	return h.sync().GetPlayer(player)
+}
+
+// SetupState deals a new board from the catalog and limits swaps to its games.
+func (h *BingoModeHandler) SetupState() error {
+	seed := h.sync().initializeSwapSeed()
+	var files []string
+	_, mainGames, _ := h.server.SnapshotGames()
+	for _, entry := range presentGames(mainGames) {
+		files = append(files, entry.File)
+	}
+	board := generateBingoBoard(files, seed)
+	if len(board) == 0 {
+		return errors.New("no games in catalog for bingo board")
+	}
+	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.BingoBoard = board
+		st.BingoWinners = nil
+		st.Games = slices.Clone(board)
+	})
+	log.Printf("[BingoMode] Dealt %dx%d board", bingoSize(board), bingoSize(board))
+	return nil
+}
+
+func (h *BingoModeHandler) HandlePlayerSwap(player string, game string, instanceID string) error {
+	return h.sync().HandlePlayerSwap(player, game, instanceID)
+}
+
+func (h *BingoModeHandler) HandleRandomSwapForPlayer(playerName string) error {
+	return h.sync().HandleRandomSwapForPlayer(playerName)
+}
+
+func (h *BingoModeHandler) HandleGroupSwap(group string) error {
+	return h.sync().HandleGroupSwap(group)
+}
+
+func (h *BingoModeHandler) HandleSwapWhere(label string, in func(protocol.Player) bool) error {
+	return h.sync().HandleSwapWhere(label, in)
+}
+
+// checkBingo records and announces every player who newly completed a line
+// of the board. It is a no-op outside bingo mode.
+func (s *Server) checkBingo() {
+	var winners []string
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		size := bingoSize(st.BingoBoard)
+		if st.Mode != protocol.GameModeBingo || size == 0 {
+			return
+		}
+		names := make([]string, 0, len(st.Players))
+		for name := range st.Players {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if slices.Contains(st.BingoWinners, name) {
+				continue
+			}
+			if hasBingo(bingoMarked(st, st.Players[name]), size) {
+				st.BingoWinners = append(st.BingoWinners, name)
+				winners = append(winners, name)
+			}
+		}
+	})
+	for _, name := range winners {
+		log.Printf("[BingoMode] %s got bingo", name)
+		s.sendMessage(fmt.Sprintf("%s got BINGO!", name), 10, 10, 10, 16, "#FFD700", "#000000")
+	}
+}
+
+// apiBingoBoard handles GET /api/bingo/board: the board as rows plus each
+// player's marked squares.
+func (s *Server) apiBingoBoard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var out struct {
+		Size    int               `json:"size"`
+		Rows    [][]string        `json:"rows"`
+		Marked  map[string][]bool `json:"marked"`
+		Winners []string          `json:"winners"`
+	}
+	out.Rows = [][]string{}
+	out.Marked = map[string][]bool{}
+	s.withRLock(func() {
+		out.Size = bingoSize(s.state.BingoBoard)
+		for i := 0; out.Size > 0 && i < len(s.state.BingoBoard); i += out.Size {
+			out.Rows = append(out.Rows, slices.Clone(s.state.BingoBoard[i:i+out.Size]))
+		}
+		for name, p := range s.state.Players {
+			out.Marked[name] = bingoMarked(&s.state, p)
+		}
+		out.Winners = append([]string{}, s.state.BingoWinners...)
+	})
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
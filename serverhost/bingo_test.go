@@ -0,0 +1,132 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestGenerateBingoBoardSizes(t *testing.T) {
+	games := func(n int) []string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = fmt.Sprintf("g%d.nes", i)
+		}
+		return out
+	}
+	for n, want := range map[int]int{30: 25, 25: 25, 10: 9, 3: 1, 0: 0} {
+		board := generateBingoBoard(games(n), 42)
+		if len(board) != want {
+			t.Errorf("%d games: board has %d squares, want %d", n, len(board), want)
+		}
+	}
+	a, b := generateBingoBoard(games(30), 7), generateBingoBoard(games(30), 7)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatal("same seed should deal the same board")
+		}
+	}
+}
+
+func TestHasBingoLines(t *testing.T) {
+	mark := func(idx ...int) []bool {
+		m := make([]bool, 9)
+		for _, i := range idx {
+			m[i] = true
+		}
+		return m
+	}
+	for name, c := range map[string]struct {
+		marked []bool
+		want   bool
+	}{
+		"row":      {mark(3, 4, 5), true},
+		"column":   {mark(1, 4, 7), true},
+		"diagonal": {mark(0, 4, 8), true},
+		"anti":     {mark(2, 4, 6), true},
+		"scatter":  {mark(0, 1, 5, 6), false},
+	} {
+		if got := hasBingo(c.marked, 3); got != c.want {
+			t.Errorf("%s: hasBingo = %v, want %v", name, got, c.want)
+		}
+	}
+}
+
+func TestBingoModeAnnouncesCompletedLine(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeBingo
+		for i := range 25 {
+			file := fmt.Sprintf("g%02d.nes", i)
+			if err := os.WriteFile(filepath.Join("roms", file), []byte("rom"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			st.MainGames = append(st.MainGames, protocol.GameEntry{File: file})
+		}
+		st.Players["amy"] = protocol.Player{Name: "amy", Connected: true}
+	})
+	if err := s.GetGameModeHandler().SetupState(); err != nil {
+		t.Fatal(err)
+	}
+	amy := registerPlayerWSClient(s, "amy")
+
+	var board []string
+	s.withRLock(func() { board = s.state.BingoBoard })
+	if len(board) != 25 || len(s.SnapshotState().Games) != 25 {
+		t.Fatalf("board = %v", board)
+	}
+
+	// Complete the middle column: four games directly, the last via an instance.
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		p := st.Players["amy"]
+		p.CompletedGames = []string{board[2], board[7], board[12], board[17]}
+		st.Players["amy"] = p
+	})
+	s.checkBingo()
+	if len(s.SnapshotState().BingoWinners) != 0 {
+		t.Fatal("bingo declared with an incomplete line")
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameSwapInstances = append(st.GameSwapInstances, protocol.GameSwapInstance{ID: "i1", Game: board[22]})
+		p := st.Players["amy"]
+		p.CompletedInstances = []string{"i1"}
+		st.Players["amy"] = p
+	})
+	s.checkBingo()
+	s.checkBingo()
+
+	if w := s.SnapshotState().BingoWinners; len(w) != 1 || w[0] != "amy" {
+		t.Fatalf("winners = %v", w)
+	}
+	select {
+	case cmd := <-amy.sendCh:
+		if msg, _ := cmd.Payload.(map[string]any)["message"].(string); cmd.Cmd != protocol.CmdMessage || msg != "amy got BINGO!" {
+			t.Fatalf("unexpected broadcast: %+v", cmd)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("bingo not announced")
+	}
+
+	rec := httptest.NewRecorder()
+	s.apiBingoBoard(rec, httptest.NewRequest(http.MethodGet, "/api/bingo/board", nil))
+	var out struct {
+		Size   int               `json:"size"`
+		Rows   [][]string        `json:"rows"`
+		Marked map[string][]bool `json:"marked"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Size != 5 || len(out.Rows) != 5 || out.Rows[4][2] != board[22] || !out.Marked["amy"][22] || out.Marked["amy"][0] {
+		t.Fatalf("unexpected board response: %+v", out)
+	}
+}
@@ -0,0 +1,94 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// configProfileTimeout bounds how long apiPlayerConfigProfile, and a swap that
+// carries a profile, waits for a client to swap config.ini and relaunch BizHawk.
+const configProfileTimeout = time.Minute
+
+// configProfileForGame returns the config profile a swap to game should carry.
+// Once any catalog entry names a profile, games without one map to the
+// default profile so clients switch back; ok is false when the catalog uses
+// no profiles, leaving clients on whatever profile they run.
+func configProfileForGame(entries []protocol.GameEntry, game string) (profile string, ok bool) {
+	for _, e := range entries {
+		if e.ConfigProfile != "" {
+			ok = true
+		}
+		if e.File == game {
+			profile = e.ConfigProfile
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	if profile == "" {
+		profile = protocol.DefaultConfigProfile
+	}
+	return profile, true
+}
+
+// apiPlayerConfigProfile: POST /api/players/{player}/config_profile {profile}
+// Sends apply_config_profile and waits for the client to switch.
+func (s *Server) apiPlayerConfigProfile(w http.ResponseWriter, r *http.Request, playerName string) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	var b struct {
+		Profile string `json:"profile"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
+		return
+	}
+	if b.Profile == "" {
+		b.Profile = protocol.DefaultConfigProfile
+	}
+	if !protocol.ValidConfigProfile(b.Profile) {
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "invalid profile: use letters, digits, '-' or '_'")
+		return
+	}
+	var player protocol.Player
+	var ok bool
+	s.withRLock(func() { player, ok = s.state.Players[playerName] })
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, errCodePlayerNotFound, fmt.Sprintf("player %s: %v", playerName, ErrPlayerNotFound))
+		return
+	}
+	if !player.Connected {
+		writeAPIError(w, http.StatusConflict, errCodePlayerNotConnected, fmt.Sprintf("player %s: %v", playerName, ErrPlayerNotConnected))
+		return
+	}
+	cmd := protocol.Command{
+		Cmd:     protocol.CmdApplyConfigProfile,
+		Payload: map[string]any{"profile": b.Profile},
+		ID:      fmt.Sprintf("config-profile-%d-%s", time.Now().UnixNano(), playerName),
+	}
+	res, err := s.sendAndWait(player, cmd, configProfileTimeout)
+	switch {
+	case errors.Is(err, ErrTimeout):
+		writeAPIError(w, http.StatusGatewayTimeout, errCodeTimeout, "timed out waiting for config profile switch")
+		return
+	case err != nil:
+		writeAPIError(w, http.StatusConflict, playerErrorCode(err), err.Error())
+		return
+	}
+	if strings.HasPrefix(res, "nack") {
+		writeAPIError(w, http.StatusBadGateway, errCodeClientFailed, "config profile switch failed: "+strings.TrimPrefix(strings.TrimPrefix(res, "nack"), "|"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"result": "ok", "player": playerName, "profile": b.Profile}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
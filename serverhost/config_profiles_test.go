@@ -0,0 +1,20 @@
+package serverhost
+
+import (
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestConfigProfileForGame(t *testing.T) {
+	plain := []protocol.GameEntry{{File: "a.nes"}, {File: "b.sfc"}}
+	if p, ok := configProfileForGame(plain, "a.nes"); ok {
+		t.Fatalf("catalog without profiles gave %q", p)
+	}
+	mixed := []protocol.GameEntry{{File: "a.nes"}, {File: "b.sfc", ConfigProfile: "snes"}}
+	for game, want := range map[string]string{"b.sfc": "snes", "a.nes": protocol.DefaultConfigProfile} {
+		if p, ok := configProfileForGame(mixed, game); !ok || p != want {
+			t.Fatalf("%s: profile %q ok=%v, want %q", game, p, ok, want)
+		}
+	}
+}
@@ -1,6 +1,6 @@
 package serverhost
 
-// connMu protects websocket client maps only (conns, playerClients, adminClients).
+// connMu protects websocket client maps only (conns, playerClients, adminClients, spectatorClients).
 // Game/state data uses s.mu. Shutdown closes sockets via liveConns without either lock.
 func (s *Server) withConnLock(fn func()) {
 	s.connMu.Lock()
@@ -31,3 +31,7 @@ func (s *Server) findAdminNameForClientLocked(client *wsClient) string {
 	}
 	return ""
 }
+
+func (s *Server) findSpectatorNameForClientLocked(client *wsClient) string {
+	return s.spectatorClients[client]
+}
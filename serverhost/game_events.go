@@ -0,0 +1,158 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// maxGameEventRules bounds ServerState.GameEventRules as set through the API.
+const maxGameEventRules = 50
+
+// gameEventName matches event names plugins may report: lowercase letters,
+// digits and underscores, at most 32 characters.
+var gameEventName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// validateGameEventRules rejects rules with a malformed event or an unknown or
+// missing action.
+func validateGameEventRules(rules []protocol.GameEventRule) error {
+	if len(rules) > maxGameEventRules {
+		return fmt.Errorf("at most %d rules", maxGameEventRules)
+	}
+	for i, rule := range rules {
+		if !gameEventName.MatchString(rule.Event) {
+			return fmt.Errorf("rule %d: event must be 1-32 lowercase letters, digits or underscores", i)
+		}
+		if len(rule.Actions) == 0 {
+			return fmt.Errorf("rule %d: no actions", i)
+		}
+		for _, a := range rule.Actions {
+			switch a {
+			case protocol.GameEventActionMarkCompleted, protocol.GameEventActionSwapPlayer,
+				protocol.GameEventActionSwapAll, protocol.GameEventActionAnnounce:
+			default:
+				return fmt.Errorf("rule %d: unknown action %q", i, a)
+			}
+		}
+	}
+	return nil
+}
+
+// gameEventActions returns the actions of every rule matching event in game,
+// without duplicates, in the order they run: completion first, then the
+// announcement, then at most one swap (a full swap wins over a player swap).
+func gameEventActions(rules []protocol.GameEventRule, event, game string) []protocol.GameEventAction {
+	matched := map[protocol.GameEventAction]bool{}
+	for _, rule := range rules {
+		if rule.Event == event && (rule.Game == "" || rule.Game == game) {
+			for _, a := range rule.Actions {
+				matched[a] = true
+			}
+		}
+	}
+	if matched[protocol.GameEventActionSwapAll] {
+		delete(matched, protocol.GameEventActionSwapPlayer)
+	}
+	var out []protocol.GameEventAction
+	for _, a := range []protocol.GameEventAction{
+		protocol.GameEventActionMarkCompleted,
+		protocol.GameEventActionAnnounce,
+		protocol.GameEventActionSwapAll,
+		protocol.GameEventActionSwapPlayer,
+	} {
+		if matched[a] {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// handleGameEvent applies GameEventRules to a Lua "game_event" from
+// playerName. fields carries "event" (required), and optionally "game" (the
+// player's current game when empty) and "detail" (free text, logged only).
+func (s *Server) handleGameEvent(playerName string, fields map[string]string) {
+	event := strings.ToLower(strings.TrimSpace(fields["event"]))
+	if !gameEventName.MatchString(event) {
+		log.Printf("[ERROR] LuaCmdGameEvent: invalid event %q from %s", fields["event"], playerName)
+		return
+	}
+	game := strings.TrimSpace(fields["game"])
+	var rules []protocol.GameEventRule
+	var ok bool
+	s.withRLock(func() {
+		var p protocol.Player
+		p, ok = s.state.Players[playerName]
+		if game == "" {
+			game = p.Game
+		}
+		rules = slices.Clone(s.state.GameEventRules)
+	})
+	if !ok {
+		log.Printf("[ERROR] LuaCmdGameEvent: unknown player %s", playerName)
+		return
+	}
+	actions := gameEventActions(rules, event, game)
+	log.Printf("Lua game event from %s: event=%q game=%q detail=%q actions=%v", playerName, event, game, fields["detail"], actions)
+
+	for _, a := range actions {
+		switch a {
+		case protocol.GameEventActionMarkCompleted:
+			s.markLuaCompletion(playerName, map[string]string{"game": game})
+		case protocol.GameEventActionAnnounce:
+			s.sendMessage(fmt.Sprintf("%s: %s", playerName, strings.ReplaceAll(event, "_", " ")), 3,
+				countdownX, countdownY, countdownFontSize, countdownFG, countdownBG)
+		case protocol.GameEventActionSwapAll:
+			if err := s.performLuaSwap(); err != nil {
+				log.Printf("game event %s swap error: %v", event, err)
+			}
+		case protocol.GameEventActionSwapPlayer:
+			if err := s.performRandomSwapForPlayer(playerName); err != nil {
+				log.Printf("game event %s swap for %s error: %v", event, playerName, err)
+			}
+		}
+	}
+}
+
+// apiGameEventRules: GET/POST /api/game_event_rules {rules: [...]}
+// POST replaces the whole list.
+func (s *Server) apiGameEventRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		var rules []protocol.GameEventRule
+		s.withRLock(func() { rules = slices.Clone(s.state.GameEventRules) })
+		if rules == nil {
+			rules = []protocol.GameEventRule{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{"rules": rules}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b struct {
+		Rules []protocol.GameEventRule `json:"rules"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateGameEventRules(b.Rules); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameEventRules = b.Rules
+	})
+	if _, err := w.Write([]byte("ok")); err != nil {
+		fmt.Printf("write response error: %v\n", err)
+	}
+}
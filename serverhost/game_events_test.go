@@ -0,0 +1,84 @@
+package serverhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestGameEventActionsOrderAndMatch(t *testing.T) {
+	rules := []protocol.GameEventRule{
+		{Event: "game_over", Actions: []protocol.GameEventAction{protocol.GameEventActionSwapPlayer}},
+		{Event: "game_over", Game: "a.zip", Actions: []protocol.GameEventAction{
+			protocol.GameEventActionSwapAll, protocol.GameEventActionMarkCompleted,
+		}},
+		{Event: "death", Actions: []protocol.GameEventAction{protocol.GameEventActionAnnounce}},
+	}
+	got := gameEventActions(rules, "game_over", "a.zip")
+	want := []protocol.GameEventAction{protocol.GameEventActionMarkCompleted, protocol.GameEventActionSwapAll}
+	if !slices.Equal(got, want) {
+		t.Fatalf("a.zip actions %v, want %v", got, want)
+	}
+	if got := gameEventActions(rules, "game_over", "b.zip"); !slices.Equal(got, []protocol.GameEventAction{protocol.GameEventActionSwapPlayer}) {
+		t.Fatalf("b.zip actions %v", got)
+	}
+	if got := gameEventActions(rules, "level_up", "a.zip"); len(got) != 0 {
+		t.Fatalf("unmatched event actions %v", got)
+	}
+}
+
+func TestHandleGameEventMarksCompleted(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players = map[string]protocol.Player{
+			"alice": {Name: "alice", Connected: true, Game: "a.zip"},
+		}
+		st.GameEventRules = []protocol.GameEventRule{
+			{Event: "game_over", Actions: []protocol.GameEventAction{protocol.GameEventActionMarkCompleted}},
+		}
+	})
+
+	s.handleGameEvent("alice", map[string]string{"event": "death"})
+	if p := s.SnapshotPlayers()["alice"]; len(p.CompletedGames) != 0 {
+		t.Fatalf("death completed %v", p.CompletedGames)
+	}
+	s.handleGameEvent("alice", map[string]string{"event": " Game_Over ", "detail": "lives=0"})
+	if p := s.SnapshotPlayers()["alice"]; !slices.Equal(p.CompletedGames, []string{"a.zip"}) {
+		t.Fatalf("completed games %v", p.CompletedGames)
+	}
+}
+
+func TestAPIGameEventRulesValidation(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		s.apiGameEventRules(rec, httptest.NewRequest(http.MethodPost, "/api/game_event_rules", strings.NewReader(body)))
+		return rec.Code
+	}
+	for _, body := range []string{
+		`{"rules":[{"event":"Game Over","actions":["swap_player"]}]}`,
+		`{"rules":[{"event":"game_over","actions":[]}]}`,
+		`{"rules":[{"event":"game_over","actions":["explode"]}]}`,
+	} {
+		if code := post(body); code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d", body, code)
+		}
+	}
+	if code := post(`{"rules":[{"event":"game_over","game":"a.zip","actions":["mark_completed","swap_player"]}]}`); code != http.StatusOK {
+		t.Fatalf("valid rules: status %d", code)
+	}
+	rec := httptest.NewRecorder()
+	s.apiGameEventRules(rec, httptest.NewRequest(http.MethodGet, "/api/game_event_rules", nil))
+	if !strings.Contains(rec.Body.String(), `"actions":["mark_completed","swap_player"]`) {
+		t.Fatalf("GET body %s", rec.Body.String())
+	}
+}
@@ -5,16 +5,23 @@
 //     Each player maintains their own save state for the shared game.
 //   - Save mode: Players have individual game instances and swap save states between each other.
 //     Multiple players can play the same game but with different save files.
+//   - Race mode: Like sync mode, but the first player to complete a game wins and swaps stop.
 //
 // The "better random" setting (PreventSameGameSwap) controls whether players avoid
 // being assigned the same game they just played, improving variety in random selections.
 package serverhost
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/michael4d45/bizshuffle/protocol"
@@ -54,10 +61,96 @@ func validateNoDuplicateInstanceAssignments(state *protocol.ServerState) error {
 	return nil
 }
 
-// selectNextGame selects the next game from available games using deterministic random with seed.
-// This function is abstracted to support future ordering modes (e.g., sequential, custom).
+// repairDuplicateInstanceAssignments leaves each instance with a single player and
+// clears the others, returning the displaced players sorted by name. Connected
+// players win over disconnected ones, then the lower measured ping, then the
+// first name alphabetically.
+func repairDuplicateInstanceAssignments(state *protocol.ServerState) []string {
+	byInstance := make(map[string][]string)
+	for name, player := range state.Players {
+		if player.InstanceID != "" {
+			byInstance[player.InstanceID] = append(byInstance[player.InstanceID], name)
+		}
+	}
+	var displaced []string
+	for id, names := range byInstance {
+		if len(names) < 2 {
+			continue
+		}
+		slices.SortFunc(names, func(a, b string) int {
+			pa, pb := state.Players[a], state.Players[b]
+			if pa.Connected != pb.Connected {
+				if pa.Connected {
+					return -1
+				}
+				return 1
+			}
+			if c := cmp.Compare(pingRank(pa), pingRank(pb)); c != 0 {
+				return c
+			}
+			return strings.Compare(a, b)
+		})
+		for _, name := range names[1:] {
+			p := state.Players[name]
+			log.Printf("[SaveMode] Repair: instance %s kept by %s, clearing it from %s", id, names[0], name)
+			p.Game = ""
+			p.InstanceID = ""
+			state.Players[name] = p
+			displaced = append(displaced, name)
+		}
+	}
+	slices.Sort(displaced)
+	return displaced
+}
+
+// pingRank orders players by ping; a zero ping has not been measured yet and ranks last.
+func pingRank(p protocol.Player) int {
+	if p.PingMs == 0 {
+		return math.MaxInt
+	}
+	return p.PingMs
+}
+
+// repairInstanceAssignments clears duplicate instance assignments from state and
+// returns the displaced players; callers reassign them with reassignPlayers.
+func (s *Server) repairInstanceAssignments() []string {
+	var displaced []string
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		displaced = repairDuplicateInstanceAssignments(st)
+	})
+	return displaced
+}
+
+// reassignPlayers gives each player a fresh random swap from the current mode.
+func (s *Server) reassignPlayers(players []string) {
+	for _, name := range players {
+		if err := s.GetGameModeHandler().HandleRandomSwapForPlayer(name); err != nil && !isSwapDeclined(err) {
+			log.Printf("[SaveMode] Reassigning %s failed: %v", name, err)
+		}
+	}
+}
+
+// gameWeights returns per-game selection weights from the catalog, or nil when every
+// entry has the default weight so callers keep the uniform selection path.
+func gameWeights(entries []protocol.GameEntry) map[string]int {
+	var weights map[string]int
+	for _, e := range entries {
+		if e.Weight > 1 {
+			if weights == nil {
+				weights = make(map[string]int)
+			}
+			weights[e.File] = e.Weight
+		}
+	}
+	return weights
+}
+
+// selectNextGame selects the next game from available games according to the order mode.
+// In random mode (the default) it uses deterministic random with seed, drawing from a weighted
+// pool when weights is non-nil (missing or non-positive weights count as 1). In sequential mode
+// it returns the first non-excluded game after current in availableGames, wrapping at the end.
 // It excludes games in the exclude list.
-func selectNextGame(availableGames []string, exclude []string, seed int64) string {
+func selectNextGame(availableGames []string, exclude []string, seed int64, order protocol.OrderMode, current string, weights map[string]int) string {
 	if len(availableGames) == 0 {
 		return ""
 	}
@@ -68,6 +161,23 @@ func selectNextGame(availableGames []string, exclude []string, seed int64) strin
 		excludeMap[g] = true
 	}
 
+	if order == protocol.OrderModeSequential {
+		start := 0
+		for i, g := range availableGames {
+			if g == current {
+				start = i + 1
+				break
+			}
+		}
+		for i := range availableGames {
+			g := availableGames[(start+i)%len(availableGames)]
+			if !excludeMap[g] {
+				return g
+			}
+		}
+		return ""
+	}
+
 	// Filter available games
 	var filtered []string
 	for _, g := range availableGames {
@@ -82,7 +192,22 @@ func selectNextGame(availableGames []string, exclude []string, seed int64) strin
 
 	// Use deterministic random with seed
 	rng := rand.New(rand.NewSource(seed))
-	return filtered[rng.Intn(len(filtered))]
+	if weights == nil {
+		return filtered[rng.Intn(len(filtered))]
+	}
+
+	total := 0
+	for _, g := range filtered {
+		total += max(weights[g], 1)
+	}
+	pick := rng.Intn(total)
+	for _, g := range filtered {
+		pick -= max(weights[g], 1)
+		if pick < 0 {
+			return g
+		}
+	}
+	return filtered[len(filtered)-1]
 }
 
 // GameModeHandler defines the interface for implementing game mode behavior
@@ -100,6 +225,30 @@ type GameModeHandler interface {
 
 	// Perform a random swap for a specific player
 	HandleRandomSwapForPlayer(param1 string) error
+
+	// HandleGroupSwap swaps only the players whose Team is group, leaving
+	// everyone else on their current game
+	HandleGroupSwap(group string) error
+
+	// HandleSwapWhere swaps only the players matching in, leaving everyone
+	// else on their current game; label names them in logs
+	HandleSwapWhere(label string, in func(protocol.Player) bool) error
+}
+
+// Reasons HandleRandomSwapForPlayer may decline to swap a player. Best-effort
+// callers (the scheduler, Lua swap_me) treat these as a no-op; see isSwapDeclined.
+var (
+	ErrNoAvailableGames = errors.New("no available games")
+	ErrRaceWon          = errors.New("race already won")
+	ErrSavesPending     = errors.New("saves are still being transferred")
+	ErrManualMode       = errors.New("manual mode: games are only assigned by the admin")
+	ErrSwapsDisabled    = errors.New("swaps disabled")
+)
+
+// isSwapDeclined reports whether err is a reason not to swap rather than a failure.
+func isSwapDeclined(err error) bool {
+	return errors.Is(err, ErrNoAvailableGames) || errors.Is(err, ErrRaceWon) || errors.Is(err, ErrSavesPending) ||
+		errors.Is(err, ErrManualMode) || errors.Is(err, ErrSwapsDisabled)
 }
 
 // SyncModeHandler implements the sync game mode where all players play the same game
@@ -109,10 +258,15 @@ type SyncModeHandler struct {
 
 // getCurrentGame returns the game currently being played by any player in sync mode
 func (h *SyncModeHandler) getCurrentGame() string {
+	return h.currentGameWhere(func(protocol.Player) bool { return true })
+}
+
+// currentGameWhere returns the game of any player matching in
+func (h *SyncModeHandler) currentGameWhere(in func(protocol.Player) bool) string {
 	var currentGame string
 	h.server.withRLock(func() {
 		for _, player := range h.server.state.Players {
-			if player.Game != "" {
+			if player.Game != "" && in(player) {
 				currentGame = player.Game
 				break
 			}
@@ -122,11 +276,11 @@ func (h *SyncModeHandler) getCurrentGame() string {
 }
 
 // selectGameForPlayer selects an appropriate game for a player, considering their completed games
-func (h *SyncModeHandler) selectGameForPlayer(player protocol.Player, games []string, excludeList []string, seed int64) string {
+func (h *SyncModeHandler) selectGameForPlayer(player protocol.Player, games []string, excludeList []string, seed int64, order protocol.OrderMode, current string, weights map[string]int) string {
 	playerExclusions := append([]string{}, excludeList...)
 	playerExclusions = append(playerExclusions, player.CompletedGames...)
 
-	game := selectNextGame(games, playerExclusions, seed)
+	game := selectNextGame(games, playerExclusions, seed, order, current, weights)
 	if game == "" {
 		log.Printf("[SyncMode] Player %s has all games completed, skipping game assignment", player.Name)
 	}
@@ -150,6 +304,20 @@ func (h *SyncModeHandler) initializeSwapSeed() int64 {
 	return seed
 }
 
+// selectionSeed mixes the persisted swap counter into seed so selections differ across restarts
+func (h *SyncModeHandler) selectionSeed(seed int64) int64 {
+	var counter int64
+	h.server.withRLock(func() {
+		counter = h.server.state.SwapCounter
+	})
+	return mixSwapSeed(seed, counter)
+}
+
+// mixSwapSeed XORs the counter into the high bits so it can't cancel out the seed's own +1 steps
+func mixSwapSeed(seed, counter int64) int64 {
+	return seed ^ (counter << 32)
+}
+
 // isGameCompletedForPlayer checks if a game is in the player's completed games list
 func (h *SyncModeHandler) isGameCompletedForPlayer(player protocol.Player, game string) bool {
 	for _, completedGame := range player.CompletedGames {
@@ -163,41 +331,79 @@ func (h *SyncModeHandler) isGameCompletedForPlayer(player protocol.Player, game
 // HandleSwap performs a synchronized swap where all players switch to the same new game.
 // In sync mode, all players play the same game simultaneously, swapping together as a group.
 func (h *SyncModeHandler) HandleSwap() error {
+	if _, err := h.swapWhere("all players", func(protocol.Player) bool { return true }); err != nil {
+		return err
+	}
+	h.server.sendSwapAll(SwapSendOptions{Preview: true})
+	return nil
+}
+
+// HandleGroupSwap moves the players of one team to a new shared game.
+func (h *SyncModeHandler) HandleGroupSwap(group string) error {
+	return h.HandleSwapWhere("team "+group, onTeam(group))
+}
+
+// HandleSwapWhere moves the players matching in to a new shared game.
+func (h *SyncModeHandler) HandleSwapWhere(label string, in func(protocol.Player) bool) error {
+	members, err := h.swapWhere(label, in)
+	if err != nil {
+		return err
+	}
+	for _, name := range members {
+		h.server.sendSwap(protocol.Player{Name: name}, SwapSendOptions{Preview: true})
+	}
+	return nil
+}
+
+// swapWhere selects one new game and assigns it to every player matching in,
+// handling individual completions. It returns the players that were assigned
+// a game; label names them in logs.
+func (h *SyncModeHandler) swapWhere(label string, in func(protocol.Player) bool) ([]string, error) {
 	var preventSame bool
 	var games []string
+	var order protocol.OrderMode
+	var weights map[string]int
 	h.server.withRLock(func() {
 		preventSame = h.server.state.PreventSameGameSwap
 		games = h.server.state.Games
+		order = h.server.state.OrderMode
+		weights = gameWeights(h.server.state.MainGames)
 	})
 
-	currentGame := h.getCurrentGame()
+	currentGame := h.currentGameWhere(in)
 	seed := h.initializeSwapSeed()
+	mixed := h.selectionSeed(seed)
 
-	// Select next game using deterministic seed
+	// Select next game using deterministic seed (or list order when sequential)
 	exclude := []string{}
 	if preventSame && currentGame != "" {
 		exclude = append(exclude, currentGame)
 	}
-	game := selectNextGame(games, exclude, seed)
+	game := selectNextGame(games, exclude, mixed, order, currentGame, weights)
 	if game == "" {
 		// Try without exclusion if no game found with current restrictions
-		game = selectNextGame(games, []string{}, seed)
+		game = selectNextGame(games, []string{}, mixed, order, currentGame, weights)
 		if game == "" {
-			return errors.New("no games available for swap")
+			return nil, errors.New("no games available for swap")
 		}
 	}
 
-	log.Printf("[SyncMode] Selected game %s for all players (preventSame=%v, seed=%d)",
-		game, preventSame, seed)
+	log.Printf("[SyncMode] Selected game %s for %s (preventSame=%v, order=%s, seed=%d)",
+		game, label, preventSame, order, seed)
 
-	// Increment seed for next swap
+	// Increment seed and counter for next swap
 	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
 		st.SwapSeed = seed + 1
+		st.SwapCounter++
 	})
 
-	// Assign the game to all players, handling individual completions
+	// Assign the game to the matching players, handling individual completions
+	var assigned []string
 	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
 		for name, player := range st.Players {
+			if !in(player) {
+				continue
+			}
 			playerGame := game
 			// Check if selected game is completed for this player
 			if h.isGameCompletedForPlayer(player, game) {
@@ -206,7 +412,7 @@ func (h *SyncModeHandler) HandleSwap() error {
 				if preventSame && currentGame != "" && currentGame != game {
 					excludeList = append(excludeList, currentGame)
 				}
-				playerGame = h.selectGameForPlayer(player, games, excludeList, seed)
+				playerGame = h.selectGameForPlayer(player, games, excludeList, mixed, order, game, weights)
 				if playerGame == "" {
 					// No available games for this player, skip them
 					continue
@@ -215,18 +421,27 @@ func (h *SyncModeHandler) HandleSwap() error {
 			player.Game = playerGame
 			player.InstanceID = ""
 			st.Players[name] = player
+			assigned = append(assigned, name)
 			log.Printf("[SyncMode] Assigned game %s to player %s", playerGame, name)
 		}
 	})
-
-	h.server.sendSwapAll(SwapSendOptions{})
-	return nil
+	slices.Sort(assigned)
+	return assigned, nil
 }
 
 func (h *SyncModeHandler) GetPlayer(player string) protocol.Player {
-	seed := h.initializeSwapSeed()
+	seed := h.selectionSeed(h.initializeSwapSeed())
 	var result protocol.Player
 	h.server.withRLock(func() {
+		// Join a teammate's game first, so a team keeps playing together.
+		if team := h.server.state.Players[player].Team; team != "" {
+			for _, pp := range h.server.state.Players {
+				if pp.Team == team && pp.Game != "" {
+					result = protocol.Player{Name: player, Game: pp.Game}
+					return
+				}
+			}
+		}
 		// If any player already has a game assigned, return that game for the requesting player.
 		for _, pp := range h.server.state.Players {
 			if pp.Game != "" {
@@ -236,7 +451,7 @@ func (h *SyncModeHandler) GetPlayer(player string) protocol.Player {
 		}
 		// Otherwise pick a random game from the available games
 		if len(h.server.state.Games) > 0 {
-			result = protocol.Player{Name: player, Game: selectNextGame(h.server.state.Games, []string{}, seed)}
+			result = protocol.Player{Name: player, Game: selectNextGame(h.server.state.Games, []string{}, seed, h.server.state.OrderMode, "", gameWeights(h.server.state.MainGames))}
 			return
 		}
 		result = protocol.Player{Name: player}
@@ -246,12 +461,13 @@ func (h *SyncModeHandler) GetPlayer(player string) protocol.Player {
 
 func (h *SyncModeHandler) SetupState() error {
 	// add all gaemes in catalog to available games if not already present
+	present := h.server.presentGameFiles()
 	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
 		existing := make(map[string]bool)
 		for _, g := range st.Games {
 			existing[g] = true
 		}
-		for _, entry := range st.MainGames {
+		for _, entry := range keepPresentGames(st.MainGames, present) {
 			if !existing[entry.File] {
 				st.Games = append(st.Games, entry.File)
 				existing[entry.File] = true
@@ -276,7 +492,7 @@ func (h *SyncModeHandler) HandlePlayerSwap(player string, game string, _ string)
 		st.Players[player] = p
 	})
 
-	h.server.sendSwap(p, SwapSendOptions{})
+	h.server.sendSwap(p, SwapSendOptions{Preview: true})
 	return nil
 }
 
@@ -286,15 +502,19 @@ func (h *SyncModeHandler) HandleRandomSwapForPlayer(playerName string) error {
 	var found bool
 	var preventSame bool
 	var games []string
+	var order protocol.OrderMode
+	var weights map[string]int
 
 	h.server.withRLock(func() {
 		preventSame = h.server.state.PreventSameGameSwap
 		games = h.server.state.Games
+		order = h.server.state.OrderMode
+		weights = gameWeights(h.server.state.MainGames)
 		player, found = h.server.state.Players[playerName]
 	})
 
 	if !found {
-		return fmt.Errorf("player %s not found", playerName)
+		return fmt.Errorf("player %s: %w", playerName, ErrPlayerNotFound)
 	}
 
 	seed := h.initializeSwapSeed()
@@ -305,18 +525,19 @@ func (h *SyncModeHandler) HandleRandomSwapForPlayer(playerName string) error {
 		exclude = append(exclude, player.Game)
 	}
 
-	game := selectNextGame(games, exclude, seed)
+	game := selectNextGame(games, exclude, h.selectionSeed(seed), order, player.Game, weights)
 	if game == "" {
 		log.Printf("[SyncMode] Player %s has no available games for random swap (all completed or same game prevented)", playerName)
-		return nil
+		return fmt.Errorf("player %s: %w (all completed or same game prevented)", playerName, ErrNoAvailableGames)
 	}
 
 	log.Printf("[SyncMode] Random swap for player %s: %s -> %s (preventSame=%v)",
 		playerName, player.Game, game, preventSame)
 
-	// Increment seed for next swap
+	// Increment seed and counter for next swap
 	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
 		st.SwapSeed = seed + 1
+		st.SwapCounter++
 	})
 
 	return h.HandlePlayerSwap(playerName, game, "")
@@ -355,6 +576,7 @@ func (h *SaveModeHandler) findAvailableInstanceForPlayer(
 	isAvailable := func(idx int) bool {
 		inst := gameInstances[idx]
 		return !assignedInstances[idx] &&
+			!inst.Locked &&
 			!completedInstances[inst.ID] &&
 			!completedGames[inst.Game]
 	}
@@ -437,14 +659,20 @@ func (h *SaveModeHandler) HandleSwap() error {
 	}
 
 	var preventSame bool
-	h.server.withRLock(func() { preventSame = h.server.state.PreventSameGameSwap })
+	var strategy protocol.SwapStrategy
+	h.server.withRLock(func() {
+		preventSame = h.server.state.PreventSameGameSwap
+		strategy = h.server.state.SwapStrategy
+	})
 
 	// Ensure there are instances to swap between
 	if len(h.server.state.GameSwapInstances) == 0 {
 		return errors.New("no game instances available for swap")
 	}
 
-	log.Printf("[SaveMode] Starting full swap (preventSame=%v)", preventSame)
+	h.server.waitForPlayersReady(readyCheckWait)
+
+	log.Printf("[SaveMode] Starting full swap (preventSame=%v strategy=%s)", preventSame, strategy)
 
 	h.server.SetPendingAllFiles()
 	h.server.RequestPendingSaves()
@@ -458,8 +686,10 @@ func (h *SaveModeHandler) HandleSwap() error {
 	playerCurrentGames := make(map[string]string)
 	playerCurrentInstances := make(map[string]string)
 	var gameInstances []protocol.GameSwapInstance
+	var counter int64
 
 	h.server.withRLock(func() {
+		counter = h.server.state.SwapCounter
 		for name := range h.server.state.Players {
 			players = append(players, name)
 		}
@@ -470,13 +700,30 @@ func (h *SaveModeHandler) HandleSwap() error {
 		gameInstances = make([]protocol.GameSwapInstance, len(h.server.state.GameSwapInstances))
 		copy(gameInstances, h.server.state.GameSwapInstances)
 	})
+	// Same order as PreviewSwap, so the preview names the same unassigned players.
+	players = swapDealOrder(players, counter)
 
 	// Shuffle instances for randomness
 	rand.Shuffle(len(gameInstances), func(i, j int) {
 		gameInstances[i], gameInstances[j] = gameInstances[j], gameInstances[i]
 	})
 
+	var displaced []string
 	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.SwapCounter++
+		// Snapshot each player's previous game/instance for the preference logic
+		current := make(map[string]protocol.Player, len(players))
+		for _, pname := range players {
+			player := st.Players[pname]
+			current[pname] = protocol.Player{
+				Name:               player.Name,
+				Game:               playerCurrentGames[pname],
+				InstanceID:         playerCurrentInstances[pname],
+				CompletedGames:     player.CompletedGames,
+				CompletedInstances: player.CompletedInstances,
+			}
+		}
+
 		// Clear all players' assignments for a fresh round-robin assignment
 		for n, p := range st.Players {
 			p.InstanceID = ""
@@ -484,49 +731,213 @@ func (h *SaveModeHandler) HandleSwap() error {
 			st.Players[n] = p
 		}
 
-		// Assign instances to players using round-robin with preference logic
-		maxAssign := min(len(gameInstances), len(players))
-		assignedInstances := make(map[int]bool) // track assigned instance indices
-
-		for i := range maxAssign {
-			pname := players[i]
+		assigned := h.assignInstances(players, current, gameInstances, preventSame, strategy)
+		for _, pname := range players {
+			idx, ok := assigned[pname]
+			if !ok {
+				log.Printf("[SaveMode] Player %s has no available instances for swap (all completed)", pname)
+				continue
+			}
+			inst := gameInstances[idx]
 			player := st.Players[pname]
+			player.Game = inst.Game
+			player.InstanceID = inst.ID
+			st.Players[pname] = player
+			log.Printf("[SaveMode] Assigned instance %s (game %s) to player %s", inst.ID, inst.Game, pname)
+		}
 
-			// Create a temporary player object with current game/instance for preference logic
-			tempPlayer := protocol.Player{
-				Name:               player.Name,
-				Game:               playerCurrentGames[pname],
-				InstanceID:         playerCurrentInstances[pname],
-				CompletedGames:     player.CompletedGames,
-				CompletedInstances: player.CompletedInstances,
+		// Repair duplicates before anything is sent, so no one is told to
+		// load an instance another player keeps.
+		displaced = repairDuplicateInstanceAssignments(st)
+	})
+
+	h.server.sendSwapAll(SwapSendOptions{SkipSave: true, Preview: true})
+	h.server.reassignPlayers(displaced)
+	return nil
+}
+
+// assignInstances deals instances for a full swap using strategy. current
+// holds each player's previous game/instance and completions. It returns
+// player name -> index into instances; players missing from the result stay
+// unassigned.
+func (h *SaveModeHandler) assignInstances(
+	players []string,
+	current map[string]protocol.Player,
+	instances []protocol.GameSwapInstance,
+	preventSame bool,
+	strategy protocol.SwapStrategy,
+) map[string]int {
+	if strategy == protocol.SwapStrategyDerangement {
+		return h.assignDerangement(players, current, instances, preventSame)
+	}
+	return h.assignRoundRobin(players, current, instances, preventSame)
+}
+
+// assignRoundRobin applies the full-swap round-robin: each of the first
+// min(len(instances), len(players)) players, in order, takes the best
+// available instance per findAvailableInstanceForPlayer.
+func (h *SaveModeHandler) assignRoundRobin(
+	players []string,
+	current map[string]protocol.Player,
+	instances []protocol.GameSwapInstance,
+	preventSame bool,
+) map[string]int {
+	out := make(map[string]int)
+	maxAssign := min(len(instances), len(players))
+	assignedInstances := make(map[int]bool) // track assigned instance indices
+	for i := range maxAssign {
+		pname := players[i]
+		if idx, found := h.findAvailableInstanceForPlayer(current[pname], instances, assignedInstances, preventSame); found {
+			out[pname] = idx
+			assignedInstances[idx] = true
+		}
+	}
+	return out
+}
+
+// assignDerangement matches players to instances so that, whenever some
+// assignment allows it, nobody keeps their current instance. It builds a
+// maximum bipartite matching in tiers of decreasing strictness: a different
+// game (only with preventSame), then a different instance, then any
+// instance. Each tier only extends the previous matching, so players fall
+// back to their own instance only when no complete derangement exists.
+// Locked instances and completed games and instances are never assigned.
+func (h *SaveModeHandler) assignDerangement(
+	players []string,
+	current map[string]protocol.Player,
+	instances []protocol.GameSwapInstance,
+	preventSame bool,
+) map[string]int {
+	type tier func(p protocol.Player, inst protocol.GameSwapInstance) bool
+	tiers := []tier{
+		func(p protocol.Player, inst protocol.GameSwapInstance) bool { return inst.ID != p.InstanceID },
+		func(protocol.Player, protocol.GameSwapInstance) bool { return true },
+	}
+	if preventSame {
+		tiers = append([]tier{func(p protocol.Player, inst protocol.GameSwapInstance) bool {
+			return inst.ID != p.InstanceID && (p.Game == "" || inst.Game != p.Game)
+		}}, tiers...)
+	}
+
+	// available[i] lists the instance indices player i may ever take.
+	available := make([][]int, len(players))
+	for i, pname := range players {
+		completedInstances, completedGames := h.buildCompletedMaps(current[pname])
+		for j, inst := range instances {
+			if !inst.Locked && !completedInstances[inst.ID] && !completedGames[inst.Game] {
+				available[i] = append(available[i], j)
 			}
+		}
+	}
 
-			// Find the best available instance for this player
-			assignedIdx, found := h.findAvailableInstanceForPlayer(tempPlayer, gameInstances, assignedInstances, preventSame)
-			if found {
-				inst := gameInstances[assignedIdx]
-				player.Game = inst.Game
-				player.InstanceID = inst.ID
-				st.Players[pname] = player
-				assignedInstances[assignedIdx] = true
-				log.Printf("[SaveMode] Assigned instance %s (game %s) to player %s", inst.ID, inst.Game, pname)
-			} else {
-				log.Printf("[SaveMode] Player %s has no available instances for swap (all completed)", pname)
+	owner := make([]int, len(instances)) // instance index -> player index, -1 if free
+	for j := range owner {
+		owner[j] = -1
+	}
+	matched := make([]bool, len(players))
+	for _, allowed := range tiers {
+		var augment func(i int, seen []bool) bool
+		augment = func(i int, seen []bool) bool {
+			p := current[players[i]]
+			for _, j := range available[i] {
+				if seen[j] || !allowed(p, instances[j]) {
+					continue
+				}
+				seen[j] = true
+				if owner[j] == -1 || augment(owner[j], seen) {
+					owner[j] = i
+					return true
+				}
+			}
+			return false
+		}
+		for i := range players {
+			if !matched[i] {
+				matched[i] = augment(i, make([]bool, len(instances)))
 			}
 		}
+	}
 
-		// Validate the final state
-		if err := validateNoDuplicateInstanceAssignments(st); err != nil {
-			log.Printf("[SaveMode] WARNING: State validation failed after swap: %v", err)
+	out := make(map[string]int)
+	for j, i := range owner {
+		if i != -1 {
+			out[players[i]] = j
 		}
+	}
+	return out
+}
+
+// SwapAssignment is one player's proposed instance in a swap preview.
+type SwapAssignment struct {
+	Player     string `json:"player"`
+	InstanceID string `json:"instance_id"`
+	Game       string `json:"game"`
+}
+
+// SwapPreview is the outcome a full swap would produce right now. Because
+// the instance order is shuffled, it is one possible outcome, not a promise.
+type SwapPreview struct {
+	Assignments []SwapAssignment `json:"assignments"`
+	Unassigned  []string         `json:"unassigned"`
+}
+
+// swapDealOrder sorts players and rotates the start by counter. The order is
+// deterministic so PreviewSwap matches HandleSwap, yet when there are more
+// players than instances a different player is left out each swap.
+func swapDealOrder(players []string, counter int64) []string {
+	slices.Sort(players)
+	if n := len(players); n > 1 {
+		k := int(counter % int64(n))
+		players = append(players[k:], players[:k]...)
+	}
+	return players
+}
+
+// PreviewSwap runs the HandleSwap assignment logic against a copy of the
+// state and returns the proposed player -> instance mapping. It does not
+// wait for saves, mutate s.state, or send any commands.
+func (h *SaveModeHandler) PreviewSwap() SwapPreview {
+	var preventSame bool
+	var strategy protocol.SwapStrategy
+	var players []string
+	current := make(map[string]protocol.Player)
+	var gameInstances []protocol.GameSwapInstance
+	var counter int64
+	h.server.withRLock(func() {
+		preventSame = h.server.state.PreventSameGameSwap
+		strategy = h.server.state.SwapStrategy
+		counter = h.server.state.SwapCounter
+		for name, p := range h.server.state.Players {
+			players = append(players, name)
+			current[name] = p
+		}
+		gameInstances = slices.Clone(h.server.state.GameSwapInstances)
 	})
+	players = swapDealOrder(players, counter)
 
-	h.server.sendSwapAll(SwapSendOptions{SkipSave: true})
-	return nil
+	rand.Shuffle(len(gameInstances), func(i, j int) {
+		gameInstances[i], gameInstances[j] = gameInstances[j], gameInstances[i]
+	})
+	assigned := h.assignInstances(players, current, gameInstances, preventSame, strategy)
+
+	preview := SwapPreview{Assignments: []SwapAssignment{}, Unassigned: []string{}}
+	for _, pname := range players {
+		idx, ok := assigned[pname]
+		if !ok {
+			preview.Unassigned = append(preview.Unassigned, pname)
+			continue
+		}
+		inst := gameInstances[idx]
+		preview.Assignments = append(preview.Assignments, SwapAssignment{Player: pname, InstanceID: inst.ID, Game: inst.Game})
+	}
+	return preview
 }
 
+// GetPlayer hands player the first free, unlocked instance. With per-player
+// saves it prefers a free instance the player already has a save for, so a
+// returning player resumes their own progress.
 func (h *SaveModeHandler) GetPlayer(player string) protocol.Player {
-	var result protocol.Player
+	var free []protocol.GameSwapInstance
 	h.server.withRLock(func() {
 		assigned := map[string]struct{}{}
 		for _, p := range h.server.state.Players {
@@ -535,26 +946,38 @@ func (h *SaveModeHandler) GetPlayer(player string) protocol.Player {
 			}
 		}
 		for _, inst := range h.server.state.GameSwapInstances {
-			if _, ok := assigned[inst.ID]; ok {
+			if _, ok := assigned[inst.ID]; ok || inst.Locked {
 				continue
 			}
-			result = protocol.Player{
-				Name:       player,
-				Game:       inst.Game,
-				InstanceID: inst.ID,
-			}
-			return
+			free = append(free, inst)
 		}
 	})
-	if result.Name != "" {
-		return result
+	if len(free) == 0 {
+		return protocol.Player{Name: player}
+	}
+	pick := free[0]
+	if owner := h.server.saveOwner(player); owner != "" {
+		for _, inst := range free {
+			savePath := filepath.Join("./saves", protocol.SaveKey(inst.ID, owner)+".state")
+			if _, err := os.Stat(savePath); err == nil {
+				pick = inst
+				break
+			}
+		}
+	}
+	return protocol.Player{
+		Name:       player,
+		Game:       pick.Game,
+		InstanceID: pick.ID,
 	}
-	return protocol.Player{Name: player}
 }
 
 func (h *SaveModeHandler) SetupState() error {
+	present := h.server.presentGameFiles()
 	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
-		updated := protocol.SetupSaveState(*st)
+		filtered := *st
+		filtered.MainGames = keepPresentGames(st.MainGames, present)
+		updated := protocol.SetupSaveState(filtered)
 		st.GameSwapInstances = updated.GameSwapInstances
 	})
 	return nil
@@ -631,7 +1054,8 @@ func (h *SaveModeHandler) HandlePlayerSwap(player string, game string, instanceI
 	return nil
 }
 
-// categorizeInstances groups available instances by preference level for a player
+// categorizeInstances groups available instances by preference level for a player.
+// Locked instances are left out, so random swaps never deal them.
 func (h *SaveModeHandler) categorizeInstances(player protocol.Player, _ bool) InstanceCategory {
 	completedInstances, completedGames := h.buildCompletedMaps(player)
 
@@ -647,12 +1071,16 @@ func (h *SaveModeHandler) categorizeInstances(player protocol.Player, _ bool) In
 	category := InstanceCategory{}
 
 	for _, inst := range h.server.state.GameSwapInstances {
-		// Skip completed instances/games
-		if completedInstances[inst.ID] || completedGames[inst.Game] {
+		// Skip locked instances and completed instances/games
+		if inst.Locked || completedInstances[inst.ID] || completedGames[inst.Game] {
 			continue
 		}
 
 		playerByInstance, hasPlayer := playersByInstance[inst.ID]
+		// Never take an instance from another team
+		if hasPlayer && playerByInstance.Team != player.Team {
+			continue
+		}
 
 		if hasPlayer {
 			// Instance is assigned to someone
@@ -753,7 +1181,7 @@ func (h *SaveModeHandler) getRandomInstanceForPlayer(player protocol.Player) (pr
 // HandleRandomSwapForPlayer performs a random swap for a specific player in save mode (TS parity).
 func (h *SaveModeHandler) HandleRandomSwapForPlayer(playerName string) error {
 	if h.waitForFileCheck() {
-		return nil
+		return ErrSavesPending
 	}
 
 	pending := make(map[string]bool)
@@ -772,12 +1200,15 @@ func (h *SaveModeHandler) HandleRandomSwapForPlayer(playerName string) error {
 			player, found = h.server.state.Players[current]
 		})
 		if !found {
-			return fmt.Errorf("player %s not found", current)
+			return fmt.Errorf("player %s: %w", current, ErrPlayerNotFound)
 		}
 
 		instance, hasInstance, otherPlayer, hasOtherPlayer := h.getRandomInstanceForPlayer(player)
 		if !hasInstance {
 			log.Printf("[SaveMode] Player %s has no available instances for random swap", current)
+			if step == 0 {
+				return fmt.Errorf("player %s: %w (all instances completed or taken)", current, ErrNoAvailableGames)
+			}
 			break
 		}
 
@@ -792,7 +1223,7 @@ func (h *SaveModeHandler) HandleRandomSwapForPlayer(playerName string) error {
 		h.server.RequestPendingSaves()
 		if h.server.WaitForPendingSaves(60 * time.Second) {
 			log.Printf("[SaveMode] timed out waiting for random-swap saves")
-			return nil
+			return fmt.Errorf("%w: timed out waiting for random-swap saves", ErrSavesPending)
 		}
 
 		player.InstanceID = instance.ID
@@ -813,7 +1244,7 @@ func (h *SaveModeHandler) HandleRandomSwapForPlayer(playerName string) error {
 			}
 		})
 
-		h.server.sendSwap(player, SwapSendOptions{SkipSave: true})
+		h.server.sendSwap(player, SwapSendOptions{SkipSave: true, Preview: true})
 		delete(pending, player.Name)
 
 		if !hasOtherPlayer {
@@ -829,7 +1260,155 @@ func (h *SaveModeHandler) HandleRandomSwapForPlayer(playerName string) error {
 	return nil
 }
 
-// getGameModeHandler returns the appropriate handler for the given game mode
+// RaceModeHandler implements the race game mode: everyone plays the same game like sync mode,
+// and the first player to complete a game wins, which freezes further swaps.
+type RaceModeHandler struct {
+	server *Server
+}
+
+func (h *RaceModeHandler) sync() *SyncModeHandler {
+	return &SyncModeHandler{server: h.server}
+}
+
+// raceWinner returns the winner if the race is already decided
+func (h *RaceModeHandler) raceWinner() string {
+	var winner string
+	h.server.withRLock(func() {
+		winner = h.server.state.RaceWinner
+	})
+	return winner
+}
+
+// HandleSwap assigns the same new game to all players unless the race has been won.
+func (h *RaceModeHandler) HandleSwap() error {
+	if winner := h.raceWinner(); winner != "" {
+		log.Printf("[RaceMode] Race already won by %s, ignoring swap", winner)
+		return nil
+	}
+	return h.sync().HandleSwap()
+}
+
+func (h *RaceModeHandler) GetPlayer(player string) protocol.Player {
+	return h.sync().GetPlayer(player)
+}
+
+// SetupState seeds Games from MainGames like sync mode and starts a fresh race.
+func (h *RaceModeHandler) SetupState() error {
+	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.RaceWinner = ""
+	})
+	return h.sync().SetupState()
+}
+
+func (h *RaceModeHandler) HandlePlayerSwap(player string, game string, instanceID string) error {
+	return h.sync().HandlePlayerSwap(player, game, instanceID)
+}
+
+func (h *RaceModeHandler) HandleRandomSwapForPlayer(playerName string) error {
+	if winner := h.raceWinner(); winner != "" {
+		log.Printf("[RaceMode] Race already won by %s, ignoring random swap for %s", winner, playerName)
+		return fmt.Errorf("%w by %s", ErrRaceWon, winner)
+	}
+	return h.sync().HandleRandomSwapForPlayer(playerName)
+}
+
+func (h *RaceModeHandler) HandleGroupSwap(group string) error {
+	if winner := h.raceWinner(); winner != "" {
+		log.Printf("[RaceMode] Race already won by %s, ignoring swap for team %s", winner, group)
+		return nil
+	}
+	return h.sync().HandleGroupSwap(group)
+}
+
+func (h *RaceModeHandler) HandleSwapWhere(label string, in func(protocol.Player) bool) error {
+	if winner := h.raceWinner(); winner != "" {
+		log.Printf("[RaceMode] Race already won by %s, ignoring swap for %s", winner, label)
+		return nil
+	}
+	return h.sync().HandleSwapWhere(label, in)
+}
+
+// recordRaceCompletion declares playerName the winner if the server is in race mode and nobody
+// has won yet. It stops automatic swaps and announces the winner to every player.
+func (s *Server) recordRaceCompletion(playerName string, game string) {
+	won := false
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		if st.Mode != protocol.GameModeRace || st.RaceWinner != "" {
+			return
+		}
+		st.RaceWinner = playerName
+		st.SwapEnabled = false
+		st.NextSwapAt = 0
+		won = true
+	})
+	if !won {
+		return
+	}
+	s.emitAdminEvent(protocol.CmdSwapEnabledChanged, protocol.SwapEnabledEvent{Enabled: false})
+	select {
+	case s.schedulerCh <- struct{}{}:
+	default:
+	}
+	log.Printf("[RaceMode] %s won the race by completing %s", playerName, game)
+	s.sendMessage(fmt.Sprintf("%s wins! (%s)", playerName, game), 10, 10, 10, 16, "#FFD700", "#000000")
+}
+
+// ManualModeHandler implements manual mode for curated sessions: the server never
+// picks a game, so connecting players wait until an admin assigns one with
+// apiSwapPlayer, and scheduled or Lua-triggered swaps do nothing.
+type ManualModeHandler struct {
+	server *Server
+}
+
+func (h *ManualModeHandler) sync() *SyncModeHandler {
+	return &SyncModeHandler{server: h.server}
+}
+
+func (h *ManualModeHandler) HandleSwap() error {
+	log.Printf("[ManualMode] Ignoring automatic swap")
+	return nil
+}
+
+func (h *ManualModeHandler) GetPlayer(player string) protocol.Player {
+	return protocol.Player{Name: player}
+}
+
+// SetupState seeds Games from MainGames like sync mode so the admin can pick from them.
+func (h *ManualModeHandler) SetupState() error {
+	return h.sync().SetupState()
+}
+
+func (h *ManualModeHandler) HandlePlayerSwap(player string, game string, instanceID string) error {
+	return h.sync().HandlePlayerSwap(player, game, instanceID)
+}
+
+func (h *ManualModeHandler) HandleRandomSwapForPlayer(playerName string) error {
+	return fmt.Errorf("player %s: %w", playerName, ErrManualMode)
+}
+
+func (h *ManualModeHandler) HandleGroupSwap(group string) error {
+	log.Printf("[ManualMode] Ignoring swap for team %s", group)
+	return nil
+}
+
+func (h *ManualModeHandler) HandleSwapWhere(label string, _ func(protocol.Player) bool) error {
+	log.Printf("[ManualMode] Ignoring swap for %s", label)
+	return nil
+}
+
+// knownGameMode reports whether mode has a GameModeHandler.
+func knownGameMode(mode protocol.GameMode) bool {
+	switch mode {
+	case protocol.GameModeSync, protocol.GameModeSave, protocol.GameModeRace, protocol.GameModeBingo,
+		protocol.GameModeManual:
+		return true
+	}
+	return false
+}
+
+// getGameModeHandler returns the appropriate handler for the given game mode.
+// An unknown mode falls back to sync rather than taking the server down;
+// loadState and apiMode keep such modes out of state.
 func (s *Server) GetGameModeHandler() GameModeHandler {
 	var mode protocol.GameMode
 	s.withRLock(func() { mode = s.state.Mode })
@@ -843,7 +1422,22 @@ func (s *Server) GetGameModeHandler() GameModeHandler {
 		return &SaveModeHandler{
 			server: s,
 		}
+	case protocol.GameModeRace:
+		return &RaceModeHandler{
+			server: s,
+		}
+	case protocol.GameModeBingo:
+		return &BingoModeHandler{
+			server: s,
+		}
+	case protocol.GameModeManual:
+		return &ManualModeHandler{
+			server: s,
+		}
 	default:
-		panic("unexpected game mode: \"" + mode + "\"")
+		log.Printf("unexpected game mode %q; using sync", mode)
+		return &SyncModeHandler{
+			server: s,
+		}
 	}
 }
@@ -1,7 +1,13 @@
 package serverhost
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/michael4d45/bizshuffle/protocol"
 )
@@ -23,21 +29,95 @@ func TestValidateNoDuplicateInstanceAssignments(t *testing.T) {
 	}
 }
 
+func TestRepairDuplicateInstanceAssignments(t *testing.T) {
+	st := &protocol.ServerState{
+		Players: map[string]protocol.Player{
+			"amy":  {Name: "amy", InstanceID: "inst-1", Game: "a.nes", Connected: true, PingMs: 80},
+			"bob":  {Name: "bob", InstanceID: "inst-1", Game: "a.nes", Connected: true, PingMs: 20},
+			"cat":  {Name: "cat", InstanceID: "inst-1", Game: "a.nes"},
+			"dan":  {Name: "dan", InstanceID: "inst-2", Game: "b.nes", Connected: true},
+			"eve":  {Name: "eve", InstanceID: "inst-2", Game: "b.nes", Connected: true},
+			"finn": {Name: "finn", InstanceID: "inst-3", Game: "c.nes"},
+		},
+	}
+	displaced := repairDuplicateInstanceAssignments(st)
+	if want := []string{"amy", "cat", "eve"}; !slices.Equal(displaced, want) {
+		t.Fatalf("displaced = %v, want %v", displaced, want)
+	}
+	if err := validateNoDuplicateInstanceAssignments(st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Players["bob"].InstanceID != "inst-1" || st.Players["dan"].InstanceID != "inst-2" || st.Players["finn"].InstanceID != "inst-3" {
+		t.Fatalf("wrong keepers: %+v", st.Players)
+	}
+	if p := st.Players["amy"]; p.InstanceID != "" || p.Game != "" {
+		t.Fatalf("amy not cleared: %+v", p)
+	}
+}
+
 func TestSelectNextGameRespectsExcludeAndSeed(t *testing.T) {
 	games := []string{"a.zip", "b.zip", "c.zip"}
-	first := selectNextGame(games, []string{"a.zip"}, 99)
-	second := selectNextGame(games, []string{"a.zip"}, 99)
+	first := selectNextGame(games, []string{"a.zip"}, 99, protocol.OrderModeRandom, "", nil)
+	second := selectNextGame(games, []string{"a.zip"}, 99, protocol.OrderModeRandom, "", nil)
 	if first == "" || first != second {
 		t.Fatalf("deterministic pick %q vs %q", first, second)
 	}
 	if first == "a.zip" {
 		t.Fatalf("excluded game was selected: %q", first)
 	}
-	if selectNextGame(nil, nil, 1) != "" {
+	if selectNextGame(nil, nil, 1, protocol.OrderModeRandom, "", nil) != "" {
 		t.Fatal("expected empty for no games")
 	}
 }
 
+func TestSelectNextGameWeightedSkew(t *testing.T) {
+	games := []string{"short.zip", "long.zip"}
+	weights := gameWeights([]protocol.GameEntry{
+		{File: "short.zip", Weight: 99},
+		{File: "long.zip"},
+	})
+	counts := map[string]int{}
+	for seed := int64(0); seed < 1000; seed++ {
+		counts[selectNextGame(games, nil, seed, protocol.OrderModeRandom, "", weights)]++
+	}
+	if counts["short.zip"] < 950 || counts["long.zip"] == 0 {
+		t.Fatalf("unexpected weighted distribution: %v", counts)
+	}
+	if g := selectNextGame(games, []string{"short.zip"}, 1, protocol.OrderModeRandom, "", weights); g != "long.zip" {
+		t.Fatalf("excluded heavy game was selected: %q", g)
+	}
+}
+
+func TestSelectNextGameUniformWeightsMatchUnweighted(t *testing.T) {
+	games := []string{"a.zip", "b.zip", "c.zip"}
+	weights := gameWeights([]protocol.GameEntry{{File: "a.zip", Weight: 1}, {File: "b.zip"}, {File: "c.zip", Weight: 1}})
+	if weights != nil {
+		t.Fatalf("expected nil weights for uniform catalog, got %v", weights)
+	}
+	for seed := int64(0); seed < 50; seed++ {
+		if selectNextGame(games, nil, seed, protocol.OrderModeRandom, "", weights) !=
+			selectNextGame(games, nil, seed, protocol.OrderModeRandom, "", nil) {
+			t.Fatalf("seed %d: uniform weights changed selection", seed)
+		}
+	}
+}
+
+func TestSelectNextGameSequentialWraps(t *testing.T) {
+	games := []string{"a.zip", "b.zip", "c.zip"}
+	if g := selectNextGame(games, nil, 1, protocol.OrderModeSequential, "", nil); g != "a.zip" {
+		t.Fatalf("expected first game with no current, got %q", g)
+	}
+	if g := selectNextGame(games, nil, 1, protocol.OrderModeSequential, "a.zip", nil); g != "b.zip" {
+		t.Fatalf("expected b.zip after a.zip, got %q", g)
+	}
+	if g := selectNextGame(games, nil, 1, protocol.OrderModeSequential, "c.zip", nil); g != "a.zip" {
+		t.Fatalf("expected wrap to a.zip, got %q", g)
+	}
+	if g := selectNextGame(games, []string{"b.zip"}, 1, protocol.OrderModeSequential, "a.zip", nil); g != "c.zip" {
+		t.Fatalf("expected excluded b.zip to be skipped, got %q", g)
+	}
+}
+
 func TestFindAvailableInstanceForPlayerPrefersDifferentGame(t *testing.T) {
 	chdirToTemp(t)
 	s := New()
@@ -74,6 +154,74 @@ func TestSyncModeHandleSwapAssignsSharedGame(t *testing.T) {
 	}
 }
 
+func TestSyncModeHandleSwapSequentialAdvances(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.OrderMode = protocol.OrderModeSequential
+		st.Games = []string{"a.zip", "b.zip", "c.zip"}
+		st.Players["p1"] = protocol.Player{Name: "p1", Game: "b.zip"}
+		st.Players["p2"] = protocol.Player{Name: "p2", Game: "b.zip"}
+	})
+	h := &SyncModeHandler{server: s}
+	for _, want := range []string{"c.zip", "a.zip"} {
+		if err := h.HandleSwap(); err != nil {
+			t.Fatal(err)
+		}
+		st := s.SnapshotState()
+		for _, name := range []string{"p1", "p2"} {
+			if got := st.Players[name].Game; got != want {
+				t.Fatalf("%s: expected %q, got %q", name, want, got)
+			}
+		}
+	}
+}
+
+func TestSwapCounterMigratesAndAdvances(t *testing.T) {
+	chdirToTemp(t)
+	if err := os.WriteFile("state.json", []byte(`{"players":{},"swap_seed":42}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	if got := s.SnapshotState().SwapCounter; got != 1 {
+		t.Fatalf("expected counter bumped to 1 on load, got %d", got)
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Games = []string{"a.zip", "b.zip"}
+		st.Players["p1"] = protocol.Player{Name: "p1"}
+	})
+	h := &SyncModeHandler{server: s}
+	if err := h.HandleSwap(); err != nil {
+		t.Fatal(err)
+	}
+	st := s.SnapshotState()
+	if st.SwapCounter != 2 || st.SwapSeed != 43 {
+		t.Fatalf("expected counter=2 seed=43, got counter=%d seed=%d", st.SwapCounter, st.SwapSeed)
+	}
+	if mixSwapSeed(42, 1) == mixSwapSeed(43, 0) {
+		t.Fatal("counter must not cancel out seed increments")
+	}
+}
+
+func TestLoadStateNormalizesUnknownMode(t *testing.T) {
+	chdirToTemp(t)
+	if err := os.WriteFile("state.json", []byte(`{"players":{},"mode":"bogus"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	if got := s.SnapshotState().Mode; got != protocol.GameModeSync {
+		t.Fatalf("mode after load = %q, want sync", got)
+	}
+
+	// A mode that slips into state anyway must not panic.
+	s.withLock(func() { s.state.Mode = "bogus" })
+	if _, ok := s.GetGameModeHandler().(*SyncModeHandler); !ok {
+		t.Fatalf("unknown mode handler = %T, want *SyncModeHandler", s.GetGameModeHandler())
+	}
+}
+
 func TestSaveModeHandleSwapReassignsInstances(t *testing.T) {
 	chdirToTemp(t)
 	s := New()
@@ -106,3 +254,348 @@ func TestSaveModeHandleSwapReassignsInstances(t *testing.T) {
 		ids[id] = true
 	}
 }
+
+func TestSaveModePreviewSwapLeavesStateUntouched(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "i1", Game: "g1.zip"},
+			{ID: "i2", Game: "g2.zip"},
+		}
+		st.Players["p1"] = protocol.Player{Name: "p1", InstanceID: "i1", Game: "g1.zip"}
+		st.Players["p2"] = protocol.Player{Name: "p2", InstanceID: "i2", Game: "g2.zip"}
+		st.Players["p3"] = protocol.Player{Name: "p3"}
+	})
+	client := registerPlayerWSClient(s, "p1")
+	before := s.SnapshotPlayers()
+
+	h := &SaveModeHandler{server: s}
+	preview := h.PreviewSwap()
+	if len(preview.Assignments) != 2 || len(preview.Unassigned) != 1 {
+		t.Fatalf("expected 2 assignments and 1 unassigned, got %+v", preview)
+	}
+	seen := map[string]bool{}
+	for _, a := range preview.Assignments {
+		if seen[a.InstanceID] {
+			t.Fatalf("duplicate instance %s in preview", a.InstanceID)
+		}
+		seen[a.InstanceID] = true
+	}
+
+	after := s.SnapshotPlayers()
+	for name, p := range before {
+		if after[name].InstanceID != p.InstanceID || after[name].Game != p.Game {
+			t.Fatalf("preview mutated player %s: %+v -> %+v", name, p, after[name])
+		}
+	}
+	select {
+	case cmd := <-client.sendCh:
+		t.Fatalf("preview sent command %s", cmd.Cmd)
+	default:
+	}
+}
+
+func TestSaveModeHandleSwapUnassignsSamePlayersAsPreview(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	h := &SaveModeHandler{server: s}
+	// Repeat so random map order in HandleSwap would show up.
+	for range 5 {
+		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			st.Mode = protocol.GameModeSave
+			st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "i1", Game: "g1.zip"}}
+			for _, name := range []string{"p1", "p2", "p3", "p4"} {
+				st.Players[name] = protocol.Player{Name: name}
+			}
+		})
+		preview := h.PreviewSwap()
+		if err := h.HandleSwap(); err != nil {
+			t.Fatal(err)
+		}
+		var unassigned []string
+		for name, p := range s.SnapshotPlayers() {
+			if p.InstanceID == "" {
+				unassigned = append(unassigned, name)
+			}
+		}
+		slices.Sort(unassigned)
+		slices.Sort(preview.Unassigned)
+		if !slices.Equal(unassigned, preview.Unassigned) {
+			t.Fatalf("swap left %v unassigned, preview named %v", unassigned, preview.Unassigned)
+		}
+	}
+}
+
+func TestSaveModeHandleSwapRotatesUnassignedPlayers(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	h := &SaveModeHandler{server: s}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "i1", Game: "g1.zip"}, {ID: "i2", Game: "g2.zip"}}
+		for _, name := range []string{"p1", "p2", "p3", "p4", "p5"} {
+			st.Players[name] = protocol.Player{Name: name}
+		}
+	})
+	played := map[string]bool{}
+	for range 5 {
+		if err := h.HandleSwap(); err != nil {
+			t.Fatal(err)
+		}
+		for name, p := range s.SnapshotPlayers() {
+			if p.InstanceID != "" {
+				played[name] = true
+			}
+		}
+	}
+	if len(played) != 5 {
+		t.Fatalf("only %v got an instance across 5 swaps", played)
+	}
+}
+
+func TestRaceModeFirstCompletionWinsAndFreezesSwaps(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeRace
+		st.SwapEnabled = true
+		st.Games = []string{"a.zip", "b.zip"}
+		st.Players["p1"] = protocol.Player{Name: "p1"}
+		st.Players["p2"] = protocol.Player{Name: "p2"}
+	})
+	h, ok := s.GetGameModeHandler().(*RaceModeHandler)
+	if !ok {
+		t.Fatal("expected race mode handler")
+	}
+	if err := h.HandleSwap(); err != nil {
+		t.Fatal(err)
+	}
+	st := s.SnapshotState()
+	game := st.Players["p1"].Game
+	if game == "" || game != st.Players["p2"].Game {
+		t.Fatalf("expected shared game, got %q and %q", game, st.Players["p2"].Game)
+	}
+
+	s.recordRaceCompletion("p2", game)
+	s.recordRaceCompletion("p1", game)
+	st = s.SnapshotState()
+	if st.RaceWinner != "p2" || st.SwapEnabled {
+		t.Fatalf("expected p2 to win with swaps disabled, got winner=%q swap_enabled=%v", st.RaceWinner, st.SwapEnabled)
+	}
+
+	seed := st.SwapSeed
+	if err := h.HandleSwap(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.SnapshotState().SwapSeed; got != seed {
+		t.Fatalf("swap ran after race was won (seed %d -> %d)", seed, got)
+	}
+}
+
+func TestManualModeOnlyAssignsOnAdminSwap(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeManual
+		st.MainGames = []protocol.GameEntry{{File: "a.zip"}, {File: "b.zip"}}
+		st.Players["p1"] = protocol.Player{Name: "p1"}
+	})
+	for _, f := range []string{"a.zip", "b.zip"} {
+		if err := os.WriteFile(filepath.Join("roms", f), []byte("rom"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h, ok := s.GetGameModeHandler().(*ManualModeHandler)
+	if !ok {
+		t.Fatal("expected manual mode handler")
+	}
+	if err := h.SetupState(); err != nil {
+		t.Fatal(err)
+	}
+	if games := s.SnapshotState().Games; len(games) != 2 {
+		t.Fatalf("catalog not seeded into games: %v", games)
+	}
+
+	if p := s.AssignPlayerOnConnect("p2"); p.Game != "" {
+		t.Fatalf("connecting player assigned %q", p.Game)
+	}
+	if err := h.HandleSwap(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.HandleRandomSwapForPlayer("p1"); !isSwapDeclined(err) {
+		t.Fatalf("random swap err = %v, want declined", err)
+	}
+	for name, p := range s.SnapshotState().Players {
+		if p.Game != "" {
+			t.Fatalf("%s auto-assigned %q", name, p.Game)
+		}
+	}
+
+	if err := h.HandlePlayerSwap("p1", "b.zip", ""); err != nil {
+		t.Fatal(err)
+	}
+	if g := s.SnapshotState().Players["p1"].Game; g != "b.zip" {
+		t.Fatalf("admin swap game = %q", g)
+	}
+}
+
+func TestAssignDerangementTwoPlayersSwapInstances(t *testing.T) {
+	h := &SaveModeHandler{}
+	players := []string{"amy", "bob"}
+	current := map[string]protocol.Player{
+		"amy": {Name: "amy", Game: "a.nes", InstanceID: "inst-1"},
+		"bob": {Name: "bob", Game: "a.nes", InstanceID: "inst-2"},
+	}
+	// Both orders, with and without preventSame (both instances run the same game).
+	for _, instances := range [][]protocol.GameSwapInstance{
+		{{ID: "inst-1", Game: "a.nes"}, {ID: "inst-2", Game: "a.nes"}},
+		{{ID: "inst-2", Game: "a.nes"}, {ID: "inst-1", Game: "a.nes"}},
+	} {
+		for _, preventSame := range []bool{false, true} {
+			got := h.assignInstances(players, current, instances, preventSame, protocol.SwapStrategyDerangement)
+			if len(got) != 2 || instances[got["amy"]].ID != "inst-2" || instances[got["bob"]].ID != "inst-1" {
+				t.Fatalf("preventSame=%v order=%v: got %v", preventSame, instances, got)
+			}
+		}
+	}
+}
+
+func TestAssignDerangementReroutesWhereRoundRobinStalls(t *testing.T) {
+	h := &SaveModeHandler{}
+	players := []string{"amy", "bob", "cat"}
+	current := map[string]protocol.Player{
+		"amy": {Name: "amy", Game: "a.nes", InstanceID: "inst-1"},
+		"bob": {Name: "bob", Game: "b.nes", InstanceID: "inst-2"},
+		"cat": {Name: "cat", Game: "c.nes", InstanceID: "inst-3"},
+	}
+	instances := []protocol.GameSwapInstance{{ID: "inst-2", Game: "b.nes"}, {ID: "inst-1", Game: "a.nes"}, {ID: "inst-3", Game: "c.nes"}}
+
+	rr := h.assignInstances(players, current, instances, true, protocol.SwapStrategyRoundRobin)
+	if instances[rr["cat"]].ID != "inst-3" {
+		t.Fatalf("round-robin expected to leave cat in place for this order, got %v", rr)
+	}
+	got := h.assignInstances(players, current, instances, true, protocol.SwapStrategyDerangement)
+	if len(got) != 3 {
+		t.Fatalf("expected every player assigned, got %v", got)
+	}
+	for _, p := range players {
+		if instances[got[p]].ID == current[p].InstanceID {
+			t.Fatalf("%s kept %s: %v", p, current[p].InstanceID, got)
+		}
+	}
+}
+
+func TestAssignDerangementFallsBackWhenImpossible(t *testing.T) {
+	h := &SaveModeHandler{}
+	// amy has completed inst-2, so the only derangement is unavailable.
+	players := []string{"amy", "bob"}
+	current := map[string]protocol.Player{
+		"amy": {Name: "amy", InstanceID: "inst-1", CompletedInstances: []string{"inst-2"}},
+		"bob": {Name: "bob", InstanceID: "inst-2"},
+	}
+	instances := []protocol.GameSwapInstance{{ID: "inst-1", Game: "a.nes"}, {ID: "inst-2", Game: "b.nes"}}
+	got := h.assignInstances(players, current, instances, false, protocol.SwapStrategyDerangement)
+	if len(got) != 2 || instances[got["amy"]].ID != "inst-1" || instances[got["bob"]].ID != "inst-2" {
+		t.Fatalf("expected both players kept on their instances, got %v", got)
+	}
+
+	// A single player with a single instance keeps it.
+	got = h.assignInstances([]string{"amy"}, map[string]protocol.Player{"amy": {Name: "amy", InstanceID: "inst-1"}}, instances[:1], true, protocol.SwapStrategyDerangement)
+	if idx, ok := got["amy"]; !ok || idx != 0 {
+		t.Fatalf("single player: %v", got)
+	}
+}
+
+func TestSaveModeHandleSwapRepairsDuplicatesBeforeSending(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	// Two catalog entries sharing an ID make the round-robin deal "dup" to
+	// both players; p1 keeps it (connected, equal ping, first by name).
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "dup", Game: "g1.zip"},
+			{ID: "dup", Game: "g1.zip"},
+		}
+		st.Players["p1"] = protocol.Player{Name: "p1", Connected: true, BizhawkReady: true}
+		st.Players["p2"] = protocol.Player{Name: "p2", Connected: true, BizhawkReady: true}
+	})
+
+	// Fake clients ack everything and answer save requests by marking the
+	// instance uploaded. No swap may go out while two players share an
+	// instance, and p2's reassignment starts by asking p1 to save, so any
+	// swap p2 gets before that came from the full swap itself.
+	var mu sync.Mutex
+	saveRequested := false
+	var early, shared []string
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, name := range []string{"p1", "p2"} {
+		c := registerPlayerWSClient(s, name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case cmd := <-c.sendCh:
+					var payload struct {
+						InstanceID string `json:"instance_id"`
+					}
+					b, _ := json.Marshal(cmd.Payload)
+					_ = json.Unmarshal(b, &payload)
+					if cmd.Cmd == protocol.CmdSwap {
+						st := s.SnapshotState()
+						if err := validateNoDuplicateInstanceAssignments(&st); err != nil {
+							mu.Lock()
+							shared = append(shared, err.Error())
+							mu.Unlock()
+						}
+					}
+					mu.Lock()
+					if cmd.Cmd == protocol.CmdRequestSave {
+						saveRequested = true
+					}
+					if cmd.Cmd == protocol.CmdSwap && name == "p2" && !saveRequested {
+						early = append(early, payload.InstanceID)
+					}
+					mu.Unlock()
+					if cmd.Cmd == protocol.CmdRequestSave {
+						s.setInstanceFileState(payload.InstanceID, protocol.FileStateReady)
+					}
+					s.withRLock(func() {
+						if ch, ok := s.pending[cmd.ID]; ok {
+							ch <- "ack"
+						}
+					})
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	h := &SaveModeHandler{server: s}
+	if err := h.HandleSwap(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(shared) > 0 {
+		t.Fatalf("swap sent while an instance was shared: %v", shared)
+	}
+	if len(early) > 0 {
+		t.Fatalf("displaced player was sent swaps %q by the full swap", early)
+	}
+	if !saveRequested {
+		t.Fatal("displaced player was not reassigned")
+	}
+}
@@ -0,0 +1,175 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// migrateGameFile rewrites every catalog, instance and player reference to
+// the game file from so it points at to. Save files are keyed by instance
+// ID, so they carry over unchanged. Call it inside UpdateStateAndPersist.
+func (s *Server) migrateGameFile(st *protocol.ServerState, from, to string) {
+	renameIn := func(list []string) {
+		for i, v := range list {
+			if v == from {
+				list[i] = to
+			}
+		}
+	}
+	for i := range st.MainGames {
+		if st.MainGames[i].File == from {
+			st.MainGames[i].File = to
+		}
+		renameIn(st.MainGames[i].ExtraFiles)
+	}
+	renameIn(st.Games)
+	renameIn(st.BingoBoard)
+	for i := range st.GameSwapInstances {
+		if st.GameSwapInstances[i].Game == from {
+			st.GameSwapInstances[i].Game = to
+		}
+	}
+	for name, p := range st.Players {
+		p.CompletedGames = slices.Clone(p.CompletedGames)
+		renameIn(p.CompletedGames)
+		if p.Game == from {
+			applied := s.appliedSwapTarget[name] == s.swapTargetKey(p)
+			p.Game = to
+			// Keep the applied-swap record in step so the rename alone doesn't trigger a reload.
+			if applied {
+				s.appliedSwapTarget[name] = s.swapTargetKey(p)
+			}
+		}
+		st.Players[name] = p
+	}
+}
+
+// entryChecksum returns the sha256 of e's main file: the catalog's own
+// checksum, the ./roms copy, or the last digest cached for it (a renamed
+// file is gone from disk). Empty when unknown.
+func (s *Server) entryChecksum(e protocol.GameEntry) string {
+	if sum := e.Checksums[e.File]; sum != "" {
+		return sum
+	}
+	if sum, err := s.romChecksum(e.File); err == nil {
+		return sum
+	}
+	s.romHashes.mu.Lock()
+	defer s.romHashes.mu.Unlock()
+	return s.romHashes.entries[e.File].sum
+}
+
+// detectGameRenames pairs main files dropped from the catalog with files
+// added to it when both have the same checksum, the stable key of a ROM
+// renamed on disk. Ambiguous checksums are left alone.
+func (s *Server) detectGameRenames(oldEntries, newEntries []protocol.GameEntry) map[string]string {
+	removed := make(map[string][]string)
+	for _, e := range oldEntries {
+		if gameEntryHasFile(newEntries, e.File) {
+			continue
+		}
+		if sum := s.entryChecksum(e); sum != "" {
+			removed[sum] = append(removed[sum], e.File)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	added := make(map[string][]string)
+	for _, e := range newEntries {
+		if gameEntryHasFile(oldEntries, e.File) {
+			continue
+		}
+		if sum := s.entryChecksum(e); sum != "" {
+			added[sum] = append(added[sum], e.File)
+		}
+	}
+	renames := make(map[string]string)
+	for sum, from := range removed {
+		if to := added[sum]; len(from) == 1 && len(to) == 1 {
+			renames[from[0]] = to[0]
+		}
+	}
+	return renames
+}
+
+// apiGameRename handles POST /api/games/rename {from, to}: it points
+// instances, players and the catalog at a ROM that was renamed on disk.
+// The catalog entry for from is renamed unless to is already listed.
+func (s *Server) apiGameRename(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	var b struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
+		return
+	}
+	if b.From == "" || b.To == "" {
+		writeAPIError(w, http.StatusBadRequest, errCodeMissingField, "from and to are required")
+		return
+	}
+	if b.From == b.To {
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, "from and to must differ")
+		return
+	}
+	var status int
+	var players []string
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		if gameEntryHasFile(st.MainGames, b.From) && gameEntryHasFile(st.MainGames, b.To) {
+			status = http.StatusConflict
+			return
+		}
+		if !gameReferenced(st, b.From) {
+			status = http.StatusNotFound
+			return
+		}
+		for name, p := range st.Players {
+			if p.Game == b.From {
+				players = append(players, name)
+			}
+		}
+		s.migrateGameFile(st, b.From, b.To)
+	})
+	switch status {
+	case http.StatusConflict:
+		writeAPIError(w, status, errCodeConflict, fmt.Sprintf("%s and %s are both in the catalog", b.From, b.To))
+		return
+	case http.StatusNotFound:
+		writeAPIError(w, status, errCodeNotFound, b.From+" is not referenced")
+		return
+	}
+	sort.Strings(players)
+	s.broadcastGamesUpdate(nil)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"result": "ok", "players": players, "missing_files": missingRoms([]string{b.To})}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// gameReferenced reports whether anything in st still points at file.
+func gameReferenced(st *protocol.ServerState, file string) bool {
+	if gameEntryHasFile(st.MainGames, file) || slices.Contains(st.Games, file) || slices.Contains(st.BingoBoard, file) {
+		return true
+	}
+	for _, inst := range st.GameSwapInstances {
+		if inst.Game == file {
+			return true
+		}
+	}
+	for _, p := range st.Players {
+		if p.Game == file || slices.Contains(p.CompletedGames, file) {
+			return true
+		}
+	}
+	return false
+}
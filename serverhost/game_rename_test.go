@@ -0,0 +1,124 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestAPIGameRenameReassignsPlayers(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	if err := os.WriteFile(filepath.Join("roms", "new.nes"), []byte("rom"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.MainGames = []protocol.GameEntry{{File: "old.nes"}, {File: "other.nes"}}
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "i1", Game: "old.nes"}, {ID: "i2", Game: "other.nes"}}
+		st.Players["amy"] = protocol.Player{Name: "amy", Game: "old.nes", InstanceID: "i1"}
+		st.Players["bo"] = protocol.Player{Name: "bo", Game: "other.nes", InstanceID: "i2", CompletedGames: []string{"old.nes"}}
+	})
+	s.recordSwapApplied("amy", s.SnapshotState().Players["amy"])
+
+	rename := func(body string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		s.apiGameRename(rec, httptest.NewRequest(http.MethodPost, "/api/games/rename", strings.NewReader(body)))
+		var out map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		return rec.Code, out
+	}
+	if code, _ := rename(`{"from":"old.nes"}`); code != http.StatusBadRequest {
+		t.Fatalf("missing to: status %d", code)
+	}
+	if code, _ := rename(`{"from":"gone.nes","to":"new.nes"}`); code != http.StatusNotFound {
+		t.Fatalf("unknown from: status %d", code)
+	}
+	if code, _ := rename(`{"from":"old.nes","to":"other.nes"}`); code != http.StatusConflict {
+		t.Fatalf("both in catalog: status %d", code)
+	}
+	code, out := rename(`{"from":"old.nes","to":"new.nes"}`)
+	if code != http.StatusOK {
+		t.Fatalf("rename: status %d", code)
+	}
+	if players, _ := out["players"].([]any); len(players) != 1 || players[0] != "amy" {
+		t.Fatalf("reassigned players %v", out["players"])
+	}
+
+	st := s.SnapshotState()
+	if st.MainGames[0].File != "new.nes" || st.GameSwapInstances[0].Game != "new.nes" {
+		t.Fatalf("catalog not updated: %+v %+v", st.MainGames, st.GameSwapInstances)
+	}
+	amy, bo := st.Players["amy"], st.Players["bo"]
+	if amy.Game != "new.nes" || amy.InstanceID != "i1" {
+		t.Fatalf("amy not reassigned: %+v", amy)
+	}
+	if bo.Game != "other.nes" || !slices.Equal(bo.CompletedGames, []string{"new.nes"}) {
+		t.Fatalf("bo: %+v", bo)
+	}
+	if s.ShouldSendSwap(amy, false) {
+		t.Fatal("rename alone should not trigger a swap resend")
+	}
+}
+
+func TestAPIGamesMigratesRenamedFiles(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	if err := os.WriteFile(filepath.Join("roms", "zeldaa.nes"), []byte("zelda"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("roms", "metroid (v2).nes"), []byte("metroid"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.MainGames = []protocol.GameEntry{{File: "zeldaa.nes"}, {File: "metroid.nes"}}
+		st.Games = []string{"zeldaa.nes", "metroid.nes"}
+		st.Players["amy"] = protocol.Player{Name: "amy", Game: "zeldaa.nes", CompletedGames: []string{"metroid.nes"}}
+		st.Players["bo"] = protocol.Player{Name: "bo", Game: "metroid.nes"}
+	})
+	// The server saw zeldaa.nes before it was renamed on disk.
+	if _, err := s.romChecksum("zeldaa.nes"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join("roms", "zeldaa.nes"), filepath.Join("roms", "zelda.nes")); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.apiGames(rec, httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(`{
+		"main_games": [{"file": "zelda.nes"}, {"file": "metroid (v2).nes"}],
+		"games": ["zelda.nes", "metroid (v2).nes"],
+		"renames": {"metroid.nes": "metroid (v2).nes", "unused.nes": "zelda.nes"}
+	}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var out struct {
+		Renamed map[string]string `json:"renamed"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Renamed) != 2 || out.Renamed["zeldaa.nes"] != "zelda.nes" || out.Renamed["metroid.nes"] != "metroid (v2).nes" {
+		t.Fatalf("renamed %v", out.Renamed)
+	}
+
+	st := s.SnapshotState()
+	amy, bo := st.Players["amy"], st.Players["bo"]
+	if amy.Game != "zelda.nes" || !slices.Equal(amy.CompletedGames, []string{"metroid (v2).nes"}) {
+		t.Fatalf("amy: %+v", amy)
+	}
+	if bo.Game != "metroid (v2).nes" {
+		t.Fatalf("bo: %+v", bo)
+	}
+}
@@ -0,0 +1,96 @@
+package serverhost
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// maxSecondsForGame returns game's max_seconds_per_game from the catalog, or
+// 0 when it has no limit.
+func maxSecondsForGame(entries []protocol.GameEntry, game string) int {
+	for _, e := range entries {
+		if e.File == game {
+			return e.MaxSecondsPerGame
+		}
+	}
+	return 0
+}
+
+// startGameTimer restarts a player's time on game after an acknowledged swap,
+// setting GameDeadlineAt from the game's max_seconds_per_game.
+func (s *Server) startGameTimer(playerName, game string) {
+	_, mainGames, _ := s.SnapshotGames()
+	var deadline int64
+	if limit := maxSecondsForGame(mainGames, game); limit > 0 {
+		deadline = time.Now().Add(time.Duration(limit) * time.Second).Unix()
+	}
+	var current int64
+	s.withRLock(func() { current = s.state.Players[playerName].GameDeadlineAt })
+	if deadline == 0 && current == 0 {
+		return
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		p, ok := st.Players[playerName]
+		if !ok {
+			return
+		}
+		p.GameDeadlineAt = deadline
+		st.Players[playerName] = p
+	})
+}
+
+// enforceGameTimeLimits swaps connected players whose current game reached its
+// time limit. Save mode swaps each such player on their own; the other modes
+// keep everyone on one game, so the whole session swaps and the global
+// interval restarts. Manual mode never swaps on its own, so limits are ignored.
+func (s *Server) enforceGameTimeLimits(now int64) {
+	var mode protocol.GameMode
+	var expired []string
+	s.withRLock(func() {
+		if !s.state.Running || !s.state.SwapEnabled || s.state.Mode == protocol.GameModeManual {
+			return
+		}
+		mode = s.state.Mode
+		for name, p := range s.state.Players {
+			if p.Connected && p.GameDeadlineAt > 0 && now >= p.GameDeadlineAt {
+				expired = append(expired, name)
+			}
+		}
+	})
+	if len(expired) == 0 {
+		return
+	}
+	// Clear the deadlines first so the next tick doesn't fire again while the
+	// swap runs; its ack starts the next game's timer.
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		for _, name := range expired {
+			p, ok := st.Players[name]
+			if !ok {
+				continue
+			}
+			p.GameDeadlineAt = 0
+			st.Players[name] = p
+		}
+	})
+	if mode == protocol.GameModeSave {
+		for _, name := range expired {
+			go func(name string) {
+				if err := s.performRandomSwapForPlayer(name); err != nil {
+					fmt.Printf("performRandomSwapForPlayer error: %v\n", err)
+				}
+			}(name)
+		}
+		return
+	}
+	go func() {
+		if err := s.performSwap(); err != nil {
+			fmt.Printf("performSwap error: %v\n", err)
+		}
+		select {
+		case s.schedulerCh <- struct{}{}:
+		default:
+		}
+	}()
+}
@@ -0,0 +1,53 @@
+package serverhost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestGameTimeLimits(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.MainGames = []protocol.GameEntry{{File: "a.nes", MaxSecondsPerGame: 90}, {File: "b.nes"}}
+		st.Players["amy"] = protocol.Player{Name: "amy", Connected: true, Game: "a.nes"}
+	})
+	deadline := func() int64 { return s.SnapshotPlayers()["amy"].GameDeadlineAt }
+
+	before := time.Now().Unix()
+	s.startGameTimer("amy", "a.nes")
+	if d := deadline(); d < before+90 || d > time.Now().Unix()+90 {
+		t.Fatalf("deadline = %d, want ~%d", d, before+90)
+	}
+	s.startGameTimer("amy", "b.nes")
+	if d := deadline(); d != 0 {
+		t.Fatalf("game without a limit kept deadline %d", d)
+	}
+
+	expire := func(mode protocol.GameMode) {
+		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			st.Mode, st.Running, st.SwapEnabled = mode, true, true
+			p := st.Players["amy"]
+			p.GameDeadlineAt = time.Now().Add(-time.Second).Unix()
+			st.Players["amy"] = p
+		})
+	}
+
+	// Manual mode never swaps on its own.
+	expire(protocol.GameModeManual)
+	s.enforceGameTimeLimits(time.Now().Unix())
+	if deadline() == 0 {
+		t.Fatal("manual mode acted on an expired time limit")
+	}
+
+	// Elsewhere an expired limit triggers a swap and is cleared so it fires once.
+	expire(protocol.GameModeSync)
+	s.enforceGameTimeLimits(time.Now().Unix())
+	if d := deadline(); d != 0 {
+		t.Fatalf("expired deadline not cleared: %d", d)
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { st.Running = false })
+}
@@ -0,0 +1,42 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// handleHealthz reports session health for uptime monitors. It only reads
+// in-memory state under the read lock and never touches the network.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	out := map[string]any{
+		"status":      "ok",
+		"uptime_secs": int64(time.Since(s.startedAt).Seconds()),
+	}
+	s.withRLock(func() {
+		connected := 0
+		for _, p := range s.state.Players {
+			if p.Connected {
+				connected++
+			}
+		}
+		out["players"] = len(s.state.Players)
+		out["connected_players"] = connected
+		out["pending_commands"] = len(s.pending)
+		out["pending_instances"] = s.pendingInstancecount
+		out["mode"] = s.state.Mode
+		out["running"] = s.state.Running
+		out["swap_enabled"] = s.state.SwapEnabled
+		out["next_swap_at"] = s.state.NextSwapAt
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
@@ -0,0 +1,54 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestHealthzReportsSessionCounters(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.SetAdminToken("secret")
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.NextSwapAt = 1234
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+		st.Players["amy"] = protocol.Player{Name: "amy"}
+	})
+	s.withLock(func() {
+		s.pending["cmd-1"] = make(chan string, 1)
+		s.pendingInstancecount = 2
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	res, err := http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status %d (healthz must not require the admin token)", res.StatusCode)
+	}
+	var out struct {
+		ConnectedPlayers int    `json:"connected_players"`
+		PendingCommands  int    `json:"pending_commands"`
+		PendingInstances int    `json:"pending_instances"`
+		Mode             string `json:"mode"`
+		NextSwapAt       int64  `json:"next_swap_at"`
+		UptimeSecs       *int64 `json:"uptime_secs"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.ConnectedPlayers != 1 || out.PendingCommands != 1 || out.PendingInstances != 2 ||
+		out.Mode != "save" || out.NextSwapAt != 1234 || out.UptimeSecs == nil {
+		t.Fatalf("unexpected health %+v", out)
+	}
+}
@@ -22,9 +22,17 @@ func chdirToTemp(t *testing.T) {
 }
 
 func registerPlayerWSClient(s *Server, name string) *wsClient {
-	client := &wsClient{sendCh: make(chan protocol.Command, 8)}
+	client := &wsClient{sendCh: make(chan protocol.Command, 8), closed: make(chan struct{})}
 	s.withConnLock(func() {
 		s.playerClients[name] = client
 	})
 	return client
 }
+
+// stopStateSaverOnCleanup cancels the debounced state save before the test's
+// working directory is restored, so late writes (state.json, plugin
+// settings.kv) don't land in the package directory. Call after chdirToTemp.
+func stopStateSaverOnCleanup(t *testing.T, s *Server) {
+	t.Helper()
+	t.Cleanup(s.StopStateSaver)
+}
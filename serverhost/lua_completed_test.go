@@ -0,0 +1,31 @@
+package serverhost
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestLuaCompletedMarksCurrentGameOnce(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players = map[string]protocol.Player{
+			"alice": {Name: "alice", Connected: true, Game: "a.zip", InstanceID: "inst-1"},
+		}
+	})
+
+	s.markLuaCompletion("alice", map[string]string{})
+	s.markLuaCompletion("alice", map[string]string{})
+	s.markLuaCompletion("alice", map[string]string{"game": "b.zip"})
+
+	p := s.SnapshotState().Players["alice"]
+	if !slices.Equal(p.CompletedGames, []string{"a.zip", "b.zip"}) {
+		t.Fatalf("completed games = %v", p.CompletedGames)
+	}
+	if !slices.Equal(p.CompletedInstances, []string{"inst-1"}) {
+		t.Fatalf("completed instances = %v", p.CompletedInstances)
+	}
+}
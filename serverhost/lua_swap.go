@@ -0,0 +1,88 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// defaultLuaSwapCooldown applies when ServerState.LuaSwapCooldownSecs is 0.
+const defaultLuaSwapCooldown = 5 * time.Second
+
+// luaSwapCooldown returns the configured gap between Lua-requested swaps; 0 means no guard.
+func (s *Server) luaSwapCooldown() time.Duration {
+	var secs int
+	s.withRLock(func() { secs = s.state.LuaSwapCooldownSecs })
+	switch {
+	case secs < 0:
+		return 0
+	case secs == 0:
+		return defaultLuaSwapCooldown
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// performLuaSwap runs a full swap requested by a plugin's "swap" command.
+// Requests within the cooldown of the last accepted one are dropped so a
+// plugin that fires swap every frame can't storm the players, and all are
+// ignored while swaps are disabled. Admin and scheduled swaps call performSwap
+// directly and are never debounced.
+func (s *Server) performLuaSwap() error {
+	if !s.swapsEnabled() {
+		log.Printf("Lua swap ignored: swaps disabled")
+		return nil
+	}
+	cooldown := s.luaSwapCooldown()
+	var wait time.Duration
+	s.withLock(func() {
+		if since := time.Since(s.lastLuaSwap); since < cooldown {
+			wait = cooldown - since
+			return
+		}
+		s.lastLuaSwap = time.Now()
+	})
+	if wait > 0 {
+		log.Printf("Lua swap dropped: within %s cooldown (%s left)", cooldown, wait.Round(time.Millisecond))
+		return nil
+	}
+	return s.performSwap()
+}
+
+// apiLuaSwapCooldown reads (GET) or sets (POST {"lua_swap_cooldown_secs": n})
+// the Lua swap cooldown; posting 0 disables it.
+func (s *Server) apiLuaSwapCooldown(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		if err := json.NewEncoder(w).Encode(map[string]any{"lua_swap_cooldown_secs": int(s.luaSwapCooldown() / time.Second)}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method == http.MethodPost {
+		var b struct {
+			Secs int `json:"lua_swap_cooldown_secs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if b.Secs < 0 {
+			http.Error(w, "lua_swap_cooldown_secs must be >= 0", http.StatusBadRequest)
+			return
+		}
+		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			st.LuaSwapCooldownSecs = b.Secs
+			if b.Secs == 0 {
+				st.LuaSwapCooldownSecs = -1
+			}
+		})
+		if _, err := w.Write([]byte("ok")); err != nil {
+			fmt.Printf("write response error: %v\n", err)
+		}
+		return
+	}
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,43 @@
+package serverhost
+
+import (
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestLuaSwapsAreDebounced(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Games = []string{"a.zip", "b.zip"}
+	})
+	counter := func() int64 { return s.SnapshotState().SwapCounter }
+	start := counter()
+
+	for range 10 {
+		if err := s.performLuaSwap(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := counter() - start; got != 1 {
+		t.Fatalf("%d swaps executed from 10 rapid Lua requests, want 1", got)
+	}
+
+	if err := s.performSwap(); err != nil {
+		t.Fatal(err)
+	}
+	if got := counter() - start; got != 2 {
+		t.Fatal("admin swap should bypass the Lua cooldown")
+	}
+
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { st.LuaSwapCooldownSecs = -1 })
+	if err := s.performLuaSwap(); err != nil {
+		t.Fatal(err)
+	}
+	if got := counter() - start; got != 3 {
+		t.Fatal("disabled cooldown still dropped a Lua swap")
+	}
+}
@@ -0,0 +1,63 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// pendingCommand is what the server sent for a pending ack, so a nack can be
+// tied back to the player and command it answers.
+type pendingCommand struct {
+	player string
+	cmd    protocol.CommandName
+}
+
+// nackReason extracts the client's {"reason"} from a nack payload, falling
+// back to the raw payload JSON.
+func nackReason(payload any) string {
+	if m, ok := payload.(map[string]any); ok {
+		if r, ok := m["reason"].(string); ok && r != "" {
+			return r
+		}
+	}
+	if payload == nil {
+		return "nack"
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "nack"
+	}
+	return string(b)
+}
+
+// recordCommandFailure stores a nack as the player's LastFailure and sends
+// admins command_failed. Nacks from clients not registered as players are
+// only logged.
+func (s *Server) recordCommandFailure(sent pendingCommand, nack protocol.Command) {
+	failure := protocol.CommandFailure{
+		Cmd:       sent.cmd,
+		CommandID: nack.ID,
+		Reason:    nackReason(nack.Payload),
+		At:        time.Now().Unix(),
+	}
+	log.Printf("[nack] player=%q cmd=%q id=%s reason=%s", sent.player, failure.Cmd, failure.CommandID, failure.Reason)
+	if sent.player == "" {
+		return
+	}
+	found := false
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		p, ok := st.Players[sent.player]
+		if !ok {
+			return
+		}
+		found = true
+		p.LastFailure = &failure
+		st.Players[sent.player] = p
+	})
+	if found {
+		s.emitAdminEvent(protocol.CmdCommandFailed, protocol.CommandFailedEvent{Player: sent.player, CommandFailure: failure})
+	}
+}
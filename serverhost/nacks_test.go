@@ -0,0 +1,73 @@
+package serverhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestNackRecordedAsPlayerLastFailure(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdHello, ID: "1", Payload: map[string]any{"name": "bob"}}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.SnapshotPlayers()["bob"].Connected {
+		if time.Now().After(deadline) {
+			t.Fatal("bob never connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	go func() {
+		_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var cmd protocol.Command
+			if err := c.ReadJSON(&cmd); err != nil {
+				return
+			}
+			if cmd.Cmd == protocol.CmdMessage {
+				_ = c.WriteJSON(protocol.Command{Cmd: protocol.CmdNack, ID: cmd.ID, Payload: map[string]string{"reason": "download failed: 404"}})
+				return
+			}
+		}
+	}()
+	res, err := s.sendAndWait(protocol.Player{Name: "bob"}, protocol.Command{Cmd: protocol.CmdMessage, ID: "msg-1", Payload: map[string]any{"message": "hi"}}, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(res, "nack|") {
+		t.Fatalf("result %q, want nack", res)
+	}
+
+	f := s.SnapshotPlayers()["bob"].LastFailure
+	if f == nil {
+		t.Fatal("nack not recorded on player")
+	}
+	if f.Cmd != protocol.CmdMessage || f.CommandID != "msg-1" || f.Reason != "download failed: 404" || f.At == 0 {
+		t.Fatalf("last failure %+v", f)
+	}
+	s.withRLock(func() {
+		if len(s.pendingCmds) != 0 {
+			t.Errorf("pendingCmds not cleaned up: %v", s.pendingCmds)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+const (
+	// dashboardPingInterval is how often players are pinged while someone is
+	// watching latency, on top of the websocket keepalive (ping_interval_secs).
+	dashboardPingInterval = 5 * time.Second
+	// dashboardIdleAfter ends the faster pinging once no admin websocket is
+	// connected and GET /api/players/pings has not been polled for this long.
+	dashboardIdleAfter = 30 * time.Second
+	// pingStaleAfter marks a connected player stale when no pong arrived
+	// within it (three missed dashboard pings).
+	pingStaleAfter = 3 * dashboardPingInterval
+	// pingPersistDelta is the RTT change in ms that is worth persisting and
+	// broadcasting; smaller jitter only updates the in-memory value.
+	pingPersistDelta = 25
+)
+
+// PlayerPings is returned by GET /api/players/pings.
+type PlayerPings struct {
+	Pings map[string]int `json:"pings"` // connected player -> last RTT in ms
+	Stale []string       `json:"stale"` // connected players with no recent pong
+}
+
+// recordPong stores a player's measured round-trip time. The value is kept
+// in memory on every pong (GET /api/players/pings reads it from there); state
+// is only persisted and broadcast when it moves by at least pingPersistDelta,
+// so dashboard pinging doesn't rewrite state.json every few seconds.
+func (s *Server) recordPong(name string, rtt time.Duration) {
+	ms := int(rtt.Milliseconds())
+	persist := false
+	s.withLock(func() {
+		s.lastPong[name] = time.Now()
+		pl, ok := s.state.Players[name]
+		if !ok || pl.PingMs == ms {
+			return
+		}
+		delta := pl.PingMs - ms
+		if delta < 0 {
+			delta = -delta
+		}
+		if pl.PingMs == 0 || delta >= pingPersistDelta {
+			persist = true
+			return
+		}
+		pl.PingMs = ms
+		s.state.Players[name] = pl
+	})
+	if !persist {
+		return
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		pl, ok := st.Players[name]
+		if !ok {
+			return
+		}
+		pl.PingMs = ms
+		st.Players[name] = pl
+	})
+}
+
+// dashboardWatching reports whether an admin is connected or recently polled
+// the pings endpoint.
+func (s *Server) dashboardWatching() bool {
+	admins := 0
+	s.withConnRLock(func() { admins = len(s.adminClients) })
+	if admins > 0 {
+		return true
+	}
+	var polled time.Time
+	s.withRLock(func() { polled = s.pingsPolledAt })
+	return time.Since(polled) < dashboardIdleAfter
+}
+
+// dashboardPingLoop pings every connected player each dashboardPingInterval
+// while dashboardWatching, keeping PingMs fresh for the admin UI.
+func (s *Server) dashboardPingLoop() {
+	ticker := time.NewTicker(dashboardPingInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if atomic.LoadInt32(&s.shuttingDown) != 0 {
+			return
+		}
+		if !s.dashboardWatching() {
+			continue
+		}
+		var players []protocol.Player
+		s.withRLock(func() {
+			for _, p := range s.state.Players {
+				if p.Connected {
+					players = append(players, p)
+				}
+			}
+		})
+		for _, p := range players {
+			if err := s.sendPing(p); err != nil {
+				fmt.Printf("dashboard ping %s: %v\n", p.Name, err)
+			}
+		}
+	}
+}
+
+// apiPlayerPings handles GET /api/players/pings.
+func (s *Server) apiPlayerPings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	now := time.Now()
+	out := PlayerPings{Pings: map[string]int{}, Stale: []string{}}
+	s.withLock(func() {
+		s.pingsPolledAt = now
+		for name, p := range s.state.Players {
+			if !p.Connected {
+				continue
+			}
+			if p.PingMs > 0 {
+				out.Pings[name] = p.PingMs
+			}
+			if now.Sub(s.lastPong[name]) > pingStaleAfter {
+				out.Stale = append(out.Stale, name)
+			}
+		}
+	})
+	sort.Strings(out.Stale)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
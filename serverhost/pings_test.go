@@ -0,0 +1,81 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestAPIPlayerPingsMarksStalePlayers(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["amy"] = protocol.Player{Name: "amy", Connected: true}
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true, PingMs: 80}
+		st.Players["cat"] = protocol.Player{Name: "cat", PingMs: 20}
+	})
+	s.recordPong("amy", 42*time.Millisecond)
+	s.withLock(func() { s.lastPong["bob"] = time.Now().Add(-2 * pingStaleAfter) })
+
+	if s.dashboardWatching() {
+		t.Fatal("watching before any admin or poll")
+	}
+	rec := httptest.NewRecorder()
+	s.apiPlayerPings(rec, httptest.NewRequest(http.MethodGet, "/api/players/pings", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	var out PlayerPings
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Pings) != 2 || out.Pings["amy"] != 42 || out.Pings["bob"] != 80 {
+		t.Fatalf("pings = %v", out.Pings)
+	}
+	if len(out.Stale) != 1 || out.Stale[0] != "bob" {
+		t.Fatalf("stale = %v", out.Stale)
+	}
+	if !s.dashboardWatching() {
+		t.Fatal("polling the endpoint should enable dashboard pings")
+	}
+}
+
+func TestRecordPongPersistsOnlySignificantChanges(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["amy"] = protocol.Player{Name: "amy", Connected: true, PingMs: 40}
+	})
+	persisted := func() time.Time {
+		var at time.Time
+		s.withRLock(func() { at = s.state.UpdatedAt })
+		return at
+	}
+	before := persisted()
+
+	s.recordPong("amy", 43*time.Millisecond)
+	if got := s.SnapshotPlayers()["amy"].PingMs; got != 43 {
+		t.Fatalf("in-memory ping = %d, want 43", got)
+	}
+	if !persisted().Equal(before) {
+		t.Fatal("small RTT jitter should not persist state")
+	}
+
+	s.recordPong("amy", 43*time.Millisecond+pingPersistDelta*time.Millisecond)
+	if persisted().Equal(before) {
+		t.Fatal("large RTT change should persist state")
+	}
+
+	s.removePlayer("amy", false)
+	s.withRLock(func() {
+		if _, ok := s.lastPong["amy"]; ok {
+			t.Fatal("lastPong kept for removed player")
+		}
+	})
+}
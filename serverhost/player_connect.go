@@ -1,6 +1,12 @@
 package serverhost
 
-import "github.com/michael4d45/bizshuffle/protocol"
+import (
+	"log"
+	"slices"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
 
 // AssignPlayerOnConnect persists game-mode assignment for a newly connected player.
 func (s *Server) AssignPlayerOnConnect(name string) protocol.Player {
@@ -84,4 +90,67 @@ func (s *Server) ClearAppliedSwap(playerName string) {
 type SwapSendOptions struct {
 	SkipSave bool
 	Force    bool
+	// Preview shows the player the incoming game for swap_preview_secs
+	// before the swap is sent. Left off for swaps that must be instant, such
+	// as on connect or session start.
+	Preview bool
+}
+
+// readyCheckWait bounds how long a full save-mode swap waits for emulators to report ready.
+const readyCheckWait = 10 * time.Second
+
+// unreadyPlayers returns connected players with a live socket whose BizHawk
+// has not reported ready, sorted by name.
+func (s *Server) unreadyPlayers() []string {
+	var names []string
+	s.withRLock(func() {
+		for name, p := range s.state.Players {
+			if p.Connected && !p.BizhawkReady {
+				names = append(names, name)
+			}
+		}
+	})
+	s.withConnRLock(func() {
+		names = slices.DeleteFunc(names, func(name string) bool {
+			_, ok := s.playerClients[name]
+			return !ok
+		})
+	})
+	slices.Sort(names)
+	return names
+}
+
+// waitForPlayersReady is the pre-swap ready gate. It pings players whose
+// emulator isn't ready, waits up to timeout for them to report bizhawk_ready,
+// and records whoever is still not ready as NotReadyPlayers for the admin UI.
+// The swap then goes ahead; those players get their assignment once ready.
+func (s *Server) waitForPlayersReady(timeout time.Duration) []string {
+	notReady := s.unreadyPlayers()
+	if len(notReady) > 0 {
+		log.Printf("[ready] waiting up to %s for BizHawk on %v", timeout, notReady)
+		for _, name := range notReady {
+			if err := s.sendPing(protocol.Player{Name: name}); err != nil {
+				log.Printf("[ready] ping %s: %v", name, err)
+			}
+		}
+		for deadline := time.Now().Add(timeout); len(notReady) > 0 && time.Now().Before(deadline); {
+			time.Sleep(200 * time.Millisecond)
+			notReady = s.unreadyPlayers()
+		}
+		if len(notReady) > 0 {
+			log.Printf("[ready] swapping without %v: BizHawk not ready", notReady)
+		}
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.NotReadyPlayers = notReady
+	})
+	return notReady
+}
+
+// clearNotReady drops name from the not-ready list once it reports ready or leaves.
+func clearNotReady(st *protocol.ServerState, name string) {
+	st.NotReadyPlayers = slices.DeleteFunc(st.NotReadyPlayers, func(n string) bool { return n == name })
+	if len(st.NotReadyPlayers) == 0 {
+		st.NotReadyPlayers = nil
+	}
 }
@@ -1,7 +1,9 @@
 package serverhost
 
 import (
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/michael4d45/bizshuffle/protocol"
 )
@@ -65,3 +67,47 @@ func TestShouldSendSwapDedupes(t *testing.T) {
 		t.Fatal("expected force swap")
 	}
 }
+
+func TestWaitForPlayersReadyReportsStragglers(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["amy"] = protocol.Player{Name: "amy", Connected: true, BizhawkReady: true}
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+		st.Players["cat"] = protocol.Player{Name: "cat", Connected: true}
+		st.Players["dan"] = protocol.Player{Name: "dan"} // offline: not gated
+	})
+	registerPlayerWSClient(s, "amy")
+	bob := registerPlayerWSClient(s, "bob")
+	registerPlayerWSClient(s, "cat")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			p := st.Players["cat"]
+			p.BizhawkReady = true
+			st.Players["cat"] = p
+		})
+	}()
+	notReady := s.waitForPlayersReady(500 * time.Millisecond)
+	if !slices.Equal(notReady, []string{"bob"}) {
+		t.Fatalf("not ready = %v, want [bob]", notReady)
+	}
+	if got := s.SnapshotState().NotReadyPlayers; !slices.Equal(got, []string{"bob"}) {
+		t.Fatalf("state not_ready_players = %v", got)
+	}
+	select {
+	case cmd := <-bob.sendCh:
+		if cmd.Cmd != protocol.CmdPing {
+			t.Fatalf("expected ping, got %+v", cmd)
+		}
+	default:
+		t.Fatal("unready player was not pinged")
+	}
+
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { clearNotReady(st, "bob") })
+	if got := s.SnapshotState().NotReadyPlayers; got != nil {
+		t.Fatalf("not_ready_players after clear = %v", got)
+	}
+}
@@ -0,0 +1,23 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// decodeStatusUpdate converts a status_update payload to its schema; nil if
+// the payload does not fit it.
+func decodeStatusUpdate(payload map[string]any) *protocol.StatusUpdate {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	var status protocol.StatusUpdate
+	if err := json.Unmarshal(b, &status); err != nil {
+		log.Printf("[ws] bad status_update payload: %v", err)
+		return nil
+	}
+	return &status
+}
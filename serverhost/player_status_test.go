@@ -0,0 +1,75 @@
+package serverhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestStatusUpdateStoredOnPlayer(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdHello, ID: "1", Payload: map[string]any{"name": "bob"}}); err != nil {
+		t.Fatal(err)
+	}
+	free := int64(5 << 30)
+	status := protocol.StatusUpdate{Game: "a.zip", InstanceID: "a", PendingFile: "b.zip", SavesFreeBytes: &free}
+	if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdStatusUpdate, Payload: status}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p := s.SnapshotPlayers()["bob"]
+		if p.Status != nil {
+			got := *p.Status
+			if got.Game != "a.zip" || got.InstanceID != "a" || got.PendingFile != "b.zip" || got.SavesFreeBytes == nil || *got.SavesFreeBytes != free {
+				t.Fatalf("status %+v", got)
+			}
+			if p.StatusAt == 0 {
+				t.Fatal("status_at not set")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("status_update never stored")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A heartbeat without a readiness change is kept in memory only.
+	var before time.Time
+	s.withRLock(func() { before = s.state.UpdatedAt })
+	status.Game = "c.zip"
+	if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdStatusUpdate, Payload: status}); err != nil {
+		t.Fatal(err)
+	}
+	for s.SnapshotPlayers()["bob"].Status.Game != "c.zip" {
+		if time.Now().After(deadline) {
+			t.Fatal("second status_update never stored")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var after time.Time
+	s.withRLock(func() { after = s.state.UpdatedAt })
+	if !after.Equal(before) {
+		t.Fatal("heartbeat status_update persisted state")
+	}
+}
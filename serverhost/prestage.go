@@ -0,0 +1,195 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// Prestage wait bounds for POST /api/prestage.
+const (
+	defaultPrestageTimeout = 10 * time.Minute
+	maxPrestageTimeout     = time.Hour
+)
+
+// prestageDisconnected is the prestage error recorded for a player who
+// disconnects (or is removed) before acking.
+const prestageDisconnected = "disconnected during prestage"
+
+// prestageRun tracks one POST /api/prestage: the players still expected to
+// answer with games_update_ack; done is closed once none are left.
+type prestageRun struct {
+	waiting map[string]bool
+	done    chan struct{}
+}
+
+// PrestageResult is one player's entry in the /api/prestage summary.
+type PrestageResult struct {
+	Status protocol.PrestageStatus `json:"status"`
+	Errors []string                `json:"errors,omitempty"`
+}
+
+// prestagePayload is a games_update whose games list holds every catalog
+// game, so clients download all ROMs and extras, not only active instances.
+func (s *Server) prestagePayload() map[string]any {
+	games, mainGames, gameInstances := s.SnapshotGames()
+	all := append([]string(nil), games...)
+	seen := make(map[string]bool, len(all))
+	for _, g := range all {
+		seen[g] = true
+	}
+	for _, e := range presentGames(mainGames) {
+		if !seen[e.File] {
+			seen[e.File] = true
+			all = append(all, e.File)
+		}
+	}
+	return map[string]any{
+		"game_instances": gameInstances,
+		"main_games":     s.withExternalChecksums(mainGames),
+		"games":          all,
+		"prestage":       true,
+	}
+}
+
+// notePrestageAck records a games_update_ack from name against a running
+// prestage. It reports whether the ack was counted.
+func (s *Server) notePrestageAck(name string, hasFiles bool, errs []string) bool {
+	counted := false
+	s.withLock(func() {
+		run := s.prestage
+		if run == nil || !run.waiting[name] {
+			return
+		}
+		delete(run.waiting, name)
+		if len(run.waiting) == 0 {
+			close(run.done)
+		}
+		counted = true
+	})
+	if !counted {
+		return false
+	}
+	status := protocol.PrestageReady
+	if !hasFiles {
+		status = protocol.PrestageFailed
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		p, ok := st.Players[name]
+		if !ok {
+			return
+		}
+		p.Prestage = status
+		p.PrestageErrors = errs
+		st.Players[name] = p
+	})
+	return true
+}
+
+// apiPrestage handles POST /api/prestage {timeout_secs?}: it sends every
+// connected player the full catalog and waits (default 10 min) for their
+// games_update_ack, then returns who has every file.
+func (s *Server) apiPrestage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b struct {
+		TimeoutSecs int `json:"timeout_secs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	timeout := defaultPrestageTimeout
+	if b.TimeoutSecs < 0 {
+		http.Error(w, "timeout_secs must not be negative", http.StatusBadRequest)
+		return
+	} else if b.TimeoutSecs > 0 {
+		timeout = min(time.Duration(b.TimeoutSecs)*time.Second, maxPrestageTimeout)
+	}
+
+	var players []protocol.Player
+	for _, p := range s.SnapshotPlayers() {
+		if p.Connected {
+			players = append(players, p)
+		}
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+
+	run := &prestageRun{waiting: make(map[string]bool, len(players)), done: make(chan struct{})}
+	for _, p := range players {
+		run.waiting[p.Name] = true
+	}
+	if len(players) == 0 {
+		close(run.done)
+	}
+	busy := false
+	s.withLock(func() {
+		if s.prestage != nil {
+			busy = true
+			return
+		}
+		s.prestage = run
+	})
+	if busy {
+		http.Error(w, "prestage already running", http.StatusConflict)
+		return
+	}
+	defer s.withLock(func() { s.prestage = nil })
+
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		for _, p := range players {
+			sp := st.Players[p.Name]
+			sp.Prestage = protocol.PrestagePending
+			sp.PrestageErrors = nil
+			st.Players[p.Name] = sp
+		}
+	})
+	payload := s.prestagePayload()
+	log.Printf("[prestage] sending %d games to %d players", len(payload["games"].([]string)), len(players))
+	for _, p := range players {
+		cmd := protocol.Command{Cmd: protocol.CmdGamesUpdate, Payload: payload, ID: fmt.Sprintf("prestage-%d-%s", time.Now().UnixNano(), p.Name)}
+		if err := s.sendToPlayer(p, cmd); err != nil {
+			log.Printf("[prestage] send to %s: %v", p.Name, err)
+			s.notePrestageAck(p.Name, false, []string{err.Error()})
+		}
+	}
+
+	complete := true
+	select {
+	case <-run.done:
+	case <-time.After(timeout):
+		complete = false
+	case <-r.Context().Done():
+		return
+	}
+
+	summary := make(map[string]PrestageResult, len(players))
+	ready := 0
+	current := s.SnapshotPlayers()
+	for _, p := range players {
+		cp := current[p.Name]
+		summary[p.Name] = PrestageResult{Status: cp.Prestage, Errors: cp.PrestageErrors}
+		if cp.Prestage == protocol.PrestageReady {
+			ready++
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"complete": complete,
+		"ready":    ready,
+		"total":    len(players),
+		"games":    payload["games"],
+		"players":  summary,
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
@@ -0,0 +1,172 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestAPIPrestageSendsFullCatalogAndSummarizes(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	for _, f := range []string{"a.zip", "b.zip"} {
+		if err := os.WriteFile(filepath.Join("roms", f), []byte("rom"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.MainGames = []protocol.GameEntry{{File: "a.zip"}, {File: "b.zip"}}
+		st.Games = []string{"a.zip"}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	// alice answers over a real socket; bob's client is registered but never acks.
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdHello, ID: "1", Payload: map[string]any{"name": "alice"}}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.SnapshotPlayers()["alice"].Connected {
+		if time.Now().After(deadline) {
+			t.Fatal("alice never connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	registerPlayerWSClient(s, "bob")
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+	})
+
+	gotGames := make(chan []any, 1)
+	go func() {
+		_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var cmd protocol.Command
+			if err := c.ReadJSON(&cmd); err != nil {
+				return
+			}
+			pl, _ := cmd.Payload.(map[string]any)
+			if cmd.Cmd != protocol.CmdGamesUpdate || pl["prestage"] != true {
+				continue
+			}
+			gotGames <- pl["games"].([]any)
+			_ = c.WriteJSON(protocol.Command{Cmd: protocol.CmdGamesUpdateAck, ID: "2", Payload: map[string]any{"has_files": true, "prestage": true}})
+			return
+		}
+	}()
+
+	res, err := http.Post(srv.URL+"/api/prestage", "application/json", strings.NewReader(`{"timeout_secs":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", res.StatusCode)
+	}
+	var out struct {
+		Complete bool                      `json:"complete"`
+		Ready    int                       `json:"ready"`
+		Total    int                       `json:"total"`
+		Players  map[string]PrestageResult `json:"players"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if games := <-gotGames; !slices.Contains(games, any("b.zip")) {
+		t.Fatalf("prestage games %v, want the full catalog", games)
+	}
+	if out.Complete || out.Ready != 1 || out.Total != 2 {
+		t.Fatalf("summary %+v", out)
+	}
+	if out.Players["alice"].Status != protocol.PrestageReady || out.Players["bob"].Status != protocol.PrestagePending {
+		t.Fatalf("players %+v", out.Players)
+	}
+	if got := s.SnapshotPlayers()["alice"].Prestage; got != protocol.PrestageReady {
+		t.Fatalf("alice prestage %q", got)
+	}
+	s.withRLock(func() {
+		if s.prestage != nil {
+			t.Error("prestage run not cleared")
+		}
+	})
+}
+
+func TestAPIPrestageFailsPlayerWhoDisconnects(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdHello, ID: "1", Payload: map[string]any{"name": "alice"}}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.SnapshotPlayers()["alice"].Connected {
+		if time.Now().After(deadline) {
+			t.Fatal("alice never connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// alice drops as soon as the prestage arrives instead of acking.
+	go func() {
+		_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var cmd protocol.Command
+			if err := c.ReadJSON(&cmd); err != nil {
+				return
+			}
+			if pl, _ := cmd.Payload.(map[string]any); cmd.Cmd == protocol.CmdGamesUpdate && pl["prestage"] == true {
+				_ = c.Close()
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	res, err := http.Post(srv.URL+"/api/prestage", "application/json", strings.NewReader(`{"timeout_secs":30}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	var out struct {
+		Complete bool                      `json:"complete"`
+		Players  map[string]PrestageResult `json:"players"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("prestage waited for a disconnected player")
+	}
+	got := out.Players["alice"]
+	if !out.Complete || got.Status != protocol.PrestageFailed || !slices.Contains(got.Errors, prestageDisconnected) {
+		t.Fatalf("summary %+v", out)
+	}
+}
@@ -0,0 +1,170 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// PublicStatus is the read-only spectator view served by /status.json and
+// rendered by /status: no admin token, settings or file details.
+type PublicStatus struct {
+	Running    bool                `json:"running"`
+	Paused     bool                `json:"paused,omitempty"`
+	Mode       protocol.GameMode   `json:"mode"`
+	NextSwapAt int64               `json:"next_swap_at,omitempty"`
+	ServerTime int64               `json:"server_time"`
+	Players    []PublicPlayerState `json:"players"`
+}
+
+// PublicPlayerState is one player's entry in PublicStatus. NextSwapAt is
+// only set for players on their own swap timer.
+type PublicPlayerState struct {
+	Name       string `json:"name"`
+	Game       string `json:"game,omitempty"`
+	Connected  bool   `json:"connected"`
+	NextSwapAt int64  `json:"next_swap_at,omitempty"`
+}
+
+// publicStatus builds the spectator view of st, players sorted by name.
+func publicStatus(st protocol.ServerState) PublicStatus {
+	ps := PublicStatus{
+		Running:    st.Running,
+		Paused:     st.Paused,
+		Mode:       st.Mode,
+		NextSwapAt: st.NextSwapAt,
+		ServerTime: time.Now().Unix(),
+		Players:    make([]PublicPlayerState, 0, len(st.Players)),
+	}
+	for name, p := range st.Players {
+		ps.Players = append(ps.Players, PublicPlayerState{
+			Name:       name,
+			Game:       p.Game,
+			Connected:  p.Connected,
+			NextSwapAt: p.NextSwapAt,
+		})
+	}
+	sort.Slice(ps.Players, func(i, j int) bool { return ps.Players[i].Name < ps.Players[j].Name })
+	return ps
+}
+
+// handleStatusJSON serves GET /status.json, the data behind /status.
+func (s *Server) handleStatusJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(publicStatus(s.SnapshotState())); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// handleStatusPage serves GET /status: a spectator page rendered from
+// PublicStatus that polls /status.json to stay current.
+func (s *Server) handleStatusPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := statusPageTmpl.Execute(w, publicStatus(s.SnapshotState())); err != nil {
+		fmt.Printf("write response error: %v\n", err)
+	}
+}
+
+var statusPageTmpl = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>BizShuffle status</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0; padding: 1rem; background: #111; color: #eee; }
+h1 { font-size: 1.3rem; margin: 0 0 .5rem; }
+#summary { margin-bottom: 1rem; color: #bbb; }
+table { width: 100%; border-collapse: collapse; }
+th, td { text-align: left; padding: .4rem .3rem; border-bottom: 1px solid #333; }
+.off { color: #777; }
+</style>
+</head>
+<body>
+<h1>BizShuffle</h1>
+<div id="summary" data-next="{{.NextSwapAt}}" data-running="{{.Running}}" data-paused="{{.Paused}}" data-mode="{{.Mode}}">{{if .Paused}}Paused{{else if .Running}}Running{{else}}Stopped{{end}} · {{.Mode}} mode</div>
+<table>
+<thead><tr><th>Player</th><th>Game</th><th>Next swap</th></tr></thead>
+<tbody id="players">
+{{range .Players}}<tr{{if not .Connected}} class="off"{{end}}><td>{{.Name}}</td><td>{{.Game}}</td><td data-next="{{.NextSwapAt}}"></td></tr>
+{{else}}<tr><td colspan="3">No players</td></tr>
+{{end}}</tbody>
+</table>
+<script>
+(function () {
+  var offset = {{.ServerTime}} - Math.floor(Date.now() / 1000);
+  var state = null;
+  function now() { return Math.floor(Date.now() / 1000) + offset; }
+  function left(at) {
+    if (!at) return "";
+    var s = Math.max(0, at - now());
+    return Math.floor(s / 60) + ":" + String(s % 60).padStart(2, "0");
+  }
+  function cell(row, text) {
+    var td = document.createElement("td");
+    td.textContent = text;
+    row.appendChild(td);
+    return td;
+  }
+  function render() {
+    var sum = document.getElementById("summary");
+    var next = state ? state.next_swap_at : Number(sum.dataset.next);
+    var running = state ? state.running : sum.dataset.running === "true";
+    var paused = state ? !!state.paused : sum.dataset.paused === "true";
+    var mode = state ? state.mode : sum.dataset.mode;
+    var text = (paused ? "Paused" : running ? "Running" : "Stopped") + " · " + mode + " mode";
+    if (running && !paused && next) text += " · next swap in " + left(next);
+    sum.textContent = text;
+    if (!state) {
+      document.querySelectorAll("#players td[data-next]").forEach(function (td) {
+        td.textContent = left(Number(td.dataset.next));
+      });
+      return;
+    }
+    var body = document.getElementById("players");
+    body.textContent = "";
+    if (!state.players.length) {
+      cell(body.insertRow(), "No players").colSpan = 3;
+    }
+    state.players.forEach(function (p) {
+      var row = body.insertRow();
+      if (!p.connected) row.className = "off";
+      cell(row, p.name);
+      cell(row, p.game || "");
+      cell(row, left(p.next_swap_at));
+    });
+  }
+  function poll() {
+    fetch("status.json", { cache: "no-store" })
+      .then(function (r) { return r.ok ? r.json() : null; })
+      .then(function (s) {
+        if (!s) return;
+        offset = s.server_time - Math.floor(Date.now() / 1000);
+        state = s;
+        render();
+      })
+      .catch(function () {});
+  }
+  render();
+  setInterval(render, 1000);
+  setInterval(poll, 5000);
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,76 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestPublicStatusOpenWithoutAdminToken(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.SetAdminToken("secret-token")
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Running = true
+		st.Mode = protocol.GameModeSync
+		st.Players["zed"] = protocol.Player{Name: "zed", Game: "b.zip", Connected: true}
+		st.Players["<amy>"] = protocol.Player{Name: "<amy>", Game: "a.zip", NextSwapAt: 1900000100}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/status.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ps PublicStatus
+	err = json.NewDecoder(resp.Body).Decode(&ps)
+	_ = resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("status.json: %d %v", resp.StatusCode, err)
+	}
+	if !ps.Running || len(ps.Players) != 2 {
+		t.Fatalf("status %+v", ps)
+	}
+	if p := ps.Players[0]; p.Name != "<amy>" || p.Game != "a.zip" || p.Connected || p.NextSwapAt != 1900000100 {
+		t.Fatalf("first player %+v", p)
+	}
+	if p := ps.Players[1]; p.Name != "zed" || !p.Connected {
+		t.Fatalf("second player %+v", p)
+	}
+
+	resp, err = http.Get(srv.URL + "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("status page: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	page := string(body)
+	if strings.Contains(page, "secret-token") || strings.Contains(page, "/api/") {
+		t.Fatal("status page exposes admin details")
+	}
+	if !strings.Contains(page, "&lt;amy&gt;") || strings.Contains(page, "<amy>") || !strings.Contains(page, "b.zip") {
+		t.Fatalf("status page players not rendered safely:\n%s", page)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/status.json", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status %d", resp.StatusCode)
+	}
+}
@@ -1,12 +1,133 @@
 package serverhost
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/michael4d45/bizshuffle/protocol"
 )
 
+// romHashCache memoizes sha256 digests of files under ./roms, keyed by path and
+// invalidated when the file's modtime or size changes.
+type romHashCache struct {
+	mu      sync.Mutex
+	entries map[string]romHashEntry
+}
+
+type romHashEntry struct {
+	modTime time.Time
+	size    int64
+	sum     string
+}
+
+// romChecksum returns the sha256 hex digest of ./roms/{name}, computing it only when
+// the file changed since the last call.
+func (s *Server) romChecksum(name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("invalid rom path %q", name)
+	}
+	path := filepath.Join("./roms", filepath.FromSlash(name))
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	s.romHashes.mu.Lock()
+	entry, ok := s.romHashes.entries[name]
+	s.romHashes.mu.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.sum, nil
+	}
+
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return "", err
+	}
+	s.romHashes.mu.Lock()
+	if s.romHashes.entries == nil {
+		s.romHashes.entries = make(map[string]romHashEntry)
+	}
+	s.romHashes.entries[name] = romHashEntry{modTime: info.ModTime(), size: info.Size(), sum: sum}
+	s.romHashes.mu.Unlock()
+	return sum, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// romMismatches compares each player's reported ROM checksums with the server's copies
+// and returns the files that differ, keyed by player. Files missing on the server are skipped.
+func (s *Server) romMismatches(players map[string]protocol.Player) map[string][]string {
+	out := make(map[string][]string)
+	for name, p := range players {
+		for file, sum := range p.RomChecksums {
+			serverSum, err := s.romChecksum(file)
+			if err != nil || serverSum == sum {
+				continue
+			}
+			out[name] = append(out[name], file)
+		}
+		sort.Strings(out[name])
+	}
+	return out
+}
+
+// validExternalURL reports whether u is an absolute http(s) URL usable as a
+// GameEntry external download source.
+func validExternalURL(u string) bool {
+	parsed, err := url.Parse(u)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// withExternalChecksums fills in, from the server's own ./roms copy, the sha256
+// of every file that has an external URL but no catalog checksum, so clients
+// can verify what they fetch from the external host. entries is modified in
+// place and must not alias server state.
+func (s *Server) withExternalChecksums(entries []protocol.GameEntry) []protocol.GameEntry {
+	for i, e := range entries {
+		if len(e.URLs) == 0 {
+			continue
+		}
+		sums := maps.Clone(e.Checksums)
+		if sums == nil {
+			sums = make(map[string]string)
+		}
+		for file := range e.URLs {
+			if sums[file] != "" {
+				continue
+			}
+			if sum, err := s.romChecksum(file); err == nil {
+				sums[file] = sum
+			}
+		}
+		entries[i].Checksums = sums
+	}
+	return entries
+}
+
 // ListRoms returns relative paths of files under ./roms (forward slashes).
 func ListRoms() []string {
 	romsDir := "./roms"
@@ -28,6 +149,151 @@ func ListRoms() []string {
 	return files
 }
 
+// romExists reports whether ./roms/{name} is a regular file.
+func romExists(name string) bool {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return false
+	}
+	info, err := os.Stat(filepath.Join("./roms", filepath.FromSlash(name)))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// missingRoms returns the sorted, de-duplicated names that are not files under ./roms.
+func missingRoms(names []string) []string {
+	seen := map[string]bool{}
+	missing := []string{}
+	for _, n := range names {
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		if !romExists(n) {
+			missing = append(missing, n)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// romDirFiles lists every file under the roms/ folder dir, recursively, as
+// sorted slash-separated names relative to ./roms.
+func romDirFiles(dir string) ([]string, error) {
+	root, err := romFilePath(strings.TrimSuffix(dir, "/"))
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", errRomNotFound, dir)
+	}
+	files := []string{}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel("./roms", p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// missingRomDirs returns the sorted extra_dirs of entries that are not folders
+// under ./roms, each with a trailing slash.
+func missingRomDirs(entries []protocol.GameEntry) []string {
+	seen := map[string]bool{}
+	missing := []string{}
+	for _, e := range entries {
+		for _, d := range e.ExtraDirs {
+			if d == "" || seen[d] {
+				continue
+			}
+			seen[d] = true
+			if _, err := romDirFiles(d); err != nil {
+				missing = append(missing, strings.TrimSuffix(d, "/")+"/")
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// inExtraDir reports whether file lies inside one of dirs.
+func inExtraDir(dirs []string, file string) bool {
+	file = filepath.ToSlash(file)
+	for _, d := range dirs {
+		if d = strings.Trim(d, "/"); d != "" && strings.HasPrefix(file, d+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// catalogFiles lists every main and extra file referenced by entries that the
+// server must serve itself; files with an external URL are left out.
+func catalogFiles(entries []protocol.GameEntry) []string {
+	var files []string
+	for _, e := range entries {
+		for _, f := range append([]string{e.File}, e.ExtraFiles...) {
+			if e.URLs[f] == "" {
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
+
+// presentGames returns the entries whose main file exists under ./roms or has an
+// external URL, logging the rest so mode setup never assigns a game the clients
+// cannot download.
+func presentGames(entries []protocol.GameEntry) []protocol.GameEntry {
+	out := make([]protocol.GameEntry, 0, len(entries))
+	for _, e := range entries {
+		if !romExists(e.File) && e.URLs[e.File] == "" {
+			log.Printf("[setup] skipping %q: not found under ./roms", e.File)
+			continue
+		}
+		if missing := missingRoms(e.ExtraFiles); len(missing) > 0 {
+			log.Printf("[setup] %q is missing extra files under ./roms: %v", e.File, missing)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// presentGameFiles stats the main file of every catalog entry without holding
+// the state lock and returns the ones presentGames keeps, for mode setup to
+// filter the live catalog with inside its mutator.
+func (s *Server) presentGameFiles() map[string]bool {
+	_, mainGames, _ := s.SnapshotGames()
+	present := make(map[string]bool, len(mainGames))
+	for _, e := range presentGames(mainGames) {
+		present[e.File] = true
+	}
+	return present
+}
+
+// keepPresentGames returns the entries whose main file is in present.
+func keepPresentGames(entries []protocol.GameEntry, present map[string]bool) []protocol.GameEntry {
+	out := make([]protocol.GameEntry, 0, len(entries))
+	for _, e := range entries {
+		if present[e.File] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
 func gameEntryHasFile(entries []protocol.GameEntry, file string) bool {
 	for _, g := range entries {
 		if g.File == file {
@@ -42,6 +308,14 @@ func gameEntryHasFile(entries []protocol.GameEntry, file string) bool {
 	return false
 }
 
+// catalogCovers reports whether file is a main or extra file of entries or lies
+// inside one of their extra_dirs.
+func catalogCovers(entries []protocol.GameEntry, file string) bool {
+	return gameEntryHasFile(entries, file) || slices.ContainsFunc(entries, func(g protocol.GameEntry) bool {
+		return inExtraDir(g.ExtraDirs, file)
+	})
+}
+
 // SyncCatalogFromRoms merges ROM files from ./roms into MainGames and runs mode setup when needed.
 // Returns true when catalog state was updated or setup ran.
 func (s *Server) SyncCatalogFromRoms() (bool, error) {
@@ -54,7 +328,7 @@ func (s *Server) SyncCatalogFromRoms() (bool, error) {
 	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 		main := append([]protocol.GameEntry(nil), st.MainGames...)
 		for _, f := range files {
-			if !gameEntryHasFile(main, f) {
+			if !catalogCovers(main, f) {
 				main = append(main, protocol.GameEntry{File: f})
 				merged = true
 			}
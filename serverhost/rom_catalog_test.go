@@ -1,9 +1,15 @@
 package serverhost
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
 )
 
 func TestListRomsEmpty(t *testing.T) {
@@ -42,3 +48,211 @@ func TestSyncCatalogFromRoms(t *testing.T) {
 		t.Fatalf("got %+v", st.MainGames)
 	}
 }
+
+func TestRomMismatchesFlagsDifferingChecksum(t *testing.T) {
+	chdirToTemp(t)
+	_ = os.MkdirAll("roms", 0o755)
+	if err := os.WriteFile(filepath.Join("roms", "game.nes"), []byte("server copy"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	good, err := s.romChecksum("game.nes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	players := map[string]protocol.Player{
+		"ok":  {Name: "ok", RomChecksums: map[string]string{"game.nes": good}},
+		"bad": {Name: "bad", RomChecksums: map[string]string{"game.nes": "deadbeef", "missing.nes": "x"}},
+	}
+	got := s.romMismatches(players)
+	if len(got["ok"]) != 0 {
+		t.Fatalf("unexpected mismatch for matching player: %v", got["ok"])
+	}
+	if len(got["bad"]) != 1 || got["bad"][0] != "game.nes" {
+		t.Fatalf("expected game.nes mismatch, got %v", got["bad"])
+	}
+	if _, err := s.romChecksum("../state.json"); err == nil {
+		t.Fatal("expected path outside roms to be rejected")
+	}
+}
+
+func TestSetupSkipsGamesMissingFromRoms(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	if err := os.WriteFile(filepath.Join("roms", "mario.nes"), []byte("rom"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.MainGames = []protocol.GameEntry{{File: "mario.nes"}, {File: "zeldaa.nes"}}
+	})
+
+	if err := (&SyncModeHandler{server: s}).SetupState(); err != nil {
+		t.Fatal(err)
+	}
+	if games := s.SnapshotState().Games; len(games) != 1 || games[0] != "mario.nes" {
+		t.Fatalf("sync games = %v", games)
+	}
+	if err := (&SaveModeHandler{server: s}).SetupState(); err != nil {
+		t.Fatal(err)
+	}
+	if inst := s.SnapshotState().GameSwapInstances; len(inst) != 1 || inst[0].Game != "mario.nes" {
+		t.Fatalf("save instances = %+v", inst)
+	}
+}
+
+func TestAPIGamesReportsMissingFiles(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	if err := os.WriteFile(filepath.Join("roms", "mario.nes"), []byte("rom"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"main_games":[{"file":"mario.nes","extra_files":["mario.cue"]},{"file":"zeldaa.nes"}]}`
+	rec := httptest.NewRecorder()
+	s.apiGames(rec, httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	var out struct {
+		MissingFiles []string `json:"missing_files"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.MissingFiles) != 2 || out.MissingFiles[0] != "mario.cue" || out.MissingFiles[1] != "zeldaa.nes" {
+		t.Fatalf("missing_files = %v", out.MissingFiles)
+	}
+	if n := len(s.SnapshotState().MainGames); n != 2 {
+		t.Fatalf("missing entries should still be saved, got %d", n)
+	}
+}
+
+func TestAPIGamesValidatesAndStoresWeights(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.apiGames(rec, httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(body)))
+		return rec
+	}
+
+	for _, body := range []string{
+		`{"main_games":[{"file":"a.nes","weight":-1}]}`,
+		`{"main_games":[{"file":"a.nes","weight":"heavy"}]}`,
+		`{"main_games":{"file":"a.nes"}}`,
+	} {
+		if rec := post(body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d, want 400", body, rec.Code)
+		}
+	}
+	if n := len(s.SnapshotState().MainGames); n != 0 {
+		t.Fatalf("rejected posts changed the catalog: %d entries", n)
+	}
+
+	if rec := post(`{"main_games":[{"file":"a.nes","weight":3},{"file":"b.nes"}]}`); rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	mg := s.SnapshotState().MainGames
+	if len(mg) != 2 || mg[0].Weight != 3 || mg[1].Weight != 0 {
+		t.Fatalf("stored main_games %+v", mg)
+	}
+}
+
+func TestExternalURLsSkipMissingAndCarryChecksums(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	if err := os.WriteFile(filepath.Join("roms", "mario.nes"), []byte("rom"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := `{"main_games":[{"file":"mario.nes","urls":{"mario.nes":"ftp://cdn/mario.nes"}}]}`
+	rec := httptest.NewRecorder()
+	s.apiGames(rec, httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(bad)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("ftp url: status %d", rec.Code)
+	}
+
+	body := `{"main_games":[{"file":"mario.nes","urls":{"mario.nes":"https://cdn/mario.nes"}},` +
+		`{"file":"zelda.nes","urls":{"zelda.nes":"https://cdn/zelda.nes"},"checksums":{"zelda.nes":"abc"}}]}`
+	rec = httptest.NewRecorder()
+	s.apiGames(rec, httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	var out struct {
+		MissingFiles []string `json:"missing_files"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.MissingFiles) != 0 {
+		t.Fatalf("externally hosted files reported missing: %v", out.MissingFiles)
+	}
+
+	_, mainGames, _ := s.SnapshotGames()
+	entries := s.withExternalChecksums(mainGames)
+	want, _ := s.romChecksum("mario.nes")
+	if got := entries[0].Checksums["mario.nes"]; got != want {
+		t.Fatalf("mario.nes checksum %q, want server copy %q", got, want)
+	}
+	if got := entries[1].Checksums["zelda.nes"]; got != "abc" {
+		t.Fatalf("catalog checksum replaced: %q", got)
+	}
+	if s.SnapshotState().MainGames[0].Checksums != nil {
+		t.Fatal("withExternalChecksums modified server state")
+	}
+}
+
+func TestFilesTreeAndExtraDirs(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	for _, f := range []string{"ff7/disc1.bin", "ff7/disc1.cue", "ff7/art/palette.pal", "ff7.m3u"} {
+		_ = os.MkdirAll(filepath.Dir(filepath.Join("roms", f)), 0o755)
+		if err := os.WriteFile(filepath.Join("roms", f), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleFilesTree(rec, httptest.NewRequest(http.MethodGet, "/api/files/tree?dir=ff7", nil))
+	var out struct {
+		Files []string `json:"files"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "ff7/art/palette.pal,ff7/disc1.bin,ff7/disc1.cue"; strings.Join(out.Files, ",") != want {
+		t.Fatalf("files = %v, want %s", out.Files, want)
+	}
+	for q, want := range map[string]int{"../etc": http.StatusBadRequest, "nope": http.StatusNotFound} {
+		rec := httptest.NewRecorder()
+		s.handleFilesTree(rec, httptest.NewRequest(http.MethodGet, "/api/files/tree?dir="+q, nil))
+		if rec.Code != want {
+			t.Fatalf("dir=%s: status %d, want %d", q, rec.Code, want)
+		}
+	}
+
+	body := `{"main_games":[{"file":"ff7.m3u","extra_dirs":["ff7","psx-bios"]}]}`
+	rec = httptest.NewRecorder()
+	s.apiGames(rec, httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(body)))
+	var games struct {
+		MissingFiles []string `json:"missing_files"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&games); err != nil {
+		t.Fatal(err)
+	}
+	if len(games.MissingFiles) != 1 || games.MissingFiles[0] != "psx-bios/" {
+		t.Fatalf("missing_files = %v", games.MissingFiles)
+	}
+	if _, err := s.SyncCatalogFromRoms(); err != nil {
+		t.Fatal(err)
+	}
+	if main := s.SnapshotState().MainGames; len(main) != 1 {
+		t.Fatalf("files inside extra_dirs must not become catalog entries, got %+v", main)
+	}
+}
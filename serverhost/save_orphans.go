@@ -0,0 +1,92 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// OrphanSave is a ./saves/<id>.state file whose instance is no longer in the
+// catalog.
+type OrphanSave struct {
+	Name       string    `json:"name"`
+	InstanceID string    `json:"instance_id"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+}
+
+// findOrphanSaves lists the .state files in ./saves whose instance ID is not
+// among the current GameSwapInstances, sorted by name.
+func (s *Server) findOrphanSaves() ([]OrphanSave, error) {
+	_, _, instances := s.SnapshotGames()
+	known := make(map[string]bool, len(instances))
+	for _, inst := range instances {
+		known[inst.ID] = true
+	}
+	entries, err := os.ReadDir("./saves")
+	if errors.Is(err, os.ErrNotExist) {
+		return []OrphanSave{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	orphans := []OrphanSave{}
+	for _, e := range entries {
+		key, ok := strings.CutSuffix(e.Name(), ".state")
+		id, _ := protocol.SplitSaveKey(key)
+		if e.IsDir() || !ok || id == "" || known[id] {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		orphans = append(orphans, OrphanSave{Name: e.Name(), InstanceID: id, Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return orphans, nil
+}
+
+// apiSaveOrphans lists (GET) or deletes (DELETE) saves of instances that no
+// longer exist. Deleting also removes the instance's archived versions.
+func (s *Server) apiSaveOrphans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	orphans, err := s.findOrphanSaves()
+	if err != nil {
+		http.Error(w, "list saves: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := map[string]any{"orphans": orphans}
+	if r.Method == http.MethodDelete {
+		removed := []string{}
+		var freed int64
+		for _, o := range orphans {
+			if err := os.Remove(filepath.Join("./saves", o.Name)); err != nil {
+				fmt.Printf("remove orphan save %s: %v\n", o.Name, err)
+				continue
+			}
+			key := strings.TrimSuffix(o.Name, ".state")
+			if err := os.RemoveAll(saveVersionsDir(key)); err != nil {
+				fmt.Printf("remove save versions of %s: %v\n", key, err)
+			}
+			removed = append(removed, o.Name)
+			freed += o.Size
+		}
+		fmt.Printf("Removed %d orphaned save files (%d bytes)\n", len(removed), freed)
+		resp = map[string]any{"result": "ok", "removed": removed, "freed_bytes": freed}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
@@ -0,0 +1,65 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestAPISaveOrphansListsAndDeletes(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "kept", Game: "a.nes"}}
+	})
+	for _, name := range []string{"kept.state", "gone.state", "notes.txt", filepath.Join("gone", "20240101T000000.000000000Z.state")} {
+		path := filepath.Join("saves", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("save"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.apiSaveOrphans(rec, httptest.NewRequest(http.MethodGet, "/api/saves/orphans", nil))
+	var list struct {
+		Orphans []OrphanSave `json:"orphans"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Orphans) != 1 || list.Orphans[0].Name != "gone.state" || list.Orphans[0].InstanceID != "gone" {
+		t.Fatalf("orphans = %+v", list.Orphans)
+	}
+
+	rec = httptest.NewRecorder()
+	s.apiSaveOrphans(rec, httptest.NewRequest(http.MethodDelete, "/api/saves/orphans", nil))
+	var del struct {
+		Removed    []string `json:"removed"`
+		FreedBytes int64    `json:"freed_bytes"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&del); err != nil {
+		t.Fatal(err)
+	}
+	if len(del.Removed) != 1 || del.Removed[0] != "gone.state" || del.FreedBytes != 4 {
+		t.Fatalf("delete = %+v", del)
+	}
+	for _, gone := range []string{"gone.state", "gone"} {
+		if _, err := os.Stat(filepath.Join("saves", gone)); !os.IsNotExist(err) {
+			t.Fatalf("%s not removed: %v", gone, err)
+		}
+	}
+	for _, kept := range []string{"kept.state", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join("saves", kept)); err != nil {
+			t.Fatalf("%s removed: %v", kept, err)
+		}
+	}
+}
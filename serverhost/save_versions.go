@@ -0,0 +1,249 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// defaultSaveVersions is how many previous saves are kept per instance when
+// ServerState.SaveVersions is unset.
+const defaultSaveVersions = 3
+
+// saveVersionLayout names archived saves so they sort chronologically.
+const saveVersionLayout = "20060102T150405.000000000Z"
+
+// SaveVersion describes one archived copy of an instance's save.
+type SaveVersion struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// saveVersionLimit returns how many versions to keep; 0 disables versioning.
+func (s *Server) saveVersionLimit() int {
+	var n int
+	s.withRLock(func() { n = s.state.SaveVersions })
+	switch {
+	case n == 0:
+		return defaultSaveVersions
+	case n < 0:
+		return 0
+	}
+	return n
+}
+
+func saveVersionsDir(instanceID string) string {
+	return filepath.Join("./saves", instanceID)
+}
+
+// archiveSaveVersion copies the current ./saves/<id>.state into ./saves/<id>/
+// before it is overwritten, then prunes versions beyond the configured limit.
+func (s *Server) archiveSaveVersion(instanceID string) error {
+	keep := s.saveVersionLimit()
+	if keep == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join("./saves", instanceID+".state"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	dir := saveVersionsDir(instanceID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format(saveVersionLayout) + ".state"
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		return err
+	}
+	return pruneSaveVersions(instanceID, keep)
+}
+
+// pruneSaveVersions removes all but the newest keep versions of an instance.
+func pruneSaveVersions(instanceID string, keep int) error {
+	versions, err := listSaveVersions(instanceID)
+	if err != nil {
+		return err
+	}
+	for len(versions) > keep {
+		if err := os.Remove(filepath.Join(saveVersionsDir(instanceID), versions[len(versions)-1].Name)); err != nil {
+			return err
+		}
+		versions = versions[:len(versions)-1]
+	}
+	return nil
+}
+
+// listSaveVersions returns the archived saves of an instance, newest first.
+func listSaveVersions(instanceID string) ([]SaveVersion, error) {
+	entries, err := os.ReadDir(saveVersionsDir(instanceID))
+	if errors.Is(err, os.ErrNotExist) {
+		return []SaveVersion{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	versions := []SaveVersion{}
+	for _, e := range entries {
+		stamp, ok := strings.CutSuffix(e.Name(), ".state")
+		if e.IsDir() || !ok {
+			continue
+		}
+		savedAt, err := time.Parse(saveVersionLayout, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		versions = append(versions, SaveVersion{Name: e.Name(), Size: info.Size(), SavedAt: savedAt})
+	}
+	slices.SortFunc(versions, func(a, b SaveVersion) int { return strings.Compare(b.Name, a.Name) })
+	return versions, nil
+}
+
+// apiSaveVersions lists (GET) an instance's archived saves.
+func (s *Server) apiSaveVersions(w http.ResponseWriter, r *http.Request, instanceID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	versions, err := listSaveVersions(filepath.Base(instanceID))
+	if err != nil {
+		http.Error(w, "list versions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"versions": versions}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// apiRollbackSave restores an archived save (body {"version": name}; empty
+// means the newest) as the instance's current save, archiving the save it
+// replaces, and re-pushes it to the player assigned to the instance.
+func (s *Server) apiRollbackSave(w http.ResponseWriter, r *http.Request, instanceID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b struct {
+		Version string `json:"version"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var found, pending bool
+	var assigned protocol.Player
+	s.withRLock(func() {
+		for _, inst := range s.state.GameSwapInstances {
+			if inst.ID == instanceID {
+				found = true
+				pending = inst.FileState == protocol.FileStatePending
+			}
+		}
+		for _, p := range s.state.Players {
+			if p.InstanceID == instanceID {
+				assigned = p
+			}
+		}
+	})
+	if !found {
+		http.Error(w, "instance not found", http.StatusNotFound)
+		return
+	}
+	if pending {
+		http.Error(w, "instance save upload is pending", http.StatusConflict)
+		return
+	}
+
+	versions, err := listSaveVersions(instanceID)
+	if err != nil {
+		http.Error(w, "list versions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	idx := 0
+	if b.Version != "" {
+		idx = slices.IndexFunc(versions, func(v SaveVersion) bool { return v.Name == b.Version })
+	}
+	if idx < 0 || len(versions) == 0 {
+		http.Error(w, "version not found", http.StatusNotFound)
+		return
+	}
+	version := versions[idx]
+
+	data, err := os.ReadFile(filepath.Join(saveVersionsDir(instanceID), version.Name))
+	if err != nil {
+		http.Error(w, "read version: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.archiveSaveVersion(instanceID); err != nil {
+		log.Printf("rollback %s: archive current save: %v", instanceID, err)
+	}
+	if err := os.WriteFile(filepath.Join("./saves", instanceID+".state"), data, 0o644); err != nil {
+		http.Error(w, "write save file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.setInstanceFileState(instanceID, protocol.FileStateReady)
+	log.Printf("rolled back instance %s to %s", instanceID, version.Name)
+
+	// Reload the restored save on the assigned player without uploading their live state first.
+	pushedTo := ""
+	if assigned.Name != "" && s.PlayerReadyForSwap(s.currentPlayer(assigned.Name)) {
+		s.sendSwap(assigned, SwapSendOptions{SkipSave: true, Force: true})
+		pushedTo = assigned.Name
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"result": "ok", "version": version.Name, "pushed_to": pushedTo}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// apiSaveVersionsLimit reads or sets how many save versions are kept per instance.
+func (s *Server) apiSaveVersionsLimit(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		if err := json.NewEncoder(w).Encode(map[string]any{"save_versions": s.saveVersionLimit()}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method == http.MethodPost {
+		var b struct {
+			SaveVersions int `json:"save_versions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if b.SaveVersions < 0 {
+			http.Error(w, "save_versions must be >= 0", http.StatusBadRequest)
+			return
+		}
+		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			st.SaveVersions = b.SaveVersions
+			if b.SaveVersions == 0 {
+				st.SaveVersions = -1
+			}
+		})
+		if _, err := w.Write([]byte("ok")); err != nil {
+			fmt.Printf("write response error: %v\n", err)
+		}
+		return
+	}
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
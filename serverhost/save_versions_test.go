@@ -0,0 +1,115 @@
+package serverhost
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestArchiveSaveVersionPrunesToLimit(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { st.SaveVersions = 2 })
+
+	for _, content := range []string{"one", "two", "three"} {
+		if err := os.WriteFile(filepath.Join("saves", "zelda.state"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.archiveSaveVersion("zelda"); err != nil {
+			t.Fatalf("archive: %v", err)
+		}
+	}
+
+	versions, err := listSaveVersions("zelda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	newest, err := os.ReadFile(filepath.Join(saveVersionsDir("zelda"), versions[0].Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(newest) != "three" {
+		t.Fatalf("newest version = %q, want three", newest)
+	}
+
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { st.SaveVersions = -1 })
+	if err := s.archiveSaveVersion("zelda"); err != nil {
+		t.Fatal(err)
+	}
+	if versions, _ := listSaveVersions("zelda"); len(versions) != 2 {
+		t.Fatalf("versioning disabled but got %d versions", len(versions))
+	}
+}
+
+func TestAPIRollbackSaveRestoresVersion(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "zelda", Game: "zelda.zip", FileState: protocol.FileStateReady},
+		}
+	})
+	savePath := filepath.Join("saves", "zelda.state")
+	if err := os.WriteFile(savePath, []byte("good"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.archiveSaveVersion("zelda"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(savePath, []byte("bad"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	res, err := http.Post(srv.URL+"/api/instances/zelda/rollback", "application/json", bytes.NewBufferString(`{"version":"nope.state"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown version: status %d", res.StatusCode)
+	}
+
+	res, err = http.Post(srv.URL+"/api/instances/zelda/rollback", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("rollback: status %d", res.StatusCode)
+	}
+	var body struct {
+		Result   string `json:"result"`
+		PushedTo string `json:"pushed_to"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Result != "ok" || body.PushedTo != "" {
+		t.Fatalf("unexpected response: %+v", body)
+	}
+	data, err := os.ReadFile(savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "good" {
+		t.Fatalf("save after rollback = %q, want good", data)
+	}
+	// The replaced save is archived so the rollback itself can be undone.
+	versions, _ := listSaveVersions("zelda")
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions after rollback, got %d", len(versions))
+	}
+}
@@ -1,8 +1,13 @@
 package serverhost
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/michael4d45/bizshuffle/protocol"
@@ -11,22 +16,156 @@ import (
 // performSwap dispatches to the appropriate mode implementation.
 func (s *Server) performSwap() error {
 	handler := s.GetGameModeHandler()
+	mode, before := s.snapshotAssignments()
 	// Call the mode-specific swap handler.
 	if err := handler.HandleSwap(); err != nil {
 		return err
 	}
+	s.rememberSwapUndo(mode, before)
+	s.resetSkipVotes()
+	return nil
+}
+
+// performScheduledSwap is the global scheduler's swap. Players with an
+// interval override are swapped on their own timer by playerSchedulerLoop,
+// so only the players on the global schedule are swapped here.
+func (s *Server) performScheduledSwap() error {
+	var overridden, global bool
+	s.withRLock(func() {
+		for _, p := range s.state.Players {
+			if hasIntervalOverride(p) {
+				overridden = true
+			} else {
+				global = true
+			}
+		}
+	})
+	if !overridden {
+		return s.performSwap()
+	}
+	if !global {
+		log.Printf("scheduled swap skipped: every player has an interval override")
+		return nil
+	}
+	mode, before := s.snapshotAssignments()
+	inGlobal := func(p protocol.Player) bool { return !hasIntervalOverride(p) }
+	if err := s.GetGameModeHandler().HandleSwapWhere("players on the global schedule", inGlobal); err != nil {
+		return err
+	}
+	s.rememberSwapUndo(mode, before)
+	s.resetSkipVotes()
 	return nil
 }
 
 func (s *Server) performRandomSwapForPlayer(playerName string) any {
-	handler := s.GetGameModeHandler()
-	// Call the mode-specific swap handler.
-	if err := handler.HandleRandomSwapForPlayer(playerName); err != nil {
+	// Call the mode-specific swap handler. A declined swap is not an error here;
+	// the player simply keeps their game until the next attempt.
+	err := s.randomSwapForPlayer(playerName)
+	if errors.Is(err, ErrSwapsDisabled) {
+		log.Printf("random swap for %s ignored: swaps disabled", playerName)
+	}
+	if err != nil && !isSwapDeclined(err) {
 		return err
 	}
+	// Restart the player's own timer so an interval override counts from this swap.
+	s.schedulePlayerSwap(playerName)
 	return nil
 }
 
+// pickInterval returns a random interval in seconds within [minv, maxv], falling back to
+// whichever bound is set, or 300 seconds when neither is. The front-loaded
+// distribution squares a uniform sample so short intervals come up more often.
+func pickInterval(minv, maxv int, dist protocol.IntervalDistribution) int {
+	if minv > 0 && maxv > 0 && maxv >= minv {
+		span := maxv - minv + 1
+		if dist == protocol.IntervalDistributionFrontLoaded {
+			u := rand.Float64()
+			return minv + min(int(float64(span)*u*u), span-1)
+		}
+		return minv + rand.Intn(span)
+	} else if minv > 0 {
+		return minv
+	} else if maxv > 0 {
+		return maxv
+	}
+	return 300
+}
+
+// knownIntervalDistribution reports whether dist is a supported interval
+// distribution; empty counts as uniform.
+func knownIntervalDistribution(dist protocol.IntervalDistribution) bool {
+	switch dist {
+	case "", protocol.IntervalDistributionUniform, protocol.IntervalDistributionFrontLoaded:
+		return true
+	}
+	return false
+}
+
+// hasIntervalOverride reports whether the player has their own swap interval.
+func hasIntervalOverride(p protocol.Player) bool {
+	return p.MinIntervalSecs > 0 || p.MaxIntervalSecs > 0
+}
+
+// schedulePlayerSwap sets the player's NextSwapAt from their interval override,
+// or clears it when the player follows the global schedule.
+func (s *Server) schedulePlayerSwap(playerName string) {
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		p, ok := st.Players[playerName]
+		if !ok {
+			return
+		}
+		if hasIntervalOverride(p) {
+			p.NextSwapAt = time.Now().Add(time.Duration(pickInterval(p.MinIntervalSecs, p.MaxIntervalSecs, st.IntervalDistribution)) * time.Second).Unix()
+		} else {
+			p.NextSwapAt = 0
+		}
+		st.Players[playerName] = p
+	})
+}
+
+// playerSchedulerLoop performs individual random swaps for players with an interval override
+// and enforces per-game time limits. Players without an override are otherwise only swapped
+// by schedulerLoop.
+func (s *Server) playerSchedulerLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if atomic.LoadInt32(&s.shuttingDown) != 0 {
+			return
+		}
+		var due, unscheduled []string
+		now := time.Now().Unix()
+		s.withRLock(func() {
+			if !s.state.Running || !s.state.SwapEnabled {
+				return
+			}
+			for name, p := range s.state.Players {
+				if !p.Connected || !hasIntervalOverride(p) {
+					continue
+				}
+				if p.NextSwapAt == 0 {
+					unscheduled = append(unscheduled, name)
+				} else if now >= p.NextSwapAt {
+					due = append(due, name)
+				}
+			}
+		})
+		for _, name := range unscheduled {
+			s.schedulePlayerSwap(name)
+		}
+		for _, name := range due {
+			// Push the deadline out first so the next tick doesn't fire again while the swap runs.
+			s.schedulePlayerSwap(name)
+			go func(name string) {
+				if err := s.performRandomSwapForPlayer(name); err != nil {
+					fmt.Printf("performRandomSwapForPlayer error: %v\n", err)
+				}
+			}(name)
+		}
+		s.enforceGameTimeLimits(now)
+	}
+}
+
 func (s *Server) sendMessage(message string, duration int, x int, y int, fontsize int, fg string, bg string) {
 	s.broadcastToPlayers(protocol.Command{
 		Cmd: protocol.CmdMessage,
@@ -43,6 +182,49 @@ func (s *Server) sendMessage(message string, duration int, x int, y int, fontsiz
 	})
 }
 
+// countdownSteps is how many "N" messages precede a scheduled swap.
+const countdownSteps = 3
+
+// Countdown message styling; matches the Controller's CmdMessage defaults.
+const (
+	countdownX        = 10
+	countdownY        = 10
+	countdownFontSize = 12
+	countdownFG       = "#FFFFFF"
+	countdownBG       = "#000000"
+)
+
+// startSwapCountdown sends "3", "2", "1" to players, one per step, starting
+// after delay. It stops early when the returned cancel func is called or when
+// the session stops running or swaps are disabled.
+func (s *Server) startSwapCountdown(delay, step time.Duration) (cancel func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		wait := delay
+		for n := countdownSteps; n > 0; n-- {
+			t := time.NewTimer(wait)
+			select {
+			case <-t.C:
+			case <-done:
+				t.Stop()
+				return
+			}
+			var active bool
+			s.withRLock(func() {
+				active = s.state.Running && s.state.SwapEnabled
+			})
+			if !active || atomic.LoadInt32(&s.shuttingDown) != 0 {
+				return
+			}
+			duration := max(int(step/time.Second), 1)
+			s.sendMessage(strconv.Itoa(n), duration, countdownX, countdownY, countdownFontSize, countdownFG, countdownBG)
+			wait = step
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
+
 // schedulerLoop schedules automatic swaps when enabled.
 func (s *Server) schedulerLoop() {
 	for {
@@ -57,17 +239,9 @@ func (s *Server) schedulerLoop() {
 		s.mu.RLock()
 		minv := s.state.MinIntervalSecs
 		maxv := s.state.MaxIntervalSecs
+		dist := s.state.IntervalDistribution
 		s.mu.RUnlock()
-		var interval int
-		if minv > 0 && maxv > 0 && maxv >= minv {
-			interval = minv + rand.Intn(maxv-minv+1)
-		} else if minv > 0 {
-			interval = minv
-		} else if maxv > 0 {
-			interval = maxv
-		} else {
-			interval = 300
-		}
+		interval := pickInterval(minv, maxv, dist)
 		nextAt := time.Now().Add(time.Duration(interval) * time.Second).Unix()
 		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 			st.NextSwapAt = nextAt
@@ -77,85 +251,32 @@ func (s *Server) schedulerLoop() {
 		countdownEnabled = s.state.CountdownEnabled
 		s.mu.RUnlock()
 
-		// Send countdown messages if enabled and interval is long enough
-		if countdownEnabled && interval >= 3 {
-			// Wait until 3 seconds before swap
-			countdownDelay := interval - 3
-			if countdownDelay > 0 {
-				countdownTimer := time.NewTimer(time.Duration(countdownDelay) * time.Second)
-				select {
-				case <-countdownTimer.C:
-				case <-s.schedulerCh:
-					if !countdownTimer.Stop() {
-						<-countdownTimer.C
-					}
-					continue
-				}
-			}
-
-			// Check if still running before countdown
-			s.mu.RLock()
-			if !s.state.Running || !s.state.SwapEnabled {
-				s.mu.RUnlock()
-				continue
-			}
-			s.mu.RUnlock()
-
-			// Send "3" message
-			s.sendMessage("3", 1, 10, 10, 12, "#FFFFFF", "#000000")
-
-			// Wait 1 second for "2"
-			countdownTimer := time.NewTimer(1 * time.Second)
-			select {
-			case <-countdownTimer.C:
-				s.sendMessage("2", 1, 10, 10, 12, "#FFFFFF", "#000000")
-			case <-s.schedulerCh:
-				if !countdownTimer.Stop() {
-					<-countdownTimer.C
-				}
-				continue
-			}
-
-			// Wait 1 second for "1"
-			countdownTimer = time.NewTimer(1 * time.Second)
-			select {
-			case <-countdownTimer.C:
-				s.sendMessage("1", 1, 10, 10, 12, "#FFFFFF", "#000000")
-			case <-s.schedulerCh:
-				if !countdownTimer.Stop() {
-					<-countdownTimer.C
-				}
-				continue
-			}
-
-			// Check again if still running before performing swap
-			s.mu.RLock()
-			if !s.state.Running || !s.state.SwapEnabled {
-				s.mu.RUnlock()
-				continue
-			}
-			s.mu.RUnlock()
-		} else {
-			// No countdown: wait for full interval
-			timer := time.NewTimer(time.Duration(interval) * time.Second)
-			select {
-			case <-timer.C:
-			case <-s.schedulerCh:
-				if !timer.Stop() {
-					<-timer.C
-				}
-				continue
-			}
-			s.mu.RLock()
-			if !s.state.Running || !s.state.SwapEnabled {
-				s.mu.RUnlock()
-				continue
+		timer := time.NewTimer(time.Duration(interval) * time.Second)
+		stopCountdown := func() {}
+		if countdownEnabled && interval >= countdownSteps {
+			// The countdown runs on its own goroutine so this loop stays
+			// responsive to schedulerCh; waking early cancels it.
+			stopCountdown = s.startSwapCountdown(time.Duration(interval-countdownSteps)*time.Second, time.Second)
+		}
+		select {
+		case <-timer.C:
+			stopCountdown()
+		case <-s.schedulerCh:
+			stopCountdown()
+			if !timer.Stop() {
+				<-timer.C
 			}
+			continue
+		}
+		s.mu.RLock()
+		if !s.state.Running || !s.state.SwapEnabled {
 			s.mu.RUnlock()
+			continue
 		}
+		s.mu.RUnlock()
 
 		go func() {
-			err := s.performSwap()
+			err := s.performScheduledSwap()
 			if err != nil {
 				fmt.Printf("performSwap error: %v\n", err)
 			}
@@ -0,0 +1,168 @@
+package serverhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func countdownMessages(client *wsClient, wait time.Duration) []string {
+	var got []string
+	deadline := time.After(wait)
+	for {
+		select {
+		case cmd := <-client.sendCh:
+			if cmd.Cmd == protocol.CmdMessage {
+				got = append(got, cmd.Payload.(map[string]any)["message"].(string))
+			}
+		case <-deadline:
+			return got
+		}
+	}
+}
+
+func TestSwapCountdownSendsThreeTwoOne(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Running = true
+		st.SwapEnabled = true
+	})
+	client := registerPlayerWSClient(s, "bob")
+
+	s.startSwapCountdown(0, 20*time.Millisecond)
+	got := countdownMessages(client, 300*time.Millisecond)
+	if len(got) != 3 || got[0] != "3" || got[1] != "2" || got[2] != "1" {
+		t.Fatalf("countdown messages %v", got)
+	}
+}
+
+func TestSwapCountdownStopsWhenSwapsDisabled(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Running = true
+		st.SwapEnabled = true
+	})
+	client := registerPlayerWSClient(s, "bob")
+
+	s.startSwapCountdown(0, 100*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.SwapEnabled = false
+	})
+	if got := countdownMessages(client, 400*time.Millisecond); len(got) != 1 {
+		t.Fatalf("expected countdown to stop after %q, got %v", "3", got)
+	}
+
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.SwapEnabled = true
+	})
+	cancel := s.startSwapCountdown(50*time.Millisecond, 50*time.Millisecond)
+	cancel()
+	if got := countdownMessages(client, 300*time.Millisecond); len(got) != 0 {
+		t.Fatalf("expected cancelled countdown to send nothing, got %v", got)
+	}
+}
+
+func TestPickIntervalDistributions(t *testing.T) {
+	for _, dist := range []protocol.IntervalDistribution{"", protocol.IntervalDistributionUniform, protocol.IntervalDistributionFrontLoaded} {
+		seen := map[int]bool{}
+		for range 2000 {
+			v := pickInterval(10, 14, dist)
+			if v < 10 || v > 14 {
+				t.Fatalf("%q: interval %d outside [10,14]", dist, v)
+			}
+			seen[v] = true
+		}
+		if len(seen) != 5 {
+			t.Fatalf("%q: expected every value in range, got %v", dist, seen)
+		}
+	}
+
+	// Front-loaded draws land in the lower half about 71% of the time vs 50%;
+	// the margin sits well clear of sampling noise.
+	var uniformLow, frontLow int
+	for range 4000 {
+		if pickInterval(1, 100, protocol.IntervalDistributionUniform) <= 50 {
+			uniformLow++
+		}
+		if pickInterval(1, 100, protocol.IntervalDistributionFrontLoaded) <= 50 {
+			frontLow++
+		}
+	}
+	if frontLow < uniformLow+400 {
+		t.Fatalf("front-loaded low draws %d, uniform %d", frontLow, uniformLow)
+	}
+}
+
+func TestAPIIntervalValidation(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.MinIntervalSecs = 60
+		st.MaxIntervalSecs = 120
+	})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.apiInterval(rec, httptest.NewRequest(http.MethodPost, "/api/interval", strings.NewReader(body)))
+		return rec
+	}
+	for _, body := range []string{
+		`{"min_interval_secs":200,"max_interval_secs":100}`,
+		`{"min_interval_secs":300}`, // exceeds the current max
+		`{"max_interval_secs":-1}`,
+		`{"interval_distribution":"bell"}`,
+	} {
+		if rec := post(body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d", body, rec.Code)
+		}
+	}
+	if rec := post(`{"min_interval_secs":200,"max_interval_secs":100}`); !strings.Contains(rec.Body.String(), "must not exceed") {
+		t.Fatalf("inverted range error %q", rec.Body.String())
+	}
+	s.withRLock(func() {
+		if s.state.MinIntervalSecs != 60 || s.state.MaxIntervalSecs != 120 {
+			t.Fatalf("rejected update changed state: %d/%d", s.state.MinIntervalSecs, s.state.MaxIntervalSecs)
+		}
+	})
+
+	if rec := post(`{"min_interval_secs":90,"interval_distribution":"front_loaded"}`); rec.Code != http.StatusOK {
+		t.Fatalf("valid update: status %d %s", rec.Code, rec.Body.String())
+	}
+	s.withRLock(func() {
+		if s.state.MinIntervalSecs != 90 || s.state.MaxIntervalSecs != 120 || s.state.IntervalDistribution != protocol.IntervalDistributionFrontLoaded {
+			t.Fatalf("state after update: %d/%d %q", s.state.MinIntervalSecs, s.state.MaxIntervalSecs, s.state.IntervalDistribution)
+		}
+	})
+}
+
+func TestScheduledSwapSkipsIntervalOverrides(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.PreventSameGameSwap = true
+		st.Games = []string{"a.zip", "b.zip"}
+		st.Players["amy"] = protocol.Player{Name: "amy", Game: "a.zip"}
+		st.Players["bo"] = protocol.Player{Name: "bo", Game: "a.zip", MinIntervalSecs: 600}
+	})
+
+	if err := s.performScheduledSwap(); err != nil {
+		t.Fatal(err)
+	}
+	players := s.SnapshotPlayers()
+	if players["amy"].Game != "b.zip" {
+		t.Fatalf("amy not swapped by the global tick: %+v", players["amy"])
+	}
+	if players["bo"].Game != "a.zip" {
+		t.Fatalf("bo has an interval override but was swapped: %+v", players["bo"])
+	}
+}
@@ -0,0 +1,128 @@
+package serverhost
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// selfSignedCertDir caches the generated certificate between runs.
+const selfSignedCertDir = "./certs"
+
+// selfSignedValidity is how long a generated certificate lasts; it is
+// regenerated once less than selfSignedRenewBefore remains.
+const (
+	selfSignedValidity    = 365 * 24 * time.Hour
+	selfSignedRenewBefore = 30 * 24 * time.Hour
+)
+
+// selfSignedHosts returns the names and addresses a certificate for host must
+// cover: loopback, host itself, and every LAN address for wildcard binds.
+func selfSignedHosts(host string) (dnsNames []string, ips []net.IP) {
+	dnsNames = []string{"localhost"}
+	ips = []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}
+	addIP := func(ip net.IP) {
+		if !slices.ContainsFunc(ips, ip.Equal) {
+			ips = append(ips, ip)
+		}
+	}
+	switch {
+	case host == "" || isWildcardBind(host):
+		for _, a := range lanIPv4Addresses() {
+			addIP(net.ParseIP(a))
+		}
+	case net.ParseIP(host) != nil:
+		addIP(net.ParseIP(host))
+	case !slices.Contains(dnsNames, host):
+		dnsNames = append(dnsNames, host)
+	}
+	return dnsNames, ips
+}
+
+// EnsureSelfSignedCert returns cert.pem and key.pem under dir for host,
+// generating a new self-signed pair when they are missing, expiring soon or
+// don't cover host. Clients must opt into trusting it (insecure_skip_verify).
+func EnsureSelfSignedCert(dir, host string) (certFile, keyFile string, err error) {
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	dnsNames, ips := selfSignedHosts(host)
+	if selfSignedCertUsable(certFile, keyFile, dnsNames, ips) {
+		return certFile, keyFile, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"BizShuffle"}, CommonName: "BizShuffle self-signed"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              dnsNames,
+		IPAddresses:           ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return "", "", fmt.Errorf("create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return "", "", err
+	}
+	log.Printf("generated self-signed certificate %s for %v %v", certFile, dnsNames, ips)
+	return certFile, keyFile, nil
+}
+
+// selfSignedCertUsable reports whether the cached pair loads, is not about to
+// expire and covers every wanted name and address.
+func selfSignedCertUsable(certFile, keyFile string, dnsNames []string, ips []net.IP) bool {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil || time.Until(cert.NotAfter) < selfSignedRenewBefore {
+		return false
+	}
+	for _, name := range dnsNames {
+		if !slices.Contains(cert.DNSNames, name) {
+			return false
+		}
+	}
+	for _, ip := range ips {
+		if !slices.ContainsFunc(cert.IPAddresses, ip.Equal) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package serverhost
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"sync"
@@ -14,7 +15,7 @@ import (
 // Server encapsulates all state and connected websocket clients.
 //
 // Lock ownership:
-//   - connMu: websocket registries (conns, playerClients, adminClients)
+//   - connMu: websocket registries (conns, playerClients, adminClients, spectatorClients, waitlist)
 //   - mu: server state, pending acks, swap tracking, plugins in memory
 //   - liveConns: lock-free snapshot for shutdown socket close
 type Server struct {
@@ -25,8 +26,11 @@ type Server struct {
 	conns                map[*websocket.Conn]*wsClient
 	playerClients        map[string]*wsClient
 	adminClients         map[string]*wsClient
+	spectatorClients     map[*wsClient]string // client -> display name; names may repeat. Receive games_update/message only; never swapped
 	upgrader             websocket.Upgrader
 	pending              map[string]chan string
+	pendingCmds          map[string]pendingCommand // guarded by mu; who each pending command went to
+	prestage             *prestageRun              // guarded by mu; the running POST /api/prestage, if any
 	schedulerCh          chan struct{}
 	saveChan             chan struct{}
 	saveTimer            *time.Timer
@@ -37,11 +41,48 @@ type Server struct {
 	wsActive             sync.WaitGroup
 	shuttingDown         int32
 	liveConns            sync.Map // *websocket.Conn -> *wsClient; used for shutdown without s.mu
+	romHashes            romHashCache
+	shutdownReq          chan struct{}
+	startedAt            time.Time
+	shutdownReqOnce      sync.Once
+	voteSkip             voteSkipState // guarded by mu; not persisted
+	history              *swapHistory
+	lastLuaSwap          time.Time            // guarded by mu; last accepted Lua swap request
+	swapUndo             *swapUndo            // guarded by mu; assignments before the last full swap
+	lastPong             map[string]time.Time // guarded by mu; when each player last answered a ping
+	pingsPolledAt        time.Time            // guarded by mu; last GET /api/players/pings
+	waitlist             []waitingPlayer      // guarded by connMu; hellos held while MaxPlayers are connected
 }
 
 // ErrTimeout is exported so callers can detect timeout waiting for a client ack/nack.
 var ErrTimeout = fmt.Errorf("timeout waiting for result")
 
+// ErrPlayerNotFound and ErrPlayerNotConnected are returned when a command targets an unknown or offline player.
+// ErrPlayerDisconnected is returned when the player drops while a command waits for its ack.
+var (
+	ErrPlayerNotFound     = fmt.Errorf("player not found")
+	ErrPlayerNotConnected = fmt.Errorf("player not connected")
+	ErrPlayerDisconnected = fmt.Errorf("player disconnected")
+)
+
+// serverDirs are the working directories the server serves and lists from.
+var serverDirs = []string{"./roms", "./saves", "./plugins"}
+
+// ensureServerDirs creates any missing serverDirs, logging each one it creates,
+// so file serving and listing never hit a missing directory.
+func ensureServerDirs() {
+	for _, dir := range serverDirs {
+		if _, err := os.Stat(dir); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Printf("failed to create %s: %v", dir, err)
+			continue
+		}
+		log.Printf("created missing %s directory", dir)
+	}
+}
+
 // New creates and initializes a Server, loading state and starting the scheduler.
 func New() *Server {
 	s := &Server{
@@ -62,69 +103,121 @@ func New() *Server {
 		conns:             make(map[*websocket.Conn]*wsClient),
 		playerClients:     make(map[string]*wsClient),
 		adminClients:      make(map[string]*wsClient),
+		spectatorClients:  make(map[*wsClient]string),
 		upgrader:          websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }},
 		pending:           make(map[string]chan string),
+		pendingCmds:       make(map[string]pendingCommand),
 		schedulerCh:       make(chan struct{}, 1),
 		saveChan:          make(chan struct{}, 1),
 		appliedSwapTarget: make(map[string]string),
 		swapInFlight:      make(map[string]struct{}),
+		lastPong:          make(map[string]time.Time),
+		shutdownReq:       make(chan struct{}),
+		startedAt:         time.Now(),
+		history:           newSwapHistory(swapHistoryFile),
 	}
 	s.loadState()
-	_ = os.MkdirAll("./roms", 0755)
-	_ = os.MkdirAll("./saves", 0755)
+	ensureServerDirs()
 	go s.schedulerLoop()
+	go s.playerSchedulerLoop()
+	go s.dashboardPingLoop()
 	go s.startSaver()
 	return s
 }
 
 // RegisterRoutes attaches all HTTP handlers to the provided mux.
 func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	// Routes used by player clients (files, saves, state, plugin list),
+	// /healthz and the spectator /status page stay open; everything else requires the admin token when one
+	// is configured. /ws checks the token itself on hello_admin.
 	mux.HandleFunc("/ws", s.handleWS)
+	mux.HandleFunc("/healthz", s.handleHealthz)
 	mux.HandleFunc("/", s.handleAdmin)
-	mux.HandleFunc("/api/start", s.apiStart)
-	mux.HandleFunc("/api/pause", s.apiPause)
-	mux.HandleFunc("/api/clear_saves", s.apiClearSaves)
-	mux.HandleFunc("/api/toggle_swaps", s.apiToggleSwaps)
-	mux.HandleFunc("/api/toggle_countdown", s.apiToggleCountdown)
-	mux.HandleFunc("/api/do_swap", s.apiDoSwap)
-	mux.HandleFunc("/api/random_swap", s.apiRandomSwapForPlayer)
-	mux.HandleFunc("/api/mode/setup", s.apiModeSetup)
-	mux.HandleFunc("/api/mode", s.apiMode)
-	mux.HandleFunc("/api/toggle_prevent_same_game", s.apiTogglePreventSameGame)
+	mux.HandleFunc("/api/start", s.requireAdmin(s.apiStart))
+	mux.HandleFunc("/api/pause", s.requireAdmin(s.apiPause))
+	mux.HandleFunc("/api/shutdown", s.requireAdmin(s.apiShutdown))
+	mux.HandleFunc("/api/clear_saves", s.requireAdmin(s.apiClearSaves))
+	mux.HandleFunc("/api/toggle_swaps", s.requireAdmin(s.apiToggleSwaps))
+	mux.HandleFunc("/api/swap/enabled", s.requireAdmin(s.apiSwapEnabled))
+	mux.HandleFunc("/api/toggle_countdown", s.requireAdmin(s.apiToggleCountdown))
+	mux.HandleFunc("/api/swap_preview_secs", s.requireAdmin(s.apiSwapPreviewSecs))
+	mux.HandleFunc("/api/ws_keepalive", s.requireAdmin(s.apiWSKeepalive))
+	mux.HandleFunc("/api/toggle_lobby", s.requireAdmin(s.apiToggleLobby))
+	mux.HandleFunc("/api/do_swap", s.requireAdmin(s.apiDoSwap))
+	mux.HandleFunc("/api/swap/preview", s.requireAdmin(s.apiSwapPreview))
+	mux.HandleFunc("/api/swap/repair", s.requireAdmin(s.apiSwapRepair))
+	mux.HandleFunc("/api/swap/undo", s.requireAdmin(s.apiSwapUndo))
+	mux.HandleFunc("/api/swap/rotate", s.requireAdmin(s.apiSwapRotate))
+	mux.HandleFunc("/api/swap/group", s.requireAdmin(s.apiSwapGroup))
+	mux.HandleFunc("/api/game_event_rules", s.requireAdmin(s.apiGameEventRules))
+	mux.HandleFunc("/api/random_swap", s.requireAdmin(s.apiRandomSwapForPlayer))
+	mux.HandleFunc("/api/mode/setup", s.requireAdmin(s.apiModeSetup))
+	mux.HandleFunc("/api/mode", s.requireAdmin(s.apiMode))
+	mux.HandleFunc("/api/order_mode", s.requireAdmin(s.apiOrderMode))
+	mux.HandleFunc("/api/swap_strategy", s.requireAdmin(s.apiSwapStrategy))
+	mux.HandleFunc("/api/vote_skip", s.requireAdmin(s.apiVoteSkip))
+	mux.HandleFunc("/api/lua_swap_cooldown", s.requireAdmin(s.apiLuaSwapCooldown))
+	mux.HandleFunc("/api/history", s.requireAdmin(s.apiHistory))
+	mux.HandleFunc("/api/session/export", s.requireAdmin(s.apiSessionExport))
+	mux.HandleFunc("/api/session/import", s.requireAdmin(s.apiSessionImport))
+	mux.HandleFunc("/api/session/start", s.requireAdmin(s.apiSessionStart))
+	mux.HandleFunc("/api/prestage", s.requireAdmin(s.apiPrestage))
+	mux.HandleFunc("/api/bingo/board", s.requireAdmin(s.apiBingoBoard))
+	mux.HandleFunc("/api/toggle_prevent_same_game", s.requireAdmin(s.apiTogglePreventSameGame))
+	mux.HandleFunc("/api/toggle_per_player_saves", s.requireAdmin(s.apiTogglePerPlayerSaves))
 	mux.HandleFunc("/files/", s.handleFiles)
-	mux.HandleFunc("/upload", s.handleUpload)
+	mux.HandleFunc("/upload", s.requireAdmin(s.handleUpload))
+	mux.HandleFunc("/api/files", s.requireAdmin(s.apiFiles))
+	mux.HandleFunc("/api/files/rename", s.requireAdmin(s.apiRenameFile))
 	mux.HandleFunc("/files/list.json", s.handleFilesList)
+	mux.HandleFunc("/api/files/tree", s.handleFilesTree)
 	mux.HandleFunc("/api/BizhawkFiles.zip", s.handleBizhawkFilesZip)
 	// Plugin file serving
 	mux.HandleFunc("/files/plugins/", s.handlePluginFiles)
 	mux.HandleFunc("/state.json", s.handleStateJSON)
-	mux.HandleFunc("/api/share_urls", s.apiShareURLs)
-	mux.HandleFunc("/api/games", s.apiGames)
-	mux.HandleFunc("/api/interval", s.apiInterval)
-	mux.HandleFunc("/api/swap_player", s.apiSwapPlayer)
-	mux.HandleFunc("/api/remove_player", s.apiRemovePlayer)
-	mux.HandleFunc("/api/add_player", s.apiAddPlayer)
-	mux.HandleFunc("/api/swap_all_to_game", s.apiSwapAllToGame)
+	mux.HandleFunc("/status", s.handleStatusPage)
+	mux.HandleFunc("/status.json", s.handleStatusJSON)
+	mux.HandleFunc("/api/share_urls", s.requireAdmin(s.apiShareURLs))
+	mux.HandleFunc("/api/games", s.requireAdmin(s.apiGames))
+	mux.HandleFunc("/api/games/rename", s.requireAdmin(s.apiGameRename))
+	mux.HandleFunc("/api/interval", s.requireAdmin(s.apiInterval))
+	mux.HandleFunc("/api/max_players", s.requireAdmin(s.apiMaxPlayers))
+	mux.HandleFunc("/api/swap_player", s.requireAdmin(s.apiSwapPlayer))
+	mux.HandleFunc("/api/remove_player", s.requireAdmin(s.apiRemovePlayer))
+	mux.HandleFunc("/api/add_player", s.requireAdmin(s.apiAddPlayer))
+	mux.HandleFunc("/api/swap_all_to_game", s.requireAdmin(s.apiSwapAllToGame))
 	// Completed games/instances routes
-	mux.HandleFunc("/api/players/remove_all_completions", s.apiRemoveAllCompletions)
-	mux.HandleFunc("/api/players/", s.handlePlayerCompletedRoutes)
-	mux.HandleFunc("/api/games/", s.handleGameCompletedRoutes)
-	mux.HandleFunc("/api/instances/", s.handleInstanceCompletedRoutes)
+	mux.HandleFunc("/api/players/remove_all_completions", s.requireAdmin(s.apiRemoveAllCompletions))
+	mux.HandleFunc("/api/players/reset_completions", s.requireAdmin(s.apiResetCompletions))
+	mux.HandleFunc("/api/players/pings", s.requireAdmin(s.apiPlayerPings))
+	mux.HandleFunc("/api/players/pause_all", s.requireAdmin(s.apiPauseAll))
+	mux.HandleFunc("/api/players/resume_all", s.requireAdmin(s.apiResumeAll))
+	mux.HandleFunc("/api/players/", s.requireAdmin(s.handlePlayerCompletedRoutes))
+	mux.HandleFunc("/api/games/", s.requireAdmin(s.handleGameCompletedRoutes))
+	mux.HandleFunc("/api/instances/reorder", s.requireAdmin(s.apiReorderInstances))
+	mux.HandleFunc("/api/instances/search", s.requireAdmin(s.apiSearchInstances))
+	mux.HandleFunc("/api/instances/", s.requireAdmin(s.handleInstanceCompletedRoutes))
 	// Plugin management routes
 	mux.HandleFunc("/api/plugins", s.handlePluginsList)
 	// Plugin management routes - handles settings and other plugin actions
-	mux.HandleFunc("/api/plugins/", s.handlePluginAction)
-	mux.HandleFunc("/api/open_roms_folder", s.handleOpenRomsFolder)
-	mux.HandleFunc("/api/open_plugins_folder", s.handleOpenPluginsFolder)
-	mux.HandleFunc("/api/message_player", s.apiMessagePlayer)
-	mux.HandleFunc("/api/message_all", s.apiMessageAll)
-	mux.HandleFunc("/api/fullscreen_toggle", s.apiFullscreenToggle)
+	mux.HandleFunc("/api/plugins/", s.requireAdmin(s.handlePluginAction))
+	mux.HandleFunc("/api/open_roms_folder", s.requireAdmin(s.handleOpenRomsFolder))
+	mux.HandleFunc("/api/open_plugins_folder", s.requireAdmin(s.handleOpenPluginsFolder))
+	mux.HandleFunc("/api/message", s.requireAdmin(s.apiMessage))
+	mux.HandleFunc("/api/message_player", s.requireAdmin(s.apiMessagePlayer))
+	mux.HandleFunc("/api/message_all", s.requireAdmin(s.apiMessageAll))
+	mux.HandleFunc("/api/fullscreen_toggle", s.requireAdmin(s.apiFullscreenToggle))
 	// Config management endpoints
-	mux.HandleFunc("/api/check_player_config", s.apiCheckPlayerConfig)
-	mux.HandleFunc("/api/update_player_config", s.apiUpdatePlayerConfig)
-	mux.HandleFunc("/api/set_config_keys", s.apiSetConfigKeys)
+	mux.HandleFunc("/api/check_player_config", s.requireAdmin(s.apiCheckPlayerConfig))
+	mux.HandleFunc("/api/update_player_config", s.requireAdmin(s.apiUpdatePlayerConfig))
+	mux.HandleFunc("/api/set_config_keys", s.requireAdmin(s.apiSetConfigKeys))
 	// Save state management endpoints
 	mux.HandleFunc("/save/upload", s.handleSaveUpload)
+	mux.HandleFunc("/api/request_save", s.requireAdmin(s.apiRequestSave))
+	mux.HandleFunc("/api/save_versions", s.requireAdmin(s.apiSaveVersionsLimit))
+	mux.HandleFunc("/api/saves/orphans", s.requireAdmin(s.apiSaveOrphans))
+	mux.HandleFunc("/api/request_screenshot", s.requireAdmin(s.apiRequestScreenshot))
+	mux.HandleFunc("/api/screenshots/", s.handleScreenshotRoutes)
 	mux.HandleFunc("/save/no-save", s.handleNoSaveState)
 	mux.HandleFunc("/save/", s.handleSaveDownload)
 }
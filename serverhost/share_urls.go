@@ -30,33 +30,112 @@ func isWildcardBind(host string) bool {
 	return host == "0.0.0.0" || host == "::" || host == "[::]"
 }
 
-func lanIPv4Addresses() []string {
+// lanInterface is the part of a network interface that LAN address ranking
+// looks at.
+type lanInterface struct {
+	Name  string
+	Flags net.Flags
+	Addrs []net.Addr
+}
+
+// listInterfaces reads the host's interfaces; tests replace it.
+var listInterfaces = func() ([]lanInterface, error) {
 	ifaces, err := net.Interfaces()
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	ips := make(map[string]struct{})
+	out := make([]lanInterface, 0, len(ifaces))
 	for _, iface := range ifaces {
 		addrs, err := iface.Addrs()
 		if err != nil {
 			continue
 		}
-		for _, addr := range addrs {
+		out = append(out, lanInterface{Name: iface.Name, Flags: iface.Flags, Addrs: addrs})
+	}
+	return out, nil
+}
+
+// virtualAdapterPrefixes name interfaces created by containers, VMs and VPNs,
+// whose addresses other machines on the LAN usually cannot reach.
+var virtualAdapterPrefixes = []string{
+	"docker", "br-", "veth", "virbr", "vboxnet", "vmnet", "vethernet", "virtualbox",
+	"vmware", "hyper-v", "wsl", "tailscale", "zt", "utun", "tun", "tap", "wg",
+}
+
+func isVirtualAdapter(name string) bool {
+	name = strings.ToLower(name)
+	for _, prefix := range virtualAdapterPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lanIPv4Score ranks a candidate address; lower is better. Private ranges on
+// physical adapters come first (192.168/16, then 10/8, then 172.16/12), other
+// addresses after them, and link-local last. Virtual adapters sort after
+// every physical one.
+func lanIPv4Score(ifaceName string, ip net.IP) int {
+	score := 3
+	switch ip4 := ip.To4(); {
+	case ip4[0] == 192 && ip4[1] == 168:
+		score = 0
+	case ip4[0] == 10:
+		score = 1
+	case ip4[0] == 172 && ip4[1]&0xf0 == 16:
+		score = 2
+	case ip4.IsLinkLocalUnicast():
+		score = 4
+	}
+	if isVirtualAdapter(ifaceName) {
+		score += 10
+	}
+	return score
+}
+
+// rankLANIPv4 returns the IPv4 addresses of ifaces that are up and not
+// loopback, best LAN candidate first (see lanIPv4Score), ties by address.
+func rankLANIPv4(ifaces []lanInterface) []string {
+	best := make(map[string]int)
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		for _, addr := range iface.Addrs {
 			ipNet, ok := addr.(*net.IPNet)
 			if !ok || ipNet.IP.To4() == nil || ipNet.IP.IsLoopback() {
 				continue
 			}
-			ips[ipNet.IP.String()] = struct{}{}
+			ip := ipNet.IP.String()
+			score := lanIPv4Score(iface.Name, ipNet.IP)
+			if prev, seen := best[ip]; !seen || score < prev {
+				best[ip] = score
+			}
 		}
 	}
-	out := make([]string, 0, len(ips))
-	for ip := range ips {
+	out := make([]string, 0, len(best))
+	for ip := range best {
 		out = append(out, ip)
 	}
-	sort.Strings(out)
+	sort.Slice(out, func(i, j int) bool {
+		if best[out[i]] != best[out[j]] {
+			return best[out[i]] < best[out[j]]
+		}
+		return out[i] < out[j]
+	})
 	return out
 }
 
+// lanIPv4Addresses lists this machine's LAN IPv4 addresses, best first.
+func lanIPv4Addresses() []string {
+	ifaces, err := listInterfaces()
+	if err != nil {
+		return nil
+	}
+	return rankLANIPv4(ifaces)
+}
+
 func joinShareURL(host string, port int) string {
 	portStr := strconv.Itoa(port)
 	if strings.Contains(host, ":") {
@@ -65,7 +144,8 @@ func joinShareURL(host string, port int) string {
 	return "http://" + host + ":" + portStr
 }
 
-// BuildLANShareURLs returns HTTP URLs for LAN clients based on bind host.
+// BuildLANShareURLs returns HTTP URLs for LAN clients based on bind host;
+// for wildcard binds the most likely reachable address comes first.
 func BuildLANShareURLs(listenHost string, port int) []string {
 	if isLoopbackHost(listenHost) {
 		return nil
@@ -145,6 +225,15 @@ func (s *Server) apiShareURLs(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if s.Scheme() == "https" {
+		for i, u := range urls.LAN {
+			urls.LAN[i] = "https" + strings.TrimPrefix(u, "http")
+		}
+		if urls.WAN != nil {
+			u := "https" + strings.TrimPrefix(*urls.WAN, "http")
+			urls.WAN = &u
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(urls)
 }
@@ -2,6 +2,8 @@ package serverhost
 
 import (
 	"context"
+	"net"
+	"slices"
 	"testing"
 )
 
@@ -49,3 +51,47 @@ func TestResolveShareURLsLoopbackSkipsWAN(t *testing.T) {
 		t.Fatalf("got %+v", urls)
 	}
 }
+
+func TestRankLANIPv4PrefersPhysicalPrivateAddresses(t *testing.T) {
+	addrs := func(cidrs ...string) []net.Addr {
+		var out []net.Addr
+		for _, c := range cidrs {
+			ip, ipNet, err := net.ParseCIDR(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ipNet.IP = ip
+			out = append(out, ipNet)
+		}
+		return out
+	}
+	up := net.FlagUp | net.FlagBroadcast
+	got := rankLANIPv4([]lanInterface{
+		{Name: "lo", Flags: up | net.FlagLoopback, Addrs: addrs("127.0.0.1/8")},
+		{Name: "docker0", Flags: up, Addrs: addrs("172.17.0.1/16")},
+		{Name: "vEthernet (WSL)", Flags: up, Addrs: addrs("192.168.80.1/20")},
+		{Name: "eth1", Flags: up, Addrs: addrs("169.254.10.2/16", "fe80::1/64")},
+		{Name: "wlan0", Flags: up, Addrs: addrs("10.0.0.7/24")},
+		{Name: "eth0", Flags: up, Addrs: addrs("172.20.1.5/16", "192.168.1.20/24")},
+		{Name: "eth2", Flags: net.FlagBroadcast, Addrs: addrs("192.168.5.5/24")},
+	})
+	want := []string{"192.168.1.20", "10.0.0.7", "172.20.1.5", "169.254.10.2", "192.168.80.1", "172.17.0.1"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestBuildLANShareURLsWildcardUsesRankedInterfaces(t *testing.T) {
+	orig := listInterfaces
+	t.Cleanup(func() { listInterfaces = orig })
+	listInterfaces = func() ([]lanInterface, error) {
+		return []lanInterface{
+			{Name: "vboxnet0", Flags: net.FlagUp, Addrs: []net.Addr{&net.IPNet{IP: net.IPv4(192, 168, 56, 1), Mask: net.CIDRMask(24, 32)}}},
+			{Name: "en0", Flags: net.FlagUp, Addrs: []net.Addr{&net.IPNet{IP: net.IPv4(10, 1, 2, 3), Mask: net.CIDRMask(8, 32)}}},
+		}, nil
+	}
+	got := BuildLANShareURLs("0.0.0.0", 8080)
+	if len(got) != 2 || got[0] != "http://10.1.2.3:8080" {
+		t.Fatalf("got %v", got)
+	}
+}
@@ -1,7 +1,10 @@
 package serverhost
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
+	"net/http"
 	"sync/atomic"
 	"time"
 
@@ -10,6 +13,9 @@ import (
 
 const wsHandlerDrainWait = 3 * time.Second
 
+// shutdownSaveWait bounds how long /api/shutdown waits for players to upload saves.
+const shutdownSaveWait = 30 * time.Second
+
 // BeginShutdown marks the server as stopping so websocket teardown avoids contending on s.mu.
 // Call before cancelling HTTP request contexts or closing listeners.
 func (s *Server) BeginShutdown() {
@@ -50,5 +56,63 @@ func (s *Server) Shutdown() {
 	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 		st.Running = false
 	})
+	s.history.close()
 	log.Printf("serverhost: shutdown complete")
 }
+
+// ShutdownRequested is closed once /api/shutdown has flushed saves and the
+// process should exit.
+func (s *Server) ShutdownRequested() <-chan struct{} {
+	return s.shutdownReq
+}
+
+// FlushSaves stops the session, asks every connected player with an assigned
+// instance to upload its save, and waits for the uploads before persisting
+// state. It returns true if some uploads were still outstanding at timeout.
+func (s *Server) FlushSaves(timeout time.Duration) bool {
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Running = false
+	})
+	select {
+	case s.schedulerCh <- struct{}{}:
+	default:
+	}
+
+	type saveReq struct{ player, instanceID string }
+	var reqs []saveReq
+	s.withRLock(func() {
+		for name, p := range s.state.Players {
+			if p.Connected && p.InstanceID != "" {
+				reqs = append(reqs, saveReq{name, p.InstanceID})
+			}
+		}
+	})
+	for _, req := range reqs {
+		s.setInstanceFileStateWithPlayer(req.instanceID, protocol.FileStatePending, req.player)
+		if err := s.RequestSave(req.player, req.instanceID); err != nil {
+			log.Printf("shutdown: request save from %s for %s: %v", req.player, req.instanceID, err)
+			s.clearPendingInstance(req.instanceID)
+		}
+	}
+	stillWaiting := s.WaitForPendingSaves(timeout)
+
+	s.broadcastToPlayers(protocol.Command{Cmd: protocol.CmdPause, ID: fmt.Sprintf("%d", time.Now().UnixNano())})
+	if err := s.saveState(); err != nil {
+		log.Printf("shutdown: persist state: %v", err)
+	}
+	return stillWaiting
+}
+
+// apiShutdown flushes player saves and then signals ShutdownRequested.
+func (s *Server) apiShutdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	timedOut := s.FlushSaves(shutdownSaveWait)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"result": "ok", "timed_out": timedOut}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+	s.shutdownReqOnce.Do(func() { close(s.shutdownReq) })
+}
@@ -1,6 +1,9 @@
 package serverhost
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/michael4d45/bizshuffle/protocol"
@@ -19,3 +22,58 @@ func TestShutdownWithNoActiveWebsockets(t *testing.T) {
 		t.Fatal("expected running=false after shutdown")
 	}
 }
+
+func TestAPIShutdownFlushesSavesBeforeSignalling(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Running = true
+		st.GameSwapInstances = []protocol.GameSwapInstance{{ID: "i1", Game: "a.zip", FileState: protocol.FileStateReady}}
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true, InstanceID: "i1"}
+	})
+	client := registerPlayerWSClient(s, "bob")
+	go func() {
+		for cmd := range client.sendCh {
+			if cmd.Cmd != protocol.CmdRequestSave {
+				continue
+			}
+			select {
+			case <-s.ShutdownRequested():
+				t.Error("shutdown signalled before save upload")
+			default:
+			}
+			// Simulate the client's /save/upload completing.
+			s.setInstanceFileState("i1", protocol.FileStateReady)
+		}
+	}()
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	res, err := http.Post(srv.URL+"/api/shutdown", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	var out struct {
+		TimedOut bool `json:"timed_out"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.TimedOut {
+		t.Fatal("expected save upload to complete before timeout")
+	}
+	select {
+	case <-s.ShutdownRequested():
+	default:
+		t.Fatal("expected shutdown to be signalled")
+	}
+	if s.SnapshotState().Running {
+		t.Fatal("expected running=false")
+	}
+	if n := s.PendingInstanceCount(); n != 0 {
+		t.Fatalf("pendingInstancecount %d", n)
+	}
+}
@@ -0,0 +1,129 @@
+package serverhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestSpectatorGetsBroadcastsButNoPlayerSlot(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Games = []string{"a.zip"}
+	})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdHelloSpectator, ID: "1", Payload: map[string]any{"name": "tv"}}); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	read := func() protocol.Command {
+		t.Helper()
+		var cmd protocol.Command
+		if err := c.ReadJSON(&cmd); err != nil {
+			t.Fatal(err)
+		}
+		return cmd
+	}
+
+	if cmd := read(); cmd.Cmd != protocol.CmdGamesUpdate {
+		t.Fatalf("first command = %s, want games_update", cmd.Cmd)
+	}
+	s.sendMessage("hi", 3, 10, 10, 12, "#FFFFFF", "#000000")
+	if cmd := read(); cmd.Cmd != protocol.CmdMessage {
+		t.Fatalf("got %s, want message", cmd.Cmd)
+	}
+	if err := s.performSwap(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.SnapshotPlayers()); n != 0 {
+		t.Fatalf("spectator added %d players", n)
+	}
+
+	_ = c.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		s.withConnRLock(func() { n = len(s.spectatorClients) })
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("spectator not removed on disconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSpectatorsWithSameNameBothReceiveBroadcasts(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	join := func() *websocket.Conn {
+		t.Helper()
+		c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdHelloSpectator, ID: "1", Payload: map[string]any{"name": "tv"}}); err != nil {
+			t.Fatal(err)
+		}
+		_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var cmd protocol.Command
+		if err := c.ReadJSON(&cmd); err != nil || cmd.Cmd != protocol.CmdGamesUpdate {
+			t.Fatalf("hello: %v %+v", err, cmd)
+		}
+		return c
+	}
+	first, second := join(), join()
+
+	s.sendMessage("hi", 3, 10, 10, 12, "#FFFFFF", "#000000")
+	for i, c := range []*websocket.Conn{first, second} {
+		var cmd protocol.Command
+		if err := c.ReadJSON(&cmd); err != nil || cmd.Cmd != protocol.CmdMessage {
+			t.Fatalf("spectator %d: %v %+v", i, err, cmd)
+		}
+	}
+
+	// The first screen leaving must not unregister the second.
+	_ = first.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		s.withConnRLock(func() { n = len(s.spectatorClients) })
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d spectators registered, want 1", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.sendMessage("still there", 3, 10, 10, 12, "#FFFFFF", "#000000")
+	var cmd protocol.Command
+	if err := second.ReadJSON(&cmd); err != nil || cmd.Cmd != protocol.CmdMessage {
+		t.Fatalf("second spectator after first left: %v %+v", err, cmd)
+	}
+}
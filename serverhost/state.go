@@ -236,11 +236,20 @@ func (s *Server) loadState() {
 			"DisplayFps",
 		}
 	}
+	if !knownGameMode(tmp.Mode) {
+		log.Printf("state.json has unknown mode %q; using %s", tmp.Mode, protocol.GameModeSync)
+		tmp.Mode = protocol.GameModeSync
+	}
+	// Bump the swap counter on every load (older state.json files start at 0) so the
+	// first post-restart swap never repeats a selection made before the restart.
+	tmp.SwapCounter++
 	tmp.UpdatedAt = time.Now()
 	for name, player := range tmp.Players {
 		player.Connected = false
 		tmp.Players[name] = player
 	}
+	tmp.NotReadyPlayers = nil
+	tmp.Waitlist = nil
 
 	// Load plugins from plugins directory, ignore tmp.Plugins
 	tmp.Plugins = make(map[string]protocol.Plugin)
@@ -344,6 +353,11 @@ func (s *Server) savePluginConfig(plugin protocol.Plugin) error {
 		if s.state.Plugins == nil {
 			s.state.Plugins = make(map[string]protocol.Plugin)
 		}
+		// Lua errors live only in memory; keep the latest one.
+		if cur, ok := s.state.Plugins[plugin.Name]; ok {
+			fullPlugin.LastError = cur.LastError
+			fullPlugin.LastErrorPlayer = cur.LastErrorPlayer
+		}
 		s.state.Plugins[plugin.Name] = *fullPlugin
 	})
 
@@ -385,19 +399,42 @@ func (s *Server) loadPluginMetadata(pluginName string) *protocol.Plugin {
 	return &plugin
 }
 
-// handleStateJSON returns the server state as JSON.
+// handleStateJSON returns the server state as JSON. Query parameters narrow
+// it for large sessions; see parseStateQuery.
 func (s *Server) handleStateJSON(w http.ResponseWriter, r *http.Request) {
+	q, err := parseStateQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	st := s.SnapshotState()
+	st.AdminToken = ""
 	w.Header().Set("Content-Type", "application/json")
-	// Return an envelope with the persisted state runtime map.
-	out := map[string]any{
-		"state": st,
+	if !q.isZero() {
+		out, err := s.stateQueryResponse(st, q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
 	}
-	if err := json.NewEncoder(w).Encode(out); err != nil {
+	if err := json.NewEncoder(w).Encode(s.stateEnvelope(st)); err != nil {
 		fmt.Printf("encode response error: %v\n", err)
 	}
 }
 
+// stateEnvelope wraps st, already redacted, with runtime data: rom_mismatches
+// lists, per player, ROM files whose checksum differs from ./roms.
+func (s *Server) stateEnvelope(st protocol.ServerState) map[string]any {
+	return map[string]any{
+		"state":          st,
+		"rom_mismatches": s.romMismatches(st.Players),
+	}
+}
+
 func (s *Server) GetGameForPlayer(player string) protocol.Player {
 	// Use direct map lookup by player key. This is deterministic and
 	// avoids relying on the Player.Name field matching the map key.
@@ -421,8 +458,9 @@ func (s *Server) SnapshotState() protocol.ServerState {
 // SnapshotPlayers returns a shallow copy of the players map for safe
 // iteration without holding the server lock.
 func (s *Server) SnapshotPlayers() map[string]protocol.Player {
-	out := make(map[string]protocol.Player, len(s.state.Players))
+	var out map[string]protocol.Player
 	s.withRLock(func() {
+		out = make(map[string]protocol.Player, len(s.state.Players))
 		for k, v := range s.state.Players {
 			out[k] = v
 		}
@@ -0,0 +1,168 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// stateQuery narrows a /state.json response. The zero value means the full,
+// unfiltered response.
+type stateQuery struct {
+	// include lists the ServerState JSON keys to return ("instances" is
+	// accepted for game_instances, "rom_mismatches" for the envelope field).
+	include   map[string]bool
+	connected *bool
+	game      string
+	offset    int
+	limit     int
+	paged     bool
+}
+
+// parseStateQuery reads ?include=, ?connected=, ?game=, ?offset= and ?limit=.
+// Other parameters (such as ?token=) are ignored.
+func parseStateQuery(q url.Values) (stateQuery, error) {
+	var sq stateQuery
+	if v := q.Get("include"); v != "" {
+		sq.include = make(map[string]bool)
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "instances" {
+				name = "game_instances"
+			}
+			if name != "" {
+				sq.include[name] = true
+			}
+		}
+	}
+	if v := q.Get("connected"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return sq, fmt.Errorf("invalid connected")
+		}
+		sq.connected = &b
+	}
+	sq.game = q.Get("game")
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return sq, fmt.Errorf("invalid offset")
+		}
+		sq.offset, sq.paged = n, true
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return sq, fmt.Errorf("invalid limit")
+		}
+		sq.limit, sq.paged = n, true
+	}
+	return sq, nil
+}
+
+func (q stateQuery) isZero() bool {
+	return q.include == nil && q.connected == nil && q.game == "" && !q.paged
+}
+
+func (q stateQuery) filtered() bool {
+	return q.connected != nil || q.game != "" || q.paged
+}
+
+// pageBounds clips [offset, offset+limit) to n items.
+func (q stateQuery) pageBounds(n int) (int, int) {
+	start := min(q.offset, n)
+	end := n
+	if q.limit > 0 {
+		end = min(start+q.limit, n)
+	}
+	return start, end
+}
+
+// statePage reports how many players and instances matched the filters
+// before offset/limit were applied.
+type statePage struct {
+	Offset         int `json:"offset"`
+	Limit          int `json:"limit,omitempty"`
+	PlayersTotal   int `json:"players_total"`
+	InstancesTotal int `json:"instances_total"`
+}
+
+// filterPlayers keeps players matching the connected and game filters, then
+// pages them in name order.
+func (q stateQuery) filterPlayers(players map[string]protocol.Player) (map[string]protocol.Player, int) {
+	names := make([]string, 0, len(players))
+	for name, p := range players {
+		if q.connected != nil && p.Connected != *q.connected {
+			continue
+		}
+		if q.game != "" && p.Game != q.game {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	start, end := q.pageBounds(len(names))
+	out := make(map[string]protocol.Player, end-start)
+	for _, name := range names[start:end] {
+		out[name] = players[name]
+	}
+	return out, len(names)
+}
+
+// filterInstances keeps instances of the filtered game, then pages them in
+// catalog order.
+func (q stateQuery) filterInstances(instances []protocol.GameSwapInstance) ([]protocol.GameSwapInstance, int) {
+	var matched []protocol.GameSwapInstance
+	for _, inst := range instances {
+		if q.game != "" && inst.Game != q.game {
+			continue
+		}
+		matched = append(matched, inst)
+	}
+	start, end := q.pageBounds(len(matched))
+	return matched[start:end], len(matched)
+}
+
+// stateQueryResponse builds the /state.json envelope for a non-zero query:
+// players and instances are filtered and paged, then only the included
+// sections are kept. A "page" field is added whenever filters or paging apply.
+func (s *Server) stateQueryResponse(st protocol.ServerState, q stateQuery) (map[string]any, error) {
+	var playersTotal, instancesTotal int
+	st.Players, playersTotal = q.filterPlayers(st.Players)
+	st.GameSwapInstances, instancesTotal = q.filterInstances(st.GameSwapInstances)
+
+	raw, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if q.include != nil {
+		for k := range fields {
+			if !q.include[k] {
+				delete(fields, k)
+			}
+		}
+	}
+
+	out := map[string]any{"state": fields}
+	if q.include == nil || q.include["rom_mismatches"] || q.include["players"] {
+		out["rom_mismatches"] = s.romMismatches(st.Players)
+	}
+	if q.filtered() {
+		out["page"] = statePage{
+			Offset:         q.offset,
+			Limit:          q.limit,
+			PlayersTotal:   playersTotal,
+			InstancesTotal: instancesTotal,
+		}
+	}
+	return out, nil
+}
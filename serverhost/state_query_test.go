@@ -0,0 +1,83 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestStateJSONQuery(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["amy"] = protocol.Player{Name: "amy", Connected: true, Game: "a.nes"}
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true, Game: "b.nes"}
+		st.Players["cat"] = protocol.Player{Name: "cat", Game: "a.nes"}
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "1", Game: "a.nes"}, {ID: "2", Game: "b.nes"}, {ID: "3", Game: "a.nes"},
+		}
+	})
+
+	get := func(query string) (int, map[string]json.RawMessage) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.handleStateJSON(rec, httptest.NewRequest(http.MethodGet, "/state.json"+query, nil))
+		var out map[string]json.RawMessage
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+				t.Fatalf("%s: %v", query, err)
+			}
+		}
+		return rec.Code, out
+	}
+	type body struct {
+		Players   map[string]protocol.Player  `json:"players"`
+		Instances []protocol.GameSwapInstance `json:"game_instances"`
+		Mode      protocol.GameMode           `json:"mode"`
+	}
+	decodeState := func(out map[string]json.RawMessage) (b body, keys map[string]json.RawMessage) {
+		t.Helper()
+		if err := json.Unmarshal(out["state"], &b); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(out["state"], &keys); err != nil {
+			t.Fatal(err)
+		}
+		return b, keys
+	}
+
+	// No parameters (an auth token does not count) keeps the full response.
+	code, out := get("?token=x")
+	if b, _ := decodeState(out); code != http.StatusOK || len(b.Players) != 3 || len(b.Instances) != 3 || out["page"] != nil {
+		t.Fatalf("full response: %d players=%d instances=%d page=%s", code, len(b.Players), len(b.Instances), out["page"])
+	}
+
+	code, out = get("?include=players,instances&connected=true&game=a.nes")
+	b, keys := decodeState(out)
+	if code != http.StatusOK || len(keys) != 2 || len(b.Players) != 1 || b.Players["amy"].Name != "amy" || len(b.Instances) != 2 {
+		t.Fatalf("filtered: %d keys=%d players=%v instances=%v", code, len(keys), b.Players, b.Instances)
+	}
+
+	code, out = get("?offset=1&limit=1")
+	b, _ = decodeState(out)
+	var page statePage
+	if err := json.Unmarshal(out["page"], &page); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.Players["bob"]; code != http.StatusOK || len(b.Players) != 1 || !ok || len(b.Instances) != 1 || b.Instances[0].ID != "2" {
+		t.Fatalf("paged: players=%v instances=%v", b.Players, b.Instances)
+	}
+	if page.PlayersTotal != 3 || page.InstancesTotal != 3 || page.Offset != 1 || page.Limit != 1 {
+		t.Fatalf("page = %+v", page)
+	}
+
+	for _, q := range []string{"?limit=0", "?offset=-1", "?connected=maybe"} {
+		if code, _ := get(q); code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d", q, code)
+		}
+	}
+}
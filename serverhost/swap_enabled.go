@@ -0,0 +1,88 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// swapsEnabled reports ServerState.SwapEnabled. While it is false the
+// scheduler does not fire, Lua swaps are ignored and random swaps for a player
+// are declined with ErrSwapsDisabled.
+func (s *Server) swapsEnabled() bool {
+	var enabled bool
+	s.withRLock(func() { enabled = s.state.SwapEnabled })
+	return enabled
+}
+
+// setSwapEnabled sets SwapEnabled to update(current) and returns the new
+// value. Disabling clears NextSwapAt. On a change the scheduler is woken so it
+// rearms or parks, and admins get swap_enabled_changed.
+func (s *Server) setSwapEnabled(update func(enabled bool) bool) bool {
+	var enabled, changed bool
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		enabled = update(st.SwapEnabled)
+		changed = enabled != st.SwapEnabled
+		st.SwapEnabled = enabled
+		if !enabled {
+			st.NextSwapAt = 0
+		}
+	})
+	if !changed {
+		return enabled
+	}
+	select {
+	case s.schedulerCh <- struct{}{}:
+	default:
+	}
+	s.emitAdminEvent(protocol.CmdSwapEnabledChanged, protocol.SwapEnabledEvent{Enabled: enabled})
+	return enabled
+}
+
+// randomSwapForPlayer runs the current mode's random swap for playerName,
+// declining with ErrSwapsDisabled while swaps are disabled.
+func (s *Server) randomSwapForPlayer(playerName string) error {
+	var exists, enabled bool
+	s.withRLock(func() {
+		_, exists = s.state.Players[playerName]
+		enabled = s.state.SwapEnabled
+	})
+	if !exists {
+		return fmt.Errorf("player %s: %w", playerName, ErrPlayerNotFound)
+	}
+	if !enabled {
+		return fmt.Errorf("player %s: %w", playerName, ErrSwapsDisabled)
+	}
+	return s.GetGameModeHandler().HandleRandomSwapForPlayer(playerName)
+}
+
+// apiSwapEnabled: GET/POST /api/swap/enabled {enabled}
+func (s *Server) apiSwapEnabled(w http.ResponseWriter, r *http.Request) {
+	var enabled bool
+	switch r.Method {
+	case http.MethodGet:
+		enabled = s.swapsEnabled()
+	case http.MethodPost:
+		var b struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if b.Enabled == nil {
+			http.Error(w, "missing enabled", http.StatusBadRequest)
+			return
+		}
+		enabled = s.setSwapEnabled(func(bool) bool { return *b.Enabled })
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]bool{"enabled": enabled}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
@@ -0,0 +1,70 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestSwapEnabledGatesRandomAndLuaSwaps(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Games = []string{"a.zip", "b.zip"}
+		st.Players["p1"] = protocol.Player{Name: "p1", Game: "a.zip", Connected: true}
+		st.NextSwapAt = time.Now().Add(time.Minute).Unix()
+	})
+	admin := &wsClient{sendCh: make(chan protocol.Command, 8), closed: make(chan struct{})}
+	s.withConnLock(func() { s.adminClients["admin"] = admin })
+
+	rec := httptest.NewRecorder()
+	s.apiSwapEnabled(rec, httptest.NewRequest(http.MethodPost, "/api/swap/enabled", strings.NewReader(`{"enabled":false}`)))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"enabled":false}` {
+		t.Fatalf("disable: %d %s", rec.Code, rec.Body.String())
+	}
+	if st := s.SnapshotState(); st.SwapEnabled || st.NextSwapAt != 0 {
+		t.Fatalf("after disable: swap_enabled=%v next_swap_at=%d", st.SwapEnabled, st.NextSwapAt)
+	}
+	select {
+	case cmd := <-admin.sendCh:
+		if e, ok := cmd.Payload.(protocol.SwapEnabledEvent); cmd.Cmd != protocol.CmdSwapEnabledChanged || !ok || e.Enabled {
+			t.Fatalf("admin event = %+v", cmd)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("admins not told about the change")
+	}
+
+	rec = httptest.NewRecorder()
+	s.apiPlayerSwap(rec, httptest.NewRequest(http.MethodPost, "/api/players/p1/swap", nil), "p1")
+	var resp map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["swapped"] != false || resp["reason"] != "swaps_disabled" {
+		t.Fatalf("player swap while disabled = %v", resp)
+	}
+	if err := s.performLuaSwap(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.performRandomSwapForPlayer("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if st := s.SnapshotState(); st.SwapCounter != 1 || st.Players["p1"].Game != "a.zip" {
+		t.Fatalf("swapped while disabled: counter=%d game=%q", st.SwapCounter, st.Players["p1"].Game)
+	}
+
+	for _, body := range []string{`{}`, `{"enabled":"yes"}`} {
+		rec = httptest.NewRecorder()
+		s.apiSwapEnabled(rec, httptest.NewRequest(http.MethodPost, "/api/swap/enabled", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d", body, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// maxTeamNameLen bounds Player.Team as set through the admin API.
+const maxTeamNameLen = 32
+
+var errGroupEmpty = errors.New("no players in group")
+
+// onTeam matches the players whose Team is group.
+func onTeam(group string) func(protocol.Player) bool {
+	return func(p protocol.Player) bool { return p.Team == group }
+}
+
+// groupMembers returns, sorted by name, the players on team group.
+func groupMembers(st *protocol.ServerState, group string) []string {
+	return playersWhere(st, onTeam(group))
+}
+
+// playersWhere returns, sorted by name, the players matching in.
+func playersWhere(st *protocol.ServerState, in func(protocol.Player) bool) []string {
+	var members []string
+	for name, p := range st.Players {
+		if in(p) {
+			members = append(members, name)
+		}
+	}
+	slices.Sort(members)
+	return members
+}
+
+// instancePoolWhere returns the instances a partial swap may deal: those
+// held by a player matching in and those nobody holds.
+func instancePoolWhere(st *protocol.ServerState, in func(protocol.Player) bool) []protocol.GameSwapInstance {
+	holder := map[string]protocol.Player{}
+	for _, p := range st.Players {
+		if p.InstanceID != "" {
+			holder[p.InstanceID] = p
+		}
+	}
+	var pool []protocol.GameSwapInstance
+	for _, inst := range st.GameSwapInstances {
+		if p, held := holder[inst.ID]; !held || in(p) {
+			pool = append(pool, inst)
+		}
+	}
+	return pool
+}
+
+// HandleGroupSwap deals the team's pool (its members' instances plus free
+// ones) among the members using the full-swap strategy. Other players keep
+// their instances. Members' saves are uploaded first.
+func (h *SaveModeHandler) HandleGroupSwap(group string) error {
+	return h.HandleSwapWhere("team "+group, onTeam(group))
+}
+
+// HandleSwapWhere is HandleGroupSwap for any set of players: the matching
+// players' instances plus free ones are dealt among them.
+func (h *SaveModeHandler) HandleSwapWhere(label string, in func(protocol.Player) bool) error {
+	if h.waitForFileCheck() {
+		return ErrSavesPending
+	}
+
+	var preventSame bool
+	var strategy protocol.SwapStrategy
+	var members []string
+	h.server.withRLock(func() {
+		preventSame = h.server.state.PreventSameGameSwap
+		strategy = h.server.state.SwapStrategy
+		members = playersWhere(&h.server.state, in)
+	})
+	if len(members) == 0 {
+		return errGroupEmpty
+	}
+
+	log.Printf("[SaveMode] Starting swap for %s (%d players, strategy=%s)", label, len(members), strategy)
+	for _, name := range members {
+		h.server.setPlayerFilePending(protocol.Player{Name: name})
+	}
+	h.server.RequestPendingSaves()
+	if h.server.WaitForPendingSaves(60 * time.Second) {
+		return fmt.Errorf("%w: timed out waiting for %s saves", ErrSavesPending, label)
+	}
+
+	var assigned, displaced []string
+	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		members := playersWhere(st, in)
+		pool := instancePoolWhere(st, in)
+		rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+
+		current := make(map[string]protocol.Player, len(members))
+		for _, name := range members {
+			p := st.Players[name]
+			current[name] = p
+			p.Game = ""
+			p.InstanceID = ""
+			st.Players[name] = p
+		}
+
+		dealt := h.assignInstances(members, current, pool, preventSame, strategy)
+		for _, name := range members {
+			idx, ok := dealt[name]
+			if !ok {
+				log.Printf("[SaveMode] Player %s has no available instances in %s", name, label)
+				continue
+			}
+			p := st.Players[name]
+			p.Game = pool[idx].Game
+			p.InstanceID = pool[idx].ID
+			st.Players[name] = p
+			assigned = append(assigned, name)
+			log.Printf("[SaveMode] Assigned instance %s (game %s) to player %s", p.InstanceID, p.Game, name)
+		}
+		// Repair duplicates before sending, as HandleSwap does.
+		displaced = repairDuplicateInstanceAssignments(st)
+		assigned = slices.DeleteFunc(assigned, func(name string) bool { return slices.Contains(displaced, name) })
+	})
+
+	for _, name := range assigned {
+		h.server.sendSwap(protocol.Player{Name: name}, SwapSendOptions{SkipSave: true, Preview: true})
+	}
+	h.server.reassignPlayers(displaced)
+	return nil
+}
+
+// performGroupSwap swaps the players on team group. Like a full swap it can
+// be reverted with POST /api/swap/undo.
+func (s *Server) performGroupSwap(group string) error {
+	mode, before := s.snapshotAssignments()
+	if err := s.GetGameModeHandler().HandleGroupSwap(group); err != nil {
+		return err
+	}
+	s.rememberSwapUndo(mode, before)
+	return nil
+}
+
+// apiSwapGroup handles POST /api/swap/group {group}: swaps only the players
+// on that team, in the background like /api/do_swap.
+func (s *Server) apiSwapGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b struct {
+		Group string `json:"group"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if b.Group == "" {
+		http.Error(w, "missing group", http.StatusBadRequest)
+		return
+	}
+	var members []string
+	s.withRLock(func() { members = groupMembers(&s.state, b.Group) })
+	if len(members) == 0 {
+		http.Error(w, errGroupEmpty.Error(), http.StatusNotFound)
+		return
+	}
+	go func() {
+		if err := s.performGroupSwap(b.Group); err != nil {
+			fmt.Printf("performGroupSwap error: %v\n", err)
+		}
+	}()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"result":  "ok",
+		"group":   b.Group,
+		"players": members,
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
+
+// apiPlayerTeam handles POST /api/players/{name}/team {team}; an empty team
+// removes the player from their group.
+func (s *Server) apiPlayerTeam(w http.ResponseWriter, r *http.Request, playerName string) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+		return
+	}
+	var b struct {
+		Team string `json:"team"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		writeAPIError(w, http.StatusBadRequest, errCodeBadJSON, "bad json: "+err.Error())
+		return
+	}
+	team := strings.TrimSpace(b.Team)
+	if len(team) > maxTeamNameLen {
+		writeAPIError(w, http.StatusBadRequest, errCodeInvalidValue, fmt.Sprintf("team must be at most %d characters", maxTeamNameLen))
+		return
+	}
+	var found bool
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		p, ok := st.Players[playerName]
+		if !ok {
+			return
+		}
+		found = true
+		p.Team = team
+		st.Players[playerName] = p
+	})
+	if !found {
+		writeAPIError(w, http.StatusNotFound, errCodePlayerNotFound, "player not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"result": "ok", "player": playerName, "team": team}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
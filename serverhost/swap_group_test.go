@@ -0,0 +1,157 @@
+package serverhost
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestSyncGroupSwapOnlyMovesTeam(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Games = []string{"a.zip", "b.zip"}
+		st.PreventSameGameSwap = true
+		st.Players = map[string]protocol.Player{
+			"alice": {Name: "alice", Game: "a.zip", Team: "red"},
+			"bob":   {Name: "bob", Game: "a.zip", Team: "red"},
+			"carol": {Name: "carol", Game: "a.zip", Team: "blue"},
+			"dave":  {Name: "dave", Game: "a.zip"},
+		}
+	})
+
+	if err := s.performGroupSwap("red"); err != nil {
+		t.Fatalf("group swap: %v", err)
+	}
+	want := map[string]string{"alice": "b.zip", "bob": "b.zip", "carol": "a.zip", "dave": "a.zip"}
+	for name, p := range s.SnapshotPlayers() {
+		if p.Game != want[name] {
+			t.Fatalf("%s on %s, want %s", name, p.Game, want[name])
+		}
+	}
+
+	// A returning red player joins the team's game rather than anyone's.
+	if got := s.GetGameModeHandler().GetPlayer("alice").Game; got != "b.zip" {
+		t.Fatalf("teammate join game %s, want b.zip", got)
+	}
+}
+
+func TestSaveGroupSwapStaysInTeamPool(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.SwapStrategy = protocol.SwapStrategyDerangement
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "i1", Game: "a.zip"},
+			{ID: "i2", Game: "b.zip"},
+			{ID: "i3", Game: "c.zip"},
+			{ID: "i4", Game: "d.zip"},
+		}
+		st.Players = map[string]protocol.Player{
+			"alice": {Name: "alice", Game: "a.zip", InstanceID: "i1", Team: "red"},
+			"bob":   {Name: "bob", Game: "b.zip", InstanceID: "i2", Team: "red"},
+			"carol": {Name: "carol", Game: "c.zip", InstanceID: "i3", Team: "blue"},
+		}
+	})
+
+	if err := s.performGroupSwap("red"); err != nil {
+		t.Fatalf("group swap: %v", err)
+	}
+	players := s.SnapshotPlayers()
+	if p := players["carol"]; p.InstanceID != "i3" {
+		t.Fatalf("carol moved to %s", p.InstanceID)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		switch id := players[name].InstanceID; id {
+		case "i1", "i2", "i4":
+		default:
+			t.Fatalf("%s dealt %q outside the red pool", name, id)
+		}
+	}
+	if players["alice"].InstanceID == "i1" || players["bob"].InstanceID == "i2" {
+		t.Fatalf("derangement kept a seat: %+v", players)
+	}
+
+	if err := s.performGroupSwap("green"); !errors.Is(err, errGroupEmpty) {
+		t.Fatalf("empty group: %v", err)
+	}
+}
+
+func TestSaveRandomSwapSkipsOtherTeams(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "i1", Game: "a.zip"},
+			{ID: "i2", Game: "b.zip"},
+			{ID: "i3", Game: "c.zip"},
+		}
+		st.Players = map[string]protocol.Player{
+			"alice": {Name: "alice", Game: "a.zip", InstanceID: "i1", Team: "red"},
+			"bob":   {Name: "bob", Game: "b.zip", InstanceID: "i2", Team: "red"},
+			"carol": {Name: "carol", Game: "c.zip", InstanceID: "i3", Team: "blue"},
+		}
+	})
+
+	for range 10 {
+		if err := s.GetGameModeHandler().HandleRandomSwapForPlayer("alice"); err != nil {
+			t.Fatalf("random swap: %v", err)
+		}
+		if p := s.SnapshotPlayers()["carol"]; p.InstanceID != "i3" {
+			t.Fatalf("red random swap moved carol to %s", p.InstanceID)
+		}
+	}
+}
+
+func TestAPISwapGroupAndPlayerTeam(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeManual
+		st.Players = map[string]protocol.Player{"alice": {Name: "alice"}}
+	})
+
+	team := func(name, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handlePlayerCompletedRoutes(rec, httptest.NewRequest(http.MethodPost, "/api/players/"+name+"/team", strings.NewReader(body)))
+		return rec
+	}
+	if rec := team("alice", `{"team":"`+strings.Repeat("x", maxTeamNameLen+1)+`"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("long team: status %d", rec.Code)
+	}
+	if rec := team("nobody", `{"team":"red"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown player: status %d", rec.Code)
+	}
+	if rec := team("alice", `{"team":" red "}`); rec.Code != http.StatusOK {
+		t.Fatalf("set team: status %d %s", rec.Code, rec.Body.String())
+	}
+	if p := s.SnapshotPlayers()["alice"]; p.Team != "red" {
+		t.Fatalf("team %q", p.Team)
+	}
+
+	group := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.apiSwapGroup(rec, httptest.NewRequest(http.MethodPost, "/api/swap/group", strings.NewReader(body)))
+		return rec
+	}
+	if rec := group(`{}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing group: status %d", rec.Code)
+	}
+	if rec := group(`{"group":"blue"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("empty group: status %d", rec.Code)
+	}
+	if rec := group(`{"group":"red"}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"players":["alice"]`) {
+		t.Fatalf("red group: status %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,256 @@
+package serverhost
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// swapHistoryFile is the append-only JSON-lines log of applied swaps.
+const swapHistoryFile = "./swap_history.jsonl"
+
+// swapHistoryCap bounds how many records are kept in memory for /api/history.
+const swapHistoryCap = 1000
+
+// swapHistoryFileMax is how many lines swap_history.jsonl may grow to before
+// it is rewritten with just the newest swapHistoryCap records.
+const swapHistoryFileMax = 2 * swapHistoryCap
+
+// SwapRecord is one applied swap: a player acknowledged loading ToGame.
+type SwapRecord struct {
+	Time         time.Time         `json:"time"`
+	Player       string            `json:"player"`
+	FromGame     string            `json:"from_game,omitempty"`
+	FromInstance string            `json:"from_instance_id,omitempty"`
+	ToGame       string            `json:"to_game"`
+	InstanceID   string            `json:"instance_id,omitempty"`
+	Mode         protocol.GameMode `json:"mode,omitempty"`
+}
+
+// swapHistory is a ring buffer of recent swaps plus a background writer that
+// appends them to swapHistoryFile. It has its own lock so recording never
+// waits on state persistence or disk I/O.
+type swapHistory struct {
+	mu        sync.Mutex
+	path      string
+	ring      []SwapRecord // oldest first, at most swapHistoryCap
+	last      map[string]SwapRecord
+	pending   []SwapRecord
+	fileLines int // records in the file at path, for compaction
+	flushCh   chan struct{}
+	start     sync.Once
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{} // closed when the writer has exited (or never started)
+}
+
+// newSwapHistory loads the tail of the log at path so history survives restarts.
+func newSwapHistory(path string) *swapHistory {
+	h := &swapHistory{
+		path:    path,
+		last:    map[string]SwapRecord{},
+		flushCh: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer func() { _ = f.Close() }()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec SwapRecord
+		if json.Unmarshal(sc.Bytes(), &rec) != nil {
+			continue
+		}
+		h.appendLocked(rec)
+		h.fileLines++
+	}
+	return h
+}
+
+func (h *swapHistory) appendLocked(rec SwapRecord) {
+	h.ring = append(h.ring, rec)
+	if len(h.ring) > swapHistoryCap {
+		h.ring = append(h.ring[:0:0], h.ring[len(h.ring)-swapHistoryCap:]...)
+	}
+	h.last[rec.Player] = rec
+}
+
+// record fills in the player's previous game and queues rec for the log file.
+func (h *swapHistory) record(rec SwapRecord) {
+	h.mu.Lock()
+	if prev, ok := h.last[rec.Player]; ok {
+		rec.FromGame = prev.ToGame
+		rec.FromInstance = prev.InstanceID
+	}
+	h.appendLocked(rec)
+	h.pending = append(h.pending, rec)
+	h.mu.Unlock()
+
+	h.start.Do(func() { go h.flushLoop() })
+	select {
+	case h.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+func (h *swapHistory) flushLoop() {
+	defer close(h.done)
+	for {
+		select {
+		case <-h.flushCh:
+		case <-h.stop:
+			if err := h.flush(); err != nil {
+				log.Printf("[history] write %s: %v", h.path, err)
+			}
+			return
+		}
+		if err := h.flush(); err != nil {
+			log.Printf("[history] write %s: %v", h.path, err)
+		}
+	}
+}
+
+// close stops the background writer after a final flush. Swaps recorded
+// afterwards are kept in memory only.
+func (h *swapHistory) close() {
+	h.stopOnce.Do(func() { close(h.stop) })
+	// If the writer never started, claim the start so it can't start later.
+	h.start.Do(func() { close(h.done) })
+	<-h.done
+}
+
+// flush appends pending records to the log file. Once the file would exceed
+// swapHistoryFileMax lines it is rewritten with the in-memory ring instead,
+// which already holds the pending records.
+func (h *swapHistory) flush() error {
+	h.mu.Lock()
+	batch := h.pending
+	h.pending = nil
+	var keep []SwapRecord
+	compact := h.fileLines+len(batch) > swapHistoryFileMax
+	if compact {
+		keep = slices.Clone(h.ring)
+		h.fileLines = len(keep)
+	} else {
+		h.fileLines += len(batch)
+	}
+	h.mu.Unlock()
+	if compact {
+		return writeSwapHistory(h.path, keep)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, rec := range batch {
+		if err := enc.Encode(rec); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// writeSwapHistory replaces the log at path with recs.
+func writeSwapHistory(path string, recs []SwapRecord) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, rec := range recs {
+		if err := enc.Encode(rec); err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmp)
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// page returns up to limit records newest first, skipping offset, and the total kept.
+func (h *swapHistory) page(offset, limit int) ([]SwapRecord, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	total := len(h.ring)
+	out := []SwapRecord{}
+	for i := total - 1 - offset; i >= 0 && len(out) < limit; i-- {
+		out = append(out, h.ring[i])
+	}
+	return out, total
+}
+
+// recordSwapHistory logs an acknowledged swap for player and reports it to admins.
+func (s *Server) recordSwapHistory(p protocol.Player) {
+	var mode protocol.GameMode
+	s.withRLock(func() { mode = s.state.Mode })
+	s.emitAdminEvent(protocol.CmdSwapPerformed, protocol.SwapEvent{
+		Player:     p.Name,
+		Game:       p.Game,
+		InstanceID: p.InstanceID,
+		Mode:       mode,
+	})
+	s.history.record(SwapRecord{
+		Time:       time.Now(),
+		Player:     p.Name,
+		ToGame:     p.Game,
+		InstanceID: p.InstanceID,
+		Mode:       mode,
+	})
+}
+
+// apiHistory handles GET /api/history?offset=N&limit=M (newest first).
+func (s *Server) apiHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	offset, limit := 0, 100
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > swapHistoryCap {
+			http.Error(w, fmt.Sprintf("limit must be 1-%d", swapHistoryCap), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	entries, total := s.history.page(offset, limit)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+		"entries": entries,
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
@@ -0,0 +1,126 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// waitForHistoryLines waits for the background writer to flush n records.
+func waitForHistoryLines(t *testing.T, path string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		if strings.Count(string(data), "\n") == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("history not flushed: %q", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSwapHistoryRecordsFromGameAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swap_history.jsonl")
+	h := newSwapHistory(path)
+	h.record(SwapRecord{Player: "amy", ToGame: "mario.nes", InstanceID: "i1", Mode: protocol.GameModeSave})
+	h.record(SwapRecord{Player: "amy", ToGame: "zelda.nes", InstanceID: "i2", Mode: protocol.GameModeSave})
+
+	entries, total := h.page(0, 10)
+	if total != 2 || len(entries) != 2 {
+		t.Fatalf("total=%d entries=%+v", total, entries)
+	}
+	if entries[0].ToGame != "zelda.nes" || entries[0].FromGame != "mario.nes" || entries[0].FromInstance != "i1" {
+		t.Fatalf("newest entry = %+v", entries[0])
+	}
+
+	waitForHistoryLines(t, path, 2)
+
+	reloaded := newSwapHistory(path)
+	reloaded.record(SwapRecord{Player: "amy", ToGame: "metroid.nes"})
+	if entries, total := reloaded.page(0, 1); total != 3 || entries[0].FromGame != "zelda.nes" {
+		t.Fatalf("reloaded total=%d newest=%+v", total, entries)
+	}
+}
+
+func TestSwapHistoryBounded(t *testing.T) {
+	h := newSwapHistory(filepath.Join(t.TempDir(), "swap_history.jsonl"))
+	for i := 0; i < swapHistoryCap+5; i++ {
+		h.mu.Lock()
+		h.appendLocked(SwapRecord{Player: "amy"})
+		h.mu.Unlock()
+	}
+	if _, total := h.page(0, 1); total != swapHistoryCap {
+		t.Fatalf("total = %d, want %d", total, swapHistoryCap)
+	}
+}
+
+func TestAPIHistoryPagination(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	// Absolute path so a late background flush can't land in the package dir.
+	path := filepath.Join(t.TempDir(), "swap_history.jsonl")
+	s.history = newSwapHistory(path)
+	for _, g := range []string{"a.nes", "b.nes", "c.nes"} {
+		s.recordSwapHistory(protocol.Player{Name: "amy", Game: g})
+	}
+	waitForHistoryLines(t, path, 3)
+
+	rec := httptest.NewRecorder()
+	s.apiHistory(rec, httptest.NewRequest(http.MethodGet, "/api/history?offset=1&limit=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", rec.Code, rec.Body)
+	}
+	var out struct {
+		Total   int          `json:"total"`
+		Entries []SwapRecord `json:"entries"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Total != 3 || len(out.Entries) != 1 || out.Entries[0].ToGame != "b.nes" {
+		t.Fatalf("unexpected page: %+v", out)
+	}
+
+	rec = httptest.NewRecorder()
+	s.apiHistory(rec, httptest.NewRequest(http.MethodGet, "/api/history?limit=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("limit=0 status %d", rec.Code)
+	}
+}
+
+func TestSwapHistoryCompactsFileAndStops(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swap_history.jsonl")
+	h := newSwapHistory(path)
+	for i := 0; i < swapHistoryFileMax+1; i++ {
+		h.record(SwapRecord{Player: "amy", ToGame: "a.nes"})
+	}
+	h.close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n > swapHistoryFileMax {
+		t.Fatalf("history file has %d lines, want at most %d", n, swapHistoryFileMax)
+	}
+	if _, total := newSwapHistory(path).page(0, 1); total != swapHistoryCap {
+		t.Fatalf("reloaded total = %d, want %d", total, swapHistoryCap)
+	}
+
+	// The writer has exited; later records stay in memory only.
+	h.record(SwapRecord{Player: "amy", ToGame: "b.nes"})
+	if entries, _ := h.page(0, 1); entries[0].ToGame != "b.nes" {
+		t.Fatalf("newest after close = %+v", entries[0])
+	}
+}
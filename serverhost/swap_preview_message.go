@@ -0,0 +1,105 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// maxSwapPreviewSecs caps the swap preview lead time.
+const maxSwapPreviewSecs = 30
+
+// gameDisplayName returns game's file name without folders or extension.
+func gameDisplayName(game string) string {
+	base := path.Base(game)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// sendSwapPreview shows p "Next up: <game>" and waits the swap_preview_secs
+// lead time before its swap is sent. When the player's save was already
+// collected, more play would be lost on swap, so the player is paused for the
+// lead and paused reports that the caller must resume it.
+func (s *Server) sendSwapPreview(p protocol.Player, saveCollected bool) (paused bool) {
+	var lead int
+	s.withRLock(func() { lead = s.state.SwapPreviewSecs })
+	if lead <= 0 || p.Game == "" {
+		return false
+	}
+	cmd := protocol.Command{
+		Cmd: protocol.CmdMessage,
+		Payload: map[string]any{
+			"message":  "Next up: " + gameDisplayName(p.Game),
+			"duration": lead,
+			"x":        countdownX,
+			"y":        countdownY,
+			"fontsize": countdownFontSize,
+			"fg":       countdownFG,
+			"bg":       countdownBG,
+		},
+		ID: fmt.Sprintf("swap-preview-%d-%s", time.Now().UnixNano(), p.Name),
+	}
+	if err := s.sendToPlayer(p, cmd); err != nil {
+		log.Printf("[swap] preview to %s: %v", p.Name, err)
+		return false
+	}
+	if saveCollected {
+		pause := protocol.Command{Cmd: protocol.CmdPause, ID: fmt.Sprintf("swap-preview-pause-%d-%s", time.Now().UnixNano(), p.Name)}
+		paused = s.sendToPlayer(p, pause) == nil
+	}
+	time.Sleep(time.Duration(lead) * time.Second)
+	return paused
+}
+
+// resumeAfterPreview undoes sendSwapPreview's pause unless the admin paused
+// the session in the meantime.
+func (s *Server) resumeAfterPreview(p protocol.Player) {
+	var sessionPaused bool
+	s.withRLock(func() { sessionPaused = s.state.Paused })
+	if sessionPaused {
+		return
+	}
+	cmd := protocol.Command{Cmd: protocol.CmdResume, ID: fmt.Sprintf("swap-preview-resume-%d-%s", time.Now().UnixNano(), p.Name)}
+	if err := s.sendToPlayer(p, cmd); err != nil {
+		log.Printf("[swap] resume %s after preview: %v", p.Name, err)
+	}
+}
+
+// apiSwapPreviewSecs: GET/POST /api/swap_preview_secs {swap_preview_secs}
+// How long players see "Next up: <game>" before full, random and rotate
+// swaps; 0 swaps instantly.
+func (s *Server) apiSwapPreviewSecs(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		var secs int
+		s.withRLock(func() { secs = s.state.SwapPreviewSecs })
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]int{"swap_preview_secs": secs}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b struct {
+		Secs int `json:"swap_preview_secs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if b.Secs < 0 || b.Secs > maxSwapPreviewSecs {
+		http.Error(w, fmt.Sprintf("swap_preview_secs must be 0-%d", maxSwapPreviewSecs), http.StatusBadRequest)
+		return
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { st.SwapPreviewSecs = b.Secs })
+	if _, err := w.Write([]byte("ok")); err != nil {
+		fmt.Printf("write response error: %v\n", err)
+	}
+}
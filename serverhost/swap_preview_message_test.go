@@ -0,0 +1,51 @@
+package serverhost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestSendSwapPreviewAnnouncesAndPauses(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	client := registerPlayerWSClient(s, "alice")
+	p := protocol.Player{Name: "alice", Connected: true, Game: "snes/Super Metroid (USA).sfc"}
+
+	if s.sendSwapPreview(p, true) || len(client.sendCh) != 0 {
+		t.Fatal("preview sent with swap_preview_secs unset")
+	}
+
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { st.SwapPreviewSecs = 1 })
+	start := time.Now()
+	if !s.sendSwapPreview(p, true) {
+		t.Fatal("player not paused while its collected save waits")
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Fatalf("swap sent after %s, want the 1s lead", waited)
+	}
+	msg := <-client.sendCh
+	if pl, _ := msg.Payload.(map[string]any); msg.Cmd != protocol.CmdMessage || pl["message"] != "Next up: Super Metroid (USA)" {
+		t.Fatalf("preview = %+v", msg)
+	}
+	if cmd := <-client.sendCh; cmd.Cmd != protocol.CmdPause {
+		t.Fatalf("after preview got %s, want pause", cmd.Cmd)
+	}
+	s.resumeAfterPreview(p)
+	if cmd := <-client.sendCh; cmd.Cmd != protocol.CmdResume {
+		t.Fatalf("after swap got %s, want resume", cmd.Cmd)
+	}
+
+	// A save taken at swap time (sync mode) needs no pause; a paused session stays paused.
+	if s.sendSwapPreview(p, false) {
+		t.Fatal("paused although the save is taken at swap time")
+	}
+	<-client.sendCh
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { st.Paused = true })
+	s.resumeAfterPreview(p)
+	if len(client.sendCh) != 0 {
+		t.Fatal("resumed a paused session")
+	}
+}
@@ -0,0 +1,132 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+var (
+	errRotateNotSaveMode = errors.New("rotate is only available in save mode")
+	errRotateTooFew      = errors.New("fewer than two players hold an unlocked instance")
+	errRotateBusy        = errors.New("saves are still being transferred")
+)
+
+// rotationSeats returns, sorted by name, the players holding an unlocked
+// instance. Players on a locked instance keep it through a rotation.
+func rotationSeats(st *protocol.ServerState) []string {
+	locked := map[string]bool{}
+	for _, inst := range st.GameSwapInstances {
+		if inst.Locked {
+			locked[inst.ID] = true
+		}
+	}
+	var seats []string
+	for name, p := range st.Players {
+		if p.InstanceID != "" && !locked[p.InstanceID] {
+			seats = append(seats, name)
+		}
+	}
+	slices.Sort(seats)
+	return seats
+}
+
+// HandleRotate moves every seat by one: each player in rotationSeats takes the
+// instance of the next player by name, and the last takes the first's.
+// Instances keep their games, so only the save states change hands, and unlike
+// HandleSwap every rotated player is guaranteed a different instance.
+// Completions are not consulted. Current saves are uploaded first, then each
+// rotated player is sent a swap that downloads their new instance's save.
+// It returns the rotated players.
+func (h *SaveModeHandler) HandleRotate() ([]string, error) {
+	if h.waitForFileCheck() {
+		return nil, errRotateBusy
+	}
+	seats := 0
+	h.server.withRLock(func() { seats = len(rotationSeats(&h.server.state)) })
+	if seats < 2 {
+		return nil, errRotateTooFew
+	}
+
+	h.server.SetPendingAllFiles()
+	h.server.RequestPendingSaves()
+	if h.server.WaitForPendingSaves(60 * time.Second) {
+		return nil, fmt.Errorf("%w: timed out waiting for player saves", errRotateBusy)
+	}
+
+	var rotated []string
+	var err error
+	h.server.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		seats := rotationSeats(st)
+		if len(seats) < 2 {
+			err = errRotateTooFew
+			return
+		}
+		first := st.Players[seats[0]]
+		for i, name := range seats {
+			next := first
+			if i+1 < len(seats) {
+				next = st.Players[seats[i+1]]
+			}
+			p := st.Players[name]
+			p.Game = next.Game
+			p.InstanceID = next.InstanceID
+			st.Players[name] = p
+			log.Printf("[SaveMode] Rotated instance %s (game %s) to player %s", p.InstanceID, p.Game, name)
+		}
+		rotated = seats
+		if err := validateNoDuplicateInstanceAssignments(st); err != nil {
+			log.Printf("[SaveMode] WARNING: State validation failed after rotate: %v", err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range rotated {
+		h.server.sendSwap(protocol.Player{Name: name}, SwapSendOptions{SkipSave: true, Preview: true})
+	}
+	return rotated, nil
+}
+
+// apiSwapRotate handles POST /api/swap/rotate: in save mode, passes every
+// player's instance to the next player. The rotation can be reverted with
+// POST /api/swap/undo like a full swap.
+func (s *Server) apiSwapRotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h, ok := s.GetGameModeHandler().(*SaveModeHandler)
+	if !ok {
+		http.Error(w, errRotateNotSaveMode.Error(), http.StatusConflict)
+		return
+	}
+	mode, before := s.snapshotAssignments()
+	rotated, err := h.HandleRotate()
+	if err != nil {
+		switch {
+		case errors.Is(err, errRotateTooFew), errors.Is(err, errRotateBusy):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	s.rememberSwapUndo(mode, before)
+	s.resetSkipVotes()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"result":  "ok",
+		"rotated": rotated,
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
@@ -0,0 +1,58 @@
+package serverhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestAPISwapRotateMovesEverySeat(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "i1", Game: "a.zip"},
+			{ID: "i2", Game: "b.zip"},
+			{ID: "i3", Game: "a.zip"},
+			{ID: "i4", Game: "c.zip", Locked: true},
+		}
+		st.Players = map[string]protocol.Player{
+			"alice": {Name: "alice", Game: "a.zip", InstanceID: "i1"},
+			"bob":   {Name: "bob", Game: "b.zip", InstanceID: "i2"},
+			"carol": {Name: "carol", Game: "a.zip", InstanceID: "i3"},
+			"dave":  {Name: "dave", Game: "c.zip", InstanceID: "i4"},
+			"erin":  {Name: "erin"},
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	s.apiSwapRotate(rec, httptest.NewRequest(http.MethodPost, "/api/swap/rotate", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	want := map[string]string{"alice": "i2", "bob": "i3", "carol": "i1", "dave": "i4", "erin": ""}
+	games := map[string]string{"i1": "a.zip", "i2": "b.zip", "i3": "a.zip", "i4": "c.zip"}
+	for name, p := range s.SnapshotPlayers() {
+		if p.InstanceID != want[name] || p.Game != games[p.InstanceID] {
+			t.Fatalf("%s on %s/%s, want %s", name, p.InstanceID, p.Game, want[name])
+		}
+	}
+
+	if _, err := s.undoLastSwap(); err != nil {
+		t.Fatalf("undo rotate: %v", err)
+	}
+	if p := s.SnapshotPlayers()["alice"]; p.InstanceID != "i1" {
+		t.Fatalf("undo left alice on %s", p.InstanceID)
+	}
+
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { st.Mode = protocol.GameModeSync })
+	rec = httptest.NewRecorder()
+	s.apiSwapRotate(rec, httptest.NewRequest(http.MethodPost, "/api/swap/rotate", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("sync mode: status %d, want 409", rec.Code)
+	}
+}
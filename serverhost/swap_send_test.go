@@ -1,9 +1,11 @@
 package serverhost
 
 import (
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/michael4d45/bizshuffle/protocol"
 )
 
@@ -63,3 +65,33 @@ func TestClearAppliedSwapAllowsResend(t *testing.T) {
 		t.Fatal("expected swap after clear")
 	}
 }
+
+func TestSendAndWaitReturnsWhenPlayerDisconnects(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	client := registerPlayerWSClient(s, "bob")
+	var conn *websocket.Conn // handleWS registers each client under its conn
+	s.withConnLock(func() { s.conns[conn] = client })
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Players["bob"] = protocol.Player{Name: "bob", Connected: true}
+	})
+	go func() {
+		<-client.sendCh
+		s.removeWSClient(conn, client)
+	}()
+
+	start := time.Now()
+	_, err := s.sendAndWait(s.currentPlayer("bob"), protocol.Command{Cmd: protocol.CmdPause, ID: "pause-1"}, 20*time.Second)
+	if !errors.Is(err, ErrPlayerDisconnected) {
+		t.Fatalf("err %v, want ErrPlayerDisconnected", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Fatalf("sendAndWait took %s after disconnect", waited)
+	}
+	s.withRLock(func() {
+		if len(s.pending) != 0 {
+			t.Fatalf("pending not cleared: %d", len(s.pending))
+		}
+	})
+}
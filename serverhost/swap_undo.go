@@ -0,0 +1,206 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+var (
+	errNoSwapToUndo      = errors.New("no swap to undo")
+	errUndoModeChanged   = errors.New("game mode changed since the last swap")
+	errUndoUnrecoverable = errors.New("swap cannot be undone")
+	errUndoBusy          = errors.New("saves are still being transferred")
+)
+
+// swapAssignment is a player's game/instance at one point in time.
+type swapAssignment struct {
+	Game       string
+	InstanceID string
+}
+
+// swapUndo is the player -> game/instance mapping captured before the most
+// recent full swap. hadSave records which of those instances had a save on
+// disk right after the swap uploaded them; undo refuses if one has vanished.
+type swapUndo struct {
+	mode    protocol.GameMode
+	before  map[string]swapAssignment
+	hadSave map[string]bool
+}
+
+// snapshotAssignments copies every player's current game/instance.
+func (s *Server) snapshotAssignments() (protocol.GameMode, map[string]swapAssignment) {
+	out := map[string]swapAssignment{}
+	var mode protocol.GameMode
+	s.withRLock(func() {
+		mode = s.state.Mode
+		for name, p := range s.state.Players {
+			out[name] = swapAssignment{Game: p.Game, InstanceID: p.InstanceID}
+		}
+	})
+	return mode, out
+}
+
+// rememberSwapUndo stores before as the undo point when the swap that just
+// ran actually changed an assignment. A swap that bailed out early leaves the
+// previous undo point alone.
+func (s *Server) rememberSwapUndo(mode protocol.GameMode, before map[string]swapAssignment) {
+	_, after := s.snapshotAssignments()
+	if maps.Equal(before, after) {
+		return
+	}
+	hadSave := map[string]bool{}
+	for _, a := range before {
+		if a.InstanceID != "" {
+			hadSave[a.InstanceID] = instanceSaveExists(a.InstanceID)
+		}
+	}
+	s.withLock(func() {
+		s.swapUndo = &swapUndo{mode: mode, before: before, hadSave: hadSave}
+	})
+}
+
+func instanceSaveExists(instanceID string) bool {
+	_, err := os.Stat(filepath.Join("./saves", instanceID+".state"))
+	return err == nil
+}
+
+// checkUndoRecoverable reports why u can no longer be applied, or nil.
+func (s *Server) checkUndoRecoverable(u *swapUndo) error {
+	var mode protocol.GameMode
+	instances := map[string]protocol.GameSwapInstance{}
+	holders := map[string]string{}
+	s.withRLock(func() {
+		mode = s.state.Mode
+		for _, inst := range s.state.GameSwapInstances {
+			instances[inst.ID] = inst
+		}
+		for name, p := range s.state.Players {
+			if _, ok := u.before[name]; !ok && p.InstanceID != "" {
+				holders[p.InstanceID] = name
+			}
+		}
+	})
+	if mode != u.mode {
+		return errUndoModeChanged
+	}
+	if mode != protocol.GameModeSave {
+		return nil
+	}
+	for _, a := range u.before {
+		if a.InstanceID == "" {
+			continue
+		}
+		inst, ok := instances[a.InstanceID]
+		if !ok {
+			return fmt.Errorf("%w: instance %s was removed", errUndoUnrecoverable, a.InstanceID)
+		}
+		if inst.Game != a.Game {
+			return fmt.Errorf("%w: instance %s now runs %s", errUndoUnrecoverable, a.InstanceID, inst.Game)
+		}
+		if u.hadSave[a.InstanceID] && !instanceSaveExists(a.InstanceID) {
+			return fmt.Errorf("%w: save for instance %s was deleted", errUndoUnrecoverable, a.InstanceID)
+		}
+		if other, ok := holders[a.InstanceID]; ok {
+			return fmt.Errorf("%w: instance %s is now held by %s, who joined after the swap", errUndoUnrecoverable, a.InstanceID, other)
+		}
+	}
+	return nil
+}
+
+// undoLastSwap restores the assignments captured before the most recent full
+// swap and re-sends swap to every player whose target changed. In save mode
+// the current saves are uploaded first so each instance carries the latest
+// progress back to its previous player. It returns the restored players.
+func (s *Server) undoLastSwap() ([]string, error) {
+	var u *swapUndo
+	s.withRLock(func() { u = s.swapUndo })
+	if u == nil {
+		return nil, errNoSwapToUndo
+	}
+	if err := s.checkUndoRecoverable(u); err != nil {
+		return nil, err
+	}
+
+	saveMode := u.mode == protocol.GameModeSave
+	if saveMode {
+		h := &SaveModeHandler{server: s}
+		if h.waitForFileCheck() {
+			return nil, errUndoBusy
+		}
+		s.SetPendingAllFiles()
+		s.RequestPendingSaves()
+		if s.WaitForPendingSaves(60 * time.Second) {
+			return nil, fmt.Errorf("%w: timed out waiting for player saves", errUndoBusy)
+		}
+		// Uploads may have raced an admin deleting saves or instances.
+		if err := s.checkUndoRecoverable(u); err != nil {
+			return nil, err
+		}
+	}
+
+	var restored []string
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		for name, a := range u.before {
+			p, ok := st.Players[name]
+			if !ok || (p.Game == a.Game && p.InstanceID == a.InstanceID) {
+				continue
+			}
+			p.Game = a.Game
+			p.InstanceID = a.InstanceID
+			st.Players[name] = p
+			restored = append(restored, name)
+		}
+		if err := validateNoDuplicateInstanceAssignments(st); err != nil {
+			log.Printf("[undo] WARNING: State validation failed after undo: %v", err)
+		}
+	})
+	s.withLock(func() {
+		if s.swapUndo == u {
+			s.swapUndo = nil
+		}
+	})
+	slices.Sort(restored)
+	log.Printf("[undo] restored pre-swap assignments for %v", restored)
+
+	for _, name := range restored {
+		s.sendSwap(protocol.Player{Name: name}, SwapSendOptions{SkipSave: saveMode})
+	}
+	return restored, nil
+}
+
+// apiSwapUndo handles POST /api/swap/undo: reverts every player to the
+// game/instance they had before the most recent full swap.
+func (s *Server) apiSwapUndo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	restored, err := s.undoLastSwap()
+	if err != nil {
+		switch {
+		case errors.Is(err, errNoSwapToUndo), errors.Is(err, errUndoModeChanged),
+			errors.Is(err, errUndoUnrecoverable), errors.Is(err, errUndoBusy):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"result":   "ok",
+		"restored": append([]string{}, restored...),
+	}); err != nil {
+		fmt.Printf("encode response error: %v\n", err)
+	}
+}
@@ -0,0 +1,87 @@
+package serverhost
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestUndoLastSwapRestoresSyncAssignments(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Games = []string{"a.zip", "b.zip"}
+		st.PreventSameGameSwap = true
+		st.Players = map[string]protocol.Player{
+			"alice": {Name: "alice", Game: "a.zip"},
+			"bob":   {Name: "bob", Game: "a.zip"},
+		}
+	})
+
+	if _, err := s.undoLastSwap(); !errors.Is(err, errNoSwapToUndo) {
+		t.Fatalf("undo before any swap: err = %v", err)
+	}
+	if err := s.performSwap(); err != nil {
+		t.Fatal(err)
+	}
+	if g := s.SnapshotState().Players["alice"].Game; g != "b.zip" {
+		t.Fatalf("swap left alice on %q", g)
+	}
+
+	restored, err := s.undoLastSwap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 2 {
+		t.Fatalf("restored = %v", restored)
+	}
+	for name, p := range s.SnapshotState().Players {
+		if p.Game != "a.zip" {
+			t.Fatalf("%s on %q after undo", name, p.Game)
+		}
+	}
+	if _, err := s.undoLastSwap(); !errors.Is(err, errNoSwapToUndo) {
+		t.Fatalf("second undo: err = %v", err)
+	}
+}
+
+func TestUndoLastSwapRefusesWhenSaveDeleted(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.GameSwapInstances = []protocol.GameSwapInstance{
+			{ID: "i1", Game: "a.zip", FileState: protocol.FileStateReady},
+			{ID: "i2", Game: "b.zip", FileState: protocol.FileStateReady},
+		}
+		st.Players = map[string]protocol.Player{
+			"alice": {Name: "alice", Game: "b.zip", InstanceID: "i2"},
+		}
+	})
+	if err := os.MkdirAll("saves", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	save := filepath.Join("saves", "i1.state")
+	if err := os.WriteFile(save, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.rememberSwapUndo(protocol.GameModeSave, map[string]swapAssignment{
+		"alice": {Game: "a.zip", InstanceID: "i1"},
+	})
+	if err := os.Remove(save); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.undoLastSwap(); !errors.Is(err, errUndoUnrecoverable) {
+		t.Fatalf("err = %v, want errUndoUnrecoverable", err)
+	}
+	if p := s.SnapshotState().Players["alice"]; p.InstanceID != "i2" {
+		t.Fatalf("refused undo still moved alice to %q", p.InstanceID)
+	}
+}
@@ -1,5 +1,7 @@
 package serverhost
 
+import "time"
+
 // PendingCommandCount returns in-flight WS commands awaiting client ack (tests).
 func (s *Server) PendingCommandCount() int {
 	var n int
@@ -13,3 +15,18 @@ func (s *Server) PendingInstanceCount() int {
 	s.withRLock(func() { n = s.pendingInstancecount })
 	return n
 }
+
+// StopStateSaver cancels the debounced state save and stops the swap history
+// writer (tests), so neither can write after the test has left its data
+// directory.
+func (s *Server) StopStateSaver() {
+	s.history.close()
+	for deadline := time.Now().Add(time.Second); len(s.saveChan) > 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.saveMutex.Lock()
+	defer s.saveMutex.Unlock()
+	if s.saveTimer != nil {
+		s.saveTimer.Stop()
+	}
+}
@@ -0,0 +1,67 @@
+package serverhost
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// SetTLSFiles sets (or clears, when both are empty) the PEM certificate and
+// key used to serve HTTPS, and persists them.
+func (s *Server) SetTLSFiles(certFile, keyFile string) {
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.CertFile = certFile
+		st.KeyFile = keyFile
+	})
+}
+
+// SetTLS turns HTTPS on or off and persists it. With TLS on and no
+// certificate configured, Serve uses a generated self-signed one.
+func (s *Server) SetTLS(enabled bool) {
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.TLS = enabled
+	})
+}
+
+// TLSFiles returns the configured certificate and key paths.
+func (s *Server) TLSFiles() (certFile, keyFile string) {
+	s.withRLock(func() {
+		certFile, keyFile = s.state.CertFile, s.state.KeyFile
+	})
+	return certFile, keyFile
+}
+
+// Scheme returns "https" when TLS is enabled or a certificate and key are
+// configured, otherwise "http".
+func (s *Server) Scheme() string {
+	var enabled bool
+	s.withRLock(func() { enabled = s.state.TLS })
+	if certFile, keyFile := s.TLSFiles(); enabled || (certFile != "" && keyFile != "") {
+		return "https"
+	}
+	return "http"
+}
+
+// Serve serves srv on ln, with TLS when a certificate and key are configured
+// or TLS is enabled (falling back to a self-signed certificate). Setting only
+// one of the two files is an error rather than a silent fallback to HTTP.
+func (s *Server) Serve(srv *http.Server, ln net.Listener) error {
+	certFile, keyFile := s.TLSFiles()
+	switch {
+	case certFile != "" && keyFile != "":
+		return srv.ServeTLS(ln, certFile, keyFile)
+	case certFile != "" || keyFile != "":
+		_ = ln.Close()
+		return errors.New("cert_file and key_file must be set together")
+	case s.Scheme() == "https":
+		certFile, keyFile, err := EnsureSelfSignedCert(selfSignedCertDir, s.PersistedHost())
+		if err != nil {
+			_ = ln.Close()
+			return err
+		}
+		return srv.ServeTLS(ln, certFile, keyFile)
+	}
+	return srv.Serve(ln)
+}
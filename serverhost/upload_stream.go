@@ -0,0 +1,137 @@
+package serverhost
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// uploadTmpDir holds uploads while they stream in; it sits beside roms/ and
+	// saves/ so finished uploads are moved into place with a rename.
+	uploadTmpDir = "./.uploads"
+	// uploadFieldMaxBytes caps each non-file form field of a streamed upload.
+	uploadFieldMaxBytes = 64 << 10
+)
+
+var (
+	errUploadTooLarge    = errors.New("file too large")
+	errUploadFileMissing = errors.New("file part missing")
+)
+
+// multipartUpload is a multipart body read by readMultipartUpload: the file
+// part spooled to a temp file, plus the text fields.
+type multipartUpload struct {
+	File     *os.File // temp file holding the file part, positioned at its start
+	Filename string   // client-supplied filename of the file part
+	Size     int64
+	fields   map[string]string
+}
+
+// FormValue returns a text field of the upload, like http.Request.FormValue.
+func (u *multipartUpload) FormValue(name string) string {
+	return u.fields[name]
+}
+
+// Keep closes the temp file and moves it to dst, replacing any existing file.
+func (u *multipartUpload) Keep(dst string) error {
+	if err := u.File.Close(); err != nil {
+		return err
+	}
+	// CreateTemp makes 0600 files; served files need to stay world-readable.
+	if err := os.Chmod(u.File.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(u.File.Name(), dst)
+}
+
+// Close discards the temp file; it is a no-op after Keep.
+func (u *multipartUpload) Close() {
+	_ = u.File.Close()
+	_ = os.Remove(u.File.Name())
+}
+
+// readMultipartUpload streams r's multipart body with r.MultipartReader, so
+// the file part goes straight to disk instead of through ParseMultipartForm's
+// in-memory buffer. The part named fileField is copied to a temp file under
+// uploadTmpDir, at most maxBytes of it when maxBytes > 0 (errUploadTooLarge
+// otherwise); every other part is read as a text field. Fields may come
+// before or after the file. The caller must Close or Keep the result.
+func readMultipartUpload(r *http.Request, fileField string, maxBytes int64) (*multipartUpload, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	u := &multipartUpload{fields: map[string]string{}}
+	fail := func(err error) (*multipartUpload, error) {
+		if u.File != nil {
+			u.Close()
+		}
+		return nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(err)
+		}
+		name := part.FormName()
+		if name == fileField && u.File == nil {
+			if err := os.MkdirAll(uploadTmpDir, 0755); err != nil {
+				return fail(err)
+			}
+			f, err := os.CreateTemp(uploadTmpDir, "upload-*")
+			if err != nil {
+				return fail(err)
+			}
+			u.File = f
+			u.Filename = part.FileName()
+			src := io.Reader(part)
+			if maxBytes > 0 {
+				src = io.LimitReader(part, maxBytes+1)
+			}
+			n, err := io.Copy(f, src)
+			if err != nil {
+				return fail(err)
+			}
+			if maxBytes > 0 && n > maxBytes {
+				return fail(errUploadTooLarge)
+			}
+			u.Size = n
+			continue
+		}
+		value, err := io.ReadAll(io.LimitReader(part, uploadFieldMaxBytes+1))
+		if err != nil {
+			return fail(err)
+		}
+		if len(value) > uploadFieldMaxBytes {
+			return fail(fmt.Errorf("form field %q too large", name))
+		}
+		u.fields[name] = strings.TrimRight(string(value), "\r\n")
+	}
+	if u.File == nil {
+		return nil, fmt.Errorf("%q: %w", fileField, errUploadFileMissing)
+	}
+	if _, err := u.File.Seek(0, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	return u, nil
+}
+
+// writeUploadError reports a readMultipartUpload error with the status the
+// buffered handlers used.
+func writeUploadError(w http.ResponseWriter, err error, missingMsg string) {
+	switch {
+	case errors.Is(err, errUploadTooLarge):
+		http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errUploadFileMissing):
+		http.Error(w, missingMsg+": "+err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "parse multipart: "+err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,156 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// voteSkipState tallies skip votes for the game everyone is playing in sync
+// mode. It is transient: never persisted and reset after every successful swap.
+// swapping is set while a vote-triggered swap runs so further votes don't
+// start a second one; a failed swap clears it and keeps the votes.
+type voteSkipState struct {
+	game     string
+	voters   map[string]struct{}
+	swapping bool
+}
+
+// skipVotesNeeded returns how many of connected players must vote to skip;
+// percent <= 0 means a simple majority.
+func skipVotesNeeded(connected, percent int) int {
+	if connected <= 0 {
+		return 1
+	}
+	if percent <= 0 || percent > 100 {
+		return connected/2 + 1
+	}
+	needed := (connected*percent + 99) / 100
+	if needed < 1 {
+		needed = 1
+	}
+	return needed
+}
+
+// resetSkipVotes clears the tally; called after every successful swap.
+func (s *Server) resetSkipVotes() {
+	s.withLock(func() { s.voteSkip = voteSkipState{} })
+}
+
+// handleVoteSkip records player's vote against the current sync-mode game,
+// broadcasts the tally and swaps once enough connected players have voted.
+func (s *Server) handleVoteSkip(player string) {
+	var mode protocol.GameMode
+	var running bool
+	var percent, connected int
+	s.withRLock(func() {
+		mode = s.state.Mode
+		running = s.state.Running
+		percent = s.state.VoteSkipPercent
+		for _, p := range s.state.Players {
+			if p.Connected {
+				connected++
+			}
+		}
+	})
+	if mode != protocol.GameModeSync {
+		log.Printf("vote skip from %s ignored: mode is %q", player, mode)
+		return
+	}
+	if !running {
+		log.Printf("vote skip from %s ignored: session not running", player)
+		return
+	}
+	game := (&SyncModeHandler{server: s}).getCurrentGame()
+	if game == "" {
+		log.Printf("vote skip from %s ignored: no current game", player)
+		return
+	}
+
+	needed := skipVotesNeeded(connected, percent)
+	var votes int
+	var passed bool
+	s.withLock(func() {
+		if s.voteSkip.game != game || s.voteSkip.voters == nil {
+			s.voteSkip = voteSkipState{game: game, voters: map[string]struct{}{}}
+		}
+		s.voteSkip.voters[player] = struct{}{}
+		votes = len(s.voteSkip.voters)
+		if votes >= needed && !s.voteSkip.swapping {
+			passed = true
+			s.voteSkip.swapping = true
+		}
+	})
+
+	log.Printf("vote skip: %s voted to skip %s (%d/%d, need %d)", player, game, votes, connected, needed)
+	s.sendMessage(fmt.Sprintf("%d/%d voted to skip", votes, connected), 5, 10, 30, 12, "#FFFFFF", "#000000")
+	if !passed {
+		return
+	}
+	// Swap off the websocket read loop so acks from this player keep flowing.
+	// performSwap resets the tally only once the swap succeeds.
+	go func() {
+		if err := s.performSwap(); err != nil {
+			fmt.Printf("performSwap error: %v\n", err)
+			s.withLock(func() {
+				if s.voteSkip.game == game {
+					s.voteSkip.swapping = false
+				}
+			})
+		}
+	}()
+}
+
+// apiVoteSkip reads (GET) the vote threshold and current tally, or sets
+// (POST {"vote_skip_percent": n}) the threshold; 0 means a simple majority.
+func (s *Server) apiVoteSkip(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		var percent, votes, connected int
+		var game string
+		s.withRLock(func() {
+			percent = s.state.VoteSkipPercent
+			game = s.voteSkip.game
+			votes = len(s.voteSkip.voters)
+			for _, p := range s.state.Players {
+				if p.Connected {
+					connected++
+				}
+			}
+		})
+		resp := map[string]any{
+			"vote_skip_percent": percent,
+			"game":              game,
+			"votes":             votes,
+			"needed":            skipVotesNeeded(connected, percent),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method == http.MethodPost {
+		var b struct {
+			VoteSkipPercent int `json:"vote_skip_percent"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if b.VoteSkipPercent < 0 || b.VoteSkipPercent > 100 {
+			http.Error(w, "vote_skip_percent must be between 0 and 100", http.StatusBadRequest)
+			return
+		}
+		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			st.VoteSkipPercent = b.VoteSkipPercent
+		})
+		if _, err := w.Write([]byte("ok")); err != nil {
+			fmt.Printf("write response error: %v\n", err)
+		}
+		return
+	}
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,122 @@
+package serverhost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestSkipVotesNeeded(t *testing.T) {
+	cases := []struct {
+		connected, percent, want int
+	}{
+		{connected: 5, percent: 0, want: 3},
+		{connected: 4, percent: 0, want: 3},
+		{connected: 1, percent: 0, want: 1},
+		{connected: 0, percent: 0, want: 1},
+		{connected: 5, percent: 40, want: 2},
+		{connected: 4, percent: 50, want: 2},
+		{connected: 3, percent: 100, want: 3},
+	}
+	for _, c := range cases {
+		if got := skipVotesNeeded(c.connected, c.percent); got != c.want {
+			t.Errorf("skipVotesNeeded(%d, %d) = %d, want %d", c.connected, c.percent, got, c.want)
+		}
+	}
+}
+
+func TestVoteSkipSwapsOnMajority(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Running = true
+		st.SwapEnabled = false
+		st.Games = []string{"a.zip", "b.zip"}
+		for _, name := range []string{"p1", "p2", "p3"} {
+			st.Players[name] = protocol.Player{Name: name, Game: "a.zip", Connected: true}
+		}
+	})
+	p1 := registerPlayerWSClient(s, "p1")
+
+	s.handleVoteSkip("p1")
+	s.handleVoteSkip("p1")
+	var votes int
+	s.withRLock(func() { votes = len(s.voteSkip.voters) })
+	if votes != 1 {
+		t.Fatalf("repeat vote counted twice: %d votes", votes)
+	}
+	select {
+	case cmd := <-p1.sendCh:
+		if msg, _ := cmd.Payload.(map[string]any)["message"].(string); cmd.Cmd != protocol.CmdMessage || msg != "1/3 voted to skip" {
+			t.Fatalf("unexpected tally broadcast: %+v", cmd)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("tally message not broadcast")
+	}
+
+	s.handleVoteSkip("p2")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var counter int64
+		s.withRLock(func() { counter, votes = s.state.SwapCounter, len(s.voteSkip.voters) })
+		if counter > 0 && votes == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("majority vote did not swap and reset the tally: counter %d, %d votes", counter, votes)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestVoteSkipIgnoredOutsideSyncMode(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSave
+		st.Running = true
+		st.Players["p1"] = protocol.Player{Name: "p1", Game: "a.zip", Connected: true}
+	})
+	s.handleVoteSkip("p1")
+	var votes int
+	s.withRLock(func() { votes = len(s.voteSkip.voters) })
+	if votes != 0 {
+		t.Fatalf("vote counted in save mode: %d", votes)
+	}
+}
+
+func TestVoteSkipKeepsVotesWhenSwapFails(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Mode = protocol.GameModeSync
+		st.Running = true
+		st.SwapEnabled = false
+		// No games left in rotation, so the triggered swap fails.
+		st.Games = nil
+		st.Players["p1"] = protocol.Player{Name: "p1", Game: "a.zip", Connected: true}
+	})
+
+	s.handleVoteSkip("p1")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var votes int
+		var swapping bool
+		s.withRLock(func() { votes, swapping = len(s.voteSkip.voters), s.voteSkip.swapping })
+		if !swapping {
+			if votes != 1 {
+				t.Fatalf("failed swap reset the tally: %d votes", votes)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("vote-triggered swap never finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,207 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+// waitingPlayer is a player hello held back while MaxPlayers are connected.
+type waitingPlayer struct {
+	name         string
+	client       *wsClient
+	bizhawkReady bool
+}
+
+// claimPlayerSlot registers client as player name unless MaxPlayers other
+// players are already connected. When the session is full it returns false
+// and, unless RejectWhenFull is set, queues the client on the waitlist;
+// position is its 1-based place there, or 0 when the hello is rejected.
+func (s *Server) claimPlayerSlot(c *websocket.Conn, client *wsClient, name string, bizhawkReady bool) (admitted bool, position int) {
+	var limit int
+	var reject bool
+	s.withRLock(func() {
+		limit = s.state.MaxPlayers
+		reject = s.state.RejectWhenFull
+	})
+	s.withConnLock(func() {
+		_, reconnect := s.playerClients[name]
+		if limit <= 0 || reconnect || len(s.playerClients) < limit {
+			s.conns[c] = client
+			s.playerClients[name] = client
+			admitted = true
+			return
+		}
+		if reject {
+			return
+		}
+		wp := waitingPlayer{name: name, client: client, bizhawkReady: bizhawkReady}
+		for i, w := range s.waitlist {
+			if w.name == name {
+				s.waitlist[i] = wp
+				position = i + 1
+				return
+			}
+		}
+		s.waitlist = append(s.waitlist, wp)
+		position = len(s.waitlist)
+	})
+	if position > 0 {
+		s.syncWaitlist()
+		s.sendWaitlistPosition(client, name, position)
+	}
+	return admitted, position
+}
+
+// setWaitlistReady records a waiting client's BizHawk readiness so it is
+// carried over when the client is promoted.
+func (s *Server) setWaitlistReady(client *wsClient, ready bool) {
+	s.withConnLock(func() {
+		for i := range s.waitlist {
+			if s.waitlist[i].client == client {
+				s.waitlist[i].bizhawkReady = ready
+			}
+		}
+	})
+}
+
+// removeWaitingLocked drops client from the waitlist and reports whether it
+// was there. Caller must hold connMu.
+func (s *Server) removeWaitingLocked(client *wsClient) bool {
+	for i, w := range s.waitlist {
+		if w.client == client {
+			s.waitlist = append(s.waitlist[:i], s.waitlist[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// syncWaitlist mirrors the waitlist's names into ServerState.Waitlist.
+func (s *Server) syncWaitlist() {
+	var names []string
+	s.withConnRLock(func() {
+		for _, w := range s.waitlist {
+			names = append(names, w.name)
+		}
+	})
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.Waitlist = names
+	})
+}
+
+// promoteWaitlist admits waiting players, first come first served, while
+// slots are free under MaxPlayers. It runs when a player disconnects and when
+// the limit changes.
+func (s *Server) promoteWaitlist() {
+	var limit int
+	s.withRLock(func() { limit = s.state.MaxPlayers })
+	var promoted []waitingPlayer
+	changed := false
+	s.withConnLock(func() {
+		for len(s.waitlist) > 0 && (limit <= 0 || len(s.playerClients) < limit) {
+			wp := s.waitlist[0]
+			s.waitlist = s.waitlist[1:]
+			changed = true
+			if _, taken := s.playerClients[wp.name]; taken {
+				// The name reconnected on another socket while this one waited.
+				continue
+			}
+			s.playerClients[wp.name] = wp.client
+			promoted = append(promoted, wp)
+		}
+	})
+	if !changed {
+		return
+	}
+	s.syncWaitlist()
+	for _, wp := range promoted {
+		log.Printf("Promoted %q from the waitlist", wp.name)
+		s.admitPlayer(wp.name, wp.bizhawkReady)
+	}
+	var remaining []waitingPlayer
+	s.withConnRLock(func() { remaining = append(remaining, s.waitlist...) })
+	for i, wp := range remaining {
+		s.sendWaitlistPosition(wp.client, wp.name, i+1)
+	}
+}
+
+// sendWaitlistPosition tells a waiting client where it is in the queue.
+func (s *Server) sendWaitlistPosition(client *wsClient, name string, position int) {
+	cmd := protocol.Command{
+		Cmd: protocol.CmdMessage,
+		Payload: map[string]any{
+			"message":  fmt.Sprintf("Session is full; you are #%d on the waitlist", position),
+			"duration": 10,
+			"x":        10,
+			"y":        10,
+			"fontsize": 12,
+			"fg":       "#FFFFFF",
+			"bg":       "#000000",
+		},
+		ID: fmt.Sprintf("waitlist-%d-%s", time.Now().UnixNano(), name),
+	}
+	if err := enqueueWSCommand(client.sendCh, cmd, 5*time.Second, fmt.Sprintf("waiting player %s", name)); err != nil {
+		log.Printf("failed to send waitlist position to %s: %v", name, err)
+	}
+}
+
+// apiMaxPlayers: GET returns the player limit, connected count and waitlist;
+// POST sets max_players (0 = no limit) and/or reject_when_full.
+func (s *Server) apiMaxPlayers(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		var limit int
+		var reject bool
+		var waitlist []string
+		s.withRLock(func() {
+			limit = s.state.MaxPlayers
+			reject = s.state.RejectWhenFull
+			waitlist = append([]string{}, s.state.Waitlist...)
+		})
+		connected := 0
+		s.withConnRLock(func() { connected = len(s.playerClients) })
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{
+			"max_players":      limit,
+			"reject_when_full": reject,
+			"connected":        connected,
+			"waitlist":         waitlist,
+		}); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method == http.MethodPost {
+		var b struct {
+			MaxPlayers     *int  `json:"max_players"`
+			RejectWhenFull *bool `json:"reject_when_full"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if b.MaxPlayers != nil && *b.MaxPlayers < 0 {
+			http.Error(w, "max_players must be 0 (no limit) or positive", http.StatusBadRequest)
+			return
+		}
+		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+			if b.MaxPlayers != nil {
+				st.MaxPlayers = *b.MaxPlayers
+			}
+			if b.RejectWhenFull != nil {
+				st.RejectWhenFull = *b.RejectWhenFull
+			}
+		})
+		s.promoteWaitlist()
+		if _, err := w.Write([]byte("ok")); err != nil {
+			fmt.Printf("write response error: %v\n", err)
+		}
+		return
+	}
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,95 @@
+package serverhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestMaxPlayersWaitlistAndPromotion(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	hello := func(name string) *websocket.Conn {
+		t.Helper()
+		c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		if err := c.WriteJSON(protocol.Command{Cmd: protocol.CmdHello, ID: "1", Payload: map[string]any{"name": name}}); err != nil {
+			t.Fatal(err)
+		}
+		_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		return c
+	}
+	post := func(body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/max_players", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST /api/max_players %s: status %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+		}
+	}
+
+	post(`{"max_players":1}`)
+	alice := hello("alice")
+	waitFor("alice connected", func() bool { return s.SnapshotPlayers()["alice"].Connected })
+
+	bob := hello("bob")
+	var cmd protocol.Command
+	if err := bob.ReadJSON(&cmd); err != nil {
+		t.Fatal(err)
+	}
+	if msg, _ := cmd.Payload.(map[string]any)["message"].(string); cmd.Cmd != protocol.CmdMessage || !strings.Contains(msg, "#1 on the waitlist") {
+		t.Fatalf("waitlisted bob got %+v", cmd)
+	}
+	if wl := s.SnapshotState().Waitlist; len(wl) != 1 || wl[0] != "bob" {
+		t.Fatalf("waitlist = %v", wl)
+	}
+	if s.SnapshotPlayers()["bob"].Connected {
+		t.Fatal("waitlisted bob marked connected")
+	}
+
+	_ = alice.Close()
+	waitFor("bob promoted", func() bool {
+		return s.SnapshotPlayers()["bob"].Connected && len(s.SnapshotState().Waitlist) == 0
+	})
+
+	post(`{"reject_when_full":true}`)
+	carol := hello("carol")
+	for {
+		if err := carol.ReadJSON(&cmd); err != nil {
+			if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+				t.Fatalf("full hello: %v", err)
+			}
+			break
+		}
+	}
+	if _, ok := s.SnapshotPlayers()["carol"]; ok {
+		t.Fatal("rejected player registered")
+	}
+
+	// Let the server handle bob's disconnect now, so its state save is
+	// queued before the saver is stopped rather than after cleanup.
+	_ = bob.Close()
+	waitFor("bob disconnected", func() bool { return !s.SnapshotPlayers()["bob"].Connected })
+}
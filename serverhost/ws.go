@@ -19,6 +19,18 @@ import (
 type wsClient struct {
 	conn   *websocket.Conn
 	sendCh chan protocol.Command
+	// closed is closed once the client is removed, so sendAndWait can stop
+	// waiting for an ack that will never come. May be nil in tests.
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// markClosed signals closed; safe to call more than once.
+func (c *wsClient) markClosed() {
+	if c.closed == nil {
+		return
+	}
+	c.closeOnce.Do(func() { close(c.closed) })
 }
 
 const wsWriterDrainWait = 2 * time.Second
@@ -34,7 +46,7 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 		s.wsActive.Done()
 		return
 	}
-	client := &wsClient{conn: c, sendCh: make(chan protocol.Command, 256)}
+	client := &wsClient{conn: c, sendCh: make(chan protocol.Command, 256), closed: make(chan struct{})}
 	s.liveConns.Store(c, client)
 	s.withConnLock(func() {
 		s.conns[c] = client
@@ -46,12 +58,13 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	c.SetReadLimit(1024 * 16)
-	if err := c.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
+	pingInterval, readTimeout := s.wsKeepalive()
+	if err := c.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
 		log.Printf("SetReadDeadline error: %v", err)
 	}
 	// Pong handler updated to compute RTT when we sent a timestamp in the ping payload.
 	c.SetPongHandler(func(appData string) error {
-		if err := c.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
+		if err := c.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
 			log.Printf("SetReadDeadline error: %v", err)
 		}
 		// parse timestamp from pong appData (sent as unix nanoseconds string)
@@ -67,11 +80,7 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 				name = s.findPlayerNameForClientLocked(client)
 			})
 			if name != "" {
-				s.UpdateStateAndPersist(func(st *protocol.ServerState) {
-					pl := st.Players[name]
-					pl.PingMs = int(rtt.Milliseconds())
-					st.Players[name] = pl
-				})
+				s.recordPong(name, rtt)
 			}
 		}
 		return nil
@@ -80,7 +89,7 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 	var writeWG sync.WaitGroup
 	writeWG.Add(1)
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+		ticker := time.NewTicker(pingInterval)
 		defer func() { ticker.Stop(); writeWG.Done() }()
 		for {
 			select {
@@ -158,9 +167,19 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 		case protocol.CmdAck, protocol.CmdNack:
 			var ch chan string
 			var ok bool
+			var sent pendingCommand
 			s.withRLock(func() {
 				ch, ok = s.pending[cmd.ID]
+				sent = s.pendingCmds[cmd.ID]
 			})
+			if cmd.Cmd == protocol.CmdNack {
+				if sent.player == "" {
+					s.withConnRLock(func() {
+						sent.player = s.findPlayerNameForClientLocked(client)
+					})
+				}
+				s.recordCommandFailure(sent, cmd)
+			}
 			if ok {
 				if cmd.Cmd == protocol.CmdAck {
 					select {
@@ -174,7 +193,6 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 							reason = "nack|" + string(b)
 						}
 					}
-					log.Printf("received nack id=%s payload=%+v", cmd.ID, cmd.Payload)
 					select {
 					case ch <- reason:
 					default:
@@ -184,12 +202,8 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 				// remove pending entry under write lock
 				s.withLock(func() {
 					delete(s.pending, cmd.ID)
+					delete(s.pendingCmds, cmd.ID)
 				})
-			} else {
-				// Log nack/ack for commands not in pending map (like async responses)
-				if cmd.Cmd == protocol.CmdNack {
-					log.Printf("received nack for non-pending command id=%s payload=%+v", cmd.ID, cmd.Payload)
-				}
 			}
 			continue
 		case protocol.CmdGamesUpdateAck:
@@ -200,10 +214,33 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 			})
 			if name != "" {
 				if pl, ok := cmd.Payload.(map[string]any); ok {
+					var checksums map[string]string
+					if cs, ok := pl["checksums"].(map[string]any); ok {
+						checksums = make(map[string]string, len(cs))
+						for file, v := range cs {
+							if sum, ok := v.(string); ok {
+								checksums[file] = sum
+							}
+						}
+					}
 					if hf, ok := pl["has_files"].(bool); ok {
+						if prestage, _ := pl["prestage"].(bool); prestage {
+							var errs []string
+							if list, ok := pl["errors"].([]any); ok {
+								for _, e := range list {
+									if es, ok := e.(string); ok {
+										errs = append(errs, es)
+									}
+								}
+							}
+							s.notePrestageAck(name, hf, errs)
+						}
 						s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 							p := st.Players[name]
 							p.HasFiles = hf
+							if checksums != nil {
+								p.RomChecksums = checksums
+							}
 							st.Players[name] = p
 						})
 						continue
@@ -221,49 +258,26 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 					log.Printf("CmdHello missing name in payload")
 					continue
 				}
+				if s.isPlayerBanned(name) {
+					log.Printf("Rejected hello from banned player %q (%s)", name, r.RemoteAddr)
+					closeBanned(c)
+					return
+				}
 				bizhawkReady := false
 				if v, ok := pl["bizhawk_ready"].(bool); ok {
 					bizhawkReady = v
 				}
-				s.withConnLock(func() {
-					s.conns[c] = client
-					s.playerClients[name] = client
-				})
-				s.UpdateStateAndPersist(func(st *protocol.ServerState) {
-					if st.Players == nil {
-						st.Players = make(map[string]protocol.Player)
-					}
-					p, ok := st.Players[name]
-					if !ok {
-						p = protocol.Player{Name: name}
-					}
-					p.Connected = true
-					p.BizhawkReady = bizhawkReady
-					st.Players[name] = p
-				})
-
-				player := s.AssignPlayerOnConnect(name)
-				player.Connected = true
-				player.BizhawkReady = bizhawkReady
-
-				s.broadcastGamesUpdate(&player)
-				if player.Game != "" && bizhawkReady {
-					s.sendSwap(player, SwapSendOptions{SkipSave: true})
-				} else if bizhawkReady && player.Game == "" {
-					log.Printf("[ws] hello from %q with bizhawk_ready but no game/instance assigned", name)
-					obslog.Event(obslog.Swap, "skip_no_assignment", map[string]string{
-						"player": name, "reason": "hello_bizhawk_ready_no_game",
-					})
-				} else if !bizhawkReady {
-					log.Printf("[ws] hello from %q (bizhawk_ready=false); swap deferred until ready", name)
-					obslog.Event(obslog.Swap, "deferred", map[string]string{
-						"player": name, "reason": "hello_bizhawk_not_ready",
-						"game":   player.Game,
-					})
+				admitted, position := s.claimPlayerSlot(c, client, name, bizhawkReady)
+				if !admitted && position == 0 {
+					log.Printf("Rejected hello from %q: session full (%s)", name, r.RemoteAddr)
+					closePolicyViolation(c, "session full")
+					return
 				}
-				if err := s.sendPing(player); err != nil {
-					log.Printf("failed to send ping to player %s: %v", player.Name, err)
+				if !admitted {
+					log.Printf("Session full; %q waitlisted at #%d", name, position)
+					continue
 				}
+				s.admitPlayer(name, bizhawkReady)
 			} else {
 				fmt.Printf("[ERROR] Invalid payload type for CmdHello: %T\n", cmd.Payload)
 			}
@@ -274,24 +288,45 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 				s.withConnRLock(func() {
 					name = s.findPlayerNameForClientLocked(client)
 				})
-				if name == "" {
-					continue
-				}
 				bizhawkReady, hasReady := pl["bizhawk_ready"].(bool)
 				if !hasReady {
 					continue
 				}
+				bizhawkHung, _ := pl["bizhawk_hung"].(bool)
+				if name == "" {
+					s.setWaitlistReady(client, bizhawkReady)
+					continue
+				}
+				status := decodeStatusUpdate(pl)
 				becameReady := false
-				s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+				applyStatus := func(st *protocol.ServerState) {
 					p, ok := st.Players[name]
 					if !ok {
 						return
 					}
 					becameReady = bizhawkReady && !p.BizhawkReady
 					p.BizhawkReady = bizhawkReady
+					p.BizhawkHung = bizhawkHung && !bizhawkReady
+					p.Status = status
+					p.StatusAt = time.Now().Unix()
 					st.Players[name] = p
+					if bizhawkReady {
+						clearNotReady(st, name)
+					}
+				}
+				// Heartbeat fields (Status, StatusAt) stay in memory; only a
+				// readiness or hung transition is worth a state.json write.
+				transition := false
+				s.withRLock(func() {
+					p := s.state.Players[name]
+					transition = p.BizhawkReady != bizhawkReady || p.BizhawkHung != (bizhawkHung && !bizhawkReady)
 				})
-				if becameReady {
+				if transition {
+					s.UpdateStateAndPersist(applyStatus)
+				} else {
+					s.withLock(func() { applyStatus(&s.state) })
+				}
+				if becameReady && !s.inLobby() {
 					s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 						s.clearPendingForPlayer(st, name)
 					})
@@ -319,6 +354,18 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 					log.Printf("CmdHelloAdmin missing name in payload")
 					continue
 				}
+				token, _ := pl["token"].(string)
+				if token == "" {
+					token = requestAdminToken(r)
+				}
+				if !s.adminTokenValid(token) {
+					log.Printf("Admin %s rejected: invalid admin token", name)
+					msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "unauthorized")
+					if err := c.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second)); err != nil {
+						log.Printf("write close msg err: %v", err)
+					}
+					return
+				}
 
 				s.withConnLock(func() {
 					s.conns[c] = client
@@ -337,6 +384,38 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 				fmt.Printf("[ERROR] Invalid payload type for CmdHelloAdmin: %T\n", cmd.Payload)
 			}
 			continue
+		case protocol.CmdHelloSpectator:
+			if pl, ok := cmd.Payload.(map[string]any); ok {
+				name := ""
+				if v, ok := pl["name"].(string); ok {
+					name = v
+				}
+				if name == "" {
+					log.Printf("CmdHelloSpectator missing name in payload")
+					continue
+				}
+				// Spectators stay out of st.Players so they are never assigned or
+				// swapped, and are keyed by client so two "tv" screens can coexist.
+				s.withConnLock(func() {
+					s.conns[c] = client
+					s.spectatorClients[client] = name
+				})
+				log.Printf("Spectator %s connected", name)
+
+				games, mainGames, gameInstances := s.SnapshotGames()
+				select {
+				case client.sendCh <- protocol.Command{Cmd: protocol.CmdGamesUpdate, Payload: map[string]any{
+					"game_instances": gameInstances,
+					"main_games":     mainGames,
+					"games":          games,
+				}, ID: fmt.Sprintf("%d", time.Now().UnixNano())}:
+				case <-time.After(5 * time.Second):
+					fmt.Printf("[ERROR] Failed to send CmdGamesUpdate to spectator %s (queue full after 5s)\n", name)
+				}
+			} else {
+				fmt.Printf("[ERROR] Invalid payload type for CmdHelloSpectator: %T\n", cmd.Payload)
+			}
+			continue
 		case protocol.CmdTypeLua:
 			if pl, ok := cmd.Payload.(map[string]any); ok {
 				var luaCmd protocol.LuaCommand
@@ -360,10 +439,13 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 						Payload: luaCmd,
 					})
 				case protocol.LuaCmdSwap:
-					// Handle swap command
-					if err := s.performSwap(); err != nil {
-						fmt.Printf("performSwap error: %v\n", err)
-					}
+					// Handle swap command (debounced; see performLuaSwap). The swap
+					// waits for readiness and saves, so keep it off the read loop.
+					go func() {
+						if err := s.performLuaSwap(); err != nil {
+							fmt.Printf("performSwap error: %v\n", err)
+						}
+					}()
 				case protocol.LuaCmdSwapMe:
 					name := ""
 					s.withConnRLock(func() {
@@ -376,11 +458,60 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 					if err := s.performRandomSwapForPlayer(name); err != nil {
 						fmt.Printf("performRandomSwapForPlayer error: %v\n", err)
 					}
+				case protocol.LuaCmdCompleted:
+					name := ""
+					s.withConnRLock(func() {
+						name = s.findPlayerNameForClientLocked(client)
+					})
+					if name == "" {
+						fmt.Printf("[ERROR] LuaCmdCompleted: could not determine player name for client\n")
+						continue
+					}
+					s.markLuaCompletion(name, luaCmd.Fields)
+				case protocol.LuaCmdGameEvent:
+					name := ""
+					s.withConnRLock(func() {
+						name = s.findPlayerNameForClientLocked(client)
+					})
+					if name == "" {
+						fmt.Printf("[ERROR] LuaCmdGameEvent: could not determine player name for client\n")
+						continue
+					}
+					// Swaps wait on save uploads, so keep them off the read loop.
+					go s.handleGameEvent(name, luaCmd.Fields)
 				}
 			} else {
 				fmt.Printf("[ERROR] Invalid payload type for CmdTypeLua: %T\n", cmd.Payload)
 			}
 			continue
+		case protocol.CmdPluginError:
+			if pl, ok := cmd.Payload.(map[string]any); ok {
+				pluginName, _ := pl["plugin"].(string)
+				errMsg, _ := pl["error"].(string)
+				if pluginName == "" {
+					log.Printf("CmdPluginError missing plugin in payload")
+					continue
+				}
+				name := ""
+				s.withConnRLock(func() {
+					name = s.findPlayerNameForClientLocked(client)
+				})
+				s.recordPluginError(pluginName, name, errMsg)
+			} else {
+				fmt.Printf("[ERROR] Invalid payload type for CmdPluginError: %T\n", cmd.Payload)
+			}
+			continue
+		case protocol.CmdVoteSkip:
+			name := ""
+			s.withConnRLock(func() {
+				name = s.findPlayerNameForClientLocked(client)
+			})
+			if name == "" {
+				fmt.Printf("[ERROR] CmdVoteSkip: could not determine player name for client\n")
+				continue
+			}
+			s.handleVoteSkip(name)
+			continue
 		case protocol.CmdConfigResponse:
 			// Handle config response from client
 			if pl, ok := cmd.Payload.(map[string]any); ok {
@@ -424,9 +555,37 @@ func (s *Server) broadcastToPlayers(cmd protocol.Command) {
 			}
 		}(cl)
 	}
+	if spectatorCommands[cmd.Cmd] {
+		s.broadcastToSpectators(cmd)
+	}
 	s.broadcastToAdmins(cmd)
 }
 
+// spectatorCommands are the player broadcasts that spectators also receive.
+var spectatorCommands = map[protocol.CommandName]bool{
+	protocol.CmdGamesUpdate: true,
+	protocol.CmdMessage:     true,
+}
+
+// broadcastToSpectators sends a command to all currently connected spectators.
+func (s *Server) broadcastToSpectators(cmd protocol.Command) {
+	var clients []*wsClient
+	s.withConnRLock(func() {
+		for cl := range s.spectatorClients {
+			clients = append(clients, cl)
+		}
+	})
+	for _, cl := range clients {
+		go func(cl *wsClient) {
+			select {
+			case cl.sendCh <- cmd:
+			case <-time.After(5 * time.Second):
+				log.Printf("failed to broadcast to spectator: queue full")
+			}
+		}(cl)
+	}
+}
+
 // broadcastToAdmins sends a command to all currently connected admins.
 func (s *Server) broadcastToAdmins(cmd protocol.Command) {
 	clients := make([]*wsClient, 0, len(s.adminClients))
@@ -446,11 +605,21 @@ func (s *Server) broadcastToAdmins(cmd protocol.Command) {
 	}
 }
 
+// emitAdminEvent sends a granular event (player_connected, swap_performed, ...)
+// to admins. The debounced state_update still follows every state change.
+func (s *Server) emitAdminEvent(name protocol.CommandName, payload any) {
+	s.broadcastToAdmins(protocol.Command{
+		Cmd:     name,
+		Payload: payload,
+		ID:      fmt.Sprintf("%s-%d", name, time.Now().UnixNano()),
+	})
+}
+
 func (s *Server) broadcastGamesUpdate(player *protocol.Player) {
 	games, mainGames, gameInstances := s.SnapshotGames()
 	payload := map[string]any{
 		"game_instances": gameInstances,
-		"main_games":     mainGames,
+		"main_games":     s.withExternalChecksums(mainGames),
 		"games":          games,
 	}
 	if player != nil {
@@ -463,11 +632,76 @@ func (s *Server) broadcastGamesUpdate(player *protocol.Player) {
 	}
 }
 
+// admitPlayer marks a newly registered player connected and brings it up to
+// date: games update, swap to its assignment (outside the lobby), the global
+// pause and a first ping.
+func (s *Server) admitPlayer(name string, bizhawkReady bool) {
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		if st.Players == nil {
+			st.Players = make(map[string]protocol.Player)
+		}
+		p, ok := st.Players[name]
+		if !ok {
+			p = protocol.Player{Name: name}
+		}
+		p.Connected = true
+		p.BizhawkReady = bizhawkReady
+		p.BizhawkHung = false
+		st.Players[name] = p
+		if bizhawkReady {
+			clearNotReady(st, name)
+		}
+	})
+
+	s.emitAdminEvent(protocol.CmdPlayerConnected, protocol.PlayerEvent{Player: name, BizhawkReady: bizhawkReady})
+
+	lobby := s.inLobby()
+	var player protocol.Player
+	if lobby {
+		// Registered and ready, but no game until /api/session/start.
+		s.withRLock(func() { player = s.state.Players[name] })
+	} else {
+		player = s.AssignPlayerOnConnect(name)
+	}
+	player.Connected = true
+	player.BizhawkReady = bizhawkReady
+
+	s.broadcastGamesUpdate(&player)
+	if lobby {
+		log.Printf("[ws] hello from %q in lobby (bizhawk_ready=%v); waiting for session start", name, bizhawkReady)
+	} else if player.Game != "" && bizhawkReady {
+		s.sendSwap(player, SwapSendOptions{SkipSave: true})
+	} else if bizhawkReady && player.Game == "" {
+		log.Printf("[ws] hello from %q with bizhawk_ready but no game/instance assigned", name)
+		obslog.Event(obslog.Swap, "skip_no_assignment", map[string]string{
+			"player": name, "reason": "hello_bizhawk_ready_no_game",
+		})
+	} else if !bizhawkReady {
+		log.Printf("[ws] hello from %q (bizhawk_ready=false); swap deferred until ready", name)
+		obslog.Event(obslog.Swap, "deferred", map[string]string{
+			"player": name, "reason": "hello_bizhawk_not_ready",
+			"game":   player.Game,
+		})
+	}
+	paused := false
+	s.withRLock(func() { paused = s.state.Paused })
+	if paused {
+		// Keep late joiners consistent with a global pause.
+		if err := s.sendToPlayer(player, protocol.Command{Cmd: protocol.CmdPause, ID: fmt.Sprintf("%d", time.Now().UnixNano())}); err != nil {
+			log.Printf("failed to pause player %s on connect: %v", player.Name, err)
+		}
+	}
+	if err := s.sendPing(player); err != nil {
+		log.Printf("failed to send ping to player %s: %v", player.Name, err)
+	}
+}
+
 // removeWSClient unregisters a websocket client. Connection maps use connMu; player state uses UpdateStateAndPersist.
 func (s *Server) removeWSClient(conn *websocket.Conn, client *wsClient) {
 	s.liveConns.Delete(conn)
 
-	var playerName, adminName string
+	var playerName, adminName, spectatorName string
+	waiting := false
 	s.withConnLock(func() {
 		cl, ok := s.conns[conn]
 		if !ok || cl != client {
@@ -475,25 +709,41 @@ func (s *Server) removeWSClient(conn *websocket.Conn, client *wsClient) {
 		}
 		playerName = s.findPlayerNameForClientLocked(cl)
 		adminName = s.findAdminNameForClientLocked(cl)
+		spectatorName = s.findSpectatorNameForClientLocked(cl)
 		if playerName != "" {
 			delete(s.playerClients, playerName)
+		} else if s.removeWaitingLocked(cl) {
+			waiting = true
 		} else if adminName != "" {
 			delete(s.adminClients, adminName)
+		} else if spectatorName != "" {
+			delete(s.spectatorClients, cl)
 		}
 		delete(s.conns, conn)
 	})
+	client.markClosed()
 
 	if playerName != "" {
 		s.UpdateStateAndPersist(func(st *protocol.ServerState) {
 			pl := st.Players[playerName]
 			pl.Connected = false
 			pl.BizhawkReady = false
+			pl.BizhawkHung = false
 			st.Players[playerName] = pl
 			s.clearPendingForPlayer(st, playerName)
+			clearNotReady(st, playerName)
 		})
 		s.ClearAppliedSwap(playerName)
+		// A running prestage would otherwise wait for this player until it times out.
+		s.notePrestageAck(playerName, false, []string{prestageDisconnected})
+		s.emitAdminEvent(protocol.CmdPlayerDisconnected, protocol.PlayerEvent{Player: playerName})
+		s.promoteWaitlist()
+	} else if waiting {
+		s.syncWaitlist()
 	} else if adminName != "" {
 		log.Printf("Admin %s disconnected", adminName)
+	} else if spectatorName != "" {
+		log.Printf("Spectator %s disconnected", spectatorName)
 	}
 }
 
@@ -595,14 +845,36 @@ func (s *Server) broadcastPluginSettingsUpdate(pluginName string, settings map[s
 	s.broadcastToPlayers(cmd)
 }
 
+// broadcastPluginSync tells connected clients a plugin's status changed so they
+// download or remove it and reload it in BizHawk.
+func (s *Server) broadcastPluginSync(pluginName string, status protocol.PluginStatus) {
+	s.broadcastToPlayers(protocol.Command{
+		Cmd: protocol.CmdPluginSync,
+		Payload: map[string]any{
+			"plugin_name": pluginName,
+			"status":      status,
+		},
+		ID: fmt.Sprintf("plugin-sync-%d-%s", time.Now().UnixNano(), pluginName),
+	})
+}
+
 // sendAndWait convenience wrapper that registers pending and waits for ack/nack.
+// It returns ErrPlayerDisconnected as soon as the player's connection is removed.
 func (s *Server) sendAndWait(player protocol.Player, cmd protocol.Command, timeout time.Duration) (string, error) {
 	ch := make(chan string, 1)
 	s.withLock(func() {
 		s.pending[cmd.ID] = ch
+		s.pendingCmds[cmd.ID] = pendingCommand{player: player.Name, cmd: cmd.Cmd}
 	})
 	defer s.withLock(func() {
 		delete(s.pending, cmd.ID)
+		delete(s.pendingCmds, cmd.ID)
+	})
+	var closed chan struct{}
+	s.withConnRLock(func() {
+		if client := s.playerClients[player.Name]; client != nil {
+			closed = client.closed
+		}
 	})
 	if err := s.sendToPlayer(player, cmd); err != nil {
 		return "", err
@@ -610,6 +882,8 @@ func (s *Server) sendAndWait(player protocol.Player, cmd protocol.Command, timeo
 	select {
 	case res := <-ch:
 		return res, nil
+	case <-closed:
+		return "", fmt.Errorf("player %s: %w", player.Name, ErrPlayerDisconnected)
 	case <-time.After(timeout):
 		return "", ErrTimeout
 	}
@@ -676,6 +950,13 @@ func (s *Server) sendSwap(player protocol.Player, opts SwapSendOptions) {
 			})
 			return
 		}
+		if o.Preview {
+			if s.sendSwapPreview(p, o.SkipSave) {
+				defer s.resumeAfterPreview(p)
+			}
+			// The target may have moved during the lead time.
+			p = s.currentPlayer(p.Name)
+		}
 
 		payload := map[string]any{"game": p.Game}
 		if p.InstanceID != "" {
@@ -684,6 +965,13 @@ func (s *Server) sendSwap(player protocol.Player, opts SwapSendOptions) {
 		if o.SkipSave {
 			payload["skip_save"] = true
 		}
+		if owner := s.saveOwner(p.Name); owner != "" {
+			payload["save_owner"] = owner
+		}
+		_, mainGames, _ := s.SnapshotGames()
+		if profile, ok := configProfileForGame(mainGames, p.Game); ok {
+			payload["config_profile"] = profile
+		}
 		cmd := protocol.Command{
 			Cmd:     protocol.CmdSwap,
 			Payload: payload,
@@ -696,9 +984,17 @@ func (s *Server) sendSwap(player protocol.Player, opts SwapSendOptions) {
 			"instance_id": p.InstanceID,
 			"skip_save":   fmt.Sprintf("%v", o.SkipSave),
 		})
-		res, err := s.sendAndWait(p, cmd, 20*time.Second)
+		// A config profile switch restarts BizHawk before the swap runs, which
+		// needs longer than a plain swap.
+		timeout := 20 * time.Second
+		if _, ok := payload["config_profile"]; ok {
+			timeout = configProfileTimeout
+		}
+		res, err := s.sendAndWait(p, cmd, timeout)
 		if err == nil && res == "ack" {
 			s.recordSwapApplied(p.Name, p)
+			s.recordSwapHistory(p)
+			s.startGameTimer(p.Name, p.Game)
 		}
 	}(player, opts)
 }
@@ -739,20 +1035,44 @@ func (s *Server) SetPendingAllFiles() {
 
 // RequestSave sends a request to save command to the specified player for the given instance
 func (s *Server) RequestSave(playerName string, instanceID string) error {
-	player, ok := s.state.Players[playerName]
+	player, cmd, err := s.requestSaveCommand(playerName, instanceID)
+	if err != nil {
+		return err
+	}
+	return s.sendToPlayer(player, cmd)
+}
+
+// RequestSaveAndWait sends a request_save command and waits for the client's ack/nack.
+// It returns the raw result from sendAndWait ("ack" or "nack|...").
+func (s *Server) RequestSaveAndWait(playerName string, instanceID string, timeout time.Duration) (string, error) {
+	player, cmd, err := s.requestSaveCommand(playerName, instanceID)
+	if err != nil {
+		return "", err
+	}
+	return s.sendAndWait(player, cmd, timeout)
+}
+
+func (s *Server) requestSaveCommand(playerName string, instanceID string) (protocol.Player, protocol.Command, error) {
+	var player protocol.Player
+	var ok bool
+	s.withRLock(func() {
+		player, ok = s.state.Players[playerName]
+	})
 	if !ok {
-		return fmt.Errorf("player %s not found", playerName)
+		return player, protocol.Command{}, fmt.Errorf("player %s: %w", playerName, ErrPlayerNotFound)
 	}
 	if !player.Connected {
-		return fmt.Errorf("player %s not connected", playerName)
+		return player, protocol.Command{}, fmt.Errorf("player %s: %w", playerName, ErrPlayerNotConnected)
 	}
 
 	payload := map[string]string{"instance_id": instanceID}
+	if owner := s.saveOwner(playerName); owner != "" {
+		payload["save_owner"] = owner
+	}
 	cmd := protocol.Command{
 		Cmd:     protocol.CmdRequestSave,
 		Payload: payload,
 		ID:      fmt.Sprintf("request-save-%d-%s", time.Now().UnixNano(), playerName),
 	}
-
-	return s.sendToPlayer(player, cmd)
+	return player, cmd, nil
 }
@@ -0,0 +1,95 @@
+package serverhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+const (
+	defaultPingIntervalSecs = 30
+	defaultReadTimeoutSecs  = 60
+	minPingIntervalSecs     = 5
+	maxPingIntervalSecs     = 300
+	maxReadTimeoutSecs      = 900
+)
+
+// validateWSKeepalive checks an effective ping interval / read timeout pair.
+// The read timeout must cover at least two pings so a single late pong does
+// not drop the connection.
+func validateWSKeepalive(pingSecs, readSecs int) error {
+	if pingSecs < minPingIntervalSecs || pingSecs > maxPingIntervalSecs {
+		return fmt.Errorf("ping_interval_secs must be %d-%d", minPingIntervalSecs, maxPingIntervalSecs)
+	}
+	if readSecs < 2*pingSecs || readSecs > maxReadTimeoutSecs {
+		return fmt.Errorf("read_timeout_secs must be between twice ping_interval_secs (%d) and %d", 2*pingSecs, maxReadTimeoutSecs)
+	}
+	return nil
+}
+
+// effectiveWSKeepalive fills in the defaults for unset (0) values.
+func effectiveWSKeepalive(pingSecs, readSecs int) (int, int) {
+	if pingSecs == 0 {
+		pingSecs = defaultPingIntervalSecs
+	}
+	if readSecs == 0 {
+		readSecs = defaultReadTimeoutSecs
+	}
+	return pingSecs, readSecs
+}
+
+// wsKeepalive returns the ping interval and read timeout for a new player
+// websocket. Invalid values (e.g. a hand-edited state.json) fall back to the
+// defaults.
+func (s *Server) wsKeepalive() (ping, read time.Duration) {
+	var pingSecs, readSecs int
+	s.withRLock(func() { pingSecs, readSecs = s.state.PingIntervalSecs, s.state.ReadTimeoutSecs })
+	pingSecs, readSecs = effectiveWSKeepalive(pingSecs, readSecs)
+	if err := validateWSKeepalive(pingSecs, readSecs); err != nil {
+		log.Printf("ignoring websocket keepalive settings: %v", err)
+		pingSecs, readSecs = defaultPingIntervalSecs, defaultReadTimeoutSecs
+	}
+	return time.Duration(pingSecs) * time.Second, time.Duration(readSecs) * time.Second
+}
+
+// apiWSKeepalive: GET/POST /api/ws_keepalive {ping_interval_secs, read_timeout_secs}
+// 0 restores a default. Changes apply to connections opened afterwards.
+func (s *Server) apiWSKeepalive(w http.ResponseWriter, r *http.Request) {
+	type keepalive struct {
+		PingIntervalSecs int `json:"ping_interval_secs"`
+		ReadTimeoutSecs  int `json:"read_timeout_secs"`
+	}
+	if r.Method == http.MethodGet {
+		var b keepalive
+		s.withRLock(func() { b = keepalive{s.state.PingIntervalSecs, s.state.ReadTimeoutSecs} })
+		b.PingIntervalSecs, b.ReadTimeoutSecs = effectiveWSKeepalive(b.PingIntervalSecs, b.ReadTimeoutSecs)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(b); err != nil {
+			fmt.Printf("encode response error: %v\n", err)
+		}
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b keepalive
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateWSKeepalive(effectiveWSKeepalive(b.PingIntervalSecs, b.ReadTimeoutSecs)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) {
+		st.PingIntervalSecs, st.ReadTimeoutSecs = b.PingIntervalSecs, b.ReadTimeoutSecs
+	})
+	if _, err := w.Write([]byte("ok")); err != nil {
+		fmt.Printf("write response error: %v\n", err)
+	}
+}
@@ -0,0 +1,48 @@
+package serverhost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michael4d45/bizshuffle/protocol"
+)
+
+func TestWSKeepaliveSettings(t *testing.T) {
+	chdirToTemp(t)
+	s := New()
+	stopStateSaverOnCleanup(t, s)
+
+	if ping, read := s.wsKeepalive(); ping != 30*time.Second || read != 60*time.Second {
+		t.Fatalf("defaults = %v/%v", ping, read)
+	}
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		s.apiWSKeepalive(rec, httptest.NewRequest(http.MethodPost, "/api/ws_keepalive", strings.NewReader(body)))
+		return rec.Code
+	}
+	for _, body := range []string{
+		`{"ping_interval_secs":1}`,
+		`{"ping_interval_secs":60}`, // default read timeout is under two pings
+		`{"ping_interval_secs":30,"read_timeout_secs":1000}`,
+	} {
+		if code := post(body); code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d", body, code)
+		}
+	}
+	if code := post(`{"ping_interval_secs":60,"read_timeout_secs":180}`); code != http.StatusOK {
+		t.Fatalf("valid settings: status %d", code)
+	}
+	if ping, read := s.wsKeepalive(); ping != time.Minute || read != 3*time.Minute {
+		t.Fatalf("configured = %v/%v", ping, read)
+	}
+
+	// A hand-edited state.json with a bad pair falls back to the defaults.
+	s.UpdateStateAndPersist(func(st *protocol.ServerState) { st.ReadTimeoutSecs = 10 })
+	if ping, read := s.wsKeepalive(); ping != 30*time.Second || read != 60*time.Second {
+		t.Fatalf("invalid state = %v/%v", ping, read)
+	}
+}
@@ -55,7 +55,11 @@ func StartTestServer(t *testing.T) *TestServer {
 		http:    srv,
 		ln:      ln,
 	}
-	t.Cleanup(func() { ts.Stop() })
+	// Runs before the chdir back, so no debounced save lands in the tree.
+	t.Cleanup(func() {
+		ts.Stop()
+		host.StopStateSaver()
+	})
 	return ts
 }
 